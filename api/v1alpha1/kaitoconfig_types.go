@@ -0,0 +1,62 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KaitoConfigName is the name of the KaitoConfig object read by the operator.
+const KaitoConfigName = "kaito"
+
+// KaitoConfigSpec holds operator-wide settings that can be changed without restarting the operator.
+// Unset fields fall back to the command line flags of the operator.
+// The settings read at startup, e.g., the feature gates, stay command line flags.
+type KaitoConfigSpec struct {
+	// ModelRunParams are the model run parameters applied to all preset inference workloads. They override
+	// the preset defaults and are overridden by the kaito.sh/model-run-params workspace annotation.
+	// +optional
+	ModelRunParams map[string]string `json:"modelRunParams,omitempty"`
+	// SchedulerName is the scheduler of the workload pods of the workspaces that do not specify one.
+	// An empty value selects the default scheduler of the cluster.
+	// +optional
+	SchedulerName *string `json:"schedulerName,omitempty"`
+	// GPUScoringStrategy, MostAllocated or LeastAllocated, is recorded on the workload pods as a hint for
+	// GPU-aware scheduler plugins. An empty value records no hint.
+	// +optional
+	GPUScoringStrategy *string `json:"gpuScoringStrategy,omitempty"`
+	// PresetImageMirrors maps registry prefixes of the public preset images to the prefixes of their
+	// private mirrors. The longest matching prefix applies.
+	// +optional
+	PresetImageMirrors map[string]string `json:"presetImageMirrors,omitempty"`
+	// PresetImagePullSecrets are the secrets in the release namespace used to pull the public preset images.
+	// They are copied to the namespaces of the workspaces.
+	// +optional
+	PresetImagePullSecrets []string `json:"presetImagePullSecrets,omitempty"`
+}
+
+// KaitoConfig is the Schema for the kaitoconfigs API. The operator reads the object named "kaito".
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=kaitoconfigs,scope=Cluster,categories=workspace
+// +kubebuilder:storageversion
+// +kubebuilder:validation:XValidation:rule="self.metadata.name == 'kaito'",message="the KaitoConfig must be named kaito"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+type KaitoConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec KaitoConfigSpec `json:"spec,omitempty"`
+}
+
+// KaitoConfigList contains a list of KaitoConfig
+// +kubebuilder:object:root=true
+type KaitoConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KaitoConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KaitoConfig{}, &KaitoConfigList{})
+}
@@ -0,0 +1,116 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ModelPresetSpec describes a preset model curated by the cluster administrators.
+type ModelPresetSpec struct {
+	// ModelName is the preset name that workspaces reference in the preset name field. It must not be
+	// the name of a builtin preset.
+	ModelName ModelName `json:"modelName"`
+	// Version is the preset revision declared by this object. Multiple ModelPresets can declare
+	// different versions of the same model.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// ModelFamilyName is the name of the model family.
+	// +optional
+	ModelFamilyName string `json:"modelFamilyName,omitempty"`
+	// ImageAccessMode specifies whether the model image is in a public or private registry.
+	// Workspaces using a private preset must provide the image in the preset options.
+	// +kubebuilder:default:="public"
+	// +optional
+	ImageAccessMode ModelImageAccessMode `json:"imageAccessMode,omitempty"`
+	// Tag is the tag of the model image.
+	// +optional
+	Tag string `json:"tag,omitempty"`
+	// DiskStorageRequirement is the disk storage required by the model, e.g., "100Gi".
+	// +optional
+	DiskStorageRequirement string `json:"diskStorageRequirement,omitempty"`
+	// GPUCountRequirement is the number of GPUs required by the model.
+	// +optional
+	GPUCountRequirement string `json:"gpuCountRequirement,omitempty"`
+	// TotalGPUMemoryRequirement is the total GPU memory required by the model, e.g., "16Gi".
+	// +optional
+	TotalGPUMemoryRequirement string `json:"totalGPUMemoryRequirement,omitempty"`
+	// PerGPUMemoryRequirement is the GPU memory required per GPU, e.g., "0Gi".
+	// +optional
+	PerGPUMemoryRequirement string `json:"perGPUMemoryRequirement,omitempty"`
+	// MinDriverVersion is the minimum NVIDIA driver version, e.g., "525.60.13", required by the CUDA runtime
+	// of the model image. The inference workload is not created on nodes with an older driver.
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)*$`
+	// +optional
+	MinDriverVersion string `json:"minDriverVersion,omitempty"`
+	// BaseCommand is the initial command used to run the model, e.g., "accelerate launch".
+	// +optional
+	BaseCommand string `json:"baseCommand,omitempty"`
+	// TorchRunParams are the parameters of the torchrun command.
+	// +optional
+	TorchRunParams map[string]string `json:"torchRunParams,omitempty"`
+	// TorchRunRdzvParams are the rendezvous parameters of the torchrun command used by distributed inference.
+	// +optional
+	TorchRunRdzvParams map[string]string `json:"torchRunRdzvParams,omitempty"`
+	// ModelRunParams are the parameters of the model inference script.
+	// +optional
+	ModelRunParams map[string]string `json:"modelRunParams,omitempty"`
+	// Architectures are the CPU architectures the model image is built for, e.g., ["amd64", "arm64"] for a
+	// multi-arch image. The inference pods only run on nodes of these architectures. Defaults to amd64.
+	// +optional
+	Architectures []string `json:"architectures,omitempty"`
+	// ArchModelRunParams are the model run parameters specific to an architecture, e.g., arm64, merged into
+	// the model run parameters for the workspaces running on it.
+	// +optional
+	ArchModelRunParams map[string]map[string]string `json:"archModelRunParams,omitempty"`
+	// ReadinessTimeout is the maximum duration for the inference workload to become ready.
+	// +optional
+	ReadinessTimeout *metav1.Duration `json:"readinessTimeout,omitempty"`
+	// WorldSize is the number of processes required for distributed inference.
+	// +optional
+	WorldSize int `json:"worldSize,omitempty"`
+	// SupportDistributedInference specifies whether the model runs across multiple nodes.
+	// +optional
+	SupportDistributedInference bool `json:"supportDistributedInference,omitempty"`
+	// Deprecation marks the preset as deprecated. New workspaces using it get a warning, and are rejected
+	// once it is past its end of life.
+	// +optional
+	Deprecation *PresetDeprecation `json:"deprecation,omitempty"`
+}
+
+// PresetDeprecation describes the lifecycle of a deprecated preset.
+type PresetDeprecation struct {
+	// Replacement is the preset new workspaces should use instead.
+	// +optional
+	Replacement string `json:"replacement,omitempty"`
+	// EndOfLife is the time after which new workspaces cannot use the preset.
+	// +optional
+	EndOfLife *metav1.Time `json:"endOfLife,omitempty"`
+}
+
+// ModelPreset is the Schema for the modelpresets API
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=modelpresets,scope=Cluster,categories=workspace,shortName={mp,mps}
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Model",type="string",JSONPath=".spec.modelName",description=""
+// +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".spec.version",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+type ModelPreset struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ModelPresetSpec `json:"spec,omitempty"`
+}
+
+// ModelPresetList contains a list of ModelPreset
+// +kubebuilder:object:root=true
+type ModelPresetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ModelPreset `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ModelPreset{}, &ModelPresetList{})
+}
@@ -0,0 +1,34 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/azure/kaito/pkg/utils/plugin"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"knative.dev/pkg/apis"
+)
+
+// SetDefaults for the ModelPreset
+func (p *ModelPreset) SetDefaults(_ context.Context) {
+}
+
+func (p *ModelPreset) SupportedVerbs() []admissionregistrationv1.OperationType {
+	return []admissionregistrationv1.OperationType{
+		admissionregistrationv1.Create,
+		admissionregistrationv1.Update,
+	}
+}
+
+// Validate rejects the ModelPresets declaring a builtin preset. They would shadow the builtin preset,
+// which would then be lost once the ModelPreset is deleted.
+func (p *ModelPreset) Validate(_ context.Context) (errs *apis.FieldError) {
+	if plugin.KaitoModelRegister.IsBuiltin(string(p.Spec.ModelName)) {
+		errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("%s is a builtin preset, choose another model name", p.Spec.ModelName),
+			"modelName").ViaField("spec"))
+	}
+	return errs
+}
@@ -0,0 +1,23 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+)
+
+func TestModelPresetValidate(t *testing.T) {
+	RegisterValidationTestModels()
+
+	builtin := &ModelPreset{Spec: ModelPresetSpec{ModelName: "test-validation"}}
+	if errs := builtin.Validate(context.Background()); errs == nil {
+		t.Errorf("expected a ModelPreset declaring a builtin preset to be rejected")
+	}
+
+	custom := &ModelPreset{Spec: ModelPresetSpec{ModelName: "custom-preset"}}
+	if errs := custom.Validate(context.Background()); errs != nil {
+		t.Errorf("unexpected error: %v", errs)
+	}
+}
@@ -32,16 +32,6 @@ type TrainingConfig struct {
 	DataCollator       map[string]runtime.RawExtension `yaml:"DataCollator"`
 }
 
-func validateNilOrBool(value interface{}) error {
-	if value == nil {
-		return nil // nil is acceptable
-	}
-	if _, ok := value.(bool); ok {
-		return nil // Correct type
-	}
-	return fmt.Errorf("value must be either nil or a boolean, got type %T", value)
-}
-
 // UnmarshalYAML custom method
 func (t *TrainingConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var raw map[string]interface{}
@@ -112,33 +102,20 @@ func validateTrainingArgsViaConfigMap(cm *corev1.ConfigMap) *apis.FieldError {
 		return err
 	}
 
-	trainingArgs := config.TrainingConfig.TrainingArguments
-	if trainingArgs != nil {
-		trainingArgsRaw, trainingArgsExists := trainingArgs["TrainingArguments"]
-		if trainingArgsExists {
-			// If specified, ensure output dir is of type string
-			outputDirValue, found, err := utils.SearchRawExtension(trainingArgsRaw, "output_dir")
-			if err != nil {
-				return apis.ErrGeneric(fmt.Sprintf("Failed to parse 'output_dir' in ConfigMap '%s' in namespace '%s': %v", cm.Name, cm.Namespace, err), "output_dir")
-			}
-			if found {
-				userSpecifiedDir, ok := outputDirValue.(string)
-				if !ok {
-					return apis.ErrInvalidValue(fmt.Sprintf("output_dir is not a string in ConfigMap '%s' in namespace '%s'", cm.Name, cm.Namespace), "output_dir")
-				}
-
-				// Ensure the user-specified directory is under baseDir
-				baseDir := "/mnt"
-				cleanPath := filepath.Clean(filepath.Join(baseDir, userSpecifiedDir))
-				if cleanPath == baseDir || !strings.HasPrefix(cleanPath, baseDir) {
-					return apis.ErrInvalidValue(fmt.Sprintf("Invalid output_dir specified: '%s', must be a directory", userSpecifiedDir), "output_dir")
-				}
-			}
-
-			// TODO: Here we perform the tuning GPU Memory Checks!
-			fmt.Println(trainingArgsRaw)
+	trainingArgs, err := config.TrainingConfig.GetTrainingArguments()
+	if err != nil {
+		return err
+	}
+	if trainingArgs != nil && trainingArgs.OutputDir != nil {
+		// Ensure the user-specified directory is under baseDir
+		userSpecifiedDir := *trainingArgs.OutputDir
+		baseDir := "/mnt"
+		cleanPath := filepath.Clean(filepath.Join(baseDir, userSpecifiedDir))
+		if cleanPath == baseDir || !strings.HasPrefix(cleanPath, baseDir) {
+			return apis.ErrInvalidValue(fmt.Sprintf("Invalid output_dir specified: '%s', must be a directory", userSpecifiedDir), "output_dir")
 		}
 	}
+	// TODO: Here we perform the tuning GPU Memory Checks!
 	return nil
 }
 
@@ -149,43 +126,25 @@ func validateMethodViaConfigMap(cm *corev1.ConfigMap, methodLowerCase string) *a
 	}
 
 	// Validate QuantizationConfig if it exists
-	quantConfig := config.TrainingConfig.QuantizationConfig
+	quantConfig, err := config.TrainingConfig.GetQuantizationConfig()
+	if err != nil {
+		return err
+	}
 	if quantConfig != nil {
-		quantConfigRaw, quantConfigExists := quantConfig["QuantizationConfig"]
-		if quantConfigExists {
-			// Dynamic field search for quantization settings within ModelConfig
-			loadIn4bit, _, err := utils.SearchRawExtension(quantConfigRaw, "load_in_4bit")
-			if err != nil {
-				return apis.ErrInvalidValue(err.Error(), "load_in_4bit")
-			}
-			loadIn8bit, _, err := utils.SearchRawExtension(quantConfigRaw, "load_in_8bit")
-			if err != nil {
-				return apis.ErrInvalidValue(err.Error(), "load_in_8bit")
-			}
-
-			// Validate both loadIn4bit and loadIn8bit
-			if err := validateNilOrBool(loadIn4bit); err != nil {
-				return apis.ErrInvalidValue(err.Error(), "load_in_4bit")
-			}
-			if err := validateNilOrBool(loadIn8bit); err != nil {
-				return apis.ErrInvalidValue(err.Error(), "load_in_8bit")
-			}
-
-			loadIn4bitBool, _ := loadIn4bit.(bool)
-			loadIn8bitBool, _ := loadIn8bit.(bool)
+		loadIn4bit := quantConfig.LoadIn4bit != nil && *quantConfig.LoadIn4bit
+		loadIn8bit := quantConfig.LoadIn8bit != nil && *quantConfig.LoadIn8bit
 
-			// Validation Logic
-			if loadIn4bitBool && loadIn8bitBool {
-				return apis.ErrGeneric(fmt.Sprintf("Cannot set both 'load_in_4bit' and 'load_in_8bit' to true in ConfigMap '%s'", cm.Name), "QuantizationConfig")
+		// Validation Logic
+		if loadIn4bit && loadIn8bit {
+			return apis.ErrGeneric(fmt.Sprintf("Cannot set both 'load_in_4bit' and 'load_in_8bit' to true in ConfigMap '%s'", cm.Name), "QuantizationConfig")
+		}
+		if methodLowerCase == string(TuningMethodLora) {
+			if loadIn4bit || loadIn8bit {
+				return apis.ErrGeneric(fmt.Sprintf("For method 'lora', 'load_in_4bit' or 'load_in_8bit' in ConfigMap '%s' must not be true", cm.Name), "QuantizationConfig")
 			}
-			if methodLowerCase == string(TuningMethodLora) {
-				if loadIn4bitBool || loadIn8bitBool {
-					return apis.ErrGeneric(fmt.Sprintf("For method 'lora', 'load_in_4bit' or 'load_in_8bit' in ConfigMap '%s' must not be true", cm.Name), "QuantizationConfig")
-				}
-			} else if methodLowerCase == string(TuningMethodQLora) {
-				if !loadIn4bitBool && !loadIn8bitBool {
-					return apis.ErrMissingField(fmt.Sprintf("For method 'qlora', either 'load_in_4bit' or 'load_in_8bit' must be true in ConfigMap '%s'", cm.Name), "QuantizationConfig")
-				}
+		} else if methodLowerCase == string(TuningMethodQLora) {
+			if !loadIn4bit && !loadIn8bit {
+				return apis.ErrMissingField(fmt.Sprintf("For method 'qlora', either 'load_in_4bit' or 'load_in_8bit' must be true in ConfigMap '%s'", cm.Name), "QuantizationConfig")
 			}
 		}
 	} else if methodLowerCase == string(TuningMethodQLora) {
@@ -245,7 +204,13 @@ func validateConfigMapSchema(cm *corev1.ConfigMap) *apis.FieldError {
 			return apis.ErrInvalidValue(fmt.Sprintf("Unrecognized section: %s", section), "training_config.yaml")
 		}
 	}
-	return nil
+
+	// Check the parameters of the sections
+	config, err := UnmarshalTrainingConfig(cm)
+	if err != nil {
+		return err
+	}
+	return config.TrainingConfig.validateSchema()
 }
 
 func (r *TuningSpec) validateConfigMap(ctx context.Context, namespace string, methodLowerCase string, configMapName string) (errs *apis.FieldError) {
@@ -0,0 +1,151 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package v1alpha1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	"sigs.k8s.io/yaml"
+)
+
+// The schemas below mirror the dataclasses of presets/tuning/text-generation/cli.py that parse the
+// sections of the training config. The tuning job fails to start on parameters they do not declare.
+
+// ModelConfigSchema is the schema of the ModelConfig section.
+type ModelConfigSchema struct {
+	PretrainedModelNameOrPath *string                `json:"pretrained_model_name_or_path,omitempty"`
+	StateDict                 map[string]interface{} `json:"state_dict,omitempty"`
+	CacheDir                  *string                `json:"cache_dir,omitempty"`
+	FromTF                    *bool                  `json:"from_tf,omitempty"`
+	ForceDownload             *bool                  `json:"force_download,omitempty"`
+	ResumeDownload            *bool                  `json:"resume_download,omitempty"`
+	Proxies                   *string                `json:"proxies,omitempty"`
+	OutputLoadingInfo         *bool                  `json:"output_loading_info,omitempty"`
+	LocalFilesOnly            *bool                  `json:"local_files_only,omitempty"`
+	Revision                  *string                `json:"revision,omitempty"`
+	TrustRemoteCode           *bool                  `json:"trust_remote_code,omitempty"`
+	LoadIn4bit                *bool                  `json:"load_in_4bit,omitempty"`
+	LoadIn8bit                *bool                  `json:"load_in_8bit,omitempty"`
+	TorchDtype                *string                `json:"torch_dtype,omitempty"`
+	DeviceMap                 *string                `json:"device_map,omitempty"`
+}
+
+// QuantizationConfigSchema is the schema of the QuantizationConfig section.
+type QuantizationConfigSchema struct {
+	QuantMethod                 *string  `json:"quant_method,omitempty"`
+	LoadIn8bit                  *bool    `json:"load_in_8bit,omitempty"`
+	LoadIn4bit                  *bool    `json:"load_in_4bit,omitempty"`
+	LlmInt8Threshold            *float64 `json:"llm_int8_threshold,omitempty"`
+	LlmInt8SkipModules          []string `json:"llm_int8_skip_modules,omitempty"`
+	LlmInt8EnableFP32CPUOffload *bool    `json:"llm_int8_enable_fp32_cpu_offload,omitempty"`
+	LlmInt8HasFP16Weight        *bool    `json:"llm_int8_has_fp16_weight,omitempty"`
+	Bnb4bitComputeDtype         *string  `json:"bnb_4bit_compute_dtype,omitempty"`
+	Bnb4bitQuantType            *string  `json:"bnb_4bit_quant_type,omitempty"`
+	Bnb4bitUseDoubleQuant       *bool    `json:"bnb_4bit_use_double_quant,omitempty"`
+}
+
+// DatasetConfigSchema is the schema of the DatasetConfig section.
+type DatasetConfigSchema struct {
+	DatasetPath      *string  `json:"dataset_path,omitempty"`
+	DatasetExtension *string  `json:"dataset_extension,omitempty"`
+	ShuffleDataset   *bool    `json:"shuffle_dataset,omitempty"`
+	ShuffleSeed      *int     `json:"shuffle_seed,omitempty"`
+	ContextColumn    *string  `json:"context_column,omitempty"`
+	ResponseColumn   *string  `json:"response_column,omitempty"`
+	MessagesColumn   *string  `json:"messages_column,omitempty"`
+	TrainTestSplit   *float64 `json:"train_test_split,omitempty"`
+}
+
+// TrainingArgumentsSchema declares the parameters of the TrainingArguments section checked by Kaito.
+// The section accepts all the parameters of transformers.TrainingArguments.
+type TrainingArgumentsSchema struct {
+	OutputDir                 *string  `json:"output_dir,omitempty"`
+	NumTrainEpochs            *float64 `json:"num_train_epochs,omitempty"`
+	PerDeviceTrainBatchSize   *int     `json:"per_device_train_batch_size,omitempty"`
+	GradientAccumulationSteps *int     `json:"gradient_accumulation_steps,omitempty"`
+	AutoFindBatchSize         *bool    `json:"auto_find_batch_size,omitempty"`
+	DDPFindUnusedParameters   *bool    `json:"ddp_find_unused_parameters,omitempty"`
+	SaveStrategy              *string  `json:"save_strategy,omitempty"`
+}
+
+// decodeSection decodes the named section of the training config into out and returns whether the
+// section is set. If strict, parameters not declared by out are rejected.
+func decodeSection(sections map[string]runtime.RawExtension, name string, out interface{}, strict bool) (bool, *apis.FieldError) {
+	raw, found := sections[name]
+	if !found {
+		return false, nil
+	}
+	data, err := yaml.YAMLToJSON(raw.Raw)
+	if err != nil {
+		return true, apis.ErrInvalidValue(fmt.Sprintf("failed to parse %s: %v", name, err), name)
+	}
+	if bytes.Equal(data, []byte("null")) {
+		return true, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(out); err != nil {
+		return true, apis.ErrInvalidValue(fmt.Sprintf("invalid %s: %v", name, err), name)
+	}
+	return true, nil
+}
+
+// GetModelConfig returns the ModelConfig section, or nil if it is not set.
+func (t *TrainingConfig) GetModelConfig() (*ModelConfigSchema, *apis.FieldError) {
+	var section ModelConfigSchema
+	if found, err := decodeSection(t.ModelConfig, "ModelConfig", &section, true); !found || err != nil {
+		return nil, err
+	}
+	return &section, nil
+}
+
+// GetQuantizationConfig returns the QuantizationConfig section, or nil if it is not set.
+func (t *TrainingConfig) GetQuantizationConfig() (*QuantizationConfigSchema, *apis.FieldError) {
+	var section QuantizationConfigSchema
+	if found, err := decodeSection(t.QuantizationConfig, "QuantizationConfig", &section, true); !found || err != nil {
+		return nil, err
+	}
+	return &section, nil
+}
+
+// GetDatasetConfig returns the DatasetConfig section, or nil if it is not set.
+func (t *TrainingConfig) GetDatasetConfig() (*DatasetConfigSchema, *apis.FieldError) {
+	var section DatasetConfigSchema
+	if found, err := decodeSection(t.DatasetConfig, "DatasetConfig", &section, true); !found || err != nil {
+		return nil, err
+	}
+	return &section, nil
+}
+
+// GetTrainingArguments returns the TrainingArguments section, or nil if it is not set.
+func (t *TrainingConfig) GetTrainingArguments() (*TrainingArgumentsSchema, *apis.FieldError) {
+	var section TrainingArgumentsSchema
+	if found, err := decodeSection(t.TrainingArguments, "TrainingArguments", &section, false); !found || err != nil {
+		return nil, err
+	}
+	return &section, nil
+}
+
+// validateSchema checks the parameters and their types in the sections of the training config.
+func (t *TrainingConfig) validateSchema() (errs *apis.FieldError) {
+	if _, err := t.GetModelConfig(); err != nil {
+		errs = errs.Also(err)
+	}
+	if _, err := t.GetQuantizationConfig(); err != nil {
+		errs = errs.Also(err)
+	}
+	if _, err := t.GetDatasetConfig(); err != nil {
+		errs = errs.Also(err)
+	}
+	if _, err := t.GetTrainingArguments(); err != nil {
+		errs = errs.Also(err)
+	}
+	return errs
+}
@@ -0,0 +1,96 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UsageReportSpec is the usage of a workspace over a period, recorded by the operator for chargeback.
+type UsageReportSpec struct {
+	// Workspace is the name of the workspace, in the namespace of the report.
+	Workspace string `json:"workspace"`
+	// Start is the beginning of the period of the report.
+	Start metav1.Time `json:"start"`
+	// End is the end of the period of the report, and the beginning of the period of the next report.
+	End metav1.Time `json:"end"`
+	// InstanceType is the instance type of the nodes of the workspace.
+	// +optional
+	InstanceType string `json:"instanceType,omitempty"`
+	// GPUHours is the GPU time of the ready nodes of the workspace over the period, as a decimal number, e.g., "1.500".
+	// It is approximated by the number of ready nodes at the end of the period times its duration, so the nodes
+	// added or removed during the period are billed for the whole period or not at all. Shorter reporting
+	// intervals make the approximation closer.
+	GPUHours string `json:"gpuHours"`
+	// Requests is the number of generation requests served by the preset inference service over the period.
+	// The counts are zero for the other workloads.
+	// +optional
+	Requests int64 `json:"requests,omitempty"`
+	// InputTokens is the number of tokens of the prompts of the requests.
+	// +optional
+	InputTokens int64 `json:"inputTokens,omitempty"`
+	// OutputTokens is the number of tokens generated for the requests.
+	// +optional
+	OutputTokens int64 `json:"outputTokens,omitempty"`
+	// Pods are the counters of the inference pods at the end of the period. The counts of the next period
+	// are derived from them.
+	// +optional
+	Pods []PodUsage `json:"pods,omitempty"`
+}
+
+// PodUsage holds the counters of an inference pod since its inference service started.
+type PodUsage struct {
+	// Name is the name of the pod.
+	Name string `json:"name"`
+	// Requests is the number of generation requests served by the pod.
+	// +optional
+	Requests int64 `json:"requests,omitempty"`
+	// InputTokens is the number of tokens of the prompts of the requests.
+	// +optional
+	InputTokens int64 `json:"inputTokens,omitempty"`
+	// OutputTokens is the number of tokens generated for the requests.
+	// +optional
+	OutputTokens int64 `json:"outputTokens,omitempty"`
+}
+
+// UsageReportConditionTypePosted reports whether the usage report was posted to the usage report webhook.
+// The reports not posted yet are posted again every interval, oldest first.
+const UsageReportConditionTypePosted = ConditionType("Posted")
+
+// UsageReportStatus is the delivery of a usage report to the usage report webhook.
+type UsageReportStatus struct {
+	// Conditions report whether the report was posted, see UsageReportConditionTypePosted.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// UsageReport is the Schema for the usagereports API. The operator writes one report per workspace and
+// reporting period when usage reporting is enabled.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=usagereports,scope=Namespaced,categories=workspace,shortName=ur
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Workspace",type="string",JSONPath=".spec.workspace",description=""
+// +kubebuilder:printcolumn:name="End",type="date",JSONPath=".spec.end",description=""
+// +kubebuilder:printcolumn:name="GPUHours",type="string",JSONPath=".spec.gpuHours",description=""
+// +kubebuilder:printcolumn:name="Requests",type="integer",JSONPath=".spec.requests",description=""
+type UsageReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UsageReportSpec   `json:"spec,omitempty"`
+	Status UsageReportStatus `json:"status,omitempty"`
+}
+
+// UsageReportList contains a list of UsageReport
+// +kubebuilder:object:root=true
+type UsageReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []UsageReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&UsageReport{}, &UsageReportList{})
+}
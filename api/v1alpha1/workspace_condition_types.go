@@ -19,6 +19,13 @@ const (
 	// WorkspaceConditionTypeInferenceStatus is the state when Inference has been created.
 	WorkspaceConditionTypeInferenceStatus = ConditionType("InferenceReady")
 
+	// WorkspaceConditionTypeRunParamsOverridden is the state when model run parameters are overridden by a source of higher precedence.
+	WorkspaceConditionTypeRunParamsOverridden = ConditionType("RunParamsOverridden")
+
+	// WorkspaceConditionTypeDriftDetected is the state when fields of the inference workload drift from the manifest generated by Kaito
+	// and are left in place, see DriftFields. Drifted fields that are corrected are reported by a DriftCorrected event instead.
+	WorkspaceConditionTypeDriftDetected = ConditionType("DriftDetected")
+
 	//WorkspaceConditionTypeDeleting is the Workspace state when starts to get deleted.
 	WorkspaceConditionTypeDeleting = ConditionType("WorkspaceDeleting")
 
@@ -10,3 +10,23 @@ import (
 // SetDefaults for the Workspace
 func (w *Workspace) SetDefaults(_ context.Context) {
 }
+
+// ApplyWorkspaceClass sets the defaults of the class on the fields the workspace leaves unset. The
+// defaults are not persisted, so that the changes to the class apply to the existing workspaces.
+func (w *Workspace) ApplyWorkspaceClass(class *WorkspaceClass) {
+	if w.Resource.SchedulerName == "" {
+		w.Resource.SchedulerName = class.Spec.SchedulerName
+	}
+	if w.Inference != nil && w.Inference.Storage == nil && class.Spec.Storage != nil {
+		w.Inference.Storage = class.Spec.Storage.DeepCopy()
+	}
+	for key, value := range class.Spec.Annotations {
+		if _, ok := w.Annotations[key]; ok {
+			continue
+		}
+		if w.Annotations == nil {
+			w.Annotations = map[string]string{}
+		}
+		w.Annotations[key] = value
+	}
+}
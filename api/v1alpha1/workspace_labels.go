@@ -13,9 +13,100 @@ const (
 	// AnnotationEnableLB determines whether kaito creates LoadBalancer type service for testing.
 	AnnotationEnableLB = KAITOPrefix + "enablelb"
 
+	// AnnotationPresetHash records on the workload pod template the hash of the preset parameters it was created from.
+	AnnotationPresetHash = KAITOPrefix + "preset-hash"
+
+	// AnnotationSpecHash records on the workload the hash of the spec generated by Kaito, to detect that the
+	// generated spec has changed without comparing it with the spec defaulted by the API server.
+	AnnotationSpecHash = KAITOPrefix + "spec-hash"
+
+	// AnnotationModelRunParams overrides the model run parameters of the preset, as a JSON object of strings.
+	AnnotationModelRunParams = KAITOPrefix + "model-run-params"
+
+	// AnnotationRDMA overrides whether the workload pods are configured for RDMA, which is enabled on
+	// RDMA capable instance types. Its value is "enabled" or "disabled".
+	AnnotationRDMA = KAITOPrefix + "rdma"
+
+	// AnnotationGPUScoringStrategy hints GPU-aware scheduler plugins how to score the nodes for the workload pods,
+	// e.g., "MostAllocated" to bin-pack them.
+	AnnotationGPUScoringStrategy = KAITOPrefix + "gpu-scoring-strategy"
+
+	// AnnotationChatTemplate selects the chat template of the preset inference service by its key in the
+	// ConfigMaps of inference.chatTemplates.
+	AnnotationChatTemplate = KAITOPrefix + "chat-template"
+
+	// AnnotationDriftIgnoredFields lists, comma-separated, the fields of the inference workload whose drift from
+	// the manifest generated by Kaito is reported but not corrected, e.g., "replicas" to scale the workload by hand.
+	AnnotationDriftIgnoredFields = KAITOPrefix + "drift-ignored-fields"
+
+	// AnnotationInferenceLogLevel sets the log level of the preset inference service: "debug", "info", "warning" or "error".
+	AnnotationInferenceLogLevel = KAITOPrefix + "inference-log-level"
+
+	// AnnotationInferenceLogFormat sets the format of the logs of the preset inference service: "text" or "json".
+	AnnotationInferenceLogFormat = KAITOPrefix + "inference-log-format"
+
+	// AnnotationRequestLogging sets what the preset inference service logs about the requests: "none", "access" for
+	// one line per request, or "full" to also log the generated text.
+	AnnotationRequestLogging = KAITOPrefix + "request-logging"
+
+	// AnnotationReadinessTimeout overrides how long the inference workload is given to become ready, as a
+	// duration, e.g., "2h".
+	AnnotationReadinessTimeout = KAITOPrefix + "readiness-timeout"
+
+	// AnnotationInferenceResources sets the CPU and memory requests of the preset inference container, as a JSON
+	// object of quantities, e.g., {"cpu": "8", "memory": "64Gi"}.
+	AnnotationInferenceResources = KAITOPrefix + "inference-resources"
+
+	// AnnotationPresetImageTag pins the tag of the public preset image of the workspace, e.g., "0.0.4", instead of
+	// following the tag of the preset. The tag must be allowed by the operator.
+	AnnotationPresetImageTag = KAITOPrefix + "preset-image-tag"
+
+	// AnnotationTuningColocation sets whether the tuning job of the workspace can be scheduled on the nodes serving
+	// inference workspaces: "deny", "partitioned" for the nodes whose GPUs are shared with MPS or MIG only, or "allow".
+	AnnotationTuningColocation = KAITOPrefix + "tuning-colocation"
+
+	// LabelInferenceNode is set by Kaito on the nodes of the inference workspaces, so that tuning jobs can avoid them.
+	LabelInferenceNode = KAITOPrefix + "inference-node"
+
 	// LabelWorkspaceName is the label for workspace name.
 	LabelWorkspaceName = KAITOPrefix + "workspace"
 
 	// LabelWorkspaceName is the label for workspace namespace.
 	LabelWorkspaceNamespace = KAITOPrefix + "workspacenamespace"
 )
+
+const (
+	RDMAEnabled  = "enabled"
+	RDMADisabled = "disabled"
+)
+
+// The values of the tuning colocation annotation.
+const (
+	TuningColocationDeny        = "deny"
+	TuningColocationPartitioned = "partitioned"
+	TuningColocationAllow       = "allow"
+)
+
+// TuningColocationPolicies are the values of the tuning colocation annotation.
+var TuningColocationPolicies = []string{TuningColocationDeny, TuningColocationPartitioned, TuningColocationAllow}
+
+// The values of the logging annotations of the preset inference service.
+var (
+	InferenceLogLevels  = []string{"debug", "info", "warning", "error"}
+	InferenceLogFormats = []string{"text", "json"}
+	RequestLoggingModes = []string{"none", "access", "full"}
+)
+
+// The fields of the inference workload checked for drift. Drift detection only covers the replicas of the
+// inference workload and these fields of its inference container: the other fields of the pod template, the
+// other containers, and the other objects created by Kaito, e.g., the Service, are not checked.
+const (
+	DriftFieldReplicas  = "replicas"
+	DriftFieldImage     = "image"
+	DriftFieldCommand   = "command"
+	DriftFieldEnv       = "env"
+	DriftFieldResources = "resources"
+)
+
+// DriftFields are the fields of the inference workload checked for drift, in the order they are reported.
+var DriftFields = []string{DriftFieldReplicas, DriftFieldImage, DriftFieldCommand, DriftFieldEnv, DriftFieldResources}
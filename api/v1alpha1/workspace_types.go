@@ -4,7 +4,9 @@
 package v1alpha1
 
 import (
+	"github.com/azure/kaito/pkg/utils/plugin"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -38,6 +40,11 @@ type ResourceSpec struct {
 	// the required instanceType, it will be ignored.
 	// +optional
 	PreferredNodes []string `json:"preferredNodes,omitempty"`
+
+	// SchedulerName specifies the scheduler of the workload pods, e.g., a GPU-aware scheduler.
+	// The default scheduler of Kaito is used if not specified.
+	// +optional
+	SchedulerName string `json:"schedulerName,omitempty"`
 }
 
 type ModelName string
@@ -54,6 +61,15 @@ type PresetMeta struct {
 	// +kubebuilder:default:="public"
 	// +optional
 	AccessMode ModelImageAccessMode `json:"accessMode,omitempty"`
+	// Version pins the preset revision of the model, so that the workspace keeps using the preset
+	// configurations it was validated against. If not specified, the default revision is used.
+	// +optional
+	Version string `json:"version,omitempty"`
+}
+
+// ModelReference returns the reference used to look up the preset revision in the model register.
+func (p *PresetMeta) ModelReference() string {
+	return plugin.ModelReference(string(p.Name), p.Version)
 }
 
 type PresetOptions struct {
@@ -87,6 +103,47 @@ type InferenceSpec struct {
 	// Users can specify multiple adapters for the model and the respective weight of using each of them.
 	// +optional
 	Adapters []AdapterSpec `json:"adapters,omitempty"`
+	// Storage specifies where the preset inference service stores the model files, which are mounted
+	// at /mnt/model-cache. If not specified, the local NVMe model cache of the node is used if it is set up.
+	// +optional
+	Storage *ModelStorageSpec `json:"storage,omitempty"`
+	// ChatTemplates are the names of ConfigMaps in the same namespace whose keys are jinja chat templates.
+	// They are mounted at /workspace/chat_templates, and the template used by the preset inference service
+	// is selected by the kaito.sh/chat-template annotation. Note that the keys of the ConfigMaps must be unique.
+	// Chat templates are only supported by the presets served by the text-generation inference script,
+	// e.g., falcon, mistral and phi.
+	// +optional
+	ChatTemplates []string `json:"chatTemplates,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=NodeLocal;Ephemeral;Persistent
+type ModelStoragePolicy string
+
+const (
+	// ModelStoragePolicyNodeLocal stores the model files on the node, in the local NVMe model cache if it
+	// is set up for the instance type or in an emptyDir volume otherwise.
+	ModelStoragePolicyNodeLocal ModelStoragePolicy = "NodeLocal"
+	// ModelStoragePolicyEphemeral stores the model files in a generic ephemeral volume, deleted with the pod.
+	ModelStoragePolicyEphemeral ModelStoragePolicy = "Ephemeral"
+	// ModelStoragePolicyPersistent stores the model files in a dedicated PVC, retained across pod restarts
+	// and deleted with the workspace.
+	ModelStoragePolicyPersistent ModelStoragePolicy = "Persistent"
+)
+
+type ModelStorageSpec struct {
+	// Policy is the storage medium of the model files.
+	// This field defaults to "NodeLocal" if not specified.
+	// +kubebuilder:default:="NodeLocal"
+	// +optional
+	Policy ModelStoragePolicy `json:"policy,omitempty"`
+	// StorageClassName is the storage class of the Ephemeral or Persistent volume.
+	// The default storage class is used if not specified.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+	// Size is the size of the Ephemeral or Persistent volume.
+	// This field defaults to the disk storage requirement of the preset if not specified.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
 }
 
 type AdapterSpec struct {
@@ -118,6 +175,10 @@ type DataSource struct {
 	// ImagePullSecrets is a list of secret names in the same namespace used for pulling the data image.
 	// +optional
 	ImagePullSecrets []string `json:"imagePullSecrets,omitempty"`
+	// VolumeClaim, if specified, makes Kaito create a PersistentVolumeClaim the data of the URLs or the image
+	// is downloaded to, instead of an emptyDir volume. It is only supported by the tuning input.
+	// +optional
+	VolumeClaim *VolumeClaimSpec `json:"volumeClaim,omitempty"`
 }
 
 type DataDestination struct {
@@ -133,6 +194,35 @@ type DataDestination struct {
 	// information that is needed for running `docker push`.
 	// +optional
 	ImagePushSecret string `json:"imagePushSecret,omitempty"`
+	// VolumeClaim, if specified, makes Kaito create a PersistentVolumeClaim the output data is saved to.
+	// +optional
+	VolumeClaim *VolumeClaimSpec `json:"volumeClaim,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Retain;Delete
+type VolumeClaimRetentionPolicy string
+
+const (
+	// VolumeClaimRetentionPolicyRetain keeps the PersistentVolumeClaim until the workspace is deleted.
+	VolumeClaimRetentionPolicyRetain VolumeClaimRetentionPolicy = "Retain"
+	// VolumeClaimRetentionPolicyDelete deletes the PersistentVolumeClaim once the tuning job completes.
+	VolumeClaimRetentionPolicyDelete VolumeClaimRetentionPolicy = "Delete"
+)
+
+// VolumeClaimSpec describes a PersistentVolumeClaim created by Kaito for the data of the tuning job.
+type VolumeClaimSpec struct {
+	// Size is the size of the volume.
+	Size resource.Quantity `json:"size"`
+	// StorageClassName is the storage class of the volume.
+	// The default storage class is used if not specified.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+	// RetentionPolicy is what happens to the PersistentVolumeClaim once the tuning job completes.
+	// This field defaults to "Retain" if not specified. The Delete policy of an output volume claim
+	// requires an output image, which holds the adapter once the volume claim is deleted.
+	// +kubebuilder:default:="Retain"
+	// +optional
+	RetentionPolicy VolumeClaimRetentionPolicy `json:"retentionPolicy,omitempty"`
 }
 
 type TuningMethod string
@@ -159,6 +249,29 @@ type TuningSpec struct {
 	Input *DataSource `json:"input"`
 	// Output specified where to store the tuning output.
 	Output *DataDestination `json:"output"`
+	// Env are additional environment variables of the tuning container, e.g., the API key of an experiment
+	// tracker read from a secret. The variables set by Kaito cannot be overridden.
+	// +optional
+	Env []v1.EnvVar `json:"env,omitempty"`
+	// Mounts are the secrets and configmaps mounted read-only in the tuning container, e.g., the pip
+	// configuration of a private package index.
+	// +optional
+	Mounts []TuningMount `json:"mounts,omitempty"`
+}
+
+// TuningMount mounts a secret or a configmap of the namespace of the workspace in the tuning container.
+type TuningMount struct {
+	// Name identifies the mount, it must be a DNS label unique among the mounts.
+	Name string `json:"name"`
+	// MountPath is the absolute path the secret or configmap is mounted at. The paths under /mnt and
+	// /dev/shm are reserved for the volumes of Kaito.
+	MountPath string `json:"mountPath"`
+	// Secret is the name of the secret to mount.
+	// +optional
+	Secret string `json:"secret,omitempty"`
+	// ConfigMap is the name of the configmap to mount.
+	// +optional
+	ConfigMap string `json:"configMap,omitempty"`
 }
 
 // WorkspaceStatus defines the observed state of Workspace
@@ -170,6 +283,80 @@ type WorkspaceStatus struct {
 	// Conditions report the current conditions of the workspace.
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Inference reports how the preset inference service of the workspace is run.
+	// +optional
+	Inference *InferenceStatus `json:"inference,omitempty"`
+
+	// Tuning reports the failed attempts of the tuning job of the workspace.
+	// +optional
+	Tuning *TuningStatus `json:"tuning,omitempty"`
+}
+
+// InferenceStatus reports the rendered command and the image of the preset inference service.
+type InferenceStatus struct {
+	// Command is the command line of the inference container.
+	Command string `json:"command,omitempty"`
+	// RunParams are the model run parameters resolved from the preset, the operator configuration and the workspace.
+	// +optional
+	RunParams map[string]string `json:"runParams,omitempty"`
+	// PresetHash is the hash of the preset parameters the command is rendered from.
+	// +optional
+	PresetHash string `json:"presetHash,omitempty"`
+	// Image is the image of the inference container.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// ImageDigest is the digest of the image the inference pods run, as resolved by the container runtime.
+	// +optional
+	ImageDigest string `json:"imageDigest,omitempty"`
+	// ObservedGeneration is the generation of the workspace the command is rendered for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// TuningFailureReason is the classification of the failure of a tuning job.
+// +kubebuilder:validation:Enum=OutOfMemory;HostOutOfMemory;NaNLoss;DatasetError;Preempted;Unknown
+type TuningFailureReason string
+
+const (
+	// TuningFailureOutOfMemory means the tuning container ran out of GPU memory.
+	TuningFailureOutOfMemory TuningFailureReason = "OutOfMemory"
+	// TuningFailureHostOutOfMemory means the tuning container was killed for exceeding its host memory
+	// limit, which a smaller batch size does not fix.
+	TuningFailureHostOutOfMemory TuningFailureReason = "HostOutOfMemory"
+	// TuningFailureNaNLoss means the training loss diverged to NaN.
+	TuningFailureNaNLoss TuningFailureReason = "NaNLoss"
+	// TuningFailureDatasetError means the dataset could not be loaded or prepared.
+	TuningFailureDatasetError TuningFailureReason = "DatasetError"
+	// TuningFailurePreempted means the tuning pod was preempted, evicted or lost its node.
+	TuningFailurePreempted TuningFailureReason = "Preempted"
+	// TuningFailureUnknown is any other failure.
+	TuningFailureUnknown TuningFailureReason = "Unknown"
+)
+
+// TuningStatus reports the failed attempts of the tuning job.
+type TuningStatus struct {
+	// Attempts are the failed attempts of the tuning job, the oldest first.
+	// +optional
+	Attempts []TuningAttempt `json:"attempts,omitempty"`
+}
+
+// TuningAttempt is a failed attempt of the tuning job.
+type TuningAttempt struct {
+	// JobUID is the UID of the job of the attempt.
+	JobUID string `json:"jobUID"`
+	// FailureReason is the classification of the failure.
+	FailureReason TuningFailureReason `json:"failureReason"`
+	// Message describes the failure, e.g., the exit code or the last lines of the logs of the tuning container.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// FailedTime is when the failure was observed.
+	FailedTime metav1.Time `json:"failedTime"`
+	// BatchSizeDivisor is the divisor the batch size of the attempt was reduced by, if any.
+	// +optional
+	BatchSizeDivisor int `json:"batchSizeDivisor,omitempty"`
+	// Retried is whether the job was retried after the failure, per the retry policy of the controller.
+	Retried bool `json:"retried"`
 }
 
 // Workspace is the Schema for the workspaces API
@@ -186,6 +373,11 @@ type Workspace struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
+	// WorkspaceClassName is the name of the WorkspaceClass whose defaults and policy apply to the workspace.
+	// It cannot be changed once the workspace is created.
+	// +optional
+	WorkspaceClassName string `json:"workspaceClassName,omitempty"`
+
 	Resource  ResourceSpec    `json:"resource,omitempty"`
 	Inference *InferenceSpec  `json:"inference,omitempty"`
 	Tuning    *TuningSpec     `json:"tuning,omitempty"`
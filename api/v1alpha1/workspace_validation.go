@@ -6,20 +6,31 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
+	"path"
 	"reflect"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/azure/kaito/pkg/featuregates"
+	"github.com/azure/kaito/pkg/k8sclient"
+	"github.com/azure/kaito/pkg/model"
+	"github.com/azure/kaito/pkg/runparams"
 	"github.com/azure/kaito/pkg/utils"
+	"github.com/azure/kaito/pkg/utils/consts"
 	"github.com/azure/kaito/pkg/utils/plugin"
 
+	"github.com/samber/lo"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/klog/v2"
 	"knative.dev/pkg/apis"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
@@ -29,8 +40,48 @@ const (
 	DefaultLoraConfigMapTemplate  = "lora-params-template"
 	DefaultQloraConfigMapTemplate = "qlora-params-template"
 	MaxAdaptersNumber             = 10
+
+	// gpuMemoryHeadroomPercent is the GPU memory above the requirement of a preset under which the
+	// instance type is reported to barely fit the model.
+	gpuMemoryHeadroomPercent = 10
 )
 
+// ReservedTuningEnv are the environment variables of the tuning container set by Kaito, see pkg/tuning.
+var ReservedTuningEnv = []string{"BATCH_SIZE_DIVISOR", "PER_DEVICE_TRAIN_BATCH_SIZE", "GRADIENT_ACCUMULATION_STEPS"}
+
+// reservedTuningMountPaths are the directories of the volumes mounted by Kaito in the tuning container.
+var reservedTuningMountPaths = []string{"/mnt", utils.DefaultVolumeMountPath}
+
+// maxTuningMountNameLength leaves room for the prefix of the names of the volumes of the mounts.
+const maxTuningMountNameLength = validation.DNS1123LabelMaxLength - len("mount-")
+
+type warnOnlyKey struct{}
+
+// WithWarnOnly returns a context in which the validation errors of the workspaces are reported as
+// warnings, e.g., while migrating workspaces that do not pass new validations.
+func WithWarnOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, warnOnlyKey{}, true)
+}
+
+func isWarnOnly(ctx context.Context) bool {
+	warnOnly, _ := ctx.Value(warnOnlyKey{}).(bool)
+	return warnOnly
+}
+
+type offlineKey struct{}
+
+// WithOffline returns a context in which the workspaces are validated for an air-gapped cluster: their
+// data sources cannot be downloaded from URLs, and public presets are only allowed if presetImageInternal
+// reports that their image is pulled from an internal registry.
+func WithOffline(ctx context.Context, presetImageInternal func(presetName string) bool) context.Context {
+	return context.WithValue(ctx, offlineKey{}, presetImageInternal)
+}
+
+func offlinePresetImageInternal(ctx context.Context) (func(presetName string) bool, bool) {
+	presetImageInternal, ok := ctx.Value(offlineKey{}).(func(presetName string) bool)
+	return presetImageInternal, ok
+}
+
 func (w *Workspace) SupportedVerbs() []admissionregistrationv1.OperationType {
 	return []admissionregistrationv1.OperationType{
 		admissionregistrationv1.Create,
@@ -40,9 +91,66 @@ func (w *Workspace) SupportedVerbs() []admissionregistrationv1.OperationType {
 
 func (w *Workspace) Validate(ctx context.Context) (errs *apis.FieldError) {
 	base := apis.GetBaseline(ctx)
+	if base == nil {
+		// The defaults of the workspace class are validated along with the workspace.
+		errs = errs.Also(w.applyWorkspaceClass(ctx))
+	}
+	if value, ok := w.Annotations[AnnotationModelRunParams]; ok {
+		if _, err := runparams.ParseAnnotation(value); err != nil {
+			errs = errs.Also(apis.ErrInvalidValue(err.Error(), AnnotationModelRunParams).ViaField("metadata", "annotations"))
+		}
+	}
+	if value, ok := w.Annotations[AnnotationRDMA]; ok && value != RDMAEnabled && value != RDMADisabled {
+		errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("%s must be %q or %q", value, RDMAEnabled, RDMADisabled), AnnotationRDMA).ViaField("metadata", "annotations"))
+	}
+	if value, ok := w.Annotations[AnnotationDriftIgnoredFields]; ok {
+		for _, field := range strings.Split(value, ",") {
+			if !utils.Contains(DriftFields, strings.TrimSpace(field)) {
+				errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("unsupported drift field %q, must be one of %s", field, strings.Join(DriftFields, ", ")),
+					AnnotationDriftIgnoredFields).ViaField("metadata", "annotations"))
+			}
+		}
+	}
+	for annotation, values := range map[string][]string{
+		AnnotationInferenceLogLevel:  InferenceLogLevels,
+		AnnotationInferenceLogFormat: InferenceLogFormats,
+		AnnotationRequestLogging:     RequestLoggingModes,
+		AnnotationTuningColocation:   TuningColocationPolicies,
+	} {
+		if value, ok := w.Annotations[annotation]; ok && !utils.Contains(values, value) {
+			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("%s must be one of %s", value, strings.Join(values, ", ")), annotation).ViaField("metadata", "annotations"))
+		}
+	}
+	if value, ok := w.Annotations[AnnotationReadinessTimeout]; ok {
+		if timeout, err := time.ParseDuration(value); err != nil || timeout <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("%s must be a positive duration, e.g., 2h", value), AnnotationReadinessTimeout).ViaField("metadata", "annotations"))
+		}
+	}
+	if value, ok := w.Annotations[AnnotationInferenceResources]; ok {
+		if _, err := utils.ParseResourceRequests(value); err != nil {
+			errs = errs.Also(apis.ErrInvalidValue(err.Error(), AnnotationInferenceResources).ViaField("metadata", "annotations"))
+		}
+	}
+	if value, ok := w.Annotations[AnnotationPresetImageTag]; ok {
+		if w.Inference == nil || w.Inference.Preset == nil {
+			errs = errs.Also(apis.ErrGeneric("preset image tag pinned without inference.preset", AnnotationPresetImageTag).ViaField("metadata", "annotations"))
+		} else if err := plugin.KaitoModelRegister.ValidateImageTag(value); err != nil {
+			errs = errs.Also(apis.ErrInvalidValue(err.Error(), AnnotationPresetImageTag).ViaField("metadata", "annotations"))
+		}
+	}
+	if value, ok := w.Annotations[AnnotationChatTemplate]; ok {
+		if w.Inference == nil || len(w.Inference.ChatTemplates) == 0 {
+			errs = errs.Also(apis.ErrGeneric("chat template selected without inference.chatTemplates", AnnotationChatTemplate).ViaField("metadata", "annotations"))
+		} else if msgs := validation.IsConfigMapKey(value); len(msgs) > 0 {
+			errs = errs.Also(apis.ErrInvalidValue(strings.Join(msgs, "; "), AnnotationChatTemplate).ViaField("metadata", "annotations"))
+		} else if preset := w.Inference.Preset; preset != nil && !presetSupportsChatTemplate(preset) {
+			errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("Preset %s does not support chat templates", preset.Name), AnnotationChatTemplate).ViaField("metadata", "annotations"))
+		}
+	}
 	if base == nil {
 		klog.InfoS("Validate creation", "workspace", fmt.Sprintf("%s/%s", w.Namespace, w.Name))
-		errs = errs.Also(w.validateCreate().ViaField("spec"))
+		w.resolvePresets(ctx)
+		errs = errs.Also(w.validateCreate().ViaField("spec"), w.validateOffline(ctx))
 		if w.Inference != nil {
 			// TODO: Add Adapter Spec Validation - Including DataSource Validation for Adapter
 			errs = errs.Also(w.Resource.validateCreate(*w.Inference).ViaField("resource"),
@@ -66,9 +174,53 @@ func (w *Workspace) Validate(ctx context.Context) (errs *apis.FieldError) {
 			errs = errs.Also(w.Tuning.validateUpdate(old.Tuning).ViaField("tuning"))
 		}
 	}
+	if isWarnOnly(ctx) {
+		return errs.At(apis.WarningLevel)
+	}
+	return errs
+}
+
+// applyWorkspaceClass applies the defaults of the workspace class to the workspace and checks the
+// instance type of the workspace against the class.
+func (w *Workspace) applyWorkspaceClass(ctx context.Context) (errs *apis.FieldError) {
+	if w.WorkspaceClassName == "" {
+		return nil
+	}
+	if k8sclient.Client == nil {
+		return apis.ErrGeneric("Failed to obtain client from context.Context")
+	}
+	class := &WorkspaceClass{}
+	if err := k8sclient.Client.Get(ctx, client.ObjectKey{Name: w.WorkspaceClassName}, class); err != nil {
+		if apierrors.IsNotFound(err) {
+			return apis.ErrInvalidValue(fmt.Sprintf("WorkspaceClass '%s' not found", w.WorkspaceClassName), "workspaceClassName")
+		}
+		return apis.ErrGeneric(fmt.Sprintf("Failed to get WorkspaceClass '%s': %v", w.WorkspaceClassName, err), "workspaceClassName")
+	}
+	if !class.Spec.AllowsInstanceType(w.Resource.InstanceType) {
+		errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("instance type %s is not allowed by WorkspaceClass '%s', allowed instance types: %s",
+			w.Resource.InstanceType, w.WorkspaceClassName, strings.Join(class.Spec.InstanceTypes, ", ")), "instanceType").ViaField("resource"))
+	}
+	w.ApplyWorkspaceClass(class)
 	return errs
 }
 
+// resolvePresets loads the referenced preset models that are not registered yet, e.g., presets
+// declared by ConfigMaps, so that the validation can find them.
+func (w *Workspace) resolvePresets(ctx context.Context) {
+	var presets []*PresetSpec
+	if w.Inference != nil && w.Inference.Preset != nil {
+		presets = append(presets, w.Inference.Preset)
+	}
+	if w.Tuning != nil && w.Tuning.Preset != nil {
+		presets = append(presets, w.Tuning.Preset)
+	}
+	for _, preset := range presets {
+		if _, err := plugin.KaitoModelRegister.GetOrResolve(ctx, preset.ModelReference()); err != nil {
+			klog.InfoS("Failed to resolve preset", "preset", preset.ModelReference(), "err", err)
+		}
+	}
+}
+
 func (w *Workspace) validateCreate() (errs *apis.FieldError) {
 	if w.Inference == nil && w.Tuning == nil {
 		errs = errs.Also(apis.ErrGeneric("Either Inference or Tuning must be specified, not neither", ""))
@@ -79,6 +231,47 @@ func (w *Workspace) validateCreate() (errs *apis.FieldError) {
 	return errs
 }
 
+// validateOffline rejects the workspaces that depend on external endpoints in an air-gapped cluster.
+func (w *Workspace) validateOffline(ctx context.Context) (errs *apis.FieldError) {
+	presetImageInternal, offline := offlinePresetImageInternal(ctx)
+	if !offline {
+		return nil
+	}
+	if w.Inference != nil {
+		if w.Inference.Preset != nil {
+			if model, err := plugin.KaitoModelRegister.Get(w.Inference.Preset.ModelReference()); err == nil {
+				errs = errs.Also(validateOfflinePreset(w.Inference.Preset, model.GetInferenceParameters(), presetImageInternal).ViaField("inference", "preset"))
+			}
+		}
+		for i, adapter := range w.Inference.Adapters {
+			if adapter.Source != nil && len(adapter.Source.URLs) > 0 {
+				errs = errs.Also(apis.ErrGeneric("URLs cannot be downloaded in offline mode", "urls").ViaFieldIndex("adapters", i).ViaField("inference"))
+			}
+		}
+	}
+	if w.Tuning != nil {
+		if w.Tuning.Preset != nil {
+			if model, err := plugin.KaitoModelRegister.Get(w.Tuning.Preset.ModelReference()); err == nil {
+				errs = errs.Also(validateOfflinePreset(w.Tuning.Preset, model.GetTuningParameters(), presetImageInternal).ViaField("tuning", "preset"))
+			}
+		}
+		if w.Tuning.Input != nil && len(w.Tuning.Input.URLs) > 0 {
+			errs = errs.Also(apis.ErrGeneric("URLs cannot be downloaded in offline mode", "urls").ViaField("tuning", "input"))
+		}
+	}
+	return errs
+}
+
+// validateOfflinePreset checks that the image of a public preset is pulled from an internal registry.
+// The images of private presets are set by the workspaces.
+func validateOfflinePreset(preset *PresetSpec, param *model.PresetParam, presetImageInternal func(presetName string) bool) *apis.FieldError {
+	if param == nil || param.ImageAccessMode == string(ModelImageAccessModePrivate) || presetImageInternal(string(preset.Name)) {
+		return nil
+	}
+	return apis.ErrGeneric(fmt.Sprintf("the image of preset %s is pulled from the public preset registry, "+
+		"offline mode requires an internal registry or mirror", preset.Name), "name")
+}
+
 func (w *Workspace) validateUpdate(old *Workspace) (errs *apis.FieldError) {
 	if (old.Inference == nil && w.Inference != nil) || (old.Inference != nil && w.Inference == nil) {
 		errs = errs.Also(apis.ErrGeneric("Inference field cannot be toggled once set", "inference"))
@@ -87,6 +280,10 @@ func (w *Workspace) validateUpdate(old *Workspace) (errs *apis.FieldError) {
 	if (old.Tuning == nil && w.Tuning != nil) || (old.Tuning != nil && w.Tuning == nil) {
 		errs = errs.Also(apis.ErrGeneric("Tuning field cannot be toggled once set", "tuning"))
 	}
+
+	if old.WorkspaceClassName != w.WorkspaceClassName {
+		errs = errs.Also(apis.ErrGeneric("WorkspaceClassName field is immutable", "workspaceClassName"))
+	}
 	return errs
 }
 
@@ -112,6 +309,9 @@ func (r *AdapterSpec) validateCreateorUpdate() (errs *apis.FieldError) {
 		if r.Source.Image == "" {
 			errs = errs.Also(apis.ErrMissingField("Image of Adapter field must be specified"))
 		}
+		if r.Source.VolumeClaim != nil {
+			errs = errs.Also(apis.ErrDisallowedFields("VolumeClaim of Adapter"))
+		}
 		if r.Strength == nil {
 			var defaultStrength = "1.0"
 			r.Strength = &defaultStrength
@@ -135,7 +335,7 @@ func (r *TuningSpec) validateCreate(ctx context.Context, workspaceNamespace stri
 	}
 	if r.ConfigTemplate == "" {
 		klog.InfoS("Tuning config not specified. Using default based on method.")
-		releaseNamespace, err := utils.GetReleaseNamespace()
+		releaseNamespace, err := utils.GetReleaseNamespace(ctx)
 		if err != nil {
 			errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("Failed to determine release namespace: %v", err), "namespace"))
 		}
@@ -163,11 +363,19 @@ func (r *TuningSpec) validateCreate(ctx context.Context, workspaceNamespace stri
 	} else {
 		errs = errs.Also(r.Output.validateCreate().ViaField("Output"))
 	}
+	errs = errs.Also(r.validateEnv(), r.validateMounts())
 	// Currently require a preset to specified, in future we can consider defining a template
 	if r.Preset == nil {
 		errs = errs.Also(apis.ErrMissingField("Preset"))
-	} else if presetName := string(r.Preset.Name); !isValidPreset(presetName) {
-		errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("Unsupported tuning preset name %s", presetName), "presetName"))
+	} else {
+		presetName := r.Preset.ModelReference()
+		if err := plugin.KaitoModelRegister.ValidateReference(presetName); err != nil {
+			errs = errs.Also(apis.ErrInvalidValue(err.Error(), "presetName"))
+		} else if !isValidPreset(presetName) {
+			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("Unsupported tuning preset name %s", presetName), "presetName"))
+		} else if model, err := plugin.KaitoModelRegister.Get(presetName); err == nil {
+			errs = errs.Also(validatePresetLifecycle(presetName, model.GetTuningParameters()))
+		}
 	}
 	return errs
 }
@@ -190,10 +398,71 @@ func (r *TuningSpec) validateUpdate(old *TuningSpec) (errs *apis.FieldError) {
 	if !reflect.DeepEqual(oldMethod, newMethod) {
 		errs = errs.Also(apis.ErrGeneric("Method cannot be changed", "Method"))
 	}
+	if !reflect.DeepEqual(old.Env, r.Env) {
+		errs = errs.Also(apis.ErrGeneric("Env cannot be changed", "Env"))
+	}
+	if !reflect.DeepEqual(old.Mounts, r.Mounts) {
+		errs = errs.Also(apis.ErrGeneric("Mounts cannot be changed", "Mounts"))
+	}
 	// Consider supporting config fields changing
 	return errs
 }
 
+// validateEnv checks that the environment variables of the tuning container have valid, unique names
+// that are not set by Kaito.
+func (r *TuningSpec) validateEnv() (errs *apis.FieldError) {
+	names := map[string]bool{}
+	for i, env := range r.Env {
+		if msgs := validation.IsEnvVarName(env.Name); len(msgs) > 0 {
+			errs = errs.Also(apis.ErrInvalidValue(strings.Join(msgs, ", "), "name").ViaFieldIndex("Env", i))
+		} else if utils.Contains(ReservedTuningEnv, env.Name) {
+			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("%s is set by Kaito", env.Name), "name").ViaFieldIndex("Env", i))
+		} else if names[env.Name] {
+			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("duplicate environment variable %s", env.Name), "name").ViaFieldIndex("Env", i))
+		}
+		names[env.Name] = true
+		if env.Value != "" && env.ValueFrom != nil {
+			errs = errs.Also(apis.ErrMultipleOneOf("value", "valueFrom").ViaFieldIndex("Env", i))
+		}
+	}
+	return errs
+}
+
+// validateMounts checks that the mounts of the tuning container have unique names and paths outside of
+// the directories of Kaito, and mount either a secret or a configmap.
+func (r *TuningSpec) validateMounts() (errs *apis.FieldError) {
+	names, paths := map[string]bool{}, map[string]bool{}
+	for i, mount := range r.Mounts {
+		if msgs := validation.IsDNS1123Label(mount.Name); len(msgs) > 0 {
+			errs = errs.Also(apis.ErrInvalidValue(strings.Join(msgs, ", "), "name").ViaFieldIndex("Mounts", i))
+		} else if len(mount.Name) > maxTuningMountNameLength {
+			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("must be no more than %d characters", maxTuningMountNameLength), "name").ViaFieldIndex("Mounts", i))
+		} else if names[mount.Name] {
+			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("duplicate mount %s", mount.Name), "name").ViaFieldIndex("Mounts", i))
+		}
+		names[mount.Name] = true
+
+		mountPath := path.Clean(mount.MountPath)
+		if !path.IsAbs(mount.MountPath) || mountPath == "/" {
+			errs = errs.Also(apis.ErrInvalidValue("must be an absolute path to a directory", "mountPath").ViaFieldIndex("Mounts", i))
+		} else if reserved, found := lo.Find(reservedTuningMountPaths, func(reserved string) bool {
+			return mountPath == reserved || strings.HasPrefix(mountPath, reserved+"/") || strings.HasPrefix(reserved, mountPath+"/")
+		}); found {
+			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("%s overlaps %s, reserved for the volumes of Kaito", mount.MountPath, reserved), "mountPath").ViaFieldIndex("Mounts", i))
+		} else if paths[mountPath] {
+			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("duplicate mount path %s", mount.MountPath), "mountPath").ViaFieldIndex("Mounts", i))
+		}
+		paths[mountPath] = true
+
+		if mount.Secret == "" && mount.ConfigMap == "" {
+			errs = errs.Also(apis.ErrMissingOneOf("secret", "configMap").ViaFieldIndex("Mounts", i))
+		} else if mount.Secret != "" && mount.ConfigMap != "" {
+			errs = errs.Also(apis.ErrMultipleOneOf("secret", "configMap").ViaFieldIndex("Mounts", i))
+		}
+	}
+	return errs
+}
+
 func (r *DataSource) validateCreate() (errs *apis.FieldError) {
 	sourcesSpecified := 0
 	if len(r.URLs) > 0 {
@@ -215,6 +484,12 @@ func (r *DataSource) validateCreate() (errs *apis.FieldError) {
 	if sourcesSpecified != 1 {
 		errs = errs.Also(apis.ErrGeneric("Exactly one of URLs, Volume, or Image must be specified", "URLs", "Volume", "Image"))
 	}
+	if r.VolumeClaim != nil {
+		if r.Volume != nil {
+			errs = errs.Also(apis.ErrMultipleOneOf("Volume", "VolumeClaim"))
+		}
+		errs = errs.Also(r.VolumeClaim.validateCreate().ViaField("VolumeClaim"))
+	}
 
 	return errs
 }
@@ -235,6 +510,9 @@ func (r *DataSource) validateUpdate(old *DataSource, isTuning bool) (errs *apis.
 		errs = errs.Also(apis.ErrInvalidValue("URLs field cannot be changed once set", "URLs"))
 	}
 	// TODO: check if the Volume is changed
+	if !reflect.DeepEqual(old.VolumeClaim, r.VolumeClaim) {
+		errs = errs.Also(apis.ErrInvalidValue("VolumeClaim field cannot be changed once set", "VolumeClaim"))
+	}
 	if old.Image != r.Image {
 		errs = errs.Also(apis.ErrInvalidValue("Image field cannot be changed once set", "Image"))
 	}
@@ -270,16 +548,31 @@ func (r *DataDestination) validateCreate() (errs *apis.FieldError) {
 		}
 		destinationsSpecified++
 	}
+	if r.VolumeClaim != nil {
+		if r.Volume != nil {
+			errs = errs.Also(apis.ErrMultipleOneOf("Volume", "VolumeClaim"))
+		}
+		errs = errs.Also(r.VolumeClaim.validateCreate().ViaField("VolumeClaim"))
+		// The output volume claim is deleted once the tuning job completes, so the adapter would be lost
+		// unless it is also pushed to an image.
+		if r.VolumeClaim.RetentionPolicy == VolumeClaimRetentionPolicyDelete && r.Image == "" {
+			errs = errs.Also(apis.ErrInvalidValue("Delete retention policy of the output volume claim requires an output image", "retentionPolicy").ViaField("VolumeClaim"))
+		}
+		destinationsSpecified++
+	}
 
 	// If no destination is specified, return an error
 	if destinationsSpecified == 0 {
-		errs = errs.Also(apis.ErrMissingField("At least one of Volume or Image must be specified"))
+		errs = errs.Also(apis.ErrMissingField("At least one of Volume, VolumeClaim or Image must be specified"))
 	}
 	return errs
 }
 
 func (r *DataDestination) validateUpdate(old *DataDestination) (errs *apis.FieldError) {
 	// TODO: Check if the Volume is changed.
+	if !reflect.DeepEqual(old.VolumeClaim, r.VolumeClaim) {
+		errs = errs.Also(apis.ErrInvalidValue("VolumeClaim field cannot be changed once set", "VolumeClaim"))
+	}
 	if old.Image != r.Image {
 		errs = errs.Also(apis.ErrInvalidValue("Image field cannot be changed once set", "Image"))
 	}
@@ -293,14 +586,14 @@ func (r *DataDestination) validateUpdate(old *DataDestination) (errs *apis.Field
 func (r *ResourceSpec) validateCreate(inference InferenceSpec) (errs *apis.FieldError) {
 	var presetName string
 	if inference.Preset != nil {
-		presetName = strings.ToLower(string(inference.Preset.Name))
+		presetName = strings.ToLower(inference.Preset.ModelReference())
 	}
 	instanceType := string(r.InstanceType)
 
 	// Check if instancetype exists in our SKUs map
 	if skuConfig, exists := SupportedGPUConfigs[instanceType]; exists {
-		if inference.Preset != nil {
-			model := plugin.KaitoModelRegister.MustGet(presetName) // InferenceSpec has been validated so the name is valid.
+		// An unsupported preset name is reported by the InferenceSpec validation.
+		if model, err := plugin.KaitoModelRegister.Get(presetName); inference.Preset != nil && err == nil {
 			// Validate GPU count for given SKU
 			machineCount := *r.Count
 			totalNumGPUs := machineCount * skuConfig.GPUCount
@@ -320,6 +613,21 @@ func (r *ResourceSpec) validateCreate(inference InferenceSpec) (errs *apis.Field
 			}
 			if int64(totalGPUMem) < modelTotalGPUMemory.ScaledValue(resource.Giga) {
 				errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("Insufficient total GPU memory: Instance type %s has a total of %d, but preset %s requires at least %d", instanceType, totalGPUMem, presetName, modelTotalGPUMemory.ScaledValue(resource.Giga)), "instanceType"))
+			} else if int64(totalGPUMem)*100 < modelTotalGPUMemory.ScaledValue(resource.Giga)*(100+gpuMemoryHeadroomPercent) {
+				errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("Instance type %s barely fits preset %s: its total GPU memory of %d leaves less than %d%% above the %d required, e.g., for long prompts", instanceType, presetName, totalGPUMem, gpuMemoryHeadroomPercent, modelTotalGPUMemory.ScaledValue(resource.Giga)), "instanceType").At(apis.WarningLevel))
+			}
+			// Without distributed inference, the pods of a workspace share the dedicated PVC, which
+			// cannot be attached to multiple nodes.
+			if inference.Storage != nil && inference.Storage.Policy == ModelStoragePolicyPersistent &&
+				!model.SupportDistributedInference() && machineCount > 1 {
+				errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("The Persistent storage policy requires a count of 1, preset %s does not support distributed inference", presetName), "count"))
+			}
+			if arch := skuConfig.Architecture(); !model.GetInferenceParameters().SupportsArchitecture(arch) {
+				errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("Instance type %s is %s, but the image of preset %s is only built for %s", instanceType, arch, presetName,
+					strings.Join(model.GetInferenceParameters().ImageArchitectures(), ", ")), "instanceType"))
+			}
+			if featuregates.FeatureGates[consts.FeatureFlagLocalNVMe] && skuConfig.NVMeDiskCount > 0 {
+				errs = errs.Also(validateLocalNVMeSize(skuConfig, presetName, model.GetInferenceParameters().DiskStorageRequirement))
 			}
 		}
 	} else {
@@ -337,6 +645,36 @@ func (r *ResourceSpec) validateCreate(inference InferenceSpec) (errs *apis.Field
 	return errs
 }
 
+// validatePresetLifecycle warns about a deprecated preset and rejects a preset past its end of life,
+// unless allowed by the lifecycle policy of the model register.
+func validatePresetLifecycle(presetName string, param *model.PresetParam) *apis.FieldError {
+	warning, err := plugin.KaitoModelRegister.ValidateLifecycle(presetName, param, time.Now())
+	if err != nil {
+		return apis.ErrInvalidValue(err.Error(), "presetName")
+	}
+	if warning != "" {
+		return apis.ErrGeneric(warning, "presetName").At(apis.WarningLevel)
+	}
+	return nil
+}
+
+// validateLocalNVMeSize checks that the local NVMe disks of the instance type, which are striped
+// and mounted at the model cache path, can hold the model.
+func validateLocalNVMeSize(skuConfig GPUConfig, presetName, diskStorageRequirement string) *apis.FieldError {
+	if diskStorageRequirement == "" {
+		return nil
+	}
+	required, err := resource.ParseQuantity(diskStorageRequirement)
+	if err != nil {
+		return apis.ErrInvalidValue(fmt.Sprintf("Invalid disk storage requirement %s of preset %s: %v", diskStorageRequirement, presetName, err), "instanceType")
+	}
+	available := resource.MustParse(fmt.Sprintf("%dGi", skuConfig.LocalNVMeSize()))
+	if available.Cmp(required) < 0 {
+		return apis.ErrInvalidValue(fmt.Sprintf("Insufficient local NVMe storage: Instance type %s has a total of %s in %d disks, but preset %s requires at least %s", skuConfig.SKU, available.String(), skuConfig.NVMeDiskCount, presetName, required.String()), "instanceType")
+	}
+	return nil
+}
+
 func (r *ResourceSpec) validateUpdate(old *ResourceSpec) (errs *apis.FieldError) {
 	// We disable changing node count for now.
 	if r.Count != nil && old.Count != nil && *r.Count != *old.Count {
@@ -345,6 +683,9 @@ func (r *ResourceSpec) validateUpdate(old *ResourceSpec) (errs *apis.FieldError)
 	if r.InstanceType != old.InstanceType {
 		errs = errs.Also(apis.ErrGeneric("field is immutable", "instanceType"))
 	}
+	if r.SchedulerName != old.SchedulerName {
+		errs = errs.Also(apis.ErrGeneric("field is immutable", "schedulerName"))
+	}
 	newLabels, err0 := metav1.LabelSelectorAsMap(r.LabelSelector)
 	oldLabels, err1 := metav1.LabelSelectorAsMap(old.LabelSelector)
 	if err0 != nil || err1 != nil {
@@ -369,22 +710,54 @@ func (i *InferenceSpec) validateCreate() (errs *apis.FieldError) {
 	}
 
 	if i.Preset != nil {
-		presetName := string(i.Preset.Name)
+		presetName := i.Preset.ModelReference()
 		// Validate preset name
-		if !isValidPreset(presetName) {
+		model, err := plugin.KaitoModelRegister.Get(presetName)
+		if refErr := plugin.KaitoModelRegister.ValidateReference(presetName); refErr != nil {
+			errs = errs.Also(apis.ErrInvalidValue(refErr.Error(), "presetName"))
+		} else if err != nil {
 			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("Unsupported inference preset name %s", presetName), "presetName"))
-		}
-		// Validate private preset has private image specified
-		if plugin.KaitoModelRegister.MustGet(string(i.Preset.Name)).GetInferenceParameters().ImageAccessMode == string(ModelImageAccessModePrivate) &&
+		} else if model.GetInferenceParameters().ImageAccessMode == string(ModelImageAccessModePrivate) &&
 			i.Preset.PresetMeta.AccessMode != ModelImageAccessModePrivate {
+			// Validate private preset has private image specified
 			errs = errs.Also(apis.ErrGeneric("This preset only supports private AccessMode, AccessMode must be private to continue"))
 		}
+		if model != nil {
+			if _, ok := model.GetInferenceParameters().ModelRunParams["trust_remote_code"]; ok {
+				errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("Preset %s runs code downloaded with the model weights (trust_remote_code)", presetName), "presetName").At(apis.WarningLevel))
+			}
+			errs = errs.Also(validatePresetLifecycle(presetName, model.GetInferenceParameters()))
+			if len(i.ChatTemplates) > 0 && !presetSupportsChatTemplate(i.Preset) {
+				errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("Preset %s does not support chat templates", presetName), "chatTemplates"))
+			}
+		}
 		// Additional validations for Preset
 		if i.Preset.PresetMeta.AccessMode == ModelImageAccessModePrivate && i.Preset.PresetOptions.Image == "" {
 			errs = errs.Also(apis.ErrGeneric("When AccessMode is private, an image must be provided in PresetOptions"))
 		}
 		// Note: we don't enforce private access mode to have image secrets, in case anonymous pulling is enabled
 	}
+	if i.Storage != nil {
+		if i.Preset == nil {
+			errs = errs.Also(apis.ErrGeneric("Storage is only supported with Preset", "storage"))
+		}
+		errs = errs.Also(i.Storage.validateCreate().ViaField("storage"))
+	}
+	if len(i.ChatTemplates) > 0 {
+		if i.Preset == nil {
+			errs = errs.Also(apis.ErrGeneric("ChatTemplates are only supported with Preset", "chatTemplates"))
+		}
+		names := make(map[string]bool)
+		for idx, name := range i.ChatTemplates {
+			if msgs := validation.IsDNS1123Subdomain(name); len(msgs) > 0 {
+				errs = errs.Also(apis.ErrInvalidArrayValue(name, "chatTemplates", idx))
+			}
+			if names[name] {
+				errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("Duplicate chat template ConfigMap found: %s", name), "chatTemplates"))
+			}
+			names[name] = true
+		}
+	}
 	if len(i.Adapters) > MaxAdaptersNumber {
 		errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("Number of Adapters exceeds the maximum limit, maximum of %s allowed", strconv.Itoa(MaxAdaptersNumber))))
 	}
@@ -398,10 +771,23 @@ func (i *InferenceSpec) validateCreate() (errs *apis.FieldError) {
 	return errs
 }
 
+// presetSupportsChatTemplate returns whether the preset accepts a chat template, see
+// PresetParam.SupportsChatTemplate. Unknown presets are reported by the preset validation.
+func presetSupportsChatTemplate(preset *PresetSpec) bool {
+	model, err := plugin.KaitoModelRegister.Get(preset.ModelReference())
+	return err != nil || model.GetInferenceParameters().SupportsChatTemplate()
+}
+
 func (i *InferenceSpec) validateUpdate(old *InferenceSpec) (errs *apis.FieldError) {
 	if !reflect.DeepEqual(i.Preset, old.Preset) {
 		errs = errs.Also(apis.ErrGeneric("field is immutable", "preset"))
 	}
+	if !reflect.DeepEqual(i.Storage, old.Storage) {
+		errs = errs.Also(apis.ErrGeneric("field is immutable", "storage"))
+	}
+	if !reflect.DeepEqual(i.ChatTemplates, old.ChatTemplates) {
+		errs = errs.Also(apis.ErrGeneric("field is immutable", "chatTemplates"))
+	}
 	// inference.template can be changed, but cannot be set/unset.
 	if (i.Template != nil && old.Template == nil) || (i.Template == nil && old.Template != nil) {
 		errs = errs.Also(apis.ErrGeneric("field cannot be unset/set if it was set/unset", "template"))
@@ -421,6 +807,34 @@ func (i *InferenceSpec) validateUpdate(old *InferenceSpec) (errs *apis.FieldErro
 	return errs
 }
 
+func (s *ModelStorageSpec) validateCreate() (errs *apis.FieldError) {
+	switch s.Policy {
+	case "", ModelStoragePolicyNodeLocal:
+		if s.StorageClassName != nil || s.Size != nil {
+			errs = errs.Also(apis.ErrGeneric("StorageClassName and Size cannot be set with the NodeLocal policy"))
+		}
+	case ModelStoragePolicyEphemeral, ModelStoragePolicyPersistent:
+		if s.Size != nil && s.Size.Sign() <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(s.Size.String(), "size"))
+		}
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(s.Policy, "policy"))
+	}
+	return errs
+}
+
+func (v *VolumeClaimSpec) validateCreate() (errs *apis.FieldError) {
+	if v.Size.Sign() <= 0 {
+		errs = errs.Also(apis.ErrInvalidValue(v.Size.String(), "size"))
+	}
+	switch v.RetentionPolicy {
+	case "", VolumeClaimRetentionPolicyRetain, VolumeClaimRetentionPolicyDelete:
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(v.RetentionPolicy, "retentionPolicy"))
+	}
+	return errs
+}
+
 func validateDuplicateName(adapters []AdapterSpec, nameMap map[string]bool) (errs *apis.FieldError) {
 	for _, adapter := range adapters {
 		if _, ok := nameMap[adapter.Source.Name]; ok {
@@ -19,7 +19,9 @@ import (
 
 	"github.com/azure/kaito/pkg/model"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -92,6 +94,13 @@ func RegisterValidationTestModels() {
 		Name:     "private-test-validation",
 		Instance: &testPrivate,
 	})
+	plugin.KaitoModelRegister.Register(&plugin.Registration{
+		Name: "text-generation-test-validation",
+		Instance: &model.StaticModel{InferenceParam: &model.PresetParam{
+			GPUCountRequirement: gpuCountRequirement,
+			ModelRunParams:      map[string]string{"pipeline": "text-generation"},
+		}},
+	})
 }
 
 func pointerToInt(i int) *int {
@@ -192,6 +201,7 @@ func TestResourceSpecValidateCreate(t *testing.T) {
 		preset              bool
 		errContent          string // Content expect error to include, if any
 		expectErrs          bool
+		warnContent         string // Content expect warning to include, if any
 	}{
 		{
 			name: "Valid resource",
@@ -246,6 +256,19 @@ func TestResourceSpecValidateCreate(t *testing.T) {
 			errContent:          "Insufficient per GPU memory",
 			expectErrs:          true,
 		},
+		{
+			name: "Barely fitting total GPU memory",
+			resourceSpec: &ResourceSpec{
+				InstanceType: "Standard_NC6",
+				Count:        pointerToInt(1),
+			},
+			modelGPUCount:       "1",
+			modelPerGPUMemory:   "0",
+			modelTotalGPUMemory: "11Gi",
+			preset:              true,
+			expectErrs:          false,
+			warnContent:         "barely fits",
+		},
 
 		{
 			name: "Invalid SKU",
@@ -309,11 +332,16 @@ func TestResourceSpecValidateCreate(t *testing.T) {
 			totalGPUMemoryRequirement = tc.modelTotalGPUMemory
 			perGPUMemoryRequirement = tc.modelPerGPUMemory
 
-			errs := tc.resourceSpec.validateCreate(spec)
+			diagnostics := tc.resourceSpec.validateCreate(spec)
+			errs := diagnostics.Filter(apis.ErrorLevel)
 			hasErrs := errs != nil
 			if hasErrs != tc.expectErrs {
 				t.Errorf("validateCreate() errors = %v, expectErrs %v", errs, tc.expectErrs)
 			}
+			warnings := diagnostics.Filter(apis.WarningLevel)
+			if (warnings != nil) != (tc.warnContent != "") || warnings != nil && !strings.Contains(warnings.Error(), tc.warnContent) {
+				t.Errorf("validateCreate() warnings = %v, expected to contain = %q", warnings, tc.warnContent)
+			}
 
 			// If there is an error and errContent is not empty, check that the error contains the expected content.
 			if hasErrs && tc.errContent != "" {
@@ -326,6 +354,31 @@ func TestResourceSpecValidateCreate(t *testing.T) {
 	}
 }
 
+func TestValidateLocalNVMeSize(t *testing.T) {
+	// Standard_NC48ads_A100_v4 has 2 local NVMe disks of 960Gi.
+	skuConfig := SupportedGPUConfigs["Standard_NC48ads_A100_v4"]
+	tests := []struct {
+		name                   string
+		diskStorageRequirement string
+		expectErrs             bool
+	}{
+		{name: "No disk requirement"},
+		{name: "Fits in one disk", diskStorageRequirement: "500Gi"},
+		{name: "Fits in the striped disks", diskStorageRequirement: "1500Gi"},
+		{name: "Exceeds the striped disks", diskStorageRequirement: "2000Gi", expectErrs: true},
+		{name: "Invalid disk requirement", diskStorageRequirement: "invalid", expectErrs: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validateLocalNVMeSize(skuConfig, "test-validation", tc.diskStorageRequirement)
+			if hasErrs := errs != nil; hasErrs != tc.expectErrs {
+				t.Errorf("validateLocalNVMeSize() errors = %v, expectErrs %v", errs, tc.expectErrs)
+			}
+		})
+	}
+}
+
 func TestResourceSpecValidateUpdate(t *testing.T) {
 
 	tests := []struct {
@@ -422,7 +475,95 @@ func TestInferenceSpecValidateCreate(t *testing.T) {
 					},
 				},
 			},
-			errContent: "model is not registered",
+			errContent: "Unsupported inference preset name",
+			expectErrs: true,
+		},
+		{
+			name: "Unregistered Preset Version",
+			inferenceSpec: &InferenceSpec{
+				Preset: &PresetSpec{
+					PresetMeta: PresetMeta{
+						Name:    ModelName("test-validation"),
+						Version: "0.0.1",
+					},
+				},
+			},
+			errContent: "Unsupported inference preset name",
+			expectErrs: true,
+		},
+		{
+			name: "Storage with Template",
+			inferenceSpec: &InferenceSpec{
+				Template: &v1.PodTemplateSpec{},
+				Storage:  &ModelStorageSpec{Policy: ModelStoragePolicyEphemeral},
+			},
+			errContent: "Storage is only supported with Preset",
+			expectErrs: true,
+		},
+		{
+			name: "NodeLocal Storage with Size",
+			inferenceSpec: &InferenceSpec{
+				Preset: &PresetSpec{
+					PresetMeta: PresetMeta{
+						Name: ModelName("test-validation"),
+					},
+				},
+				Storage: &ModelStorageSpec{
+					Policy: ModelStoragePolicyNodeLocal,
+					Size:   resource.NewQuantity(1<<30, resource.BinarySI),
+				},
+			},
+			errContent: "cannot be set with the NodeLocal policy",
+			expectErrs: true,
+		},
+		{
+			name: "Duplicate Chat Templates",
+			inferenceSpec: &InferenceSpec{
+				Preset: &PresetSpec{
+					PresetMeta: PresetMeta{
+						Name: ModelName("test-validation"),
+					},
+				},
+				ChatTemplates: []string{"templates", "templates"},
+			},
+			errContent: "Duplicate chat template ConfigMap found: templates",
+			expectErrs: true,
+		},
+		{
+			name: "Chat Templates of a text generation preset",
+			inferenceSpec: &InferenceSpec{
+				Preset: &PresetSpec{
+					PresetMeta: PresetMeta{
+						Name: ModelName("text-generation-test-validation"),
+					},
+				},
+				ChatTemplates: []string{"templates"},
+			},
+			expectErrs: false,
+		},
+		{
+			name: "Chat Templates of a preset without chat template support",
+			inferenceSpec: &InferenceSpec{
+				Preset: &PresetSpec{
+					PresetMeta: PresetMeta{
+						Name: ModelName("test-validation"),
+					},
+				},
+				ChatTemplates: []string{"templates"},
+			},
+			errContent: "Preset test-validation does not support chat templates",
+			expectErrs: true,
+		},
+		{
+			name: "Malformed Preset Name",
+			inferenceSpec: &InferenceSpec{
+				Preset: &PresetSpec{
+					PresetMeta: PresetMeta{
+						Name: ModelName("org/model/version"),
+					},
+				},
+			},
+			errContent: "invalid model reference",
 			expectErrs: true,
 		},
 		{
@@ -794,6 +935,324 @@ func TestWorkspaceValidateCreate(t *testing.T) {
 	}
 }
 
+func TestWorkspaceValidateWarnOnly(t *testing.T) {
+	workspace := &Workspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Annotations: map[string]string{AnnotationRDMA: "maybe"},
+		},
+		Inference: &InferenceSpec{Template: &v1.PodTemplateSpec{}},
+	}
+
+	errs := workspace.Validate(context.Background())
+	if errs.Filter(apis.ErrorLevel) == nil {
+		t.Fatalf("Validate() expected an error")
+	}
+
+	errs = workspace.Validate(WithWarnOnly(context.Background()))
+	if errs.Filter(apis.ErrorLevel) != nil {
+		t.Errorf("Validate() unexpected errors in warn-only mode: %v", errs.Filter(apis.ErrorLevel))
+	}
+	if warnings := errs.Filter(apis.WarningLevel); warnings == nil || !strings.Contains(warnings.Error(), AnnotationRDMA) {
+		t.Errorf("Validate() expected a warning about %s, got %v", AnnotationRDMA, warnings)
+	}
+}
+
+func TestWorkspaceValidateLoggingAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		errField    string
+	}{
+		{
+			name: "Valid logging annotations",
+			annotations: map[string]string{
+				AnnotationInferenceLogLevel:  "debug",
+				AnnotationInferenceLogFormat: "json",
+				AnnotationRequestLogging:     "full",
+			},
+		},
+		{
+			name:        "Invalid log level",
+			annotations: map[string]string{AnnotationInferenceLogLevel: "verbose"},
+			errField:    AnnotationInferenceLogLevel,
+		},
+		{
+			name:        "Invalid log format",
+			annotations: map[string]string{AnnotationInferenceLogFormat: "xml"},
+			errField:    AnnotationInferenceLogFormat,
+		},
+		{
+			name:        "Invalid request logging",
+			annotations: map[string]string{AnnotationRequestLogging: "all"},
+			errField:    AnnotationRequestLogging,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspace := &Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Annotations: tt.annotations},
+				Inference:  &InferenceSpec{Template: &v1.PodTemplateSpec{}},
+			}
+			errs := workspace.Validate(context.Background())
+			for _, annotation := range []string{AnnotationInferenceLogLevel, AnnotationInferenceLogFormat, AnnotationRequestLogging} {
+				hasErr := errs != nil && strings.Contains(errs.Error(), annotation)
+				if hasErr != (annotation == tt.errField) {
+					t.Errorf("Validate() error about %s = %v, got %v", annotation, annotation == tt.errField, errs)
+				}
+			}
+		})
+	}
+}
+
+func TestWorkspaceValidatePresetImageTag(t *testing.T) {
+	plugin.KaitoModelRegister.SetImagePolicy(plugin.ImagePolicy{AllowedTags: []string{"0.0.3", "0.0.4"}})
+	defer plugin.KaitoModelRegister.SetImagePolicy(plugin.ImagePolicy{})
+
+	preset := &InferenceSpec{Preset: &PresetSpec{PresetMeta: PresetMeta{Name: "test-validation"}}}
+	tests := []struct {
+		name      string
+		tag       string
+		inference *InferenceSpec
+		wantErr   bool
+	}{
+		{
+			name:      "Allowed tag",
+			tag:       "0.0.4",
+			inference: preset,
+		},
+		{
+			name:      "Tag not allowed",
+			tag:       "0.0.5",
+			inference: preset,
+			wantErr:   true,
+		},
+		{
+			name:      "Tag pinned without preset",
+			tag:       "0.0.4",
+			inference: &InferenceSpec{Template: &v1.PodTemplateSpec{}},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspace := &Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Annotations: map[string]string{AnnotationPresetImageTag: tt.tag}},
+				Inference:  tt.inference,
+			}
+			errs := workspace.Validate(context.Background())
+			hasErr := errs != nil && strings.Contains(errs.Error(), AnnotationPresetImageTag)
+			if hasErr != tt.wantErr {
+				t.Errorf("Validate() error about %s = %v, got %v", AnnotationPresetImageTag, tt.wantErr, errs)
+			}
+		})
+	}
+}
+
+func TestWorkspaceValidateChatTemplate(t *testing.T) {
+	RegisterValidationTestModels()
+	tests := []struct {
+		name    string
+		preset  ModelName
+		wantErr bool
+	}{
+		{
+			name:   "Text generation preset",
+			preset: "text-generation-test-validation",
+		},
+		{
+			name:    "Preset without chat template support",
+			preset:  "test-validation",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspace := &Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Annotations: map[string]string{AnnotationChatTemplate: "chatml.jinja"}},
+				Inference: &InferenceSpec{
+					Preset:        &PresetSpec{PresetMeta: PresetMeta{Name: tt.preset}},
+					ChatTemplates: []string{"templates"},
+				},
+			}
+			errs := workspace.Validate(context.Background())
+			hasErr := errs != nil && strings.Contains(errs.Error(), AnnotationChatTemplate)
+			if hasErr != tt.wantErr {
+				t.Errorf("Validate() error about %s = %v, got %v", AnnotationChatTemplate, tt.wantErr, errs)
+			}
+		})
+	}
+}
+
+func TestWorkspaceValidateWorkspaceClass(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = AddToScheme(scheme)
+	class := &WorkspaceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "team"},
+		Spec: WorkspaceClassSpec{
+			InstanceTypes: []string{"Standard_NC*_v3"},
+			SchedulerName: "gpu-scheduler",
+			Annotations:   map[string]string{AnnotationRequestLogging: "access"},
+		},
+	}
+	invalidClass := &WorkspaceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "invalid"},
+		Spec:       WorkspaceClassSpec{Annotations: map[string]string{AnnotationRequestLogging: "everything"}},
+	}
+	k8sclient.SetGlobalClient(fake.NewClientBuilder().WithScheme(scheme).WithObjects(class, invalidClass).Build())
+
+	tests := []struct {
+		name          string
+		className     string
+		instanceType  string
+		annotations   map[string]string
+		expectedError string
+	}{
+		{
+			name:         "Defaults of the class",
+			className:    "team",
+			instanceType: "Standard_NC12s_v3",
+		},
+		{
+			name:         "Annotation overriding the class",
+			className:    "team",
+			instanceType: "Standard_NC12s_v3",
+			annotations:  map[string]string{AnnotationRequestLogging: "none"},
+		},
+		{
+			name:          "Instance type not allowed by the class",
+			className:     "team",
+			instanceType:  "Standard_NC24ads_A100_v4",
+			expectedError: "resource.instanceType",
+		},
+		{
+			name:          "Class not found",
+			className:     "missing",
+			instanceType:  "Standard_NC12s_v3",
+			expectedError: "workspaceClassName",
+		},
+		{
+			name:          "Invalid default of the class",
+			className:     "invalid",
+			instanceType:  "Standard_NC12s_v3",
+			expectedError: AnnotationRequestLogging,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspace := &Workspace{
+				ObjectMeta:         metav1.ObjectMeta{Name: "test", Annotations: tt.annotations},
+				WorkspaceClassName: tt.className,
+				Resource:           ResourceSpec{InstanceType: tt.instanceType},
+			}
+			errs := workspace.applyWorkspaceClass(context.Background())
+			if errs == nil {
+				// Validate the annotations set by the class without the checks of a new inference.
+				errs = workspace.Validate(apis.WithinUpdate(context.Background(), workspace.DeepCopy()))
+			}
+			if tt.expectedError == "" {
+				if errs != nil {
+					t.Fatalf("unexpected errors: %v", errs)
+				}
+				if workspace.Resource.SchedulerName != "gpu-scheduler" {
+					t.Errorf("expected the scheduler of the class, got %q", workspace.Resource.SchedulerName)
+				}
+				if tt.annotations == nil && workspace.Annotations[AnnotationRequestLogging] != "access" {
+					t.Errorf("expected the annotations of the class, got %v", workspace.Annotations)
+				}
+				if tt.annotations != nil && workspace.Annotations[AnnotationRequestLogging] != tt.annotations[AnnotationRequestLogging] {
+					t.Errorf("expected the annotations of the workspace to take precedence, got %v", workspace.Annotations)
+				}
+				return
+			}
+			if errs == nil || !strings.Contains(errs.Error(), tt.expectedError) {
+				t.Errorf("expected error about %s, got %v", tt.expectedError, errs)
+			}
+		})
+	}
+
+	old := &Workspace{WorkspaceClassName: "team"}
+	updated := &Workspace{WorkspaceClassName: "other"}
+	if errs := updated.validateUpdate(old); errs == nil || !strings.Contains(errs.Error(), "workspaceClassName") {
+		t.Errorf("expected the workspace class to be immutable, got %v", errs)
+	}
+}
+
+func TestWorkspaceValidateOffline(t *testing.T) {
+	RegisterValidationTestModels()
+	tests := []struct {
+		name          string
+		workspace     *Workspace
+		internal      bool
+		offline       bool
+		expectedField string
+	}{
+		{
+			name:      "Public preset without offline mode",
+			workspace: &Workspace{Inference: &InferenceSpec{Preset: &PresetSpec{PresetMeta: PresetMeta{Name: "test-validation"}}}},
+		},
+		{
+			name:          "Public preset from the public registry",
+			workspace:     &Workspace{Inference: &InferenceSpec{Preset: &PresetSpec{PresetMeta: PresetMeta{Name: "test-validation"}}}},
+			offline:       true,
+			expectedField: "inference.preset.name",
+		},
+		{
+			name:      "Public preset from an internal mirror",
+			workspace: &Workspace{Inference: &InferenceSpec{Preset: &PresetSpec{PresetMeta: PresetMeta{Name: "test-validation"}}}},
+			offline:   true,
+			internal:  true,
+		},
+		{
+			name:      "Private preset",
+			workspace: &Workspace{Inference: &InferenceSpec{Preset: &PresetSpec{PresetMeta: PresetMeta{Name: "private-test-validation"}}}},
+			offline:   true,
+		},
+		{
+			name: "Adapter downloaded from URLs",
+			workspace: &Workspace{Inference: &InferenceSpec{
+				Template: &v1.PodTemplateSpec{},
+				Adapters: []AdapterSpec{{Source: &DataSource{Name: "adapter", URLs: []string{"https://example.com/adapter"}}}},
+			}},
+			offline:       true,
+			expectedField: "inference.adapters[0].urls",
+		},
+		{
+			name: "Tuning input downloaded from URLs",
+			workspace: &Workspace{Tuning: &TuningSpec{
+				Preset: &PresetSpec{PresetMeta: PresetMeta{Name: "test-validation"}},
+				Input:  &DataSource{Name: "input", URLs: []string{"https://example.com/data.parquet"}},
+			}},
+			offline:       true,
+			internal:      true,
+			expectedField: "tuning.input.urls",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.offline {
+				ctx = WithOffline(ctx, func(string) bool { return tt.internal })
+			}
+			errs := tt.workspace.validateOffline(ctx)
+			if tt.expectedField == "" {
+				if errs != nil {
+					t.Errorf("validateOffline() unexpected errors: %v", errs)
+				}
+				return
+			}
+			if errs == nil || !strings.Contains(errs.Error(), tt.expectedField) {
+				t.Errorf("validateOffline() expected an error on %s, got %v", tt.expectedField, errs)
+			}
+		})
+	}
+}
+
 func TestWorkspaceValidateUpdate(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -1033,6 +1492,18 @@ func TestTuningSpecValidateUpdate(t *testing.T) {
 			expectErrs: true,
 			errFields:  []string{"Method"},
 		},
+		{
+			name: "Env and mounts changed",
+			oldTuning: &TuningSpec{
+				Env: []v1.EnvVar{{Name: "WANDB_PROJECT", Value: "kaito"}},
+			},
+			newTuning: &TuningSpec{
+				Env:    []v1.EnvVar{{Name: "WANDB_PROJECT", Value: "other"}},
+				Mounts: []TuningMount{{Name: "pip", MountPath: "/etc/pip", ConfigMap: "pip-conf"}},
+			},
+			expectErrs: true,
+			errFields:  []string{"Env", "Mounts"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1055,6 +1526,107 @@ func TestTuningSpecValidateUpdate(t *testing.T) {
 	}
 }
 
+func TestTuningSpecValidateEnvAndMounts(t *testing.T) {
+	secretKey := &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "wandb"}, Key: "api-key"}}
+	tests := []struct {
+		name     string
+		env      []v1.EnvVar
+		mounts   []TuningMount
+		errField string
+	}{
+		{
+			name: "Valid",
+			env: []v1.EnvVar{
+				{Name: "WANDB_PROJECT", Value: "kaito"},
+				{Name: "WANDB_API_KEY", ValueFrom: secretKey},
+			},
+			mounts: []TuningMount{
+				{Name: "pip", MountPath: "/etc/pip", ConfigMap: "pip-conf"},
+				{Name: "netrc", MountPath: "/root/.config/netrc", Secret: "netrc"},
+			},
+		},
+		{
+			name:     "Invalid env name",
+			env:      []v1.EnvVar{{Name: "1WANDB", Value: "kaito"}},
+			errField: "Env[0].name",
+		},
+		{
+			name:     "Reserved env name",
+			env:      []v1.EnvVar{{Name: "BATCH_SIZE_DIVISOR", Value: "1"}},
+			errField: "Env[0].name",
+		},
+		{
+			name:     "Duplicate env name",
+			env:      []v1.EnvVar{{Name: "WANDB_PROJECT", Value: "a"}, {Name: "WANDB_PROJECT", Value: "b"}},
+			errField: "Env[1].name",
+		},
+		{
+			name:     "Value and valueFrom",
+			env:      []v1.EnvVar{{Name: "WANDB_API_KEY", Value: "key", ValueFrom: secretKey}},
+			errField: "Env[0].value, Env[0].valueFrom",
+		},
+		{
+			name:     "Invalid mount name",
+			mounts:   []TuningMount{{Name: "Pip", MountPath: "/etc/pip", ConfigMap: "pip-conf"}},
+			errField: "Mounts[0].name",
+		},
+		{
+			name:     "Mount name too long",
+			mounts:   []TuningMount{{Name: strings.Repeat("a", 60), MountPath: "/etc/pip", ConfigMap: "pip-conf"}},
+			errField: "Mounts[0].name",
+		},
+		{
+			name:     "Relative mount path",
+			mounts:   []TuningMount{{Name: "pip", MountPath: "etc/pip", ConfigMap: "pip-conf"}},
+			errField: "Mounts[0].mountPath",
+		},
+		{
+			name:     "Reserved mount path",
+			mounts:   []TuningMount{{Name: "pip", MountPath: "/mnt/pip", ConfigMap: "pip-conf"}},
+			errField: "Mounts[0].mountPath",
+		},
+		{
+			name:     "Mount path over a reserved path",
+			mounts:   []TuningMount{{Name: "dev", MountPath: "/dev", Secret: "dev"}},
+			errField: "Mounts[0].mountPath",
+		},
+		{
+			name: "Duplicate mount path",
+			mounts: []TuningMount{
+				{Name: "pip", MountPath: "/etc/pip", ConfigMap: "pip-conf"},
+				{Name: "pip2", MountPath: "/etc/pip/", ConfigMap: "pip-conf"},
+			},
+			errField: "Mounts[1].mountPath",
+		},
+		{
+			name:     "Neither secret nor configmap",
+			mounts:   []TuningMount{{Name: "pip", MountPath: "/etc/pip"}},
+			errField: "Mounts[0].configMap, Mounts[0].secret",
+		},
+		{
+			name:     "Both secret and configmap",
+			mounts:   []TuningMount{{Name: "pip", MountPath: "/etc/pip", Secret: "pip", ConfigMap: "pip-conf"}},
+			errField: "Mounts[0].configMap, Mounts[0].secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &TuningSpec{Env: tt.env, Mounts: tt.mounts}
+			errs := spec.validateEnv().Also(spec.validateMounts())
+			if tt.errField == "" {
+				if errs != nil {
+					t.Errorf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if errs == nil || !strings.Contains(errs.Error(), tt.errField) {
+				t.Errorf("errors = %v, expected an error on %s", errs, tt.errField)
+			}
+		})
+	}
+}
+
 func TestDataSourceValidateCreate(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -1124,6 +1696,41 @@ func TestDataSourceValidateCreate(t *testing.T) {
 			wantErr:  true,
 			errField: "Exactly one of URLs, Volume, or Image must be specified",
 		},
+		{
+			name: "URLs downloaded to a volume claim",
+			dataSource: &DataSource{
+				URLs:        []string{"http://example.com/data"},
+				VolumeClaim: &VolumeClaimSpec{Size: resource.MustParse("100Gi"), RetentionPolicy: VolumeClaimRetentionPolicyDelete},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Volume and volume claim specified",
+			dataSource: &DataSource{
+				Volume:      &v1.VolumeSource{},
+				VolumeClaim: &VolumeClaimSpec{Size: resource.MustParse("100Gi")},
+			},
+			wantErr:  true,
+			errField: "Volume, VolumeClaim",
+		},
+		{
+			name: "Volume claim without size",
+			dataSource: &DataSource{
+				URLs:        []string{"http://example.com/data"},
+				VolumeClaim: &VolumeClaimSpec{},
+			},
+			wantErr:  true,
+			errField: "VolumeClaim.size",
+		},
+		{
+			name: "Volume claim with invalid retention policy",
+			dataSource: &DataSource{
+				URLs:        []string{"http://example.com/data"},
+				VolumeClaim: &VolumeClaimSpec{Size: resource.MustParse("100Gi"), RetentionPolicy: "Archive"},
+			},
+			wantErr:  true,
+			errField: "VolumeClaim.retentionPolicy",
+		},
 	}
 
 	for _, tt := range tests {
@@ -1243,7 +1850,7 @@ func TestDataDestinationValidateCreate(t *testing.T) {
 			name:            "No fields specified",
 			dataDestination: &DataDestination{},
 			wantErr:         true,
-			errField:        "At least one of Volume or Image must be specified",
+			errField:        "At least one of Volume, VolumeClaim or Image must be specified",
 		},
 		{
 			name: "Volume specified only",
@@ -1285,6 +1892,39 @@ func TestDataDestinationValidateCreate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Volume claim specified only",
+			dataDestination: &DataDestination{
+				VolumeClaim: &VolumeClaimSpec{Size: resource.MustParse("10Gi")},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Volume and volume claim specified",
+			dataDestination: &DataDestination{
+				Volume:      &v1.VolumeSource{},
+				VolumeClaim: &VolumeClaimSpec{Size: resource.MustParse("10Gi")},
+			},
+			wantErr:  true,
+			errField: "Volume, VolumeClaim",
+		},
+		{
+			name: "Volume claim deleted without image",
+			dataDestination: &DataDestination{
+				VolumeClaim: &VolumeClaimSpec{Size: resource.MustParse("10Gi"), RetentionPolicy: VolumeClaimRetentionPolicyDelete},
+			},
+			wantErr:  true,
+			errField: "requires an output image",
+		},
+		{
+			name: "Volume claim deleted after pushing the image",
+			dataDestination: &DataDestination{
+				VolumeClaim:     &VolumeClaimSpec{Size: resource.MustParse("10Gi"), RetentionPolicy: VolumeClaimRetentionPolicyDelete},
+				Image:           "aimodels.azurecr.io/data-image:latest",
+				ImagePushSecret: "imagePushSecret",
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1347,6 +1987,17 @@ func TestDataDestinationValidateUpdate(t *testing.T) {
 			wantErr:   true,
 			errFields: []string{"ImagePushSecret"},
 		},
+		{
+			name: "VolumeClaim changed",
+			oldDest: &DataDestination{
+				VolumeClaim: &VolumeClaimSpec{Size: resource.MustParse("10Gi")},
+			},
+			newDest: &DataDestination{
+				VolumeClaim: &VolumeClaimSpec{Size: resource.MustParse("20Gi")},
+			},
+			wantErr:   true,
+			errFields: []string{"VolumeClaim"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1414,3 +2065,62 @@ func TestGetSupportedSKUs(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateConfigMapSchema(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		errContent string // Content expected error to include, if any
+	}{
+		{
+			name:   "Default LoRA template",
+			config: defaultConfigMapManifest().Data["training_config.yaml"],
+		},
+		{
+			name:   "Default QLoRA template",
+			config: qloraConfigMapManifest().Data["training_config.yaml"],
+		},
+		{
+			name: "Misspelled dataset parameter",
+			config: `training_config:
+  DatasetConfig:
+    shufle_dataset: true`,
+			errContent: `unknown field "shufle_dataset"`,
+		},
+		{
+			name: "Wrong quantization parameter type",
+			config: `training_config:
+  QuantizationConfig:
+    load_in_4bit: "yes"`,
+			errContent: "invalid QuantizationConfig",
+		},
+		{
+			name: "Unchecked training argument",
+			config: `training_config:
+  TrainingArguments:
+    output_dir: "output"
+    gradient_accumulation_steps: 4`,
+		},
+		{
+			name: "Wrong output_dir type",
+			config: `training_config:
+  TrainingArguments:
+    output_dir: 1`,
+			errContent: "invalid TrainingArguments",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cm := &v1.ConfigMap{Data: map[string]string{"training_config.yaml": tc.config}}
+			errs := validateConfigMapSchema(cm)
+			if tc.errContent == "" {
+				if errs != nil {
+					t.Errorf("validateConfigMapSchema() unexpected error %v", errs)
+				}
+			} else if errs == nil || !strings.Contains(errs.Error(), tc.errContent) {
+				t.Errorf("validateConfigMapSchema() error = %v, expected to contain %v", errs, tc.errContent)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package v1alpha1
+
+import (
+	"path"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkspaceClassSpec bundles the defaults and the policy shared by the workspaces of a class. The defaults
+// apply to the fields the workspaces leave unset, and changes to them roll out to the existing workspaces.
+type WorkspaceClassSpec struct {
+	// InstanceTypes are the instance types the workspaces of the class can be created with, as names or
+	// shell patterns of instance families, e.g., "Standard_NC*_v3". Empty means all instance types are allowed.
+	// +optional
+	InstanceTypes []string `json:"instanceTypes,omitempty"`
+	// SchedulerName is the scheduler of the workload pods of the workspaces that do not specify one.
+	// +optional
+	SchedulerName string `json:"schedulerName,omitempty"`
+	// Storage is where the preset inference services of the workspaces that do not specify a storage
+	// store the model files.
+	// +optional
+	Storage *ModelStorageSpec `json:"storage,omitempty"`
+	// Annotations are the kaito.sh annotations set on the workspaces that do not set them, e.g.,
+	// kaito.sh/enablelb to expose the workspaces or kaito.sh/request-logging to audit their requests.
+	// +kubebuilder:validation:XValidation:rule="self.all(k, k.startsWith('kaito.sh/'))",message="only kaito.sh annotations can be set by a workspace class"
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// AllowsInstanceType returns whether the workspaces of the class can use the instance type.
+func (s *WorkspaceClassSpec) AllowsInstanceType(instanceType string) bool {
+	if len(s.InstanceTypes) == 0 {
+		return true
+	}
+	for _, pattern := range s.InstanceTypes {
+		if matched, err := path.Match(pattern, instanceType); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// WorkspaceClass is the Schema for the workspaceclasses API. Workspaces reference a class by name in
+// workspaceClassName.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=workspaceclasses,scope=Cluster,categories=workspace,shortName=wkc
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+type WorkspaceClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkspaceClassSpec `json:"spec,omitempty"`
+}
+
+// WorkspaceClassList contains a list of WorkspaceClass
+// +kubebuilder:object:root=true
+type WorkspaceClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WorkspaceClass `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WorkspaceClass{}, &WorkspaceClassList{})
+}
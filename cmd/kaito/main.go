@@ -0,0 +1,102 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+// Command kaito is the command line of Kaito. Its render command prints the resources the workspace
+// controller would create for a workspace, without a cluster:
+//
+//	kaito render -f workspace.yaml
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/azure/kaito/pkg/featuregates"
+	"github.com/azure/kaito/pkg/render"
+	"github.com/azure/kaito/pkg/resources"
+	"github.com/azure/kaito/pkg/runparams"
+	cliflag "k8s.io/component-base/cli/flag"
+)
+
+const usage = `Usage: kaito render [flags]
+
+Print the resources the workspace controller would create for a workspace as YAML.
+`
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "render" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err := runRender(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "kaito render: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// runRender renders the workspace of the manifest named by the args. Only the flags of the workspace
+// controller that change the generated resources are accepted.
+func runRender(args []string, stdin io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	path := flags.String("f", "-",
+		"Path of the workspace manifest, or - for stdin. The manifest can also contain the WorkspaceClass of the workspace.")
+	featureGates := flags.String("feature-gates", "Karpenter=false",
+		"The feature gates of the workspace controller, e.g., Karpenter=true to render NodeClaims instead of Machines.")
+	workloadMutationConfig := flags.String("workload-mutation-config", "",
+		"The path of the workload mutation file of the workspace controller.")
+	flags.Var(cliflag.NewMapStringString(&runparams.OperatorDefaults), "model-run-params",
+		"Comma-separated key=value model run parameters applied to all preset inference workloads.")
+	flags.Var(cliflag.NewMapStringString(&resources.PresetImageMirrors), "preset-image-mirrors",
+		"Comma-separated registry=mirror prefixes the public preset images are pulled from instead.")
+	flags.StringVar(&resources.DefaultSchedulerName, "scheduler-name", "",
+		"The scheduler of the workload pods of the workspaces that do not specify one.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+
+	if err := featuregates.ParseAndValidateFeatureGates(*featureGates); err != nil {
+		return err
+	}
+	if *workloadMutationConfig != "" {
+		mutation, err := resources.LoadWorkloadMutation(*workloadMutationConfig)
+		if err != nil {
+			return err
+		}
+		resources.GlobalWorkloadMutation = mutation
+	}
+	if os.Getenv("PRESET_REGISTRY_NAME") == "" {
+		os.Setenv("PRESET_REGISTRY_NAME", resources.PublicPresetRegistry)
+	}
+
+	in := stdin
+	if *path != "-" {
+		f, err := os.Open(*path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	workspace, class, err := render.Decode(in)
+	if err != nil {
+		return err
+	}
+	objs, err := render.Workspace(context.Background(), workspace, class)
+	if err != nil {
+		return err
+	}
+	return render.Encode(out, objs)
+}
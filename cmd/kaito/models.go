@@ -0,0 +1,12 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package main
+
+import (
+	_ "github.com/azure/kaito/presets/models/falcon"
+	_ "github.com/azure/kaito/presets/models/llama2"
+	_ "github.com/azure/kaito/presets/models/llama2chat"
+	_ "github.com/azure/kaito/presets/models/mistral"
+	_ "github.com/azure/kaito/presets/models/phi-2"
+	_ "github.com/azure/kaito/presets/models/phi-3"
+)
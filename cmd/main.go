@@ -3,13 +3,27 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/azure/kaito/pkg/featuregates"
+	"github.com/azure/kaito/pkg/inference"
 	"github.com/azure/kaito/pkg/k8sclient"
+	"github.com/azure/kaito/pkg/modelpreset"
+	"github.com/azure/kaito/pkg/nodeclaim"
+	"github.com/azure/kaito/pkg/resources"
+	"github.com/azure/kaito/pkg/runparams"
+	"github.com/azure/kaito/pkg/summary"
+	"github.com/azure/kaito/pkg/tuning"
+	"github.com/azure/kaito/pkg/usage"
+	"github.com/azure/kaito/pkg/utils"
+	"github.com/azure/kaito/pkg/utils/plugin"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 
@@ -23,11 +37,19 @@ import (
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	cliflag "k8s.io/component-base/cli/flag"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -66,6 +88,25 @@ func main() {
 	var enableWebhook bool
 	var probeAddr string
 	var featureGates string
+	var transientModelCacheSize int
+	var transientModelTTL time.Duration
+	var modelResolvers string
+	var presetAllowedOrgs string
+	var presetDeniedOrgs string
+	var nodePoolSelector string
+	var enableImagePrePull bool
+	var workloadMutationConfig string
+	var presetImagePullSecrets string
+	var workspaceCleanupTimeout time.Duration
+	var allowEndOfLifePresets bool
+	var allowedPresetImageTags string
+	var presetBundle string
+	var watchNamespaces string
+	var shardName string
+	var syncPeriod time.Duration
+	var modelDownloadBandwidth string
+	var usageReporter usage.Reporter
+	var workspaceQueue, modelPresetQueue controllers.QueueOptions
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -73,7 +114,81 @@ func main() {
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.BoolVar(&enableWebhook, "webhook", true,
 		"Enable webhook for controller manager. Default is true.")
+	flag.BoolVar(&webhooks.WarnOnly, "webhook-warn-only", false,
+		"Admit the workspaces that fail validation, reporting the failures as warnings, e.g., while migrating workspaces that do not pass new validations.")
+	flag.BoolVar(&webhooks.Offline, "offline", false,
+		"Run in an air-gapped cluster: reject the workspaces that download data from URLs or pull public preset images from the public preset registry.")
+	flag.StringVar(&presetBundle, "preset-bundle", "",
+		"Path of a preset bundle, a tar archive of ModelPreset manifests, imported at startup.")
 	flag.StringVar(&featureGates, "feature-gates", "Karpenter=false", "Enable Kaito feature gates. Default,	Karpenter=false.")
+	flag.IntVar(&transientModelCacheSize, "transient-model-cache-size", 100,
+		"The maximum number of runtime-registered preset models kept in memory. Zero means unbounded.")
+	flag.DurationVar(&transientModelTTL, "transient-model-ttl", 24*time.Hour,
+		"How long an unreferenced runtime-registered preset model is kept in memory. Zero means forever.")
+	flag.StringVar(&modelResolvers, "model-resolvers", "modelpreset,configmap",
+		"Comma-separated list of resolvers consulted in order for preset models that are not builtin. Supported resolvers: modelpreset, configmap.")
+	flag.StringVar(&presetAllowedOrgs, "preset-allowed-orgs", "",
+		"Comma-separated list of the only organizations allowed in org/model preset names. Empty means all organizations are allowed.")
+	flag.StringVar(&presetDeniedOrgs, "preset-denied-orgs", "",
+		"Comma-separated list of organizations not allowed in org/model preset names. Takes precedence over --preset-allowed-orgs.")
+	flag.BoolVar(&allowEndOfLifePresets, "allow-end-of-life-presets", false,
+		"Allow new workspaces to use deprecated presets past their end of life.")
+	flag.StringVar(&allowedPresetImageTags, "allowed-preset-image-tags", "",
+		"Comma-separated list of the tags workspaces can pin their public preset image to with the kaito.sh/preset-image-tag annotation. Empty means pinning is not allowed.")
+	flag.Var(cliflag.NewMapStringString(&runparams.OperatorDefaults), "model-run-params",
+		"Comma-separated key=value model run parameters applied to all preset inference workloads. They override the preset defaults and are overridden by the kaito.sh/model-run-params workspace annotation.")
+	flag.StringVar(&utils.ReleaseNamespaceResolver.Override, "release-namespace", "",
+		"The namespace Kaito is released in. Defaults to the namespace of the operator pod or the RELEASE_NAMESPACE env var.")
+	flag.StringVar(&nodeclaim.TargetNodePool.Name, "karpenter-nodepool", "",
+		"The name of an existing Karpenter NodePool the nodeClaims created by Kaito belong to, subject to its limits and disruption budgets.")
+	flag.StringVar(&nodePoolSelector, "karpenter-nodepool-selector", "",
+		"A label selector of the existing Karpenter NodePools the nodeClaims created by Kaito belong to. Ignored if --karpenter-nodepool is set.")
+	flag.StringVar(&resources.RDMADeviceResource, "rdma-device-resource", "",
+		"The extended resource of the RDMA devices, e.g., rdma/ib, requested by the workload pods on RDMA capable instance types.")
+	flag.StringVar(&resources.DefaultSchedulerName, "scheduler-name", "",
+		"The scheduler of the workload pods of the workspaces that do not specify one. Defaults to the scheduler of the cluster.")
+	flag.StringVar(&resources.GPUScoringStrategy, "gpu-scoring-strategy", "",
+		"The GPU scoring strategy, MostAllocated or LeastAllocated, recorded on the workload pods as a hint for GPU-aware scheduler plugins.")
+	flag.StringVar(&resources.DefaultTuningColocation, "tuning-colocation", kaitov1alpha1.TuningColocationDeny,
+		"Whether the tuning jobs can be scheduled on the nodes serving inference workspaces: deny, partitioned for the nodes whose GPUs are shared with MPS or MIG only, or allow. Workspaces can set it with the kaito.sh/tuning-colocation annotation.")
+	flag.IntVar(&tuning.GlobalRetryPolicy.MaxPreemptionRetries, "tuning-max-preemption-retries", 3,
+		"How many times a tuning job is retried after its pod is preempted or evicted.")
+	flag.IntVar(&tuning.GlobalRetryPolicy.MaxOutOfMemoryRetries, "tuning-max-oom-retries", 1,
+		"How many times a tuning job is retried after running out of GPU memory, each time with half the batch size.")
+	flag.IntVar(&tuning.GlobalRetryPolicy.MaxUnknownRetries, "tuning-max-unknown-retries", 0,
+		"How many times a tuning job is retried after a failure that is neither a preemption, an out of memory, a NaN loss nor a dataset error.")
+	flag.BoolVar(&enableImagePrePull, "image-prepull", false,
+		"Pre-pull the images of the presets used by the workspaces on the GPU nodes.")
+	flag.Var(cliflag.NewMapStringString(&resources.PresetImageMirrors), "preset-image-mirrors",
+		"Comma-separated registry=mirror prefixes the public preset images are pulled from instead, e.g., mcr.microsoft.com/aks/kaito=myregistry.azurecr.io/kaito. The longest matching prefix applies.")
+	flag.StringVar(&presetImagePullSecrets, "preset-image-pull-secrets", "",
+		"Comma-separated list of secrets in the release namespace used to pull the public preset images. They are copied to the namespaces of the workspaces.")
+	flag.DurationVar(&workspaceCleanupTimeout, "workspace-cleanup-timeout", 30*time.Minute,
+		"How long the cleanup of the nodes of a deleted workspace is retried before its finalizer is removed anyway. Zero means forever.")
+	flag.StringVar(&workloadMutationConfig, "workload-mutation-config", "",
+		"The path of a YAML file with the mutation, e.g., tolerations, labels, env or image rewrites, applied to the pods of all the workloads generated by Kaito.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"Comma-separated list of the namespaces whose workspaces are reconciled by this operator, e.g., to shard the workspaces across several operator releases. Empty means all namespaces.")
+	flag.StringVar(&shardName, "shard-name", "",
+		"The name of the shard of workspaces reconciled by this operator. Each shard elects its own leader, so it must be unique across the operator releases of a cluster.")
+	flag.DurationVar(&syncPeriod, "sync-period", 10*time.Hour,
+		"How often all the watched objects are reconciled again, even if they did not change.")
+	flag.StringVar(&modelDownloadBandwidth, "model-download-bandwidth", "",
+		"The bandwidth per second, e.g., 50Mi, expected for a node to pull a model image. If set, the readiness timeout of the inference workloads is derived from the disk storage of their preset instead of fixed by the preset.")
+	flag.DurationVar(&inference.GlobalReadinessPolicy.MinTimeout, "min-readiness-timeout", 10*time.Minute,
+		"The lower bound of the readiness timeouts derived from --model-download-bandwidth.")
+	flag.DurationVar(&inference.GlobalReadinessPolicy.MaxTimeout, "max-readiness-timeout", 4*time.Hour,
+		"The upper bound of the readiness timeouts derived from --model-download-bandwidth.")
+	flag.BoolVar(&inference.DeriveHostResources, "derive-inference-resources", false,
+		"Request CPU and memory for the preset inference containers, derived from the GPU count and the model size of their preset. Workspaces can set the requests with the kaito.sh/inference-resources annotation.")
+	flag.DurationVar(&usageReporter.Interval, "usage-report-interval", 0,
+		"How often the usage of each workspace, its GPU hours and the requests and tokens served by its preset inference service, is written to a UsageReport. Zero disables the usage reports.")
+	flag.IntVar(&usageReporter.Retention, "usage-report-retention", 168,
+		"The number of usage reports kept per workspace, the older ones are deleted. Zero keeps them all.")
+	flag.StringVar(&usageReporter.WebhookURL, "usage-report-webhook", "",
+		"The URL every usage report is posted to as JSON, e.g., the ingestion endpoint of a FinOps tool.")
+	queueFlags(&workspaceQueue, "workspace", 5)
+	queueFlags(&modelPresetQueue, "modelpreset", 1)
 	opts := zap.Options{
 		Development: true,
 	}
@@ -82,25 +197,87 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	plugin.KaitoModelRegister.SetTransientCachePolicy(plugin.TransientCachePolicy{
+		MaxEntries: transientModelCacheSize,
+		TTL:        transientModelTTL,
+	})
+
+	selector, err := labels.Parse(nodePoolSelector)
+	if err != nil {
+		klog.ErrorS(err, "unable to parse `karpenter-nodepool-selector` flag")
+		exitWithErrorFunc()
+	}
+	nodeclaim.TargetNodePool.Selector = selector
+
+	resources.PresetImagePullSecrets = splitList(presetImagePullSecrets)
+
+	if workloadMutationConfig != "" {
+		mutation, err := resources.LoadWorkloadMutation(workloadMutationConfig)
+		if err != nil {
+			klog.ErrorS(err, "unable to load the workload mutation")
+			exitWithErrorFunc()
+		}
+		resources.GlobalWorkloadMutation = mutation
+	}
+
+	if err := resources.ValidateGPUScoringStrategy(resources.GPUScoringStrategy); err != nil {
+		klog.ErrorS(err, "unable to set `gpu-scoring-strategy` flag")
+		exitWithErrorFunc()
+	}
+
+	if err := resources.ValidateTuningColocation(resources.DefaultTuningColocation); err != nil {
+		klog.ErrorS(err, "unable to set `tuning-colocation` flag")
+		exitWithErrorFunc()
+	}
+
+	if modelDownloadBandwidth != "" {
+		bandwidth, err := resource.ParseQuantity(modelDownloadBandwidth)
+		if err != nil || bandwidth.Sign() <= 0 {
+			klog.ErrorS(err, "unable to parse `model-download-bandwidth` flag, it must be a positive quantity", "value", modelDownloadBandwidth)
+			exitWithErrorFunc()
+		}
+		inference.GlobalReadinessPolicy.DownloadBandwidth = bandwidth.Value()
+	}
+
+	plugin.KaitoModelRegister.SetNamePolicy(plugin.NamePolicy{
+		AllowedOrgs: splitList(presetAllowedOrgs),
+		DeniedOrgs:  splitList(presetDeniedOrgs),
+	})
+	plugin.KaitoModelRegister.SetLifecyclePolicy(plugin.LifecyclePolicy{AllowEndOfLife: allowEndOfLifePresets})
+	plugin.KaitoModelRegister.SetImagePolicy(plugin.ImagePolicy{AllowedTags: splitList(allowedPresetImageTags)})
+
+	cacheOptions, err := watchedNamespacesCacheOptions(splitList(watchNamespaces))
+	if err != nil {
+		klog.ErrorS(err, "unable to set `watch-namespaces` flag")
+		exitWithErrorFunc()
+	}
+	cacheOptions.SyncPeriod = &syncPeriod
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
 			BindAddress: metricsAddr,
+			ExtraHandlers: map[string]http.Handler{
+				plugin.ModelsPath: plugin.ModelsHandler(&plugin.KaitoModelRegister),
+			},
+		},
+		Cache: cacheOptions,
+		// Secrets are read uncached, so that the cache does not list and watch all the secrets of the cluster.
+		Client: client.Options{
+			Cache: &client.CacheOptions{DisableFor: []client.Object{&corev1.Secret{}}},
 		},
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "ef60f9b0.io",
+		LeaderElectionID:       leaderElectionID(shardName),
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
 		// speeds up voluntary leader transitions as the new leader don't have to wait
 		// LeaseDuration time first.
 		//
-		// In the default scaffold provided, the program ends immediately after
-		// the manager stops, so would be fine to enable this option. However,
-		// if you are doing or is intended to do any operation such as perform cleanups
-		// after the manager stops then its usage might be unsafe.
-		// LeaderElectionReleaseOnCancel: true,
+		// The program ends immediately after the manager stops, so the standby replicas
+		// take over a rolling update of the operator without waiting for the lease to expire.
+		LeaderElectionReleaseOnCancel: true,
 	})
 	if err != nil {
 		klog.ErrorS(err, "unable to start manager")
@@ -109,15 +286,89 @@ func main() {
 
 	k8sclient.SetGlobalClient(mgr.GetClient())
 
+	resolvers, err := newModelResolvers(modelResolvers, mgr.GetAPIReader())
+	if err != nil {
+		klog.ErrorS(err, "unable to set `model-resolvers` flag")
+		exitWithErrorFunc()
+	}
+	plugin.KaitoModelRegister.SetResolvers(resolvers...)
+
+	if err := mgr.AddMetricsServerExtraHandler(summary.WorkspacesPath, summary.WorkspacesHandler(mgr.GetClient())); err != nil {
+		klog.ErrorS(err, "unable to add workspace summary handler")
+		exitWithErrorFunc()
+	}
+
+	presetEvents := make(chan event.GenericEvent)
 	if err = (&controllers.WorkspaceReconciler{
-		Client:   k8sclient.GetGlobalClient(),
-		Log:      log.Log.WithName("controllers").WithName("Workspace"),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("KAITO-Workspace-controller"),
+		Client:         k8sclient.GetGlobalClient(),
+		Log:            log.Log.WithName("controllers").WithName("Workspace"),
+		Scheme:         mgr.GetScheme(),
+		Recorder:       mgr.GetEventRecorderFor("KAITO-Workspace-controller"),
+		PresetEvents:   presetEvents,
+		CleanupTimeout: workspaceCleanupTimeout,
+		Queue:          workspaceQueue,
 	}).SetupWithManager(mgr); err != nil {
 		klog.ErrorS(err, "unable to create controller", "controller", "Workspace")
 		exitWithErrorFunc()
 	}
+	if err = (&controllers.ModelPresetReconciler{
+		Client:          k8sclient.GetGlobalClient(),
+		Register:        &plugin.KaitoModelRegister,
+		WorkspaceEvents: presetEvents,
+		Elected:         mgr.Elected(),
+		Queue:           modelPresetQueue,
+	}).SetupWithManager(mgr); err != nil {
+		klog.ErrorS(err, "unable to create controller", "controller", "ModelPreset")
+		exitWithErrorFunc()
+	}
+	if err = (&controllers.KaitoConfigReconciler{
+		Client:          k8sclient.GetGlobalClient(),
+		Recorder:        mgr.GetEventRecorderFor("KAITO-KaitoConfig-controller"),
+		WorkspaceEvents: presetEvents,
+		Elected:         mgr.Elected(),
+	}).SetupWithManager(mgr); err != nil {
+		klog.ErrorS(err, "unable to create controller", "controller", "KaitoConfig")
+		exitWithErrorFunc()
+	}
+	if enableImagePrePull {
+		namespace, err := utils.GetReleaseNamespace(context.Background())
+		if err != nil {
+			klog.ErrorS(err, "unable to determine the namespace of the image pre-pull DaemonSet")
+			exitWithErrorFunc()
+		}
+		if err = (&controllers.ImagePrePullReconciler{
+			Client:    k8sclient.GetGlobalClient(),
+			Recorder:  mgr.GetEventRecorderFor("KAITO-Image-PrePull-controller"),
+			Namespace: namespace,
+		}).SetupWithManager(mgr); err != nil {
+			klog.ErrorS(err, "unable to create controller", "controller", "ImagePrePull")
+			exitWithErrorFunc()
+		}
+	}
+	if usageReporter.Interval > 0 {
+		usageReporter.Client = mgr.GetClient()
+		if err := mgr.Add(&usageReporter); err != nil {
+			klog.ErrorS(err, "unable to add the usage reporter")
+			exitWithErrorFunc()
+		}
+	}
+	if presetBundle != "" {
+		presets, err := modelpreset.ReadBundleFile(presetBundle)
+		if err != nil {
+			klog.ErrorS(err, "unable to read `preset-bundle` flag")
+			exitWithErrorFunc()
+		}
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			return modelpreset.ImportBundleWithRetry(ctx, mgr.GetClient(), presets, modelpreset.ImportBackoff)
+		})); err != nil {
+			klog.ErrorS(err, "unable to import the preset bundle")
+			exitWithErrorFunc()
+		}
+	}
+	if webhooks.Offline && !resources.PresetImageInternal(os.Getenv("PRESET_REGISTRY_NAME"), "") {
+		klog.InfoS("Offline mode without an internal preset registry or mirror, workspaces using public presets are rejected",
+			"registry", os.Getenv("PRESET_REGISTRY_NAME"))
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -160,3 +411,76 @@ func main() {
 		exitWithErrorFunc()
 	}
 }
+
+// newModelResolvers builds the chain of model resolvers named by the comma-separated list.
+func newModelResolvers(names string, reader client.Reader) ([]plugin.ModelResolver, error) {
+	var resolvers []plugin.ModelResolver
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "modelpreset":
+			resolvers = append(resolvers, &modelpreset.Resolver{Client: reader})
+		case "configmap":
+			namespace, err := utils.GetReleaseNamespace(context.Background())
+			if err != nil {
+				return nil, err
+			}
+			resolvers = append(resolvers, &plugin.ConfigMapResolver{Client: reader, Namespace: namespace})
+		default:
+			return nil, fmt.Errorf("unsupported model resolver %q", name)
+		}
+	}
+	return resolvers, nil
+}
+
+// queueFlags registers the flags tuning the work queue of the named controller.
+func queueFlags(options *controllers.QueueOptions, name string, defaultConcurrency int) {
+	flag.IntVar(&options.MaxConcurrentReconciles, name+"-max-concurrent-reconciles", defaultConcurrency,
+		fmt.Sprintf("The number of %s objects reconciled in parallel.", name))
+	flag.DurationVar(&options.BaseDelay, name+"-requeue-base-delay", 0,
+		fmt.Sprintf("The initial backoff of the requeues of a failing %s object. Zero keeps the default, 5ms.", name))
+	flag.DurationVar(&options.MaxDelay, name+"-requeue-max-delay", 0,
+		fmt.Sprintf("The maximum backoff of the requeues of a failing %s object. Zero keeps the default, 1000s.", name))
+	flag.Float64Var(&options.QPS, name+"-requeue-qps", 0,
+		fmt.Sprintf("The overall rate of the requeues of the %s objects. Zero keeps the default, 10.", name))
+	flag.IntVar(&options.Burst, name+"-requeue-burst", 0,
+		fmt.Sprintf("The burst of the requeues of the %s objects. Zero keeps the default, 100.", name))
+}
+
+// leaderElectionID returns the leader election ID of the shard. The unnamed shard keeps the ID of
+// the unsharded operator.
+func leaderElectionID(shardName string) string {
+	if shardName == "" {
+		return "ef60f9b0.io"
+	}
+	return shardName + ".ef60f9b0.io"
+}
+
+// watchedNamespacesCacheOptions restricts the cache of the namespaced objects to the namespaces,
+// plus the release namespace the operator reads its secrets and config maps from. Cluster-scoped
+// objects, e.g., nodes and model presets, are always cached.
+func watchedNamespacesCacheOptions(namespaces []string) (cache.Options, error) {
+	if len(namespaces) == 0 {
+		return cache.Options{}, nil
+	}
+	releaseNamespace, err := utils.GetReleaseNamespace(context.Background())
+	if err != nil {
+		return cache.Options{}, err
+	}
+	defaultNamespaces := map[string]cache.Config{releaseNamespace: {}}
+	for _, namespace := range namespaces {
+		defaultNamespaces[namespace] = cache.Config{}
+	}
+	return cache.Options{DefaultNamespaces: defaultNamespaces}, nil
+}
+
+// splitList splits a comma-separated list, dropping empty elements.
+func splitList(list string) []string {
+	var elems []string
+	for _, e := range strings.Split(list, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			elems = append(elems, e)
+		}
+	}
+	return elems
+}
@@ -0,0 +1,111 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/operatorconfig"
+	"github.com/azure/kaito/pkg/resources"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// KaitoConfigReconciler applies the settings of the KaitoConfig object to the operator, so that they
+// are changed without restarting it. The workspaces with a preset inference are sent to the workspace
+// controller, which rolls the change out to their workloads.
+type KaitoConfigReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+	// WorkspaceEvents receives the workspaces affected by a changed configuration.
+	WorkspaceEvents chan<- event.GenericEvent
+	// Elected is closed once the replica is elected leader. The controller runs on every replica, and
+	// only the leader records events and notifies the workspaces.
+	Elected <-chan struct{}
+}
+
+func (c *KaitoConfigReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	if req.Name != kaitov1alpha1.KaitoConfigName {
+		return reconcile.Result{}, nil
+	}
+
+	configObj := &kaitov1alpha1.KaitoConfig{}
+	var overrides *operatorconfig.Overrides
+	if err := c.Client.Get(ctx, req.NamespacedName, configObj); err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.ErrorS(err, "failed to get kaito config", "kaitoconfig", req.Name)
+			return reconcile.Result{}, err
+		}
+		// The settings fall back to the flags once the object is deleted.
+	} else {
+		if configObj.Spec.GPUScoringStrategy != nil {
+			if err := resources.ValidateGPUScoringStrategy(*configObj.Spec.GPUScoringStrategy); err != nil {
+				// Keep the current settings until the object is fixed.
+				if c.Recorder != nil && isLeader(c.Elected) {
+					c.Recorder.Event(configObj, corev1.EventTypeWarning, "InvalidConfig", err.Error())
+				}
+				klog.ErrorS(err, "invalid kaito config", "kaitoconfig", req.Name)
+				return reconcile.Result{}, nil
+			}
+		}
+		overrides = &operatorconfig.Overrides{
+			ModelRunParams:         configObj.Spec.ModelRunParams,
+			SchedulerName:          configObj.Spec.SchedulerName,
+			GPUScoringStrategy:     configObj.Spec.GPUScoringStrategy,
+			PresetImageMirrors:     configObj.Spec.PresetImageMirrors,
+			PresetImagePullSecrets: configObj.Spec.PresetImagePullSecrets,
+		}
+	}
+
+	current := operatorconfig.Get()
+	if overrides == nil && reflect.DeepEqual(current, &operatorconfig.Overrides{}) ||
+		overrides != nil && reflect.DeepEqual(current, overrides) {
+		return reconcile.Result{}, nil
+	}
+	operatorconfig.Set(overrides)
+	klog.InfoS("Kaito config updated", "kaitoconfig", req.Name)
+
+	return reconcile.Result{}, c.notifyWorkspaces(ctx)
+}
+
+// notifyWorkspaces sends the workspaces with a preset inference to the workspace controller.
+func (c *KaitoConfigReconciler) notifyWorkspaces(ctx context.Context) error {
+	if c.WorkspaceEvents == nil || !isLeader(c.Elected) {
+		return nil
+	}
+	workspaceList := &kaitov1alpha1.WorkspaceList{}
+	if err := c.Client.List(ctx, workspaceList); err != nil {
+		return err
+	}
+	for i := range workspaceList.Items {
+		wObj := &workspaceList.Items[i]
+		if wObj.Inference == nil || wObj.Inference.Preset == nil {
+			continue
+		}
+		select {
+		case c.WorkspaceEvents <- event.GenericEvent{Object: wObj}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager. It runs on every replica, so that the
+// admission webhook of the replicas that are not leader applies the same settings.
+func (c *KaitoConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&kaitov1alpha1.KaitoConfig{}).
+		WithOptions(controller.Options{NeedLeaderElection: pointer.Bool(false)}).
+		Complete(c)
+}
@@ -0,0 +1,118 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/operatorconfig"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestKaitoConfigReconcile(t *testing.T) {
+	t.Cleanup(func() { operatorconfig.Set(nil) })
+
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	configObj := &v1alpha1.KaitoConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: v1alpha1.KaitoConfigName},
+		Spec: v1alpha1.KaitoConfigSpec{
+			ModelRunParams: map[string]string{"torch_dtype": "float16"},
+			SchedulerName:  pointer.String("kaito-scheduler"),
+		},
+	}
+	presetWorkspace := &v1alpha1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "preset", Namespace: "default"},
+		Inference: &v1alpha1.InferenceSpec{
+			Preset: &v1alpha1.PresetSpec{PresetMeta: v1alpha1.PresetMeta{Name: "falcon-7b"}},
+		},
+	}
+	tuningWorkspace := &v1alpha1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "tuning", Namespace: "default"},
+		Tuning:     &v1alpha1.TuningSpec{},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(configObj, presetWorkspace, tuningWorkspace).Build()
+
+	events := make(chan event.GenericEvent, 10)
+	recorder := record.NewFakeRecorder(10)
+	reconciler := &KaitoConfigReconciler{Client: c, Recorder: recorder, WorkspaceEvents: events}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: v1alpha1.KaitoConfigName}}
+	ctx := context.Background()
+
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Equal(t, operatorconfig.Get().ModelRunParams["torch_dtype"], "float16")
+	assert.Equal(t, operatorconfig.String(operatorconfig.Get().SchedulerName, ""), "kaito-scheduler")
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, (<-events).Object.GetName(), "preset")
+
+	// Reconciling an unchanged config does not notify the workspaces again.
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 0)
+
+	// An invalid config keeps the current settings.
+	configObj.Spec.GPUScoringStrategy = pointer.String("Random")
+	assert.NilError(t, c.Update(ctx, configObj))
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Check(t, operatorconfig.Get().GPUScoringStrategy == nil)
+	assert.Equal(t, len(recorder.Events), 1)
+	assert.Equal(t, len(events), 0)
+
+	// Deleting the config falls back to the flags.
+	assert.NilError(t, c.Delete(ctx, configObj))
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Check(t, operatorconfig.Get().ModelRunParams == nil)
+	assert.Check(t, operatorconfig.Get().SchedulerName == nil)
+	assert.Equal(t, len(events), 1)
+}
+
+func TestKaitoConfigReconcileNotLeader(t *testing.T) {
+	t.Cleanup(func() { operatorconfig.Set(nil) })
+
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	configObj := &v1alpha1.KaitoConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: v1alpha1.KaitoConfigName},
+		Spec:       v1alpha1.KaitoConfigSpec{SchedulerName: pointer.String("kaito-scheduler")},
+	}
+	presetWorkspace := &v1alpha1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "preset", Namespace: "default"},
+		Inference: &v1alpha1.InferenceSpec{
+			Preset: &v1alpha1.PresetSpec{PresetMeta: v1alpha1.PresetMeta{Name: "falcon-7b"}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(configObj, presetWorkspace).Build()
+
+	events := make(chan event.GenericEvent, 10)
+	recorder := record.NewFakeRecorder(10)
+	reconciler := &KaitoConfigReconciler{Client: c, Recorder: recorder, WorkspaceEvents: events, Elected: make(chan struct{})}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: v1alpha1.KaitoConfigName}}
+	ctx := context.Background()
+
+	// A replica that is not leader applies the settings for its webhook, without notifying the workspaces.
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Equal(t, operatorconfig.String(operatorconfig.Get().SchedulerName, ""), "kaito-scheduler")
+	assert.Equal(t, len(events), 0)
+
+	// Nor does it record the events of an invalid config, the leader does.
+	configObj.Spec.GPUScoringStrategy = pointer.String("Random")
+	assert.NilError(t, c.Update(ctx, configObj))
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Equal(t, len(recorder.Events), 0)
+}
@@ -0,0 +1,22 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package controllers
+
+// isLeader reports whether the replica is elected leader, given the channel closed once it is, e.g.,
+// mgr.Elected(). A nil channel means leader election is not used.
+//
+// The ModelPreset and KaitoConfig controllers run on every replica, so that the admission webhook of
+// each replica sees the same presets and settings, while the workspace controller runs on the leader
+// only. The workspaces are therefore notified by the leader only: the other replicas have no workspace
+// controller to receive them, and a new leader reconciles all the workspaces when it starts.
+func isLeader(elected <-chan struct{}) bool {
+	if elected == nil {
+		return true
+	}
+	select {
+	case <-elected:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package controllers
+
+import (
+	"context"
+	"sync"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/model"
+	"github.com/azure/kaito/pkg/modelpreset"
+	"github.com/azure/kaito/pkg/utils/plugin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ModelPresetReconciler keeps the model register in sync with the ModelPreset objects, so that
+// preset fixes are applied without restarting the operator. The workspaces using a changed
+// preset are sent to the workspace controller, which rolls the change out to their workloads.
+type ModelPresetReconciler struct {
+	client.Client
+	Register *plugin.ModelRegister
+	// WorkspaceEvents receives the workspaces using a changed preset.
+	WorkspaceEvents chan<- event.GenericEvent
+	// Elected is closed once the replica is elected leader. The controller runs on every replica, and
+	// only the leader notifies the workspaces.
+	Elected <-chan struct{}
+	// Queue tunes the work queue of the controller.
+	Queue QueueOptions
+
+	// registered holds the model declared by each ModelPreset object, so that its registration is removed
+	// once the object is deleted or declares another model.
+	mu         sync.Mutex
+	registered map[string]declaredModel
+}
+
+// declaredModel is the name and version of the model declared by a ModelPreset object.
+type declaredModel struct {
+	name, version string
+}
+
+func (c *ModelPresetReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	presetObj := &kaitov1alpha1.ModelPreset{}
+	if err := c.Client.Get(ctx, req.NamespacedName, presetObj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, c.unregister(ctx, req.Name)
+		}
+		klog.ErrorS(err, "failed to get model preset", "modelpreset", req.Name)
+		return reconcile.Result{}, err
+	}
+
+	registration := modelpreset.Registration(presetObj)
+	ref := plugin.ModelReference(registration.Name, registration.Version)
+	declared := declaredModel{name: registration.Name, version: registration.Version}
+	if previous, ok := c.declared(presetObj.Name); ok && previous != declared {
+		if err := c.unregister(ctx, presetObj.Name); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	if c.Register.IsBuiltin(registration.Name) {
+		// The admission webhook rejects them, but they may predate it. The builtin preset is kept.
+		klog.InfoS("Model preset ignored, it declares a builtin preset", "modelpreset", presetObj.Name, "model", registration.Name)
+		return reconcile.Result{}, nil
+	}
+	c.setDeclared(presetObj.Name, declared)
+	if current, err := c.Register.Get(ref); err == nil {
+		if !presetChanged(current, registration.Instance) {
+			return reconcile.Result{}, nil
+		}
+		if err := c.Register.Replace(registration); err != nil {
+			return reconcile.Result{}, err
+		}
+	} else {
+		c.Register.Register(registration)
+	}
+	klog.InfoS("Model preset updated", "modelpreset", presetObj.Name, "model", ref)
+
+	return reconcile.Result{}, c.notifyWorkspaces(ctx, registration.Name)
+}
+
+// unregister removes the registration of the model declared by the deleted, or changed, ModelPreset
+// object, so that new workspaces cannot use it anymore, and notifies the workspaces using it.
+func (c *ModelPresetReconciler) unregister(ctx context.Context, objName string) error {
+	declared, ok := c.declared(objName)
+	if !ok {
+		return nil
+	}
+	c.Register.UnregisterTransient(declared.name, declared.version)
+	c.mu.Lock()
+	delete(c.registered, objName)
+	c.mu.Unlock()
+	klog.InfoS("Model preset removed", "modelpreset", objName, "model", plugin.ModelReference(declared.name, declared.version))
+	return c.notifyWorkspaces(ctx, declared.name)
+}
+
+func (c *ModelPresetReconciler) declared(objName string) (declaredModel, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	declared, ok := c.registered[objName]
+	return declared, ok
+}
+
+func (c *ModelPresetReconciler) setDeclared(objName string, declared declaredModel) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.registered == nil {
+		c.registered = make(map[string]declaredModel)
+	}
+	c.registered[objName] = declared
+}
+
+// presetChanged reports whether the updated model differs from the registered one, including the
+// parameters left out of the hash because they do not change the workloads.
+func presetChanged(current, updated model.Model) bool {
+	currentParam, updatedParam := current.GetInferenceParameters(), updated.GetInferenceParameters()
+	return currentParam.Hash() != updatedParam.Hash() ||
+		currentParam.MinDriverVersion != updatedParam.MinDriverVersion ||
+		!currentParam.Deprecation.Equal(updatedParam.Deprecation) ||
+		current.SupportDistributedInference() != updated.SupportDistributedInference()
+}
+
+// notifyWorkspaces sends the workspaces using the model to the workspace controller.
+func (c *ModelPresetReconciler) notifyWorkspaces(ctx context.Context, modelName string) error {
+	if c.WorkspaceEvents == nil || !isLeader(c.Elected) {
+		return nil
+	}
+	workspaceList := &kaitov1alpha1.WorkspaceList{}
+	if err := c.Client.List(ctx, workspaceList); err != nil {
+		return err
+	}
+	for i := range workspaceList.Items {
+		wObj := &workspaceList.Items[i]
+		if wObj.Inference == nil || wObj.Inference.Preset == nil || string(wObj.Inference.Preset.Name) != modelName {
+			continue
+		}
+		select {
+		case c.WorkspaceEvents <- event.GenericEvent{Object: wObj}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager. It runs on every replica, so that the
+// admission webhook of the replicas that are not leader validates the workspaces with the same presets.
+func (c *ModelPresetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	options := c.Queue.controllerOptions(1)
+	options.NeedLeaderElection = pointer.Bool(false)
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&kaitov1alpha1.ModelPreset{}).
+		WithOptions(options).
+		Complete(c)
+}
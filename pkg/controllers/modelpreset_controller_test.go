@@ -0,0 +1,188 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/modelpreset"
+	"github.com/azure/kaito/pkg/utils/plugin"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestModelPresetReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	presetObj := &v1alpha1.ModelPreset{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom"},
+		Spec: v1alpha1.ModelPresetSpec{
+			ModelName:      "custom",
+			Tag:            "0.0.1",
+			ModelRunParams: map[string]string{"torch_dtype": "float16"},
+		},
+	}
+	newWorkspace := func(name, preset string) *v1alpha1.Workspace {
+		return &v1alpha1.Workspace{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Inference: &v1alpha1.InferenceSpec{
+				Preset: &v1alpha1.PresetSpec{PresetMeta: v1alpha1.PresetMeta{Name: v1alpha1.ModelName(preset)}},
+			},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		presetObj, newWorkspace("uses-custom", "custom"), newWorkspace("uses-other", "other"),
+	).Build()
+
+	var reg plugin.ModelRegister
+	events := make(chan event.GenericEvent, 10)
+	reconciler := &ModelPresetReconciler{Client: c, Register: &reg, WorkspaceEvents: events}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "custom"}}
+	ctx := context.Background()
+
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Check(t, reg.Has("custom"), "expected preset to be registered")
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, (<-events).Object.GetName(), "uses-custom")
+
+	// Reconciling an unchanged preset does not notify the workspaces again.
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 0)
+
+	presetObj.Spec.ModelRunParams["torch_dtype"] = "bfloat16"
+	assert.NilError(t, c.Update(ctx, presetObj))
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Equal(t, reg.MustGet("custom").GetInferenceParameters().ModelRunParams["torch_dtype"], "bfloat16")
+	assert.Equal(t, len(events), 1)
+	<-events
+
+	// The minimum driver version is not part of the hash of the parameters but is still applied.
+	presetObj.Spec.MinDriverVersion = "535.104.05"
+	assert.NilError(t, c.Update(ctx, presetObj))
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Equal(t, reg.MustGet("custom").GetInferenceParameters().MinDriverVersion, "535.104.05")
+	assert.Equal(t, len(events), 1)
+	<-events
+
+	// So is the deprecation of the preset.
+	presetObj.Spec.Deprecation = &v1alpha1.PresetDeprecation{Replacement: "custom-v2"}
+	assert.NilError(t, c.Update(ctx, presetObj))
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Equal(t, reg.MustGet("custom").GetInferenceParameters().Deprecation.Replacement, "custom-v2")
+	assert.Equal(t, len(events), 1)
+	<-events
+
+	// Deleting the preset unregisters it and notifies the workspaces using it.
+	assert.NilError(t, c.Delete(ctx, presetObj))
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Check(t, !reg.Has("custom"), "expected preset to be unregistered")
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, (<-events).Object.GetName(), "uses-custom")
+}
+
+func TestModelPresetReconcileRenamedModel(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	presetObj := &v1alpha1.ModelPreset{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom"},
+		Spec:       v1alpha1.ModelPresetSpec{ModelName: "custom", Version: "1", Tag: "0.0.1"},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(presetObj).Build()
+	var reg plugin.ModelRegister
+	reconciler := &ModelPresetReconciler{Client: c, Register: &reg}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "custom"}}
+	ctx := context.Background()
+
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Check(t, reg.Has("custom@1"), "expected preset to be registered")
+
+	// The registration of the previously declared version is removed.
+	presetObj.Spec.Version = "2"
+	assert.NilError(t, c.Update(ctx, presetObj))
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Check(t, !reg.Has("custom@1"), "expected version 1 to be unregistered")
+	assert.Check(t, reg.Has("custom@2"), "expected version 2 to be registered")
+}
+
+func TestModelPresetReconcileBuiltinModel(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	presetObj := &v1alpha1.ModelPreset{
+		ObjectMeta: metav1.ObjectMeta{Name: "falcon"},
+		Spec:       v1alpha1.ModelPresetSpec{ModelName: "falcon-7b", Tag: "shadow"},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(presetObj).Build()
+	var reg plugin.ModelRegister
+	builtin := modelpreset.Registration(&v1alpha1.ModelPreset{Spec: v1alpha1.ModelPresetSpec{ModelName: "falcon-7b", Tag: "builtin"}})
+	builtin.Transient = false
+	reg.Register(builtin)
+	reconciler := &ModelPresetReconciler{Client: c, Register: &reg}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "falcon"}}
+	ctx := context.Background()
+
+	// The ModelPreset does not shadow the builtin preset.
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Equal(t, reg.MustGet("falcon-7b").GetInferenceParameters().Tag, "builtin")
+
+	// Nor does its deletion remove the builtin preset.
+	assert.NilError(t, c.Delete(ctx, presetObj))
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Check(t, reg.Has("falcon-7b"), "expected the builtin preset to be kept")
+	assert.Equal(t, reg.MustGet("falcon-7b").GetInferenceParameters().Tag, "builtin")
+}
+
+func TestModelPresetReconcileNotLeader(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	presetObj := &v1alpha1.ModelPreset{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom"},
+		Spec:       v1alpha1.ModelPresetSpec{ModelName: "custom", Tag: "0.0.1"},
+	}
+	workspace := &v1alpha1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "uses-custom", Namespace: "default"},
+		Inference: &v1alpha1.InferenceSpec{
+			Preset: &v1alpha1.PresetSpec{PresetMeta: v1alpha1.PresetMeta{Name: "custom"}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(presetObj, workspace).Build()
+
+	var reg plugin.ModelRegister
+	events := make(chan event.GenericEvent, 10)
+	elected := make(chan struct{})
+	reconciler := &ModelPresetReconciler{Client: c, Register: &reg, WorkspaceEvents: events, Elected: elected}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "custom"}}
+	ctx := context.Background()
+
+	// A replica that is not leader registers the preset for its webhook, without notifying the workspaces.
+	_, err := reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Check(t, reg.Has("custom"), "expected preset to be registered")
+	assert.Equal(t, len(events), 0)
+
+	// Once elected, it notifies the workspaces.
+	close(elected)
+	presetObj.Spec.MinDriverVersion = "535.104.05"
+	assert.NilError(t, c.Update(ctx, presetObj))
+	_, err = reconciler.Reconcile(ctx, req)
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, (<-events).Object.GetName(), "uses-custom")
+}
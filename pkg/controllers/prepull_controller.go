@@ -0,0 +1,154 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/inference"
+	"github.com/azure/kaito/pkg/resources"
+	"github.com/azure/kaito/pkg/tuning"
+	"github.com/azure/kaito/pkg/utils/plugin"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// annotationPrePullProgress records on the pre-pull DaemonSet the last reported progress.
+const annotationPrePullProgress = kaitov1alpha1.KAITOPrefix + "prepull-progress"
+
+// ImagePrePullReconciler keeps the public images of the presets used by the workspaces pulled on the
+// GPU nodes, so that the workloads do not wait for the large preset images on cold start. The images
+// are pulled by a DaemonSet in the release namespace, whose progress is reported in events.
+type ImagePrePullReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+	// Namespace is the namespace of the pre-pull DaemonSet.
+	Namespace string
+}
+
+func (c *ImagePrePullReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	images, err := c.presetImages(ctx)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	existingDS := &appsv1.DaemonSet{}
+	if err := c.Client.Get(ctx, client.ObjectKey{Name: resources.ImagePrePullName, Namespace: c.Namespace}, existingDS); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		if len(images) == 0 {
+			return reconcile.Result{}, nil
+		}
+		ds := resources.GenerateImagePrePullManifest(c.Namespace, images)
+		if err := resources.CreateResource(ctx, ds, c.Client); err != nil {
+			return reconcile.Result{}, client.IgnoreAlreadyExists(err)
+		}
+		c.Recorder.Eventf(ds, corev1.EventTypeNormal, "ImagePrePullStarted", "Pre-pulling %d preset images on the GPU nodes", len(images))
+		return reconcile.Result{}, nil
+	}
+
+	if len(images) == 0 {
+		klog.InfoS("No preset image to pre-pull, deleting the pre-pull DaemonSet", "daemonset", klog.KObj(existingDS))
+		return reconcile.Result{}, client.IgnoreNotFound(c.Client.Delete(ctx, existingDS))
+	}
+
+	desiredDS := resources.GenerateImagePrePullManifest(c.Namespace, images)
+	if !reflect.DeepEqual(prePulledImages(existingDS), prePulledImages(desiredDS)) {
+		existingDS.Spec.Template = desiredDS.Spec.Template
+		if err := c.Client.Update(ctx, existingDS); err != nil {
+			return reconcile.Result{}, err
+		}
+		c.Recorder.Eventf(existingDS, corev1.EventTypeNormal, "ImagePrePullUpdated", "Pre-pulling %d preset images on the GPU nodes", len(images))
+		return reconcile.Result{}, nil
+	}
+
+	// The DaemonSet pod of a node is ready once the images are pulled on the node.
+	progress := fmt.Sprintf("%d/%d", existingDS.Status.NumberReady, existingDS.Status.DesiredNumberScheduled)
+	if existingDS.Status.ObservedGeneration == existingDS.Generation && existingDS.Annotations[annotationPrePullProgress] != progress {
+		if existingDS.Annotations == nil {
+			existingDS.Annotations = map[string]string{}
+		}
+		existingDS.Annotations[annotationPrePullProgress] = progress
+		if err := c.Client.Update(ctx, existingDS); err != nil {
+			return reconcile.Result{}, err
+		}
+		c.Recorder.Eventf(existingDS, corev1.EventTypeNormal, "ImagePrePullProgress", "Preset images pulled on %d of %d GPU nodes",
+			existingDS.Status.NumberReady, existingDS.Status.DesiredNumberScheduled)
+	}
+	return reconcile.Result{}, nil
+}
+
+// presetImages returns the sorted public images of the presets used by the workspaces. The images of
+// private presets are not pre-pulled, their pull secrets are in the namespaces of the workspaces.
+func (c *ImagePrePullReconciler) presetImages(ctx context.Context) ([]string, error) {
+	workspaceList := &kaitov1alpha1.WorkspaceList{}
+	if err := c.Client.List(ctx, workspaceList); err != nil {
+		return nil, err
+	}
+	imageSet := map[string]struct{}{}
+	for i := range workspaceList.Items {
+		wObj := &workspaceList.Items[i]
+		if wObj.DeletionTimestamp != nil {
+			continue
+		}
+		if wObj.Inference != nil && wObj.Inference.Preset != nil && wObj.Inference.Preset.AccessMode != kaitov1alpha1.ModelImageAccessModePrivate {
+			if model, err := plugin.KaitoModelRegister.Get(wObj.Inference.Preset.ModelReference()); err == nil {
+				image, _ := inference.GetInferenceImageInfo(ctx, wObj, model.GetInferenceParameters())
+				imageSet[image] = struct{}{}
+			}
+		}
+		if wObj.Tuning != nil && wObj.Tuning.Preset != nil && wObj.Tuning.Preset.AccessMode != kaitov1alpha1.ModelImageAccessModePrivate {
+			if model, err := plugin.KaitoModelRegister.Get(wObj.Tuning.Preset.ModelReference()); err == nil && model.GetTuningParameters() != nil {
+				image, _ := tuning.GetTuningImageInfo(ctx, wObj, model.GetTuningParameters())
+				imageSet[image] = struct{}{}
+			}
+		}
+	}
+	images := make([]string, 0, len(imageSet))
+	for image := range imageSet {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+// prePulledImages returns the images pulled by the pre-pull DaemonSet.
+func prePulledImages(ds *appsv1.DaemonSet) []string {
+	images := make([]string, 0, len(ds.Spec.Template.Spec.InitContainers))
+	for _, container := range ds.Spec.Template.Spec.InitContainers {
+		images = append(images, container.Image)
+	}
+	return images
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (c *ImagePrePullReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: resources.ImagePrePullName, Namespace: c.Namespace}}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("imageprepull").
+		Watches(&kaitov1alpha1.Workspace{}, handler.EnqueueRequestsFromMapFunc(
+			func(ctx context.Context, obj client.Object) []reconcile.Request {
+				return []reconcile.Request{request}
+			})).
+		Watches(&appsv1.DaemonSet{}, handler.EnqueueRequestsFromMapFunc(
+			func(ctx context.Context, obj client.Object) []reconcile.Request {
+				if obj.GetName() != request.Name || obj.GetNamespace() != request.Namespace {
+					return nil
+				}
+				return []reconcile.Request{request}
+			})).
+		Complete(c)
+}
@@ -0,0 +1,66 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/resources"
+	"github.com/azure/kaito/pkg/utils/test"
+	"gotest.tools/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestImagePrePullReconcile(t *testing.T) {
+	test.RegisterTestModel()
+	t.Setenv("PRESET_REGISTRY_NAME", "registry")
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+
+	workspace := &v1alpha1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "ws", Namespace: "default"},
+		Inference: &v1alpha1.InferenceSpec{
+			Preset: &v1alpha1.PresetSpec{PresetMeta: v1alpha1.PresetMeta{Name: "test-model"}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(workspace).Build()
+	recorder := record.NewFakeRecorder(10)
+	reconciler := &ImagePrePullReconciler{Client: c, Recorder: recorder, Namespace: "kaito"}
+	ctx := context.Background()
+	key := client.ObjectKey{Name: resources.ImagePrePullName, Namespace: "kaito"}
+
+	_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+	assert.NilError(t, err)
+	ds := &appsv1.DaemonSet{}
+	assert.NilError(t, c.Get(ctx, key, ds))
+	assert.DeepEqual(t, prePulledImages(ds), []string{"registry/kaito-test-model:"})
+	assert.Equal(t, len(recorder.Events), 1)
+	<-recorder.Events
+
+	// The progress is reported once.
+	ds.Status.DesiredNumberScheduled = 2
+	ds.Status.NumberReady = 1
+	assert.NilError(t, c.Status().Update(ctx, ds))
+	for i := 0; i < 2; i++ {
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, len(recorder.Events), 1)
+	assert.Equal(t, <-recorder.Events, "Normal ImagePrePullProgress Preset images pulled on 1 of 2 GPU nodes")
+
+	// The DaemonSet is deleted with the last workspace.
+	assert.NilError(t, c.Delete(ctx, workspace))
+	_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+	assert.NilError(t, err)
+	assert.Check(t, c.Get(ctx, key, ds) != nil)
+}
@@ -0,0 +1,56 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package controllers
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+// QueueOptions tune the work queue of a controller. Zero values keep the controller-runtime defaults.
+// The queue depth and latency are exported as the workqueue_* metrics, labeled by controller name.
+type QueueOptions struct {
+	// MaxConcurrentReconciles is the number of objects reconciled in parallel.
+	MaxConcurrentReconciles int
+	// BaseDelay and MaxDelay bound the exponential backoff of the requeues of a failing object.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// QPS and Burst limit the overall rate of the requeues.
+	QPS   float64
+	Burst int
+}
+
+// controllerOptions returns the options of a controller reconciling defaultConcurrency objects in
+// parallel unless overridden.
+func (o QueueOptions) controllerOptions(defaultConcurrency int) controller.Options {
+	options := controller.Options{MaxConcurrentReconciles: defaultConcurrency}
+	if o.MaxConcurrentReconciles > 0 {
+		options.MaxConcurrentReconciles = o.MaxConcurrentReconciles
+	}
+	if o.BaseDelay == 0 && o.MaxDelay == 0 && o.QPS == 0 && o.Burst == 0 {
+		return options
+	}
+
+	baseDelay, maxDelay := 5*time.Millisecond, 1000*time.Second
+	if o.BaseDelay > 0 {
+		baseDelay = o.BaseDelay
+	}
+	if o.MaxDelay > 0 {
+		maxDelay = o.MaxDelay
+	}
+	qps, burst := 10.0, 100
+	if o.QPS > 0 {
+		qps = o.QPS
+	}
+	if o.Burst > 0 {
+		burst = o.Burst
+	}
+	options.RateLimiter = workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(qps), burst)},
+	)
+	return options
+}
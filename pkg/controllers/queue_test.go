@@ -0,0 +1,48 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestQueueControllerOptions(t *testing.T) {
+	testcases := map[string]struct {
+		queue               QueueOptions
+		expectedConcurrency int
+		expectedRateLimiter bool
+	}{
+		"Defaults": {
+			expectedConcurrency: 5,
+		},
+		"Concurrency only": {
+			queue:               QueueOptions{MaxConcurrentReconciles: 20},
+			expectedConcurrency: 20,
+		},
+		"Backoff": {
+			queue:               QueueOptions{MaxDelay: time.Minute},
+			expectedConcurrency: 5,
+			expectedRateLimiter: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			options := tc.queue.controllerOptions(5)
+			assert.Equal(t, options.MaxConcurrentReconciles, tc.expectedConcurrency)
+			assert.Equal(t, options.RateLimiter != nil, tc.expectedRateLimiter)
+			if tc.expectedRateLimiter {
+				// The failures of an item back off up to MaxDelay.
+				var delay time.Duration
+				for i := 0; i < 30; i++ {
+					delay = options.RateLimiter.When("item")
+				}
+				assert.Equal(t, delay, tc.queue.MaxDelay)
+			}
+		})
+	}
+}
@@ -21,7 +21,9 @@ import (
 	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
 	"github.com/azure/kaito/pkg/inference"
 	"github.com/azure/kaito/pkg/machine"
+	"github.com/azure/kaito/pkg/model"
 	"github.com/azure/kaito/pkg/resources"
+	"github.com/azure/kaito/pkg/runparams"
 	"github.com/azure/kaito/pkg/utils"
 	"github.com/azure/kaito/pkg/utils/plugin"
 	"github.com/go-logr/logr"
@@ -30,6 +32,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
@@ -37,10 +40,11 @@ import (
 	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
@@ -53,6 +57,13 @@ type WorkspaceReconciler struct {
 	Log      logr.Logger
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+	// PresetEvents receives the workspaces to reconcile after a change of the preset they use.
+	PresetEvents <-chan event.GenericEvent
+	// CleanupTimeout is how long the cleanup of a deleted workspace is retried before its finalizer
+	// is removed anyway. Zero means the cleanup is retried until it succeeds.
+	CleanupTimeout time.Duration
+	// Queue tunes the work queue of the controller.
+	Queue QueueOptions
 }
 
 func (c *WorkspaceReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
@@ -82,11 +93,40 @@ func (c *WorkspaceReconciler) Reconcile(ctx context.Context, req reconcile.Reque
 		}
 	}
 
-	if workspaceObj.Inference != nil && workspaceObj.Inference.Preset != nil {
-		if !plugin.KaitoModelRegister.Has(string(workspaceObj.Inference.Preset.Name)) {
-			return reconcile.Result{}, fmt.Errorf("the preset model name %s is not registered for workspace %s/%s",
-				string(workspaceObj.Inference.Preset.Name), workspaceObj.Namespace, workspaceObj.Name)
+	if err := c.applyWorkspaceClass(ctx, workspaceObj); err != nil {
+		reason := "workspaceFailed"
+		if errors.IsNotFound(err) {
+			reason = "workspaceClassNotFound"
 		}
+		if updateErr := c.updateStatusConditionIfNotMatch(ctx, workspaceObj, kaitov1alpha1.WorkspaceConditionTypeReady, metav1.ConditionFalse,
+			reason, err.Error()); updateErr != nil {
+			klog.ErrorS(updateErr, "failed to update workspace status", "workspace", klog.KObj(workspaceObj))
+			return reconcile.Result{}, updateErr
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get workspace class for workspace %s/%s: %w",
+			workspaceObj.Namespace, workspaceObj.Name, err)
+	}
+
+	for _, presetName := range workspacePresetNames(workspaceObj) {
+		if _, err := plugin.KaitoModelRegister.GetOrResolve(ctx, presetName); err != nil {
+			reason := "workspaceFailed"
+			if plugin.IsNotFound(err) {
+				reason = "presetNotFound"
+			}
+			if updateErr := c.updateStatusConditionIfNotMatch(ctx, workspaceObj, kaitov1alpha1.WorkspaceConditionTypeReady, metav1.ConditionFalse,
+				reason, err.Error()); updateErr != nil {
+				klog.ErrorS(updateErr, "failed to update workspace status", "workspace", klog.KObj(workspaceObj))
+				return reconcile.Result{}, updateErr
+			}
+			return reconcile.Result{}, fmt.Errorf("failed to get preset model for workspace %s/%s: %w",
+				workspaceObj.Namespace, workspaceObj.Name, err)
+		}
+	}
+
+	// Record the preset models referenced by this workspace so that transient registrations
+	// are kept alive until the workspace is deleted.
+	for _, presetName := range workspacePresetNames(workspaceObj) {
+		plugin.KaitoModelRegister.Acquire(presetName, client.ObjectKeyFromObject(workspaceObj).String())
 	}
 
 	return c.addOrUpdateWorkspace(ctx, workspaceObj)
@@ -109,6 +149,15 @@ func (c *WorkspaceReconciler) addOrUpdateWorkspace(ctx context.Context, wObj *ka
 		return reconcile.Result{}, err
 	}
 
+	if err := c.ensureLocalNVMeSetup(ctx, wObj); err != nil {
+		if updateErr := c.updateStatusConditionIfNotMatch(ctx, wObj, kaitov1alpha1.WorkspaceConditionTypeReady, metav1.ConditionFalse,
+			"workspaceFailed", err.Error()); updateErr != nil {
+			klog.ErrorS(updateErr, "failed to update workspace status", "workspace", klog.KObj(wObj))
+			return reconcile.Result{}, updateErr
+		}
+		return reconcile.Result{}, err
+	}
+
 	if err := c.ensureService(ctx, wObj); err != nil {
 		if updateErr := c.updateStatusConditionIfNotMatch(ctx, wObj, kaitov1alpha1.WorkspaceConditionTypeReady, metav1.ConditionFalse,
 			"workspaceFailed", err.Error()); updateErr != nil {
@@ -120,6 +169,11 @@ func (c *WorkspaceReconciler) addOrUpdateWorkspace(ctx context.Context, wObj *ka
 
 	if wObj.Tuning != nil {
 		if err = c.applyTuning(ctx, wObj); err != nil {
+			if updateErr := c.updateStatusConditionIfNotMatch(ctx, wObj, kaitov1alpha1.WorkspaceConditionTypeReady, metav1.ConditionFalse,
+				"workspaceFailed", err.Error()); updateErr != nil {
+				klog.ErrorS(updateErr, "failed to update workspace status", "workspace", klog.KObj(wObj))
+				return reconcile.Result{}, updateErr
+			}
 			return reconcile.Result{}, err
 		}
 	}
@@ -154,6 +208,32 @@ func (c *WorkspaceReconciler) deleteWorkspace(ctx context.Context, wObj *kaitov1
 	return c.garbageCollectWorkspace(ctx, wObj)
 }
 
+// applyWorkspaceClass applies the defaults of the workspace class to the workspace being reconciled.
+// They are not persisted, so that the changes to the class roll out to the workspace.
+func (c *WorkspaceReconciler) applyWorkspaceClass(ctx context.Context, wObj *kaitov1alpha1.Workspace) error {
+	if wObj.WorkspaceClassName == "" {
+		return nil
+	}
+	class := &kaitov1alpha1.WorkspaceClass{}
+	if err := c.Client.Get(ctx, client.ObjectKey{Name: wObj.WorkspaceClassName}, class); err != nil {
+		return err
+	}
+	wObj.ApplyWorkspaceClass(class)
+	return nil
+}
+
+// workspacePresetNames returns the references of the preset models used by the workspace.
+func workspacePresetNames(wObj *kaitov1alpha1.Workspace) []string {
+	var names []string
+	if wObj.Inference != nil && wObj.Inference.Preset != nil {
+		names = append(names, wObj.Inference.Preset.ModelReference())
+	}
+	if wObj.Tuning != nil && wObj.Tuning.Preset != nil {
+		names = append(names, wObj.Tuning.Preset.ModelReference())
+	}
+	return names
+}
+
 func (c *WorkspaceReconciler) selectWorkspaceNodes(qualified []*corev1.Node, preferred []string, previous []string, count int) []*corev1.Node {
 
 	sort.Slice(qualified, func(i, j int) bool {
@@ -286,6 +366,16 @@ func (c *WorkspaceReconciler) applyWorkspaceResource(ctx context.Context, wObj *
 		return err
 	}
 
+	// Label the nodes serving inference, so that tuning jobs can avoid them.
+	if wObj.Inference != nil {
+		for i := range selectedNodes {
+			if err := resources.EnsureInferenceNodeLabel(ctx, selectedNodes[i], c.Client); client.IgnoreNotFound(err) != nil {
+				klog.ErrorS(err, "failed to label the inference node", "node", selectedNodes[i].Name)
+				return err
+			}
+		}
+	}
+
 	// Add the valid nodes names to the WorkspaceStatus.WorkerNodes.
 	err = c.updateStatusNodeListIfNotMatch(ctx, wObj, selectedNodes)
 	if err != nil {
@@ -331,6 +421,10 @@ func (c *WorkspaceReconciler) getAllQualifiedNodes(ctx context.Context, wObj *ka
 			return condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue
 		})
 
+		// Skip the nodes serving inference workspaces the tuning pods cannot be scheduled on.
+		if wObj.Tuning != nil && !resources.TuningAllowedOnNode(wObj, &nodeObj) {
+			continue
+		}
 		if foundInstanceType && statusRunning {
 			qualifiedNodes = append(qualifiedNodes, lo.ToPtr(nodeObj))
 		}
@@ -353,9 +447,12 @@ func (c *WorkspaceReconciler) validateNodeInstanceType(ctx context.Context, wObj
 func (c *WorkspaceReconciler) createAndValidateNode(ctx context.Context, wObj *kaitov1alpha1.Workspace) (*corev1.Node, error) {
 	var nodeOSDiskSize string
 	if wObj.Inference != nil && wObj.Inference.Preset != nil && wObj.Inference.Preset.Name != "" {
-		presetName := string(wObj.Inference.Preset.Name)
-		nodeOSDiskSize = plugin.KaitoModelRegister.MustGet(presetName).
-			GetInferenceParameters().DiskStorageRequirement
+		presetName := wObj.Inference.Preset.ModelReference()
+		model, err := plugin.KaitoModelRegister.Get(presetName)
+		if err != nil {
+			return nil, err
+		}
+		nodeOSDiskSize = model.GetInferenceParameters().DiskStorageRequirement
 	}
 	if nodeOSDiskSize == "" {
 		nodeOSDiskSize = "0" // The default OS size is used
@@ -404,8 +501,27 @@ RetryWithDifferentName:
 }
 
 func (c *WorkspaceReconciler) CreateNodeClaim(ctx context.Context, wObj *kaitov1alpha1.Workspace, nodeOSDiskSize string) (*corev1.Node, error) {
+	var nodePool *v1beta1.NodePool
+	if nodeclaim.TargetNodePool.IsSet() {
+		var err error
+		if nodePool, err = nodeclaim.FindNodePool(ctx, nodeclaim.TargetNodePool, c.Client); err == nil {
+			err = nodeclaim.CheckNodePoolLimits(nodePool, wObj.Resource.InstanceType)
+		}
+		if err != nil {
+			if updateErr := c.updateStatusConditionIfNotMatch(ctx, wObj, kaitov1alpha1.WorkspaceConditionTypeNodeClaimStatus, metav1.ConditionFalse,
+				"nodePoolUnavailable", err.Error()); updateErr != nil {
+				klog.ErrorS(updateErr, "failed to update workspace status", "workspace", klog.KObj(wObj))
+				return nil, updateErr
+			}
+			return nil, err
+		}
+	}
+
 RetryWithDifferentName:
 	newNodeClaim := nodeclaim.GenerateNodeClaimManifest(ctx, nodeOSDiskSize, wObj)
+	if nodePool != nil {
+		nodeclaim.ApplyNodePool(newNodeClaim, nodePool)
+	}
 
 	if err := nodeclaim.CreateNodeClaim(ctx, newNodeClaim, c.Client); err != nil {
 		if apierrors.IsAlreadyExists(err) {
@@ -471,17 +587,24 @@ func (c *WorkspaceReconciler) ensureNodePlugins(ctx context.Context, wObj *kaito
 	}
 }
 
-func (c *WorkspaceReconciler) ensureService(ctx context.Context, wObj *kaitov1alpha1.Workspace) error {
-	serviceType := corev1.ServiceTypeClusterIP
-	wAnnotation := wObj.GetAnnotations()
-
-	if len(wAnnotation) != 0 {
-		val, found := wAnnotation[kaitov1alpha1.AnnotationEnableLB]
-		if found && val == "True" {
-			serviceType = corev1.ServiceTypeLoadBalancer
-		}
+// ensureLocalNVMeSetup creates the DaemonSet that stripes and mounts the local NVMe disks of the
+// workspace nodes at the model cache path, if enabled for the instance type.
+func (c *WorkspaceReconciler) ensureLocalNVMeSetup(ctx context.Context, wObj *kaitov1alpha1.Workspace) error {
+	if !resources.LocalNVMeEnabled(wObj) {
+		return nil
+	}
+	existingDS := &appsv1.DaemonSet{}
+	err := c.Client.Get(ctx, client.ObjectKey{Name: resources.LocalNVMeSetupName(wObj), Namespace: wObj.Namespace}, existingDS)
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return err
 	}
+	return resources.CreateResource(ctx, resources.GenerateLocalNVMeSetupManifest(ctx, wObj), c.Client)
+}
 
+func (c *WorkspaceReconciler) ensureService(ctx context.Context, wObj *kaitov1alpha1.Workspace) error {
 	existingSVC := &corev1.Service{}
 	err := resources.GetResource(ctx, wObj.Name, wObj.Namespace, c.Client, existingSVC)
 	if err != nil {
@@ -493,9 +616,12 @@ func (c *WorkspaceReconciler) ensureService(ctx context.Context, wObj *kaitov1al
 	}
 
 	if wObj.Inference != nil && wObj.Inference.Preset != nil {
-		presetName := string(wObj.Inference.Preset.Name)
-		model := plugin.KaitoModelRegister.MustGet(presetName)
-		serviceObj := resources.GenerateServiceManifest(ctx, wObj, serviceType, model.SupportDistributedInference())
+		presetName := wObj.Inference.Preset.ModelReference()
+		model, err := plugin.KaitoModelRegister.Get(presetName)
+		if err != nil {
+			return err
+		}
+		serviceObj := resources.GenerateServiceManifest(ctx, wObj, resources.ServiceType(wObj), model.SupportDistributedInference())
 		err = resources.CreateResource(ctx, serviceObj, c.Client)
 		if err != nil {
 			return err
@@ -515,16 +641,27 @@ func (c *WorkspaceReconciler) applyTuning(ctx context.Context, wObj *kaitov1alph
 	var err error
 	func() {
 		if wObj.Tuning.Preset != nil {
-			presetName := string(wObj.Tuning.Preset.Name)
-			model := plugin.KaitoModelRegister.MustGet(presetName)
+			presetName := wObj.Tuning.Preset.ModelReference()
+			model, getErr := plugin.KaitoModelRegister.Get(presetName)
+			if getErr != nil {
+				err = getErr
+				return
+			}
 
 			tuningParam := model.GetTuningParameters()
 			existingObj := &batchv1.Job{}
 			if err = resources.GetResource(ctx, wObj.Name, wObj.Namespace, c.Client, existingObj); err == nil {
 				klog.InfoS("A tuning workload already exists for workspace", "workspace", klog.KObj(wObj))
+				if tuning.JobFailed(existingObj) {
+					err = c.handleFailedTuningJob(ctx, wObj, existingObj)
+					return
+				}
 				if err = resources.CheckResourceStatus(existingObj, c.Client, tuningParam.ReadinessTimeout); err != nil {
 					return
 				}
+				if err = tuning.ReleaseVolumeClaims(ctx, wObj, existingObj, c.Client); err != nil {
+					return
+				}
 			} else if apierrors.IsNotFound(err) {
 				var workloadObj client.Object
 				// Need to create a new workload
@@ -546,6 +683,46 @@ func (c *WorkspaceReconciler) applyTuning(ctx context.Context, wObj *kaitov1alph
 	return nil
 }
 
+// handleFailedTuningJob records the failed attempt of the tuning job in the status of the workspace and,
+// if the retry policy allows it, deletes the job so that the next reconcile recreates it.
+func (c *WorkspaceReconciler) handleFailedTuningJob(ctx context.Context, wObj *kaitov1alpha1.Workspace, jobObj *batchv1.Job) error {
+	var attempts []kaitov1alpha1.TuningAttempt
+	if wObj.Status.Tuning != nil {
+		attempts = wObj.Status.Tuning.Attempts
+	}
+	attempt, recorded := lo.Find(attempts, func(attempt kaitov1alpha1.TuningAttempt) bool {
+		return attempt.JobUID == string(jobObj.UID)
+	})
+	if !recorded {
+		reason, message, err := tuning.ClassifyJobFailure(ctx, wObj, jobObj, c.Client)
+		if err != nil {
+			return err
+		}
+		attempt = kaitov1alpha1.TuningAttempt{
+			JobUID:        string(jobObj.UID),
+			FailureReason: reason,
+			Message:       message,
+			FailedTime:    metav1.Now(),
+			Retried:       tuning.GlobalRetryPolicy.ShouldRetry(reason, attempts),
+		}
+		if divisor := tuning.BatchSizeDivisor(wObj); divisor > 1 {
+			attempt.BatchSizeDivisor = divisor
+		}
+		if err := c.updateTuningStatus(ctx, wObj, attempt); err != nil {
+			return err
+		}
+	}
+	if !attempt.Retried {
+		return fmt.Errorf("tuning job failed, reason %s: %s", attempt.FailureReason, attempt.Message)
+	}
+
+	klog.InfoS("Retrying the failed tuning job", "workspace", klog.KObj(wObj), "reason", attempt.FailureReason)
+	if err := c.Client.Delete(ctx, jobObj, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	return fmt.Errorf("retrying the tuning job after failure %s: %s", attempt.FailureReason, attempt.Message)
+}
+
 // applyInference applies inference spec.
 func (c *WorkspaceReconciler) applyInference(ctx context.Context, wObj *kaitov1alpha1.Workspace) error {
 	var err error
@@ -561,10 +738,25 @@ func (c *WorkspaceReconciler) applyInference(ctx context.Context, wObj *kaitov1a
 				return
 			}
 		} else if wObj.Inference != nil && wObj.Inference.Preset != nil {
-			presetName := string(wObj.Inference.Preset.Name)
-			model := plugin.KaitoModelRegister.MustGet(presetName)
+			presetName := wObj.Inference.Preset.ModelReference()
+			model, getErr := plugin.KaitoModelRegister.Get(presetName)
+			if getErr != nil {
+				err = getErr
+				return
+			}
+
+			inferenceParam, overrides, resolveErr := inference.ResolveRunParams(wObj, model.GetInferenceParameters())
+			if resolveErr != nil {
+				err = resolveErr
+				return
+			}
+			if err = c.updateRunParamsOverriddenCondition(ctx, wObj, overrides); err != nil {
+				return
+			}
 
-			inferenceParam := model.GetInferenceParameters()
+			if err = c.checkNodeDriverVersions(ctx, wObj, inferenceParam); err != nil {
+				return
+			}
 
 			// TODO: we only do create if it does not exist for preset model. Need to document it.
 
@@ -578,7 +770,16 @@ func (c *WorkspaceReconciler) applyInference(ctx context.Context, wObj *kaitov1a
 
 			if err = resources.GetResource(ctx, wObj.Name, wObj.Namespace, c.Client, existingObj); err == nil {
 				klog.InfoS("An inference workload already exists for workspace", "workspace", klog.KObj(wObj))
-				if err = resources.CheckResourceStatus(existingObj, c.Client, inferenceParam.ReadinessTimeout); err != nil {
+				if err = c.rolloutPresetChange(ctx, wObj, existingObj, inferenceParam, model.SupportDistributedInference()); err != nil {
+					return
+				}
+				if err = c.correctDrift(ctx, wObj, existingObj, inferenceParam, model.SupportDistributedInference()); err != nil {
+					return
+				}
+				if err = c.updateInferenceStatusIfNotMatch(ctx, wObj, existingObj, inferenceParam); err != nil {
+					return
+				}
+				if err = resources.CheckResourceStatus(existingObj, c.Client, inference.GlobalReadinessPolicy.ReadinessTimeout(wObj, inferenceParam)); err != nil {
 					return
 				}
 			} else if apierrors.IsNotFound(err) {
@@ -588,7 +789,10 @@ func (c *WorkspaceReconciler) applyInference(ctx context.Context, wObj *kaitov1a
 				if err != nil {
 					return
 				}
-				if err = resources.CheckResourceStatus(workloadObj, c.Client, inferenceParam.ReadinessTimeout); err != nil {
+				if err = c.updateInferenceStatusIfNotMatch(ctx, wObj, workloadObj, inferenceParam); err != nil {
+					return
+				}
+				if err = resources.CheckResourceStatus(workloadObj, c.Client, inference.GlobalReadinessPolicy.ReadinessTimeout(wObj, inferenceParam)); err != nil {
 					return
 				}
 			}
@@ -613,6 +817,141 @@ func (c *WorkspaceReconciler) applyInference(ctx context.Context, wObj *kaitov1a
 	return nil
 }
 
+// checkNodeDriverVersions checks that the NVIDIA driver of the workspace nodes is recent enough for
+// the CUDA runtime of the preset image, so that an incompatible node fails early with a clear error
+// instead of the inference pods crashing.
+func (c *WorkspaceReconciler) checkNodeDriverVersions(ctx context.Context, wObj *kaitov1alpha1.Workspace, inferenceParam *model.PresetParam) error {
+	if inferenceParam.MinDriverVersion == "" {
+		return nil
+	}
+	for _, nodeName := range wObj.Status.WorkerNodes {
+		nodeObj, err := resources.GetNode(ctx, nodeName, c.Client)
+		if err != nil {
+			return err
+		}
+		if err := resources.CheckDriverVersion(nodeObj, inferenceParam.MinDriverVersion); err != nil {
+			return fmt.Errorf("preset %s: %w", wObj.Inference.Preset.Name, err)
+		}
+	}
+	return nil
+}
+
+// updateRunParamsOverriddenCondition reports the model run parameters overridden by a source of
+// higher precedence, e.g. a preset default overridden by the workspace annotation.
+func (c *WorkspaceReconciler) updateRunParamsOverriddenCondition(ctx context.Context, wObj *kaitov1alpha1.Workspace, overrides []runparams.Override) error {
+	if len(overrides) > 0 {
+		return c.updateStatusConditionIfNotMatch(ctx, wObj, kaitov1alpha1.WorkspaceConditionTypeRunParamsOverridden, metav1.ConditionTrue,
+			"RunParamsOverridden", runparams.Describe(overrides))
+	}
+	if meta.FindStatusCondition(wObj.Status.Conditions, string(kaitov1alpha1.WorkspaceConditionTypeRunParamsOverridden)) == nil {
+		return nil
+	}
+	return c.updateStatusConditionIfNotMatch(ctx, wObj, kaitov1alpha1.WorkspaceConditionTypeRunParamsOverridden, metav1.ConditionFalse,
+		"RunParamsNotOverridden", "No model run parameter is overridden")
+}
+
+// rolloutPresetChange updates the pod template of an existing inference workload if the preset
+// parameters it was created from have changed, e.g., after a ModelPreset update, or if the spec
+// generated for the workspace has changed, e.g., after a WorkspaceClass update, so that the
+// Deployment or StatefulSet controller rolls the change out. Workloads that do not record the
+// preset hash were created by an older operator and are left untouched. Workloads that do not
+// record the spec hash yet get it recorded without a rollout.
+func (c *WorkspaceReconciler) rolloutPresetChange(ctx context.Context, wObj *kaitov1alpha1.Workspace, existingObj client.Object,
+	inferenceParam *model.PresetParam, supportDistributedInference bool) error {
+	template := resources.PodTemplateOf(existingObj)
+	if template == nil {
+		return nil
+	}
+	recordedHash, ok := template.Annotations[kaitov1alpha1.AnnotationPresetHash]
+	if !ok {
+		return nil
+	}
+
+	desiredObj, err := inference.GeneratePresetInference(ctx, wObj, inferenceParam, supportDistributedInference, c.Client)
+	if err != nil {
+		return err
+	}
+	recordedSpecHash, specHashRecorded := existingObj.GetAnnotations()[kaitov1alpha1.AnnotationSpecHash]
+	specHash := desiredObj.GetAnnotations()[kaitov1alpha1.AnnotationSpecHash]
+	presetChanged := recordedHash != inferenceParam.Hash()
+	specChanged := specHashRecorded && recordedSpecHash != specHash
+	if !presetChanged && specHashRecorded && !specChanged {
+		return nil
+	}
+
+	if presetChanged || specChanged {
+		klog.InfoS("Rolling out preset change to the inference workload", "workspace", klog.KObj(wObj),
+			"previousHash", recordedHash, "hash", inferenceParam.Hash(), "previousSpecHash", recordedSpecHash, "specHash", specHash)
+		*template = *resources.PodTemplateOf(desiredObj)
+	}
+	annotations := existingObj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[kaitov1alpha1.AnnotationSpecHash] = specHash
+	existingObj.SetAnnotations(annotations)
+	if err := c.Client.Update(ctx, existingObj); err != nil {
+		return err
+	}
+	if presetChanged || specChanged {
+		c.Recorder.Eventf(wObj, corev1.EventTypeNormal, "PresetRollout", "Rolling out updated preset %s", wObj.Inference.Preset.ModelReference())
+	}
+	return nil
+}
+
+// correctDrift restores the fields of an existing inference workload edited by hand, e.g., its image
+// or replicas, to the manifest generated by Kaito. The fields listed by the kaito.sh/drift-ignored-fields
+// annotation are left in place and reported by the DriftDetected condition. Like rolloutPresetChange,
+// workloads that do not record the preset hash are left untouched. The scope is the one of
+// resources.DetectDrift: the Service and the other objects created for the workspace are not checked.
+func (c *WorkspaceReconciler) correctDrift(ctx context.Context, wObj *kaitov1alpha1.Workspace, existingObj client.Object,
+	inferenceParam *model.PresetParam, supportDistributedInference bool) error {
+	template := resources.PodTemplateOf(existingObj)
+	if template == nil {
+		return nil
+	}
+	if _, ok := template.Annotations[kaitov1alpha1.AnnotationPresetHash]; !ok {
+		return nil
+	}
+
+	desiredObj, err := inference.GeneratePresetInference(ctx, wObj, inferenceParam, supportDistributedInference, c.Client)
+	if err != nil {
+		return err
+	}
+	var ignored []string
+	if value, ok := wObj.Annotations[kaitov1alpha1.AnnotationDriftIgnoredFields]; ok {
+		for _, field := range strings.Split(value, ",") {
+			ignored = append(ignored, strings.TrimSpace(field))
+		}
+	}
+	var corrected, leftInPlace []string
+	for _, field := range resources.DetectDrift(existingObj, desiredObj) {
+		if utils.Contains(ignored, field) {
+			leftInPlace = append(leftInPlace, field)
+		} else {
+			corrected = append(corrected, field)
+		}
+	}
+
+	if len(corrected) > 0 {
+		klog.InfoS("Correcting drift of the inference workload", "workspace", klog.KObj(wObj), "fields", corrected)
+		resources.RestoreDrift(existingObj, desiredObj, corrected)
+		if err := c.Client.Update(ctx, existingObj); err != nil {
+			return err
+		}
+		c.Recorder.Eventf(wObj, corev1.EventTypeWarning, "DriftCorrected", "Restored the drifted fields of the inference workload: %s", strings.Join(corrected, ", "))
+	}
+	if len(leftInPlace) > 0 {
+		return c.updateStatusConditionIfNotMatch(ctx, wObj, kaitov1alpha1.WorkspaceConditionTypeDriftDetected, metav1.ConditionTrue,
+			"DriftIgnored", fmt.Sprintf("Drifted fields of the inference workload are left in place: %s", strings.Join(leftInPlace, ", ")))
+	}
+	if meta.FindStatusCondition(wObj.Status.Conditions, string(kaitov1alpha1.WorkspaceConditionTypeDriftDetected)) == nil {
+		return nil
+	}
+	return c.updateStatusConditionIfNotMatch(ctx, wObj, kaitov1alpha1.WorkspaceConditionTypeDriftDetected, metav1.ConditionFalse,
+		"NoDrift", "No drifted field of the inference workload is left in place")
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (c *WorkspaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	c.Recorder = mgr.GetEventRecorderFor("Workspace")
@@ -628,13 +967,18 @@ func (c *WorkspaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&kaitov1alpha1.Workspace{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&appsv1.StatefulSet{}).
+		Owns(&batchv1.Job{}).
 		Watches(&v1alpha5.Machine{}, c.watchMachines()).
-		WithOptions(controller.Options{MaxConcurrentReconciles: 5})
+		Watches(&kaitov1alpha1.WorkspaceClass{}, c.watchWorkspaceClasses()).
+		WithOptions(c.Queue.controllerOptions(5))
 
 	if featuregates.FeatureGates[consts.FeatureFlagKarpenter] {
 		builder.
 			Watches(&v1beta1.NodeClaim{}, c.watchNodeClaims()) // watches for nodeClaim with labels indicating workspace name.
 	}
+	if c.PresetEvents != nil {
+		builder.WatchesRawSource(source.Channel(c.PresetEvents, &handler.EnqueueRequestForObject{}))
+	}
 	return builder.Complete(c)
 }
 
@@ -662,6 +1006,25 @@ func (c *WorkspaceReconciler) watchMachines() handler.EventHandler {
 		})
 }
 
+// watches for workspace classes and enqueues the workspaces of the class.
+func (c *WorkspaceReconciler) watchWorkspaceClasses() handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(
+		func(ctx context.Context, o client.Object) []reconcile.Request {
+			workspaceList := &kaitov1alpha1.WorkspaceList{}
+			if err := c.Client.List(ctx, workspaceList); err != nil {
+				klog.ErrorS(err, "failed to list workspaces", "workspaceclass", o.GetName())
+				return nil
+			}
+			var requests []reconcile.Request
+			for i := range workspaceList.Items {
+				if workspaceList.Items[i].WorkspaceClassName == o.GetName() {
+					requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&workspaceList.Items[i])})
+				}
+			}
+			return requests
+		})
+}
+
 // watches for nodeClaim with labels indicating workspace name.
 func (c *WorkspaceReconciler) watchNodeClaims() handler.EventHandler {
 	return handler.EnqueueRequestsFromMapFunc(
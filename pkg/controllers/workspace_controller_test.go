@@ -14,18 +14,25 @@ import (
 	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
 	"github.com/azure/kaito/api/v1alpha1"
 	"github.com/azure/kaito/pkg/featuregates"
+	"github.com/azure/kaito/pkg/inference"
 	"github.com/azure/kaito/pkg/machine"
 	"github.com/azure/kaito/pkg/nodeclaim"
+	"github.com/azure/kaito/pkg/tuning"
 	"github.com/azure/kaito/pkg/utils/consts"
+	"github.com/azure/kaito/pkg/utils/plugin"
 	"github.com/azure/kaito/pkg/utils/test"
 	"github.com/stretchr/testify/mock"
 	"gotest.tools/assert"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"knative.dev/pkg/apis"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 )
 
@@ -559,8 +566,64 @@ func TestEnsureService(t *testing.T) {
 
 }
 
+func TestApplyWorkspaceClass(t *testing.T) {
+	testcases := map[string]struct {
+		className             string
+		callMocks             func(c *test.MockClient)
+		expectedSchedulerName string
+		expectedError         error
+	}{
+		"Workspace without class": {
+			callMocks: func(c *test.MockClient) {},
+		},
+		"Defaults of the class are applied": {
+			className: "team",
+			callMocks: func(c *test.MockClient) {
+				c.CreateOrUpdateObjectInMap(&v1alpha1.WorkspaceClass{
+					ObjectMeta: v1.ObjectMeta{Name: "team"},
+					Spec:       v1alpha1.WorkspaceClassSpec{SchedulerName: "gpu-scheduler"},
+				})
+				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1alpha1.WorkspaceClass{}), mock.Anything).Return(nil)
+			},
+			expectedSchedulerName: "gpu-scheduler",
+		},
+		"Class not found": {
+			className: "missing",
+			callMocks: func(c *test.MockClient) {
+				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1alpha1.WorkspaceClass{}), mock.Anything).Return(test.NotFoundError())
+			},
+			expectedError: test.NotFoundError(),
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			mockClient := test.NewClient()
+			tc.callMocks(mockClient)
+
+			reconciler := &WorkspaceReconciler{
+				Client: mockClient,
+				Scheme: test.NewTestScheme(),
+			}
+			workspace := test.MockWorkspaceWithPreset.DeepCopy()
+			workspace.WorkspaceClassName = tc.className
+
+			err := reconciler.applyWorkspaceClass(context.Background(), workspace)
+			if tc.expectedError == nil {
+				assert.Check(t, err == nil, "Not expected to return error")
+				assert.Equal(t, tc.expectedSchedulerName, workspace.Resource.SchedulerName)
+			} else {
+				assert.Equal(t, tc.expectedError.Error(), err.Error())
+			}
+		})
+	}
+}
+
 func TestApplyInferenceWithPreset(t *testing.T) {
 	test.RegisterTestModel()
+	testModel, _ := plugin.KaitoModelRegister.Get("test-model")
+	inferenceParam, _, _ := inference.ResolveRunParams(test.MockWorkspaceWithPreset, testModel.GetInferenceParameters())
+	generatedObj, _ := inference.GeneratePresetInference(context.Background(), test.MockWorkspaceWithPreset, inferenceParam, false, nil)
 	testcases := map[string]struct {
 		callMocks     func(c *test.MockClient)
 		workspace     v1alpha1.Workspace
@@ -572,6 +635,7 @@ func TestApplyInferenceWithPreset(t *testing.T) {
 
 				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
 				c.StatusMock.On("Update", mock.IsType(context.Background()), mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
+				c.On("List", mock.IsType(context.Background()), mock.IsType(&corev1.PodList{}), mock.Anything).Return(nil)
 			},
 			workspace:     *test.MockWorkspaceDistributedModel,
 			expectedError: errors.New("Failed to get resource"),
@@ -592,6 +656,7 @@ func TestApplyInferenceWithPreset(t *testing.T) {
 
 				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
 				c.StatusMock.On("Update", mock.IsType(context.Background()), mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
+				c.On("List", mock.IsType(context.Background()), mock.IsType(&corev1.PodList{}), mock.Anything).Return(nil)
 			},
 			workspace:     *test.MockWorkspaceWithPreset,
 			expectedError: nil,
@@ -610,10 +675,109 @@ func TestApplyInferenceWithPreset(t *testing.T) {
 
 				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
 				c.StatusMock.On("Update", mock.IsType(context.Background()), mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
+				c.On("List", mock.IsType(context.Background()), mock.IsType(&corev1.PodList{}), mock.Anything).Return(nil)
 			},
 			workspace:     *test.MockWorkspaceDistributedModel,
 			expectedError: nil,
 		},
+		"Fail to apply inference because the preset is not registered": {
+			callMocks: func(c *test.MockClient) {
+				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
+				c.StatusMock.On("Update", mock.IsType(context.Background()), mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
+				c.On("List", mock.IsType(context.Background()), mock.IsType(&corev1.PodList{}), mock.Anything).Return(nil)
+			},
+			workspace: func() v1alpha1.Workspace {
+				wObj := test.MockWorkspaceWithPreset.DeepCopy()
+				wObj.Inference.Preset.Name = "unregistered-model"
+				return *wObj
+			}(),
+			expectedError: errors.New("model unregistered-model is not registered"),
+		},
+		"Roll out preset change to existing workload": {
+			callMocks: func(c *test.MockClient) {
+				c.On("Get", mock.Anything, mock.Anything, mock.IsType(&appsv1.Deployment{}), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+					depObj := &appsv1.Deployment{}
+					key := client.ObjectKey{Namespace: "kaito", Name: "testWorkspace"}
+					c.GetObjectFromMap(depObj, key)
+					numRep := int32(1)
+					depObj.Spec.Template.Annotations = map[string]string{v1alpha1.AnnotationPresetHash: "stale"}
+					depObj.Status.ReadyReplicas = numRep
+					depObj.Spec.Replicas = &numRep
+					c.CreateOrUpdateObjectInMap(depObj)
+				})
+				c.On("Update", mock.IsType(context.Background()), mock.IsType(&appsv1.Deployment{}), mock.Anything).Return(nil)
+
+				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
+				c.StatusMock.On("Update", mock.IsType(context.Background()), mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
+				c.On("List", mock.IsType(context.Background()), mock.IsType(&corev1.PodList{}), mock.Anything).Return(nil)
+			},
+			workspace:     *test.MockWorkspaceWithPreset,
+			expectedError: nil,
+		},
+		"Correct drift of existing workload": {
+			callMocks: func(c *test.MockClient) {
+				c.On("Get", mock.Anything, mock.Anything, mock.IsType(&appsv1.Deployment{}), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+					depObj := args.Get(2).(*appsv1.Deployment)
+					generatedObj.(*appsv1.Deployment).DeepCopyInto(depObj)
+					depObj.Spec.Template.Spec.Containers[0].Image = "custom/image:latest"
+					depObj.Status.ReadyReplicas = 1
+				})
+				c.On("Update", mock.IsType(context.Background()), mock.IsType(&appsv1.Deployment{}), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+					depObj := args.Get(1).(*appsv1.Deployment)
+					assert.Equal(t, depObj.Spec.Template.Spec.Containers[0].Image, generatedObj.(*appsv1.Deployment).Spec.Template.Spec.Containers[0].Image)
+				})
+
+				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
+				c.StatusMock.On("Update", mock.IsType(context.Background()), mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
+				c.On("List", mock.IsType(context.Background()), mock.IsType(&corev1.PodList{}), mock.Anything).Return(nil)
+			},
+			workspace:     *test.MockWorkspaceWithPreset,
+			expectedError: nil,
+		},
+		"Roll out spec change to existing workload": {
+			callMocks: func(c *test.MockClient) {
+				c.On("Get", mock.Anything, mock.Anything, mock.IsType(&appsv1.Deployment{}), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+					depObj := args.Get(2).(*appsv1.Deployment)
+					generatedObj.(*appsv1.Deployment).DeepCopyInto(depObj)
+					depObj.Annotations[v1alpha1.AnnotationSpecHash] = "stale"
+					depObj.Spec.Template.Spec.SchedulerName = "previous-scheduler"
+					depObj.Status.ReadyReplicas = 1
+				})
+				c.On("Update", mock.IsType(context.Background()), mock.IsType(&appsv1.Deployment{}), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+					depObj := args.Get(1).(*appsv1.Deployment)
+					assert.Equal(t, depObj.Annotations[v1alpha1.AnnotationSpecHash], generatedObj.GetAnnotations()[v1alpha1.AnnotationSpecHash])
+					assert.Equal(t, depObj.Spec.Template.Spec.SchedulerName, generatedObj.(*appsv1.Deployment).Spec.Template.Spec.SchedulerName)
+				}).Once()
+
+				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
+				c.StatusMock.On("Update", mock.IsType(context.Background()), mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
+				c.On("List", mock.IsType(context.Background()), mock.IsType(&corev1.PodList{}), mock.Anything).Return(nil)
+			},
+			workspace:     *test.MockWorkspaceWithPreset,
+			expectedError: nil,
+		},
+		"Record spec hash of existing workload without rollout": {
+			callMocks: func(c *test.MockClient) {
+				c.On("Get", mock.Anything, mock.Anything, mock.IsType(&appsv1.Deployment{}), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+					depObj := args.Get(2).(*appsv1.Deployment)
+					generatedObj.(*appsv1.Deployment).DeepCopyInto(depObj)
+					delete(depObj.Annotations, v1alpha1.AnnotationSpecHash)
+					depObj.Spec.Template.Spec.SchedulerName = "previous-scheduler"
+					depObj.Status.ReadyReplicas = 1
+				})
+				c.On("Update", mock.IsType(context.Background()), mock.IsType(&appsv1.Deployment{}), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+					depObj := args.Get(1).(*appsv1.Deployment)
+					assert.Equal(t, depObj.Annotations[v1alpha1.AnnotationSpecHash], generatedObj.GetAnnotations()[v1alpha1.AnnotationSpecHash])
+					assert.Equal(t, depObj.Spec.Template.Spec.SchedulerName, "previous-scheduler")
+				}).Once()
+
+				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
+				c.StatusMock.On("Update", mock.IsType(context.Background()), mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
+				c.On("List", mock.IsType(context.Background()), mock.IsType(&corev1.PodList{}), mock.Anything).Return(nil)
+			},
+			workspace:     *test.MockWorkspaceWithPreset,
+			expectedError: nil,
+		},
 	}
 
 	for k, tc := range testcases {
@@ -622,8 +786,9 @@ func TestApplyInferenceWithPreset(t *testing.T) {
 			tc.callMocks(mockClient)
 
 			reconciler := &WorkspaceReconciler{
-				Client: mockClient,
-				Scheme: test.NewTestScheme(),
+				Client:   mockClient,
+				Scheme:   test.NewTestScheme(),
+				Recorder: record.NewFakeRecorder(10),
 			}
 			ctx := context.Background()
 
@@ -844,6 +1009,9 @@ func TestApplyWorkspaceResource(t *testing.T) {
 				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1alpha5.Machine{}), mock.Anything).Return(nil)
 
 				c.On("List", mock.IsType(context.Background()), mock.IsType(&corev1.NodeList{}), mock.Anything).Return(nil)
+				// The nodes of the inference workspace are labeled.
+				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&corev1.Node{}), mock.Anything).Return(nil)
+				c.On("Update", mock.IsType(context.Background()), mock.IsType(&corev1.Node{}), mock.Anything).Return(nil)
 
 				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
 				c.StatusMock.On("Update", mock.IsType(context.Background()), mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
@@ -871,6 +1039,9 @@ func TestApplyWorkspaceResource(t *testing.T) {
 				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1beta1.NodeClaim{}), mock.Anything).Return(nil)
 
 				c.On("List", mock.IsType(context.Background()), mock.IsType(&corev1.NodeList{}), mock.Anything).Return(nil)
+				// The nodes of the inference workspace are labeled.
+				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&corev1.Node{}), mock.Anything).Return(nil)
+				c.On("Update", mock.IsType(context.Background()), mock.IsType(&corev1.Node{}), mock.Anything).Return(nil)
 
 				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
 				c.StatusMock.On("Update", mock.IsType(context.Background()), mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
@@ -926,3 +1097,72 @@ func TestApplyWorkspaceResource(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleFailedTuningJob(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	_ = batchv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	ctx := context.Background()
+
+	newJob := func(uid types.UID, exitCode int32) (*batchv1.Job, *corev1.Pod) {
+		job := &batchv1.Job{
+			ObjectMeta: v1.ObjectMeta{Name: "testWorkspace", Namespace: "kaito", UID: uid},
+			Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobFailed, Status: corev1.ConditionTrue},
+			}},
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "testWorkspace-" + string(uid),
+				Namespace: "kaito",
+				Labels:    map[string]string{batchv1.JobNameLabel: "testWorkspace"},
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodFailed,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "testWorkspace",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode}},
+				}},
+			},
+		}
+		return job, pod
+	}
+	getWorkspace := func(c client.Client) *v1alpha1.Workspace {
+		wObj := &v1alpha1.Workspace{}
+		assert.NilError(t, c.Get(ctx, client.ObjectKey{Name: "testWorkspace", Namespace: "kaito"}, wObj))
+		return wObj
+	}
+
+	workspace := test.MockWorkspaceWithPreset.DeepCopy()
+	oomJob, oomPod := newJob("job1", 4)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&v1alpha1.Workspace{}).
+		WithObjects(workspace, oomJob, oomPod).Build()
+	reconciler := &WorkspaceReconciler{Client: c, Scheme: scheme}
+
+	// Out of memory, the job is deleted to be recreated with half the batch size.
+	err := reconciler.handleFailedTuningJob(ctx, getWorkspace(c), oomJob)
+	assert.ErrorContains(t, err, "retrying the tuning job after failure OutOfMemory")
+	wObj := getWorkspace(c)
+	assert.Equal(t, len(wObj.Status.Tuning.Attempts), 1)
+	assert.Equal(t, wObj.Status.Tuning.Attempts[0].JobUID, "job1")
+	assert.Equal(t, wObj.Status.Tuning.Attempts[0].Retried, true)
+	assert.Equal(t, tuning.BatchSizeDivisor(wObj), 2)
+	assert.Assert(t, c.Get(ctx, client.ObjectKeyFromObject(oomJob), &batchv1.Job{}) != nil)
+
+	// NaN loss, the job is not retried, and the attempt is recorded once.
+	nanJob, nanPod := newJob("job2", 5)
+	assert.NilError(t, c.Create(ctx, nanJob))
+	assert.NilError(t, c.Create(ctx, nanPod))
+	assert.NilError(t, c.Delete(ctx, oomPod))
+	for i := 0; i < 2; i++ {
+		err = reconciler.handleFailedTuningJob(ctx, getWorkspace(c), nanJob)
+		assert.ErrorContains(t, err, "tuning job failed, reason NaNLoss")
+	}
+	wObj = getWorkspace(c)
+	assert.Equal(t, len(wObj.Status.Tuning.Attempts), 2)
+	assert.Equal(t, wObj.Status.Tuning.Attempts[1].FailureReason, v1alpha1.TuningFailureNaNLoss)
+	assert.Equal(t, wObj.Status.Tuning.Attempts[1].BatchSizeDivisor, 2)
+	assert.Equal(t, wObj.Status.Tuning.Attempts[1].Retried, false)
+	assert.NilError(t, c.Get(ctx, client.ObjectKeyFromObject(nanJob), &batchv1.Job{}))
+}
@@ -5,12 +5,16 @@ package controllers
 
 import (
 	"context"
+	"time"
 
 	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
 	"github.com/azure/kaito/pkg/featuregates"
 	"github.com/azure/kaito/pkg/machine"
 	"github.com/azure/kaito/pkg/nodeclaim"
+	"github.com/azure/kaito/pkg/resources"
 	"github.com/azure/kaito/pkg/utils/consts"
+	"github.com/azure/kaito/pkg/utils/plugin"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -21,16 +25,46 @@ import (
 func (c *WorkspaceReconciler) garbageCollectWorkspace(ctx context.Context, wObj *kaitov1alpha1.Workspace) (ctrl.Result, error) {
 	klog.InfoS("garbageCollectWorkspace", "workspace", klog.KObj(wObj))
 
+	if err := c.deleteWorkspaceNodes(ctx, wObj); err != nil {
+		if !c.cleanupTimedOut(wObj) {
+			return ctrl.Result{}, err
+		}
+		// Do not block the deletion of the workspace forever, e.g., on a broken Karpenter installation.
+		klog.ErrorS(err, "forcing the removal of the workspace finalizer after the cleanup timeout", "workspace", klog.KObj(wObj))
+		c.Recorder.Eventf(wObj, corev1.EventTypeWarning, "ForcedCleanup",
+			"Removing the finalizer after failing to clean up for %s, the nodes of the workspace may need to be deleted by hand: %v", c.CleanupTimeout, err)
+	}
+
+	staleWObj := wObj.DeepCopy()
+	staleWObj.SetFinalizers(nil)
+	if updateErr := c.Update(ctx, staleWObj, &client.UpdateOptions{}); updateErr != nil {
+		klog.ErrorS(updateErr, "failed to remove the finalizer from the workspace",
+			"workspace", klog.KObj(wObj), "workspace", klog.KObj(staleWObj))
+		return ctrl.Result{}, updateErr
+	}
+	klog.InfoS("successfully removed the workspace finalizers",
+		"workspace", klog.KObj(wObj))
+
+	// Drop the references this workspace holds on preset models.
+	for _, presetName := range workspacePresetNames(wObj) {
+		plugin.KaitoModelRegister.Release(presetName, client.ObjectKeyFromObject(wObj).String())
+	}
+	controllerutil.RemoveFinalizer(wObj, consts.WorkspaceFinalizer)
+	return ctrl.Result{}, nil
+}
+
+// deleteWorkspaceNodes deletes the machines and nodeClaims created for the workspace.
+func (c *WorkspaceReconciler) deleteWorkspaceNodes(ctx context.Context, wObj *kaitov1alpha1.Workspace) error {
 	// Check if there are any machines associated with this workspace.
 	mList, err := machine.ListMachinesByWorkspace(ctx, wObj, c.Client)
 	if err != nil {
-		return ctrl.Result{}, err
+		return err
 	}
 	// We should delete all the machines that are created by this workspace
 	for i := range mList.Items {
-		if deleteErr := c.Delete(ctx, &mList.Items[i], &client.DeleteOptions{}); deleteErr != nil {
+		if deleteErr := c.Delete(ctx, &mList.Items[i], &client.DeleteOptions{}); client.IgnoreNotFound(deleteErr) != nil {
 			klog.ErrorS(deleteErr, "failed to delete the machine", "machine", klog.KObj(&mList.Items[i]))
-			return ctrl.Result{}, deleteErr
+			return deleteErr
 		}
 	}
 
@@ -38,27 +72,34 @@ func (c *WorkspaceReconciler) garbageCollectWorkspace(ctx context.Context, wObj
 		// Check if there are any nodeClaims associated with this workspace.
 		ncList, err := nodeclaim.ListNodeClaimByWorkspace(ctx, wObj, c.Client)
 		if err != nil {
-			return ctrl.Result{}, err
+			return err
 		}
 
 		// We should delete all the nodeClaims that are created by this workspace
 		for i := range ncList.Items {
-			if deleteErr := c.Delete(ctx, &ncList.Items[i], &client.DeleteOptions{}); deleteErr != nil {
+			if deleteErr := c.Delete(ctx, &ncList.Items[i], &client.DeleteOptions{}); client.IgnoreNotFound(deleteErr) != nil {
 				klog.ErrorS(deleteErr, "failed to delete the nodeClaim", "nodeClaim", klog.KObj(&ncList.Items[i]))
-				return ctrl.Result{}, deleteErr
+				return deleteErr
 			}
 		}
 	}
 
-	staleWObj := wObj.DeepCopy()
-	staleWObj.SetFinalizers(nil)
-	if updateErr := c.Update(ctx, staleWObj, &client.UpdateOptions{}); updateErr != nil {
-		klog.ErrorS(updateErr, "failed to remove the finalizer from the workspace",
-			"workspace", klog.KObj(wObj), "workspace", klog.KObj(staleWObj))
-		return ctrl.Result{}, updateErr
+	// The nodes that outlive the workspace, e.g., the nodes not created by Kaito, no longer serve inference.
+	if wObj.Inference != nil {
+		for _, nodeName := range wObj.Status.WorkerNodes {
+			if err := resources.RemoveInferenceNodeLabel(ctx, nodeName, c.Client); err != nil {
+				klog.ErrorS(err, "failed to remove the inference label from the node", "node", nodeName)
+				return err
+			}
+		}
 	}
-	klog.InfoS("successfully removed the workspace finalizers",
-		"workspace", klog.KObj(wObj))
-	controllerutil.RemoveFinalizer(wObj, consts.WorkspaceFinalizer)
-	return ctrl.Result{}, nil
+	return nil
+}
+
+// cleanupTimedOut returns whether the cleanup timeout has passed since the deletion of the workspace.
+func (c *WorkspaceReconciler) cleanupTimedOut(wObj *kaitov1alpha1.Workspace) bool {
+	if c.CleanupTimeout <= 0 || wObj.DeletionTimestamp == nil {
+		return false
+	}
+	return time.Since(wObj.DeletionTimestamp.Time) > c.CleanupTimeout
 }
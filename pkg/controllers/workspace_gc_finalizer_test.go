@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
 	"github.com/azure/kaito/api/v1alpha1"
@@ -12,6 +13,8 @@ import (
 	"github.com/azure/kaito/pkg/utils/test"
 	"github.com/stretchr/testify/mock"
 	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 )
@@ -20,6 +23,7 @@ func TestGarbageCollectWorkspace(t *testing.T) {
 	testcases := map[string]struct {
 		callMocks             func(c *test.MockClient)
 		karpenterFeatureGates bool
+		deletedFor            time.Duration
 		expectedError         error
 	}{
 		"Fails to delete workspace because associated machines cannot be retrieved": {
@@ -51,6 +55,27 @@ func TestGarbageCollectWorkspace(t *testing.T) {
 			},
 			expectedError: errors.New("failed to delete machine"),
 		},
+		"Removes finalizer after the cleanup timeout although associated machines cannot be deleted": {
+			callMocks: func(c *test.MockClient) {
+				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
+				c.StatusMock.On("Update", mock.IsType(context.Background()), mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
+				c.On("Update", mock.IsType(context.Background()), mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
+
+				machineList := test.MockMachineList
+				relevantMap := c.CreateMapWithType(machineList)
+				//insert machine objects into the map
+				for _, obj := range test.MockMachineList.Items {
+					m := obj
+					objKey := client.ObjectKeyFromObject(&m)
+
+					relevantMap[objKey] = &m
+				}
+				c.On("List", mock.IsType(context.Background()), mock.IsType(&v1alpha5.MachineList{}), mock.Anything).Return(nil)
+				c.On("Delete", mock.IsType(context.Background()), mock.IsType(&v1alpha5.Machine{}), mock.Anything).Return(errors.New("failed to delete machine"))
+			},
+			deletedFor:    time.Hour,
+			expectedError: nil,
+		},
 		"Fails to delete workspace because associated nodeClaims cannot be retrieved": {
 			callMocks: func(c *test.MockClient) {
 				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1alpha1.Workspace{}), mock.Anything).Return(nil)
@@ -247,14 +272,20 @@ func TestGarbageCollectWorkspace(t *testing.T) {
 			tc.callMocks(mockClient)
 
 			reconciler := &WorkspaceReconciler{
-				Client: mockClient,
-				Scheme: test.NewTestScheme(),
+				Client:         mockClient,
+				Scheme:         test.NewTestScheme(),
+				Recorder:       record.NewFakeRecorder(10),
+				CleanupTimeout: 30 * time.Minute,
 			}
 			ctx := context.Background()
 
 			featuregates.FeatureGates[consts.FeatureFlagKarpenter] = tc.karpenterFeatureGates
 
-			_, err := reconciler.garbageCollectWorkspace(ctx, test.MockWorkspaceDistributedModel)
+			workspace := test.MockWorkspaceDistributedModel.DeepCopy()
+			if tc.deletedFor > 0 {
+				workspace.DeletionTimestamp = &metav1.Time{Time: time.Now().Add(-tc.deletedFor)}
+			}
+			_, err := reconciler.garbageCollectWorkspace(ctx, workspace)
 			if tc.expectedError == nil {
 				assert.Check(t, err == nil, "Not expected to return error")
 			} else {
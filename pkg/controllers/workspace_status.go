@@ -7,8 +7,11 @@ import (
 	"context"
 	"reflect"
 	"sort"
+	"strings"
 
 	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/model"
+	"github.com/azure/kaito/pkg/resources"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -75,3 +78,76 @@ func (c *WorkspaceReconciler) updateStatusNodeListIfNotMatch(ctx context.Context
 	klog.InfoS("updateStatusNodeList", "workspace", klog.KObj(wObj))
 	return c.updateWorkspaceStatus(ctx, &client.ObjectKey{Name: wObj.Name, Namespace: wObj.Namespace}, nil, nodeNameList)
 }
+
+// updateInferenceStatusIfNotMatch records the command line and the image of the inference container of
+// the workload, the resolved run parameters it is rendered from and the image digest its pods run.
+func (c *WorkspaceReconciler) updateInferenceStatusIfNotMatch(ctx context.Context, wObj *kaitov1alpha1.Workspace, workloadObj client.Object,
+	inferenceParam *model.PresetParam) error {
+	template := resources.PodTemplateOf(workloadObj)
+	if template == nil {
+		return nil
+	}
+	container, found := lo.Find(template.Spec.Containers, func(container corev1.Container) bool {
+		return container.Name == wObj.Name
+	})
+	if !found {
+		return nil
+	}
+	status := &kaitov1alpha1.InferenceStatus{
+		Command:            strings.Join(append(append([]string{}, container.Command...), container.Args...), " "),
+		PresetHash:         template.Annotations[kaitov1alpha1.AnnotationPresetHash],
+		Image:              container.Image,
+		ObservedGeneration: wObj.GetGeneration(),
+	}
+	digest, err := resources.ImageDigest(ctx, workloadObj, container.Name, c.Client)
+	if err != nil {
+		return err
+	}
+	status.ImageDigest = digest
+	if len(inferenceParam.ModelRunParams) > 0 {
+		status.RunParams = inferenceParam.ModelRunParams
+	}
+	if reflect.DeepEqual(wObj.Status.Inference, status) {
+		return nil
+	}
+	klog.InfoS("updateInferenceStatus", "workspace", klog.KObj(wObj), "command", status.Command)
+	return retry.OnError(retry.DefaultRetry,
+		func(err error) bool {
+			return apierrors.IsServiceUnavailable(err) || apierrors.IsServerTimeout(err) || apierrors.IsTooManyRequests(err)
+		},
+		func() error {
+			// Read the latest version to avoid update conflict.
+			latest := &kaitov1alpha1.Workspace{}
+			if err := c.Client.Get(ctx, client.ObjectKeyFromObject(wObj), latest); err != nil {
+				return client.IgnoreNotFound(err)
+			}
+			latest.Status.Inference = status
+			return c.Client.Status().Update(ctx, latest)
+		})
+}
+
+// updateTuningStatus appends the failed attempt of the tuning job to the status of the workspace.
+func (c *WorkspaceReconciler) updateTuningStatus(ctx context.Context, wObj *kaitov1alpha1.Workspace, attempt kaitov1alpha1.TuningAttempt) error {
+	klog.InfoS("updateTuningStatus", "workspace", klog.KObj(wObj), "reason", attempt.FailureReason, "retried", attempt.Retried)
+	return retry.OnError(retry.DefaultRetry,
+		func(err error) bool {
+			return apierrors.IsServiceUnavailable(err) || apierrors.IsServerTimeout(err) || apierrors.IsTooManyRequests(err)
+		},
+		func() error {
+			// Read the latest version to avoid update conflict.
+			latest := &kaitov1alpha1.Workspace{}
+			if err := c.Client.Get(ctx, client.ObjectKeyFromObject(wObj), latest); err != nil {
+				return client.IgnoreNotFound(err)
+			}
+			if latest.Status.Tuning == nil {
+				latest.Status.Tuning = &kaitov1alpha1.TuningStatus{}
+			}
+			if lo.ContainsBy(latest.Status.Tuning.Attempts, func(recorded kaitov1alpha1.TuningAttempt) bool {
+				return recorded.JobUID == attempt.JobUID
+			}) {
+				return nil
+			}
+			latest.Status.Tuning.Attempts = append(latest.Status.Tuning.Attempts, attempt)
+			return c.Client.Status().Update(ctx, latest)
+		})
+}
@@ -9,9 +9,12 @@ import (
 	"testing"
 
 	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/model"
 	"github.com/azure/kaito/pkg/utils/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -170,3 +173,62 @@ func TestUpdateStatusConditionIfNotMatch(t *testing.T) {
 		assert.Nil(t, err)
 	})
 }
+
+func TestUpdateInferenceStatusIfNotMatch(t *testing.T) {
+	workspace := test.MockWorkspaceWithPreset.DeepCopy()
+	workload := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{kaitov1alpha1.AnnotationPresetHash: "hash"},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:    workspace.Name,
+						Command: []string{"/bin/sh", "-c", "python3 inference_api.py --max_length=200"},
+					}},
+				},
+			},
+		},
+	}
+	inferenceParam := &model.PresetParam{ModelRunParams: map[string]string{"max_length": "200"}}
+
+	t.Run("Should record the rendered command", func(t *testing.T) {
+		mockClient := test.NewClient()
+		reconciler := &WorkspaceReconciler{
+			Client: mockClient,
+			Scheme: test.NewTestScheme(),
+		}
+		mockClient.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&kaitov1alpha1.Workspace{}), mock.Anything).Return(nil)
+		mockClient.StatusMock.On("Update", mock.IsType(context.Background()), mock.IsType(&kaitov1alpha1.Workspace{}), mock.Anything).
+			Run(func(args mock.Arguments) {
+				status := args.Get(1).(*kaitov1alpha1.Workspace).Status.Inference
+				assert.Equal(t, "/bin/sh -c python3 inference_api.py --max_length=200", status.Command)
+				assert.Equal(t, "hash", status.PresetHash)
+				assert.Equal(t, inferenceParam.ModelRunParams, status.RunParams)
+			}).Return(nil)
+
+		err := reconciler.updateInferenceStatusIfNotMatch(context.Background(), workspace, workload, inferenceParam)
+		assert.Nil(t, err)
+		mockClient.StatusMock.AssertNumberOfCalls(t, "Update", 1)
+	})
+
+	t.Run("Should not update a matching status", func(t *testing.T) {
+		mockClient := test.NewClient()
+		reconciler := &WorkspaceReconciler{
+			Client: mockClient,
+			Scheme: test.NewTestScheme(),
+		}
+		recorded := workspace.DeepCopy()
+		recorded.Status.Inference = &kaitov1alpha1.InferenceStatus{
+			Command:            "/bin/sh -c python3 inference_api.py --max_length=200",
+			RunParams:          map[string]string{"max_length": "200"},
+			PresetHash:         "hash",
+			ObservedGeneration: recorded.Generation,
+		}
+
+		err := reconciler.updateInferenceStatusIfNotMatch(context.Background(), recorded, workload, inferenceParam)
+		assert.Nil(t, err)
+		mockClient.StatusMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
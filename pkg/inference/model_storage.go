@@ -0,0 +1,167 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package inference
+
+import (
+	"fmt"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/model"
+	"github.com/azure/kaito/pkg/resources"
+	"github.com/azure/kaito/pkg/utils"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	modelStorageVolumeName = "model-cache-volume"
+	// modelCacheEnv is the cache directory of the Hugging Face libraries of the inference runtime.
+	modelCacheEnv = "HF_HOME"
+)
+
+// ModelCachePVCName returns the name of the PVC that stores the model files of a workspace with the
+// Persistent storage policy, deployed as a Deployment.
+func ModelCachePVCName(workspaceObj *kaitov1alpha1.Workspace) string {
+	return fmt.Sprintf("%s-model-cache", workspaceObj.Name)
+}
+
+// UsesModelCachePVC returns whether the inference workload mounts the dedicated PVC of the workspace.
+// A StatefulSet gets a PVC per pod from its claim templates instead.
+func UsesModelCachePVC(workspaceObj *kaitov1alpha1.Workspace, supportDistributedInference bool) bool {
+	storage := workspaceObj.Inference.Storage
+	return !supportDistributedInference && storage != nil && storage.Policy == kaitov1alpha1.ModelStoragePolicyPersistent
+}
+
+// configModelStorage returns the volumes storing the model files of the inference workload and their
+// mounts, per the storage policy of the workspace. For the Persistent policy of a StatefulSet, the
+// volume is returned as a claim template.
+func configModelStorage(workspaceObj *kaitov1alpha1.Workspace, inferenceObj *model.PresetParam,
+	supportDistributedInference bool) ([]corev1.Volume, []corev1.VolumeMount, []corev1.PersistentVolumeClaim, error) {
+	storage := workspaceObj.Inference.Storage
+	volumeMount := corev1.VolumeMount{
+		Name:      modelStorageVolumeName,
+		MountPath: utils.DefaultModelCacheMountPath,
+	}
+
+	if storage == nil || storage.Policy == "" || storage.Policy == kaitov1alpha1.ModelStoragePolicyNodeLocal {
+		if resources.LocalNVMeEnabled(workspaceObj) {
+			volume, volumeMount := utils.ConfigModelCacheVolume()
+			return []corev1.Volume{volume}, []corev1.VolumeMount{volumeMount}, nil, nil
+		}
+		if storage == nil {
+			return nil, nil, nil, nil
+		}
+		volume := corev1.Volume{
+			Name: modelStorageVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		}
+		return []corev1.Volume{volume}, []corev1.VolumeMount{volumeMount}, nil, nil
+	}
+
+	claimSpec, err := modelStorageClaimSpec(storage, inferenceObj)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	switch storage.Policy {
+	case kaitov1alpha1.ModelStoragePolicyEphemeral:
+		volume := corev1.Volume{
+			Name: modelStorageVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Ephemeral: &corev1.EphemeralVolumeSource{
+					VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+						Spec: claimSpec,
+					},
+				},
+			},
+		}
+		return []corev1.Volume{volume}, []corev1.VolumeMount{volumeMount}, nil, nil
+	case kaitov1alpha1.ModelStoragePolicyPersistent:
+		if supportDistributedInference {
+			claim := corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: modelStorageVolumeName,
+				},
+				Spec: claimSpec,
+			}
+			return nil, []corev1.VolumeMount{volumeMount}, []corev1.PersistentVolumeClaim{claim}, nil
+		}
+		volume := corev1.Volume{
+			Name: modelStorageVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: ModelCachePVCName(workspaceObj),
+				},
+			},
+		}
+		return []corev1.Volume{volume}, []corev1.VolumeMount{volumeMount}, nil, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unsupported model storage policy %s", storage.Policy)
+	}
+}
+
+// configModelCacheEnv points the cache of the Hugging Face libraries of the inference container at the
+// model storage, so that the model files the runtime downloads, e.g., remote code or files missing from
+// the preset image, are stored on it rather than in the container filesystem.
+func configModelCacheEnv(template *corev1.PodTemplateSpec, workspaceObj *kaitov1alpha1.Workspace) {
+	for i := range template.Spec.Containers {
+		container := &template.Spec.Containers[i]
+		if container.Name != workspaceObj.Name || lo.ContainsBy(container.Env, func(env corev1.EnvVar) bool { return env.Name == modelCacheEnv }) {
+			continue
+		}
+		container.Env = append(container.Env, corev1.EnvVar{Name: modelCacheEnv, Value: utils.DefaultModelCacheMountPath})
+	}
+}
+
+// modelStorageClaimSpec returns the spec of the claims of the Ephemeral and Persistent volumes.
+func modelStorageClaimSpec(storage *kaitov1alpha1.ModelStorageSpec, inferenceObj *model.PresetParam) (corev1.PersistentVolumeClaimSpec, error) {
+	var size resource.Quantity
+	if storage.Size != nil {
+		size = *storage.Size
+	} else if inferenceObj.DiskStorageRequirement != "" {
+		var err error
+		if size, err = resource.ParseQuantity(inferenceObj.DiskStorageRequirement); err != nil {
+			return corev1.PersistentVolumeClaimSpec{}, fmt.Errorf("invalid disk storage requirement %s: %w", inferenceObj.DiskStorageRequirement, err)
+		}
+	} else {
+		return corev1.PersistentVolumeClaimSpec{}, fmt.Errorf("the size of the %s model storage must be specified", storage.Policy)
+	}
+
+	return corev1.PersistentVolumeClaimSpec{
+		AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+		StorageClassName: storage.StorageClassName,
+		Resources: corev1.VolumeResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceStorage: size,
+			},
+		},
+	}, nil
+}
+
+// GenerateModelCachePVCManifest returns the dedicated PVC of a workspace with the Persistent storage
+// policy. The PVC is owned by the workspace, it is retained across pod restarts.
+func GenerateModelCachePVCManifest(workspaceObj *kaitov1alpha1.Workspace, inferenceObj *model.PresetParam) (*corev1.PersistentVolumeClaim, error) {
+	claimSpec, err := modelStorageClaimSpec(workspaceObj.Inference.Storage, inferenceObj)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ModelCachePVCName(workspaceObj),
+			Namespace: workspaceObj.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: kaitov1alpha1.GroupVersion.String(),
+					Kind:       "Workspace",
+					UID:        workspaceObj.UID,
+					Name:       workspaceObj.Name,
+					Controller: lo.ToPtr(true),
+				},
+			},
+		},
+		Spec: claimSpec,
+	}, nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package inference
+
+import (
+	"fmt"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/model"
+	"github.com/azure/kaito/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// DeriveHostResources enables the CPU and memory requests of the inference containers derived from
+// the preset. Otherwise, only the GPUs are requested, unless the workspace sets the requests.
+var DeriveHostResources bool
+
+// hostMemoryOverhead is the memory requested on top of the weights for the runtime, the tokenizer
+// and the request buffers.
+var hostMemoryOverhead = resource.MustParse("4Gi")
+
+// configureHostResources sets the CPU and memory requests of the inference container. If enabled by
+// DeriveHostResources, the memory request holds the share of the weights of a node in the page cache
+// while they are loaded, plus hostMemoryOverhead, and a CPU is requested per GPU rank. The requests
+// of the kaito.sh/inference-resources annotation take precedence. No limits are set, so a pod can
+// burst above its requests rather than be OOMKilled while it loads the model.
+func configureHostResources(resourceReq *corev1.ResourceRequirements, wObj *kaitov1alpha1.Workspace,
+	inferenceObj *model.PresetParam, supportDistributedInference bool) error {
+	requests := corev1.ResourceList{}
+	if DeriveHostResources {
+		weights, err := resource.ParseQuantity(inferenceObj.TotalGPUMemoryRequirement)
+		if err != nil {
+			return fmt.Errorf("invalid total GPU memory requirement %q: %w", inferenceObj.TotalGPUMemoryRequirement, err)
+		}
+		nodes := int64(1)
+		if supportDistributedInference && wObj.Resource.Count != nil && *wObj.Resource.Count > 1 {
+			nodes = int64(*wObj.Resource.Count)
+		}
+		memory := resource.NewQuantity(weights.Value()/nodes, resource.BinarySI)
+		memory.Add(hostMemoryOverhead)
+		requests[corev1.ResourceMemory] = *memory
+
+		if gpus, err := resource.ParseQuantity(inferenceObj.GPUCountRequirement); err == nil && gpus.Sign() > 0 {
+			requests[corev1.ResourceCPU] = gpus
+		}
+	}
+
+	if value, ok := wObj.Annotations[kaitov1alpha1.AnnotationInferenceResources]; ok {
+		overrides, err := utils.ParseResourceRequests(value)
+		if err != nil {
+			return fmt.Errorf("invalid annotation %s: %w", kaitov1alpha1.AnnotationInferenceResources, err)
+		}
+		for name, quantity := range overrides {
+			requests[name] = quantity
+		}
+	}
+
+	if len(requests) == 0 {
+		return nil
+	}
+	if resourceReq.Requests == nil {
+		resourceReq.Requests = corev1.ResourceList{}
+	}
+	for name, quantity := range requests {
+		resourceReq.Requests[name] = quantity
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package inference
+
+import (
+	"testing"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/model"
+	"github.com/azure/kaito/pkg/utils/test"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestConfigureHostResources(t *testing.T) {
+	testcases := map[string]struct {
+		derive           bool
+		distributed      bool
+		count            int
+		annotation       string
+		expectedRequests map[corev1.ResourceName]string
+		expectErr        bool
+	}{
+		"GPUs only by default": {
+			count:            1,
+			expectedRequests: map[corev1.ResourceName]string{},
+		},
+		"derived from the preset": {
+			derive:           true,
+			count:            1,
+			expectedRequests: map[corev1.ResourceName]string{corev1.ResourceCPU: "2", corev1.ResourceMemory: "36Gi"},
+		},
+		"weights shared across the nodes of distributed inference": {
+			derive:           true,
+			distributed:      true,
+			count:            2,
+			expectedRequests: map[corev1.ResourceName]string{corev1.ResourceCPU: "2", corev1.ResourceMemory: "20Gi"},
+		},
+		"annotation overrides derived requests": {
+			derive:           true,
+			count:            1,
+			annotation:       `{"memory": "64Gi"}`,
+			expectedRequests: map[corev1.ResourceName]string{corev1.ResourceCPU: "2", corev1.ResourceMemory: "64Gi"},
+		},
+		"annotation without derivation": {
+			count:            1,
+			annotation:       `{"cpu": "8"}`,
+			expectedRequests: map[corev1.ResourceName]string{corev1.ResourceCPU: "8"},
+		},
+		"annotation with unsupported resource": {
+			count:      1,
+			annotation: `{"nvidia.com/gpu": "8"}`,
+			expectErr:  true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			DeriveHostResources = tc.derive
+			defer func() { DeriveHostResources = false }()
+
+			workspace := test.MockWorkspaceWithPreset.DeepCopy()
+			workspace.Resource.Count = lo.ToPtr(tc.count)
+			if tc.annotation != "" {
+				workspace.Annotations = map[string]string{kaitov1alpha1.AnnotationInferenceResources: tc.annotation}
+			}
+			param := &model.PresetParam{GPUCountRequirement: "2", TotalGPUMemoryRequirement: "32Gi"}
+			resourceReq := corev1.ResourceRequirements{}
+
+			err := configureHostResources(&resourceReq, workspace, param, tc.distributed)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(resourceReq.Requests) != len(tc.expectedRequests) {
+				t.Fatalf("expected requests %v, got %v", tc.expectedRequests, resourceReq.Requests)
+			}
+			for name, expected := range tc.expectedRequests {
+				if actual := resourceReq.Requests[name]; actual.Cmp(resource.MustParse(expected)) != 0 {
+					t.Errorf("expected %s request %s, got %s", name, expected, actual.String())
+				}
+			}
+			if len(resourceReq.Limits) != 0 {
+				t.Errorf("expected no limits, got %v", resourceReq.Limits)
+			}
+		})
+	}
+}
@@ -30,6 +30,10 @@ const (
 	DefaultGPUIds       = "all"
 )
 
+// DefaultMinDriverVersion is the minimum NVIDIA driver of the CUDA 12.1 runtime of the PyTorch
+// wheels in the preset images.
+const DefaultMinDriverVersion = "525.60.13"
+
 // TODO: remove the above local variables starting with lower cases.
 var (
 	DefaultTorchRunParams = map[string]string{
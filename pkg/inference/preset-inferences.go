@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path"
 	"strconv"
 
 	"github.com/azure/kaito/pkg/utils"
@@ -13,6 +14,9 @@ import (
 	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
 	"github.com/azure/kaito/pkg/model"
 	"github.com/azure/kaito/pkg/resources"
+	"github.com/azure/kaito/pkg/runparams"
+	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -80,7 +84,8 @@ func updateTorchParamsForDistributedInference(ctx context.Context, kubeClient cl
 	inferenceObj.TorchRunParams["nnodes"] = strconv.Itoa(nodes)
 	inferenceObj.TorchRunParams["nproc_per_node"] = strconv.Itoa(inferenceObj.WorldSize / nodes)
 	if nodes > 1 {
-		inferenceObj.TorchRunParams["node_rank"] = "$(echo $HOSTNAME | grep -o '[^-]*$')"
+		// The node rank is derived from the pod ordinal by the shell, see prepareInferenceParameters.
+		delete(inferenceObj.TorchRunParams, "node_rank")
 		inferenceObj.TorchRunParams["master_addr"] = existingService.Spec.ClusterIP
 		inferenceObj.TorchRunParams["master_port"] = "29500"
 	}
@@ -94,6 +99,48 @@ func updateTorchParamsForDistributedInference(ctx context.Context, kubeClient cl
 	return nil
 }
 
+// ResolveRunParams returns a copy of the preset parameters with the model run parameters merged
+// from the preset, the operator configuration, the workspace and the workspace annotation, by
+// increasing precedence, along with the overrides between them. The preset parameters specific to
+// the architecture of the instance type are part of the preset defaults. The tag of a public preset image
+// is replaced by the tag pinned by the workspace annotation.
+func ResolveRunParams(wObj *kaitov1alpha1.Workspace, inferenceObj *model.PresetParam) (*model.PresetParam, []runparams.Override, error) {
+	presetParams := inferenceObj.ModelRunParams
+	if archParams := inferenceObj.ArchModelRunParams[kaitov1alpha1.InstanceTypeArchitecture(wObj.Resource.InstanceType)]; len(archParams) > 0 {
+		presetParams = lo.Assign(presetParams, archParams)
+	}
+	layers := []runparams.Layer{
+		{Source: runparams.SourcePreset, Params: presetParams},
+		{Source: runparams.SourceOperator, Params: runparams.OperatorParams()},
+	}
+	// Only the text-generation inference script accepts a chat template, the workspaces of other presets
+	// are rejected by the validation.
+	if name, ok := wObj.Annotations[kaitov1alpha1.AnnotationChatTemplate]; ok && inferenceObj.SupportsChatTemplate() {
+		layers = append(layers, runparams.Layer{Source: runparams.SourceWorkspace, Params: map[string]string{
+			"chat_template": path.Join(utils.DefaultChatTemplatesMountPath, name),
+		}})
+	}
+	if value, ok := wObj.Annotations[kaitov1alpha1.AnnotationModelRunParams]; ok {
+		params, err := runparams.ParseAnnotation(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid annotation %s: %w", kaitov1alpha1.AnnotationModelRunParams, err)
+		}
+		layers = append(layers, runparams.Layer{Source: runparams.SourceAnnotation, Params: params})
+	}
+
+	merged, overrides := runparams.Merge(layers...)
+	inferenceObj = inferenceObj.DeepCopy()
+	// Keep presets without run parameters unchanged so that their hash is stable.
+	if len(merged) > 0 {
+		inferenceObj.ModelRunParams = merged
+	}
+	if tag, ok := wObj.Annotations[kaitov1alpha1.AnnotationPresetImageTag]; ok &&
+		inferenceObj.ImageAccessMode != string(kaitov1alpha1.ModelImageAccessModePrivate) {
+		inferenceObj.Tag = tag
+	}
+	return inferenceObj, overrides, nil
+}
+
 func GetInferenceImageInfo(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, presetObj *model.PresetParam) (string, []corev1.LocalObjectReference) {
 	imagePullSecretRefs := []corev1.LocalObjectReference{}
 	if presetObj.ImageAccessMode == string(kaitov1alpha1.ModelImageAccessModePrivate) {
@@ -106,13 +153,47 @@ func GetInferenceImageInfo(ctx context.Context, workspaceObj *kaitov1alpha1.Work
 		imageName := string(workspaceObj.Inference.Preset.Name)
 		imageTag := presetObj.Tag
 		registryName := os.Getenv("PRESET_REGISTRY_NAME")
+		// ApplyWorkloadMutation rewrites the image to its mirror, if any.
 		imageName = fmt.Sprintf("%s/kaito-%s:%s", registryName, imageName, imageTag)
-		return imageName, imagePullSecretRefs
+		return imageName, resources.PresetImagePullSecretRefs()
 	}
 }
 
 func CreatePresetInference(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace,
 	inferenceObj *model.PresetParam, supportDistributedInference bool, kubeClient client.Client) (client.Object, error) {
+	depObj, err := GeneratePresetInference(ctx, workspaceObj, inferenceObj, supportDistributedInference, kubeClient)
+	if err != nil {
+		return nil, err
+	}
+	if inferenceObj.ImageAccessMode != string(kaitov1alpha1.ModelImageAccessModePrivate) {
+		if err := resources.EnsurePresetImagePullSecrets(ctx, workspaceObj.Namespace, kubeClient); err != nil {
+			return nil, err
+		}
+	}
+	if UsesModelCachePVC(workspaceObj, supportDistributedInference) {
+		pvc, err := GenerateModelCachePVCManifest(workspaceObj, inferenceObj)
+		if err != nil {
+			return nil, err
+		}
+		if err := resources.CreateResource(ctx, pvc, kubeClient); client.IgnoreAlreadyExists(err) != nil {
+			return nil, err
+		}
+	}
+	err = resources.CreateResource(ctx, depObj, kubeClient)
+	if client.IgnoreAlreadyExists(err) != nil {
+		return nil, err
+	}
+	return depObj, nil
+}
+
+// GeneratePresetInference returns the inference workload of the preset. The hash of the preset
+// parameters is recorded on the pod template so that preset changes can be rolled out, and the
+// hash of the generated spec on the workload.
+func GeneratePresetInference(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace,
+	inferenceObj *model.PresetParam, supportDistributedInference bool, kubeClient client.Client) (client.Object, error) {
+	presetHash := inferenceObj.Hash()
+	// The torch parameters are updated per workspace below, do not alter the preset ones.
+	inferenceObj = inferenceObj.DeepCopy()
 	if inferenceObj.TorchRunParams != nil && supportDistributedInference {
 		if err := updateTorchParamsForDistributedInference(ctx, kubeClient, workspaceObj, inferenceObj); err != nil {
 			klog.ErrorS(err, "failed to update torch params", "workspace", workspaceObj)
@@ -130,39 +211,93 @@ func CreatePresetInference(ctx context.Context, workspaceObj *kaitov1alpha1.Work
 		volumeMounts = append(volumeMounts, shmVolumeMount)
 	}
 
+	modelStorageVolumes, modelStorageVolumeMounts, modelStorageClaims, err := configModelStorage(workspaceObj, inferenceObj, supportDistributedInference)
+	if err != nil {
+		return nil, err
+	}
+	volumes = append(volumes, modelStorageVolumes...)
+	volumeMounts = append(volumeMounts, modelStorageVolumeMounts...)
+
+	if len(workspaceObj.Inference.ChatTemplates) > 0 {
+		chatTemplatesVolume, chatTemplatesVolumeMount := utils.ConfigChatTemplatesVolume(workspaceObj.Inference.ChatTemplates)
+		volumes = append(volumes, chatTemplatesVolume)
+		volumeMounts = append(volumeMounts, chatTemplatesVolumeMount)
+	}
+
 	if len(workspaceObj.Inference.Adapters) > 0 {
 		adapterVolume, adapterVolumeMount := utils.ConfigAdapterVolume()
 		volumes = append(volumes, adapterVolume)
 		volumeMounts = append(volumeMounts, adapterVolumeMount)
 	}
 
-	commands, resourceReq := prepareInferenceParameters(ctx, inferenceObj)
+	rankFromHostname := inferenceObj.TorchRunParams != nil && supportDistributedInference && *workspaceObj.Resource.Count > 1
+	commands, resourceReq, err := prepareInferenceParameters(ctx, inferenceObj, rankFromHostname)
+	if err != nil {
+		return nil, err
+	}
+	if err := configureHostResources(&resourceReq, workspaceObj, inferenceObj, supportDistributedInference); err != nil {
+		return nil, err
+	}
 	image, imagePullSecrets := GetInferenceImageInfo(ctx, workspaceObj, inferenceObj)
 
 	var depObj client.Object
 	if supportDistributedInference {
-		depObj = resources.GenerateStatefulSetManifest(ctx, workspaceObj, image, imagePullSecrets, *workspaceObj.Resource.Count, commands,
+		ss := resources.GenerateStatefulSetManifest(ctx, workspaceObj, image, imagePullSecrets, *workspaceObj.Resource.Count, commands,
 			containerPorts, livenessProbe, readinessProbe, resourceReq, tolerations, volumes, volumeMounts)
+		ss.Spec.VolumeClaimTemplates = modelStorageClaims
+		if len(modelStorageClaims) > 0 {
+			// The model files of the pods are not kept after the workspace is deleted.
+			ss.Spec.PersistentVolumeClaimRetentionPolicy = &appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+				WhenDeleted: appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
+				WhenScaled:  appsv1.RetainPersistentVolumeClaimRetentionPolicyType,
+			}
+		}
+		depObj = ss
 	} else {
 		depObj = resources.GenerateDeploymentManifest(ctx, workspaceObj, image, imagePullSecrets, *workspaceObj.Resource.Count, commands,
 			containerPorts, livenessProbe, readinessProbe, resourceReq, tolerations, volumes, volumeMounts)
 	}
-	err := resources.CreateResource(ctx, depObj, kubeClient)
-	if client.IgnoreAlreadyExists(err) != nil {
-		return nil, err
+	if template := resources.PodTemplateOf(depObj); template != nil {
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		template.Annotations[kaitov1alpha1.AnnotationPresetHash] = presetHash
+		if len(modelStorageVolumeMounts) > 0 {
+			configModelCacheEnv(template, workspaceObj)
+		}
+		if resources.RDMAEnabled(workspaceObj) {
+			resources.ConfigureRDMA(template, workspaceObj)
+		}
+		resources.ConfigureLogging(template, workspaceObj)
+		resources.ConfigureScheduling(template, workspaceObj)
+		resources.ConfigureArchitectures(template, inferenceObj.ImageArchitectures())
+		resources.ApplyWorkloadMutation(template)
 	}
+	resources.SetSpecHash(depObj)
 	return depObj, nil
 }
 
 // prepareInferenceParameters builds a PyTorch command:
 // torchrun <TORCH_PARAMS> <OPTIONAL_RDZV_PARAMS> baseCommand <MODEL_PARAMS>
-// and sets the GPU resources required for inference.
+// and sets the GPU resources required for inference. If rankFromHostname is set, the node rank
+// is derived from the ordinal of the StatefulSet pod.
 // Returns the command and resource configuration.
-func prepareInferenceParameters(ctx context.Context, inferenceObj *model.PresetParam) ([]string, corev1.ResourceRequirements) {
-	torchCommand := utils.BuildCmdStr(inferenceObj.BaseCommand, inferenceObj.TorchRunParams)
-	torchCommand = utils.BuildCmdStr(torchCommand, inferenceObj.TorchRunRdzvParams)
-	modelCommand := utils.BuildCmdStr(InferenceFile, inferenceObj.ModelRunParams)
-	commands := utils.ShellCmd(torchCommand + " " + modelCommand)
+func prepareInferenceParameters(ctx context.Context, inferenceObj *model.PresetParam, rankFromHostname bool) ([]string, corev1.ResourceRequirements, error) {
+	command := utils.NewCommand(inferenceObj.BaseCommand)
+	if err := command.AddParams(inferenceObj.TorchRunParams); err != nil {
+		return nil, corev1.ResourceRequirements{}, err
+	}
+	if rankFromHostname {
+		command.AddShellParam("node_rank", "$(echo $HOSTNAME | grep -o '[^-]*$')")
+	}
+	if err := command.AddParams(inferenceObj.TorchRunRdzvParams); err != nil {
+		return nil, corev1.ResourceRequirements{}, err
+	}
+	command.AddArgs(InferenceFile)
+	if err := command.AddParams(inferenceObj.ModelRunParams); err != nil {
+		return nil, corev1.ResourceRequirements{}, err
+	}
+	commands := command.ContainerCommand()
 
 	resourceRequirements := corev1.ResourceRequirements{
 		Requests: corev1.ResourceList{
@@ -173,5 +308,5 @@ func prepareInferenceParameters(ctx context.Context, inferenceObj *model.PresetP
 		},
 	}
 
-	return commands, resourceRequirements
+	return commands, resourceRequirements, nil
 }
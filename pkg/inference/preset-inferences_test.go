@@ -11,11 +11,17 @@ import (
 
 	"github.com/azure/kaito/pkg/utils/test"
 
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/featuregates"
 	"github.com/azure/kaito/pkg/model"
+	"github.com/azure/kaito/pkg/runparams"
+	"github.com/azure/kaito/pkg/utils/consts"
 	"github.com/azure/kaito/pkg/utils/plugin"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/mock"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -38,7 +44,7 @@ func TestCreatePresetInference(t *testing.T) {
 			workload: "Deployment",
 			// No BaseCommand, TorchRunParams, TorchRunRdzvParams, or ModelRunParams
 			// So expected cmd consists of shell command and inference file
-			expectedCmd: "/bin/sh -c  inference_api.py",
+			expectedCmd: "/bin/sh -c inference_api.py",
 		},
 
 		"test-distributed-model": {
@@ -49,7 +55,7 @@ func TestCreatePresetInference(t *testing.T) {
 				c.On("Create", mock.IsType(context.TODO()), mock.IsType(&appsv1.StatefulSet{}), mock.Anything).Return(nil)
 			},
 			workload:    "StatefulSet",
-			expectedCmd: "/bin/sh -c  inference_api.py",
+			expectedCmd: "/bin/sh -c inference_api.py",
 		},
 	}
 
@@ -117,6 +123,133 @@ func TestCreatePresetInference(t *testing.T) {
 	}
 }
 
+func TestConfigModelStorage(t *testing.T) {
+	test.RegisterTestModel()
+	inferenceObj := plugin.KaitoModelRegister.MustGet("test-model").GetInferenceParameters().DeepCopy()
+	inferenceObj.DiskStorageRequirement = "100Gi"
+
+	testcases := map[string]struct {
+		storage                     *kaitov1alpha1.ModelStorageSpec
+		supportDistributedInference bool
+		expectedVolumes             int
+		expectedClaims              int
+		check                       func(t *testing.T, volumes []corev1.Volume, claims []corev1.PersistentVolumeClaim)
+	}{
+		"No storage": {},
+		"NodeLocal": {
+			storage:         &kaitov1alpha1.ModelStorageSpec{Policy: kaitov1alpha1.ModelStoragePolicyNodeLocal},
+			expectedVolumes: 1,
+			check: func(t *testing.T, volumes []corev1.Volume, claims []corev1.PersistentVolumeClaim) {
+				if volumes[0].EmptyDir == nil {
+					t.Errorf("expected an emptyDir volume, got %v", volumes[0])
+				}
+			},
+		},
+		"Ephemeral": {
+			storage: &kaitov1alpha1.ModelStorageSpec{
+				Policy:           kaitov1alpha1.ModelStoragePolicyEphemeral,
+				StorageClassName: lo.ToPtr("premium"),
+			},
+			expectedVolumes: 1,
+			check: func(t *testing.T, volumes []corev1.Volume, claims []corev1.PersistentVolumeClaim) {
+				spec := volumes[0].Ephemeral.VolumeClaimTemplate.Spec
+				if *spec.StorageClassName != "premium" || spec.Resources.Requests.Storage().String() != "100Gi" {
+					t.Errorf("unexpected claim spec %v", spec)
+				}
+			},
+		},
+		"Persistent Deployment": {
+			storage:         &kaitov1alpha1.ModelStorageSpec{Policy: kaitov1alpha1.ModelStoragePolicyPersistent},
+			expectedVolumes: 1,
+			check: func(t *testing.T, volumes []corev1.Volume, claims []corev1.PersistentVolumeClaim) {
+				if volumes[0].PersistentVolumeClaim.ClaimName != "testWorkspace-model-cache" {
+					t.Errorf("unexpected claim name %s", volumes[0].PersistentVolumeClaim.ClaimName)
+				}
+			},
+		},
+		"Persistent StatefulSet": {
+			storage: &kaitov1alpha1.ModelStorageSpec{
+				Policy: kaitov1alpha1.ModelStoragePolicyPersistent,
+				Size:   lo.ToPtr(resource.MustParse("200Gi")),
+			},
+			supportDistributedInference: true,
+			expectedClaims:              1,
+			check: func(t *testing.T, volumes []corev1.Volume, claims []corev1.PersistentVolumeClaim) {
+				if claims[0].Spec.Resources.Requests.Storage().String() != "200Gi" {
+					t.Errorf("unexpected claim spec %v", claims[0].Spec)
+				}
+			},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			workspace := test.MockWorkspaceWithPreset.DeepCopy()
+			workspace.Inference.Storage = tc.storage
+
+			volumes, volumeMounts, claims, err := configModelStorage(workspace, inferenceObj, tc.supportDistributedInference)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if len(volumes) != tc.expectedVolumes || len(claims) != tc.expectedClaims {
+				t.Fatalf("unexpected volumes %v and claims %v", volumes, claims)
+			}
+			if tc.storage != nil && (len(volumeMounts) != 1 || volumeMounts[0].MountPath != "/mnt/model-cache") {
+				t.Errorf("unexpected volume mounts %v", volumeMounts)
+			}
+			if tc.check != nil {
+				tc.check(t, volumes, claims)
+			}
+		})
+	}
+}
+
+func TestCreatePresetInferenceModelCache(t *testing.T) {
+	test.RegisterTestModel()
+	inferenceObj := plugin.KaitoModelRegister.MustGet("test-model").GetInferenceParameters()
+
+	testcases := map[string]struct {
+		storage        *kaitov1alpha1.ModelStorageSpec
+		localNVMe      bool
+		expectedCached bool
+	}{
+		"No storage": {},
+		"NodeLocal": {
+			storage:        &kaitov1alpha1.ModelStorageSpec{Policy: kaitov1alpha1.ModelStoragePolicyNodeLocal},
+			expectedCached: true,
+		},
+		"Local NVMe": {
+			localNVMe:      true,
+			expectedCached: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			featuregates.FeatureGates[consts.FeatureFlagLocalNVMe] = tc.localNVMe
+			defer delete(featuregates.FeatureGates, consts.FeatureFlagLocalNVMe)
+			mockClient := test.NewClient()
+			mockClient.On("Create", mock.IsType(context.TODO()), mock.IsType(&appsv1.Deployment{}), mock.Anything).Return(nil)
+			workspace := test.MockWorkspaceWithPreset.DeepCopy()
+			workspace.Resource.Count = lo.ToPtr(1)
+			workspace.Resource.InstanceType = "Standard_NC24ads_A100_v4"
+			workspace.Inference.Storage = tc.storage
+
+			createdObject, err := CreatePresetInference(context.TODO(), workspace, inferenceObj, false, mockClient)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			env := createdObject.(*appsv1.Deployment).Spec.Template.Spec.Containers[0].Env
+			_, cached := lo.Find(env, func(e corev1.EnvVar) bool {
+				return e.Name == "HF_HOME" && e.Value == "/mnt/model-cache"
+			})
+			if cached != tc.expectedCached {
+				t.Errorf("unexpected environment %v of the inference container", env)
+			}
+		})
+	}
+}
+
 func toParameterMap(in []string) map[string]string {
 	ret := make(map[string]string)
 	for _, each := range in {
@@ -132,3 +265,161 @@ func toParameterMap(in []string) map[string]string {
 	}
 	return ret
 }
+
+func TestPrepareInferenceParameters(t *testing.T) {
+	testcases := map[string]struct {
+		inferenceObj     *model.PresetParam
+		rankFromHostname bool
+		expectedCmd      []string
+		expectErr        bool
+	}{
+		"exec form": {
+			inferenceObj: &model.PresetParam{
+				BaseCommand:         "accelerate launch",
+				TorchRunParams:      map[string]string{"num_processes": "1", "gpu_ids": "all"},
+				ModelRunParams:      map[string]string{"torch_dtype": "bfloat16", "pipeline": "text generation"},
+				GPUCountRequirement: "1",
+			},
+			expectedCmd: []string{"accelerate", "launch", "--gpu_ids=all", "--num_processes=1", InferenceFile,
+				"--pipeline=text generation", "--torch_dtype=bfloat16"},
+		},
+		"shell form with node rank": {
+			inferenceObj: &model.PresetParam{
+				BaseCommand:         "cd /workspace/llama/llama-2 && torchrun",
+				TorchRunParams:      map[string]string{"nnodes": "2"},
+				ModelRunParams:      map[string]string{"max_seq_len": "$(id)"},
+				GPUCountRequirement: "1",
+			},
+			rankFromHostname: true,
+			expectedCmd: []string{"/bin/sh", "-c", "cd /workspace/llama/llama-2 && torchrun --nnodes=2 " +
+				"--node_rank=$(echo $HOSTNAME | grep -o '[^-]*$') " + InferenceFile + " '--max_seq_len=$(id)'"},
+		},
+		"invalid parameter name": {
+			inferenceObj: &model.PresetParam{
+				BaseCommand:         "accelerate launch",
+				ModelRunParams:      map[string]string{"a; rm -rf /": ""},
+				GPUCountRequirement: "1",
+			},
+			expectErr: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			cmd, _, err := prepareInferenceParameters(context.TODO(), tc.inferenceObj, tc.rankFromHostname)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if !reflect.DeepEqual(cmd, tc.expectedCmd) {
+				t.Errorf("unexpected command, got %q, expect %q", cmd, tc.expectedCmd)
+			}
+		})
+	}
+}
+
+func TestResolveRunParams(t *testing.T) {
+	runparams.OperatorDefaults = map[string]string{"max_length": "200"}
+	kaitov1alpha1.SupportedGPUConfigs["Test_ARM64"] = kaitov1alpha1.GPUConfig{SKU: "Test_ARM64", GPUCount: 1, GPUMem: 96, Arch: "arm64"}
+	defer func() {
+		runparams.OperatorDefaults = map[string]string{}
+		delete(kaitov1alpha1.SupportedGPUConfigs, "Test_ARM64")
+	}()
+
+	testcases := map[string]struct {
+		annotation        string
+		chatTemplate      string
+		textGeneration    bool
+		imageTag          string
+		instanceType      string
+		expectedParams    map[string]string
+		expectedOverrides int
+		expectedTag       string
+		expectErr         bool
+	}{
+		"operator configuration overrides preset defaults": {
+			expectedParams:    map[string]string{"max_length": "200", "torch_dtype": "bfloat16"},
+			expectedOverrides: 1,
+		},
+		"annotation overrides operator configuration": {
+			annotation:        `{"max_length": "300", "torch_dtype": "bfloat16"}`,
+			expectedParams:    map[string]string{"max_length": "300", "torch_dtype": "bfloat16"},
+			expectedOverrides: 2,
+		},
+		"chat template selected by annotation": {
+			chatTemplate:      "chatml.jinja",
+			textGeneration:    true,
+			expectedParams:    map[string]string{"max_length": "200", "torch_dtype": "bfloat16", "pipeline": "text-generation", "chat_template": "/workspace/chat_templates/chatml.jinja"},
+			expectedOverrides: 1,
+		},
+		"chat template not supported by the preset": {
+			chatTemplate:      "chatml.jinja",
+			expectedParams:    map[string]string{"max_length": "200", "torch_dtype": "bfloat16"},
+			expectedOverrides: 1,
+		},
+		"architecture specific preset defaults": {
+			instanceType:      "Test_ARM64",
+			expectedParams:    map[string]string{"max_length": "200", "torch_dtype": "float16"},
+			expectedOverrides: 1,
+		},
+		"image tag pinned by annotation": {
+			imageTag:          "0.0.4",
+			expectedParams:    map[string]string{"max_length": "200", "torch_dtype": "bfloat16"},
+			expectedOverrides: 1,
+			expectedTag:       "0.0.4",
+		},
+		"invalid annotation": {
+			annotation: `max_length=300`,
+			expectErr:  true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			workspace := test.MockWorkspaceWithPreset.DeepCopy()
+			workspace.Annotations = map[string]string{}
+			if tc.annotation != "" {
+				workspace.Annotations[kaitov1alpha1.AnnotationModelRunParams] = tc.annotation
+			}
+			if tc.chatTemplate != "" {
+				workspace.Annotations[kaitov1alpha1.AnnotationChatTemplate] = tc.chatTemplate
+			}
+			if tc.imageTag != "" {
+				workspace.Annotations[kaitov1alpha1.AnnotationPresetImageTag] = tc.imageTag
+			}
+			if tc.instanceType != "" {
+				workspace.Resource.InstanceType = tc.instanceType
+			}
+			presetObj := &model.PresetParam{
+				Tag:                "0.0.3",
+				ModelRunParams:     map[string]string{"max_length": "100", "torch_dtype": "bfloat16"},
+				ArchModelRunParams: map[string]map[string]string{"arm64": {"torch_dtype": "float16"}},
+			}
+			if tc.textGeneration {
+				presetObj.ModelRunParams["pipeline"] = "text-generation"
+			}
+			if tc.expectedTag == "" {
+				tc.expectedTag = presetObj.Tag
+			}
+
+			inferenceObj, overrides, err := ResolveRunParams(workspace, presetObj)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if tc.expectErr {
+				return
+			}
+			if !reflect.DeepEqual(inferenceObj.ModelRunParams, tc.expectedParams) {
+				t.Errorf("unexpected run params %v, expect %v", inferenceObj.ModelRunParams, tc.expectedParams)
+			}
+			if len(overrides) != tc.expectedOverrides {
+				t.Errorf("unexpected overrides %v", overrides)
+			}
+			if inferenceObj.Tag != tc.expectedTag {
+				t.Errorf("unexpected image tag %s, expect %s", inferenceObj.Tag, tc.expectedTag)
+			}
+			if presetObj.ModelRunParams["max_length"] != "100" {
+				t.Errorf("expected preset parameters to be left unchanged")
+			}
+		})
+	}
+}
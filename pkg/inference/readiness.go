@@ -0,0 +1,59 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package inference
+
+import (
+	"time"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/model"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+)
+
+// ReadinessPolicy derives the readiness timeout of the preset inference workloads from the size of
+// their model instead of the fixed timeout of the preset, so large models are not timed out while
+// they download and small ones fail fast.
+type ReadinessPolicy struct {
+	// DownloadBandwidth is the bandwidth, in bytes per second, expected for a node to pull the model.
+	// Zero keeps the readiness timeouts of the presets.
+	DownloadBandwidth int64
+	// MinTimeout and MaxTimeout clamp the derived timeouts. Zero means no bound.
+	MinTimeout time.Duration
+	MaxTimeout time.Duration
+}
+
+// GlobalReadinessPolicy is the readiness policy of the operator.
+var GlobalReadinessPolicy ReadinessPolicy
+
+// ReadinessTimeout returns how long the inference workload of the workspace is given to become ready.
+// The kaito.sh/readiness-timeout annotation of the workspace takes precedence. Otherwise, if the
+// policy has a download bandwidth, the timeout is twice the time to download the disk storage
+// required by the preset, which bounds the size of the model image, leaving as much time to load
+// the model; else it is the timeout of the preset.
+func (p ReadinessPolicy) ReadinessTimeout(wObj *kaitov1alpha1.Workspace, inferenceObj *model.PresetParam) time.Duration {
+	if value, ok := wObj.Annotations[kaitov1alpha1.AnnotationReadinessTimeout]; ok {
+		timeout, err := time.ParseDuration(value)
+		if err == nil && timeout > 0 {
+			return timeout
+		}
+		klog.InfoS("Ignoring invalid readiness timeout annotation", "workspace", klog.KObj(wObj), "value", value)
+	}
+	if p.DownloadBandwidth <= 0 {
+		return inferenceObj.ReadinessTimeout
+	}
+	size, err := resource.ParseQuantity(inferenceObj.DiskStorageRequirement)
+	if err != nil || size.IsZero() {
+		return inferenceObj.ReadinessTimeout
+	}
+
+	timeout := 2 * time.Duration(float64(size.Value())/float64(p.DownloadBandwidth)*float64(time.Second))
+	if p.MinTimeout > 0 && timeout < p.MinTimeout {
+		timeout = p.MinTimeout
+	}
+	if p.MaxTimeout > 0 && timeout > p.MaxTimeout {
+		timeout = p.MaxTimeout
+	}
+	return timeout
+}
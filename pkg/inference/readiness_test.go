@@ -0,0 +1,73 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package inference
+
+import (
+	"testing"
+	"time"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/model"
+	"github.com/azure/kaito/pkg/utils/test"
+)
+
+func TestReadinessTimeout(t *testing.T) {
+	const mi = 1024 * 1024
+
+	testcases := map[string]struct {
+		policy     ReadinessPolicy
+		disk       string
+		annotation string
+		expected   time.Duration
+	}{
+		"preset timeout without bandwidth": {
+			disk:     "100Gi",
+			expected: 30 * time.Minute,
+		},
+		"derived from the disk storage": {
+			policy:   ReadinessPolicy{DownloadBandwidth: 100 * mi},
+			disk:     "300Gi",
+			expected: 2 * 3072 * time.Second,
+		},
+		"clamped to the minimum": {
+			policy:   ReadinessPolicy{DownloadBandwidth: 100 * mi, MinTimeout: 10 * time.Minute},
+			disk:     "2Gi",
+			expected: 10 * time.Minute,
+		},
+		"clamped to the maximum": {
+			policy:   ReadinessPolicy{DownloadBandwidth: 10 * mi, MaxTimeout: 4 * time.Hour},
+			disk:     "600Gi",
+			expected: 4 * time.Hour,
+		},
+		"preset timeout with invalid disk storage": {
+			policy:   ReadinessPolicy{DownloadBandwidth: 100 * mi},
+			disk:     "lots",
+			expected: 30 * time.Minute,
+		},
+		"annotation overrides the policy": {
+			policy:     ReadinessPolicy{DownloadBandwidth: 100 * mi, MaxTimeout: time.Hour},
+			disk:       "600Gi",
+			annotation: "6h",
+			expected:   6 * time.Hour,
+		},
+		"invalid annotation is ignored": {
+			disk:       "100Gi",
+			annotation: "-1h",
+			expected:   30 * time.Minute,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			workspace := test.MockWorkspaceWithPreset.DeepCopy()
+			if tc.annotation != "" {
+				workspace.Annotations = map[string]string{kaitov1alpha1.AnnotationReadinessTimeout: tc.annotation}
+			}
+			param := &model.PresetParam{DiskStorageRequirement: tc.disk, ReadinessTimeout: 30 * time.Minute}
+			if timeout := tc.policy.ReadinessTimeout(workspace, param); timeout != tc.expected {
+				t.Errorf("expected timeout %s, got %s", tc.expected, timeout)
+			}
+		})
+	}
+}
@@ -7,14 +7,24 @@ import (
 
 	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
 	"github.com/azure/kaito/pkg/resources"
+	appsv1 "k8s.io/api/apps/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func CreateTemplateInference(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, kubeClient client.Client) (client.Object, error) {
-	depObj := resources.GenerateDeploymentManifestWithPodTemplate(ctx, workspaceObj, tolerations)
+	depObj := GenerateTemplateInference(ctx, workspaceObj)
 	err := resources.CreateResource(ctx, client.Object(depObj), kubeClient)
 	if client.IgnoreAlreadyExists(err) != nil {
 		return nil, err
 	}
 	return depObj, nil
 }
+
+// GenerateTemplateInference returns the inference workload of the pod template of the workspace.
+func GenerateTemplateInference(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace) *appsv1.Deployment {
+	depObj := resources.GenerateDeploymentManifestWithPodTemplate(ctx, workspaceObj, tolerations)
+	resources.ConfigureScheduling(&depObj.Spec.Template, workspaceObj)
+	resources.ApplyWorkloadMutation(&depObj.Spec.Template)
+	resources.SetSpecHash(depObj)
+	return depObj
+}
@@ -81,7 +81,7 @@ func GenerateMachineManifest(ctx context.Context, storageRequirement string, wor
 				{
 					Key:      v1.LabelArchStable,
 					Operator: v1.NodeSelectorOpIn,
-					Values:   []string{"amd64"},
+					Values:   []string{kaitov1alpha1.InstanceTypeArchitecture(workspaceObj.Resource.InstanceType)},
 				},
 				{
 					Key:      v1.LabelOSStable,
@@ -0,0 +1,54 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+// Hash returns a digest of the preset parameters. Workloads record it to detect that the
+// preset they were created from has changed.
+func (p *PresetParam) Hash() string {
+	// encoding/json sorts map keys, so the digest is stable.
+	b, _ := json.Marshal(p)
+	return fmt.Sprintf("%x", sha256.Sum256(b))[:16]
+}
+
+// DeepCopy returns a copy of the preset parameters that does not share maps with p.
+func (p *PresetParam) DeepCopy() *PresetParam {
+	if p == nil {
+		return nil
+	}
+	out := *p
+	out.TuningPerGPUMemoryRequirement = copyMap(p.TuningPerGPUMemoryRequirement)
+	out.TorchRunParams = copyMap(p.TorchRunParams)
+	out.TorchRunRdzvParams = copyMap(p.TorchRunRdzvParams)
+	out.ModelRunParams = copyMap(p.ModelRunParams)
+	if p.Architectures != nil {
+		out.Architectures = append([]string{}, p.Architectures...)
+	}
+	if p.ArchModelRunParams != nil {
+		out.ArchModelRunParams = make(map[string]map[string]string, len(p.ArchModelRunParams))
+		for arch, params := range p.ArchModelRunParams {
+			out.ArchModelRunParams[arch] = copyMap(params)
+		}
+	}
+	if p.Deprecation != nil {
+		deprecation := *p.Deprecation
+		out.Deprecation = &deprecation
+	}
+	return &out
+}
+
+func copyMap[V any](in map[string]V) map[string]V {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]V, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
@@ -3,6 +3,7 @@
 package model
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -32,4 +33,79 @@ type PresetParam struct {
 	ReadinessTimeout time.Duration
 	WorldSize        int    // Defines the number of processes required for distributed inference.
 	Tag              string // The model image tag
+	// Architectures are the CPU architectures the preset image is built for, e.g., "arm64". Empty
+	// means amd64 only. Presets that do not set it keep their hash.
+	Architectures []string `json:",omitempty"`
+	// ArchModelRunParams are the model run parameters specific to an architecture, merged into
+	// ModelRunParams for the workspaces running on it.
+	ArchModelRunParams map[string]map[string]string `json:",omitempty"`
+	// MinDriverVersion is the minimum NVIDIA driver version, e.g., "525.60.13", required by the CUDA
+	// runtime of the preset image. It does not change the workloads, so it is not part of the hash of
+	// the parameters.
+	MinDriverVersion string `json:"-"`
+	// Deprecation is set if the preset is deprecated. It does not change the workloads, so it is not
+	// part of the hash of the parameters.
+	Deprecation *Deprecation `json:"-"`
+}
+
+// DefaultArchitecture is the architecture of the preset images that do not declare theirs.
+const DefaultArchitecture = "amd64"
+
+// ImageArchitectures returns the CPU architectures the preset image is built for.
+func (p *PresetParam) ImageArchitectures() []string {
+	if len(p.Architectures) == 0 {
+		return []string{DefaultArchitecture}
+	}
+	return p.Architectures
+}
+
+// SupportsArchitecture returns whether the preset image runs on the CPU architecture.
+func (p *PresetParam) SupportsArchitecture(arch string) bool {
+	for _, a := range p.ImageArchitectures() {
+		if a == arch {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsChatTemplate returns whether the preset is served by the text-generation inference script,
+// e.g., falcon, mistral or phi, the only one accepting a chat template. Its presets are identified by
+// the transformers pipeline they run.
+func (p *PresetParam) SupportsChatTemplate() bool {
+	_, ok := p.ModelRunParams["pipeline"]
+	return ok
+}
+
+// Deprecation describes the lifecycle of a deprecated preset.
+type Deprecation struct {
+	// Replacement is the preset new workspaces should use instead, if any.
+	Replacement string
+	// EndOfLife is the time after which new workspaces cannot use the preset. Zero means never.
+	EndOfLife time.Time
+}
+
+// PastEndOfLife returns whether the preset is past its end of life at now.
+func (d *Deprecation) PastEndOfLife(now time.Time) bool {
+	return d != nil && !d.EndOfLife.IsZero() && !now.Before(d.EndOfLife)
+}
+
+// Equal returns whether the deprecations, either of which may be nil, describe the same lifecycle.
+func (d *Deprecation) Equal(other *Deprecation) bool {
+	if d == nil || other == nil {
+		return d == other
+	}
+	return d.Replacement == other.Replacement && d.EndOfLife.Equal(other.EndOfLife)
+}
+
+// Message describes the deprecation of the named preset, with the replacement to migrate to.
+func (d *Deprecation) Message(name string) string {
+	msg := fmt.Sprintf("Preset %s is deprecated", name)
+	if !d.EndOfLife.IsZero() {
+		msg += fmt.Sprintf(", its end of life is %s", d.EndOfLife.Format(time.DateOnly))
+	}
+	if d.Replacement != "" {
+		msg += fmt.Sprintf(", use preset %s instead", d.Replacement)
+	}
+	return msg
 }
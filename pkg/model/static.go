@@ -0,0 +1,25 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package model
+
+// StaticModel is a Model backed by fixed preset parameters. It is used for presets
+// declared at runtime rather than compiled into the operator. The getters return copies,
+// so callers cannot alter the registered parameters.
+type StaticModel struct {
+	InferenceParam       *PresetParam
+	TuningParam          *PresetParam
+	DistributedInference bool
+}
+
+func (m *StaticModel) GetInferenceParameters() *PresetParam {
+	return m.InferenceParam.DeepCopy()
+}
+func (m *StaticModel) GetTuningParameters() *PresetParam {
+	return m.TuningParam.DeepCopy()
+}
+func (m *StaticModel) SupportDistributedInference() bool {
+	return m.DistributedInference
+}
+func (m *StaticModel) SupportTuning() bool {
+	return m.TuningParam != nil
+}
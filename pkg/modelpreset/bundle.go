@@ -0,0 +1,173 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package modelpreset
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"time"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// A preset bundle is a tar archive, optionally gzipped, of YAML manifests of ModelPresets, e.g.,
+// created with "tar czf presets.tar.gz *.yaml". It carries the presets into air-gapped clusters.
+
+// ReadBundleFile reads the ModelPresets of the preset bundle at path.
+func ReadBundleFile(path string) ([]kaitov1alpha1.ModelPreset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	presets, err := ReadBundle(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid preset bundle %s: %w", path, err)
+	}
+	return presets, nil
+}
+
+// ReadBundle reads the ModelPresets of a preset bundle. The files of the archive that are not
+// YAML or JSON manifests are ignored.
+func ReadBundle(r io.Reader) ([]kaitov1alpha1.ModelPreset, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	var presets []kaitov1alpha1.ModelPreset
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return presets, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		switch path.Ext(header.Name) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		data, err := io.ReadAll(archive)
+		if err != nil {
+			return nil, err
+		}
+		filePresets, err := decodePresets(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", header.Name, err)
+		}
+		presets = append(presets, filePresets...)
+	}
+}
+
+// decodePresets decodes the ModelPresets of a multi-document manifest.
+func decodePresets(data []byte) ([]kaitov1alpha1.ModelPreset, error) {
+	var presets []kaitov1alpha1.ModelPreset
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		preset := kaitov1alpha1.ModelPreset{}
+		if err := decoder.Decode(&preset); err != nil {
+			if errors.Is(err, io.EOF) {
+				return presets, nil
+			}
+			return nil, err
+		}
+		if preset.Kind == "" && preset.Name == "" {
+			// Empty document.
+			continue
+		}
+		if preset.Kind != "ModelPreset" || preset.APIVersion != kaitov1alpha1.GroupVersion.String() {
+			return nil, fmt.Errorf("unexpected object %s %s/%s, a preset bundle only contains ModelPresets",
+				preset.APIVersion, preset.Kind, preset.Name)
+		}
+		presets = append(presets, preset)
+	}
+}
+
+// ImportBackoff is the backoff of the import of the preset bundle at startup, e.g., while the API server
+// is unavailable. It retries for about three minutes.
+var ImportBackoff = wait.Backoff{Duration: 2 * time.Second, Factor: 2, Jitter: 0.1, Steps: 8, Cap: time.Minute}
+
+// ImportBundleWithRetry imports the preset bundle, retrying the transient API errors with the backoff.
+// Permanent errors, e.g., a ModelPreset of the bundle rejected as invalid, are returned right away.
+func ImportBundleWithRetry(ctx context.Context, kubeClient client.Client, presets []kaitov1alpha1.ModelPreset, backoff wait.Backoff) error {
+	var importErr error
+	err := wait.ExponentialBackoffWithContext(ctx, backoff, func(ctx context.Context) (bool, error) {
+		importErr = ImportBundle(ctx, kubeClient, presets)
+		if importErr == nil {
+			return true, nil
+		}
+		if !isTransientError(importErr) {
+			return false, importErr
+		}
+		klog.InfoS("Retrying the import of the preset bundle", "error", importErr)
+		return false, nil
+	})
+	if wait.Interrupted(err) && importErr != nil {
+		return importErr
+	}
+	return err
+}
+
+// isTransientError returns whether the API error may not happen again, e.g., a timeout, throttling, a
+// conflict or an unreachable API server.
+func isTransientError(err error) bool {
+	return apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) || apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) || apierrors.IsInternalError(err) || apierrors.IsUnexpectedServerError(err) ||
+		apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) ||
+		utilnet.IsConnectionRefused(err) || utilnet.IsConnectionReset(err) || utilnet.IsProbableEOF(err)
+}
+
+// ImportBundle creates the ModelPresets of a preset bundle, and updates the spec of those that
+// exist.
+func ImportBundle(ctx context.Context, kubeClient client.Client, presets []kaitov1alpha1.ModelPreset) error {
+	for i := range presets {
+		preset := presets[i].DeepCopy()
+		existing := &kaitov1alpha1.ModelPreset{}
+		err := kubeClient.Get(ctx, client.ObjectKeyFromObject(preset), existing)
+		switch {
+		case apierrors.IsNotFound(err):
+			klog.InfoS("Importing preset", "modelpreset", klog.KObj(preset))
+			preset.ResourceVersion = ""
+			if err := kubeClient.Create(ctx, preset); err != nil {
+				return fmt.Errorf("failed to import preset %s: %w", preset.Name, err)
+			}
+		case err != nil:
+			return err
+		case !reflect.DeepEqual(existing.Spec, preset.Spec):
+			klog.InfoS("Updating preset from bundle", "modelpreset", klog.KObj(preset))
+			existing.Spec = preset.Spec
+			if err := kubeClient.Update(ctx, existing); err != nil {
+				return fmt.Errorf("failed to update preset %s: %w", preset.Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package modelpreset
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+const bundlePresets = `apiVersion: kaito.sh/v1alpha1
+kind: ModelPreset
+metadata:
+  name: custom-1
+spec:
+  modelName: custom
+  version: "1"
+  tag: 0.0.1
+---
+apiVersion: kaito.sh/v1alpha1
+kind: ModelPreset
+metadata:
+  name: custom-2
+spec:
+  modelName: custom
+  version: "2"
+  tag: 0.0.2
+`
+
+func newBundle(t *testing.T, gzipped bool, files map[string]string) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	var tw *tar.Writer
+	var gz *gzip.Writer
+	if gzipped {
+		gz = gzip.NewWriter(buf)
+		tw = tar.NewWriter(gz)
+	} else {
+		tw = tar.NewWriter(buf)
+	}
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf
+}
+
+func TestReadBundle(t *testing.T) {
+	testcases := map[string]struct {
+		gzipped       bool
+		files         map[string]string
+		expectedNames []string
+		expectedError string
+	}{
+		"tar archive": {
+			files:         map[string]string{"presets/custom.yaml": bundlePresets},
+			expectedNames: []string{"custom-1", "custom-2"},
+		},
+		"gzipped tar archive": {
+			gzipped:       true,
+			files:         map[string]string{"custom.yaml": bundlePresets, "README.md": "# Presets"},
+			expectedNames: []string{"custom-1", "custom-2"},
+		},
+		"unexpected object": {
+			files:         map[string]string{"configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: presets\n"},
+			expectedError: "only contains ModelPresets",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			presets, err := ReadBundle(newBundle(t, tc.gzipped, tc.files))
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var names []string
+			for _, preset := range presets {
+				names = append(names, preset.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tc.expectedNames, ",") {
+				t.Errorf("expected presets %v, got %v", tc.expectedNames, names)
+			}
+		})
+	}
+}
+
+func TestImportBundle(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = kaitov1alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&kaitov1alpha1.ModelPreset{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom-1"},
+		Spec:       kaitov1alpha1.ModelPresetSpec{ModelName: "custom", Version: "1", Tag: "0.0.0"},
+	}).Build()
+
+	presets, err := ReadBundle(newBundle(t, true, map[string]string{"custom.yaml": bundlePresets}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ImportBundle(context.Background(), c, presets); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, tag := range map[string]string{"custom-1": "0.0.1", "custom-2": "0.0.2"} {
+		preset := &kaitov1alpha1.ModelPreset{}
+		if err := c.Get(context.Background(), client.ObjectKey{Name: name}, preset); err != nil {
+			t.Fatalf("failed to get preset %s: %v", name, err)
+		}
+		if preset.Spec.Tag != tag {
+			t.Errorf("expected preset %s with tag %s, got %s", name, tag, preset.Spec.Tag)
+		}
+	}
+}
+
+func TestImportBundleWithRetry(t *testing.T) {
+	presets, err := ReadBundle(newBundle(t, true, map[string]string{"custom.yaml": bundlePresets}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	backoff := wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}
+	resource := schema.GroupResource{Group: "kaito.sh", Resource: "modelpresets"}
+
+	testcases := map[string]struct {
+		createErr       error
+		failures        int
+		expectedCreates int
+		expectedErr     bool
+	}{
+		"Transient error": {
+			createErr:       apierrors.NewServiceUnavailable("starting"),
+			failures:        1,
+			expectedCreates: 3,
+		},
+		"Transient error past the backoff": {
+			createErr:       apierrors.NewTooManyRequests("throttled", 1),
+			failures:        10,
+			expectedCreates: 3,
+			expectedErr:     true,
+		},
+		"Permanent error": {
+			createErr:       apierrors.NewInvalid(schema.GroupKind{Group: "kaito.sh", Kind: "ModelPreset"}, "custom-1", nil),
+			failures:        10,
+			expectedCreates: 1,
+			expectedErr:     true,
+		},
+		"Forbidden": {
+			createErr:       apierrors.NewForbidden(resource, "custom-1", nil),
+			failures:        10,
+			expectedCreates: 1,
+			expectedErr:     true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = kaitov1alpha1.AddToScheme(scheme)
+			creates := 0
+			c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					creates++
+					if creates <= tc.failures {
+						return tc.createErr
+					}
+					return c.Create(ctx, obj, opts...)
+				},
+			}).Build()
+
+			err := ImportBundleWithRetry(context.Background(), c, presets, backoff)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if creates != tc.expectedCreates {
+				t.Errorf("expected %d creates, got %d", tc.expectedCreates, creates)
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+// Package conformance checks ModelPreset definitions before they are applied to a cluster. Preset
+// authors call Run from a Go test:
+//
+//	func TestMyPreset(t *testing.T) {
+//		preset := &kaitov1alpha1.ModelPreset{...}
+//		conformance.Run(t, preset, conformance.Options{})
+//	}
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/inference"
+	"github.com/azure/kaito/pkg/modelpreset"
+	"github.com/azure/kaito/pkg/resources"
+	"github.com/azure/kaito/pkg/sku"
+	"github.com/azure/kaito/pkg/utils/plugin"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	// renderNamespace and renderName name the workspace the preset commands are rendered for.
+	renderNamespace = "conformance"
+	renderName      = "conformance"
+
+	defaultSmokeTimeout = 30 * time.Minute
+	smokePollInterval   = 10 * time.Second
+)
+
+// Options configure the conformance checks.
+type Options struct {
+	// GPUConfigs are the SKUs the GPU requirements of the preset are checked against. Defaults to
+	// the Azure SKUs.
+	GPUConfigs map[string]sku.GPUConfig
+	// Smoke, if set, deploys the preset to a live cluster. Otherwise, the smoke test is skipped.
+	Smoke *SmokeOptions
+}
+
+// SmokeOptions configure the deployment of the preset to a cluster running Kaito.
+type SmokeOptions struct {
+	Client    client.Client
+	Namespace string
+	// InstanceType is the SKU of the node provisioned for the workspace.
+	InstanceType string
+	// Image and ImagePullSecrets are required by the presets in private image access mode.
+	Image            string
+	ImagePullSecrets []string
+	// Timeout bounds the wait for the inference to be ready. Defaults to 30 minutes.
+	Timeout time.Duration
+}
+
+// Run runs the conformance checks of the preset as subtests of t.
+func Run(t *testing.T, preset *kaitov1alpha1.ModelPreset, options Options) {
+	t.Helper()
+	t.Run("Schema", func(t *testing.T) {
+		for _, err := range Validate(preset) {
+			t.Error(err)
+		}
+	})
+	t.Run("RenderCommand", func(t *testing.T) {
+		if _, err := RenderCommand(preset); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("GPUMemory", func(t *testing.T) {
+		if _, err := FittingSKUs(preset, options.GPUConfigs); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("Smoke", func(t *testing.T) {
+		if options.Smoke == nil {
+			t.Skip("smoke test not configured")
+		}
+		ctx := context.Background()
+		if deadline, ok := t.Deadline(); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, deadline)
+			defer cancel()
+		}
+		if err := Smoke(ctx, preset, *options.Smoke); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// Validate checks the fields of the preset and returns all the errors found.
+func Validate(preset *kaitov1alpha1.ModelPreset) []error {
+	spec := &preset.Spec
+	var errs []error
+	if spec.ModelName == "" {
+		errs = append(errs, errors.New("modelName is required"))
+	} else {
+		register := &plugin.ModelRegister{}
+		if err := register.ValidateReference(plugin.ModelReference(string(spec.ModelName), spec.Version)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	switch kaitov1alpha1.ModelImageAccessMode(spec.ImageAccessMode) {
+	case "", kaitov1alpha1.ModelImageAccessModePublic:
+		if spec.Tag == "" {
+			errs = append(errs, errors.New("tag is required by presets in public image access mode"))
+		}
+	case kaitov1alpha1.ModelImageAccessModePrivate:
+	default:
+		errs = append(errs, fmt.Errorf("imageAccessMode %q must be %s or %s", spec.ImageAccessMode,
+			kaitov1alpha1.ModelImageAccessModePublic, kaitov1alpha1.ModelImageAccessModePrivate))
+	}
+
+	if spec.BaseCommand == "" {
+		errs = append(errs, errors.New("baseCommand is required"))
+	}
+	if _, err := gpuCount(spec); err != nil {
+		errs = append(errs, err)
+	}
+	for _, field := range []struct{ name, value string }{
+		{"diskStorageRequirement", spec.DiskStorageRequirement},
+		{"totalGPUMemoryRequirement", spec.TotalGPUMemoryRequirement},
+	} {
+		if field.value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", field.name))
+		} else if _, err := parseQuantity(field.name, field.value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if spec.PerGPUMemoryRequirement != "" {
+		if _, err := parseQuantity("perGPUMemoryRequirement", spec.PerGPUMemoryRequirement); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if spec.ReadinessTimeout != nil && spec.ReadinessTimeout.Duration < 0 {
+		errs = append(errs, fmt.Errorf("readinessTimeout %s must not be negative", spec.ReadinessTimeout.Duration))
+	}
+	if spec.WorldSize < 0 {
+		errs = append(errs, fmt.Errorf("worldSize %d must not be negative", spec.WorldSize))
+	}
+	if spec.SupportDistributedInference {
+		if spec.WorldSize == 0 {
+			errs = append(errs, errors.New("worldSize is required by distributed inference"))
+		}
+		if len(spec.TorchRunParams) == 0 {
+			errs = append(errs, errors.New("torchRunParams are required by distributed inference"))
+		}
+	}
+	return errs
+}
+
+// RenderCommand returns the command of the inference container of a workspace using the preset,
+// as rendered by the operator, without a cluster.
+func RenderCommand(preset *kaitov1alpha1.ModelPreset) ([]string, error) {
+	if errs := Validate(preset); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid preset: %w", errors.Join(errs...))
+	}
+	registration := modelpreset.Registration(preset)
+	workspaceObj := &kaitov1alpha1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: renderName, Namespace: renderNamespace},
+		Resource: kaitov1alpha1.ResourceSpec{
+			Count:         lo.ToPtr(1),
+			LabelSelector: &metav1.LabelSelector{},
+		},
+		Inference: &kaitov1alpha1.InferenceSpec{
+			Preset: &kaitov1alpha1.PresetSpec{
+				PresetMeta: kaitov1alpha1.PresetMeta{
+					Name:       kaitov1alpha1.ModelName(plugin.ModelReference(registration.Name, registration.Version)),
+					AccessMode: kaitov1alpha1.ModelImageAccessMode(preset.Spec.ImageAccessMode),
+				},
+			},
+		},
+	}
+	// The distributed inference reads the address of the workspace service.
+	kubeClient := fake.NewClientBuilder().WithObjects(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: renderName, Namespace: renderNamespace},
+	}).Build()
+
+	obj, err := inference.GeneratePresetInference(context.Background(), workspaceObj,
+		registration.Instance.GetInferenceParameters(), registration.Instance.SupportDistributedInference(), kubeClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render the inference workload: %w", err)
+	}
+	template := resources.PodTemplateOf(obj)
+	if template == nil || len(template.Spec.Containers) == 0 {
+		return nil, errors.New("the inference workload has no container")
+	}
+	command := template.Spec.Containers[0].Command
+	if len(command) == 0 {
+		return nil, errors.New("the inference container has no command")
+	}
+	return command, nil
+}
+
+// FittingSKUs returns the SKUs whose GPUs fit the preset: a node of the SKU has the GPU count and
+// the total GPU memory required, and each of its GPUs has the memory required per GPU. The total
+// GPU memory of distributed presets can span nodes, so only the memory per GPU is checked. SKUs of
+// CPU architectures the preset image is not built for do not fit. It returns an error if the
+// requirements are inconsistent or no SKU fits.
+func FittingSKUs(preset *kaitov1alpha1.ModelPreset, gpuConfigs map[string]sku.GPUConfig) ([]string, error) {
+	if gpuConfigs == nil {
+		gpuConfigs = sku.NewAzureSKUHandler().GetGPUConfigs()
+	}
+	param := modelpreset.Registration(preset).Instance.GetInferenceParameters()
+	count, err := gpuCount(&preset.Spec)
+	if err != nil {
+		return nil, err
+	}
+	total, err := parseQuantity("totalGPUMemoryRequirement", param.TotalGPUMemoryRequirement)
+	if err != nil {
+		return nil, err
+	}
+	perGPU := resource.Quantity{}
+	if param.PerGPUMemoryRequirement != "" {
+		if perGPU, err = parseQuantity("perGPUMemoryRequirement", param.PerGPUMemoryRequirement); err != nil {
+			return nil, err
+		}
+	}
+	if total.IsZero() {
+		return nil, errors.New("totalGPUMemoryRequirement must be positive")
+	}
+	if !perGPU.IsZero() && !preset.Spec.SupportDistributedInference &&
+		perGPU.Value()*int64(count) < total.Value() {
+		return nil, fmt.Errorf("%d GPUs of %s cannot hold the total GPU memory of %s", count, perGPU.String(), total.String())
+	}
+
+	var fitting []string
+	for name, config := range gpuConfigs {
+		if !param.SupportsArchitecture(config.Architecture()) {
+			continue
+		}
+		if fits(config, count, total, perGPU, preset.Spec.SupportDistributedInference) {
+			fitting = append(fitting, name)
+		}
+	}
+	sort.Strings(fitting)
+	if len(fitting) == 0 {
+		return nil, fmt.Errorf("no SKU has %d GPUs with %s of memory in total and %s per GPU", count, total.String(), perGPU.String())
+	}
+	return fitting, nil
+}
+
+func fits(config sku.GPUConfig, count int, total, perGPU resource.Quantity, distributed bool) bool {
+	if config.GPUCount == 0 {
+		return false
+	}
+	skuMemory := resource.MustParse(fmt.Sprintf("%dGi", config.GPUMem))
+	memoryPerGPU := skuMemory.Value() / int64(config.GPUCount)
+	if memoryPerGPU < perGPU.Value() {
+		return false
+	}
+	if distributed {
+		return true
+	}
+	return config.GPUCount >= count && skuMemory.Cmp(total) >= 0
+}
+
+// Smoke applies the preset to the cluster, deploys a workspace using it and waits for its
+// inference to be ready. The workspace is deleted afterwards.
+func Smoke(ctx context.Context, preset *kaitov1alpha1.ModelPreset, options SmokeOptions) error {
+	if options.Client == nil || options.Namespace == "" || options.InstanceType == "" {
+		return errors.New("smoke test requires a client, a namespace and an instance type")
+	}
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = defaultSmokeTimeout
+	}
+
+	existing := &kaitov1alpha1.ModelPreset{}
+	err := options.Client.Get(ctx, client.ObjectKeyFromObject(preset), existing)
+	switch {
+	case apierrors.IsNotFound(err):
+		if err := options.Client.Create(ctx, preset.DeepCopy()); err != nil {
+			return fmt.Errorf("failed to create model preset %s: %w", preset.Name, err)
+		}
+	case err != nil:
+		return err
+	default:
+		existing.Spec = preset.Spec
+		if err := options.Client.Update(ctx, existing); err != nil {
+			return fmt.Errorf("failed to update model preset %s: %w", preset.Name, err)
+		}
+	}
+
+	workspaceObj := &kaitov1alpha1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: "conformance-", Namespace: options.Namespace},
+		Resource: kaitov1alpha1.ResourceSpec{
+			Count:        lo.ToPtr(1),
+			InstanceType: options.InstanceType,
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"kaito-workspace": "conformance"},
+			},
+		},
+		Inference: &kaitov1alpha1.InferenceSpec{
+			Preset: &kaitov1alpha1.PresetSpec{
+				PresetMeta: kaitov1alpha1.PresetMeta{
+					Name:       kaitov1alpha1.ModelName(plugin.ModelReference(string(preset.Spec.ModelName), preset.Spec.Version)),
+					AccessMode: kaitov1alpha1.ModelImageAccessMode(preset.Spec.ImageAccessMode),
+				},
+				PresetOptions: kaitov1alpha1.PresetOptions{
+					Image:            options.Image,
+					ImagePullSecrets: options.ImagePullSecrets,
+				},
+			},
+		},
+	}
+	if err := options.Client.Create(ctx, workspaceObj); err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+	defer func() {
+		// The workspace is deleted even if the context of the test is done.
+		_ = options.Client.Delete(context.Background(), workspaceObj)
+	}()
+
+	err = wait.PollUntilContextTimeout(ctx, smokePollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		if err := options.Client.Get(ctx, client.ObjectKeyFromObject(workspaceObj), workspaceObj); err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		return meta.IsStatusConditionTrue(workspaceObj.Status.Conditions, string(kaitov1alpha1.WorkspaceConditionTypeInferenceStatus)), nil
+	})
+	if err != nil {
+		return fmt.Errorf("inference of workspace %s/%s not ready after %s: %w", workspaceObj.Namespace, workspaceObj.Name, timeout, err)
+	}
+	return nil
+}
+
+func gpuCount(spec *kaitov1alpha1.ModelPresetSpec) (int, error) {
+	count, err := strconv.Atoi(spec.GPUCountRequirement)
+	if err != nil || count < 1 {
+		return 0, fmt.Errorf("gpuCountRequirement %q must be a positive integer", spec.GPUCountRequirement)
+	}
+	return count, nil
+}
+
+func parseQuantity(field, value string) (resource.Quantity, error) {
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("%s %q is not a quantity: %w", field, value, err)
+	}
+	if quantity.Sign() < 0 {
+		return resource.Quantity{}, fmt.Errorf("%s %q must not be negative", field, value)
+	}
+	return quantity, nil
+}
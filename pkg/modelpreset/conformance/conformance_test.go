@@ -0,0 +1,180 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package conformance
+
+import (
+	"strings"
+	"testing"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/sku"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newPreset() *kaitov1alpha1.ModelPreset {
+	return &kaitov1alpha1.ModelPreset{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom-phi"},
+		Spec: kaitov1alpha1.ModelPresetSpec{
+			ModelName:                 "custom-phi",
+			Version:                   "1",
+			Tag:                       "0.0.1",
+			DiskStorageRequirement:    "50Gi",
+			GPUCountRequirement:       "1",
+			TotalGPUMemoryRequirement: "12Gi",
+			PerGPUMemoryRequirement:   "0Gi",
+			BaseCommand:               "accelerate launch",
+			TorchRunParams:            map[string]string{"num_processes": "1"},
+			ModelRunParams:            map[string]string{"torch_dtype": "float16", "pipeline": "text-generation"},
+		},
+	}
+}
+
+func TestRunConformingPreset(t *testing.T) {
+	Run(t, newPreset(), Options{})
+}
+
+func TestValidate(t *testing.T) {
+	testcases := map[string]struct {
+		mutate        func(spec *kaitov1alpha1.ModelPresetSpec)
+		expectedError string
+	}{
+		"valid": {
+			mutate: func(spec *kaitov1alpha1.ModelPresetSpec) {},
+		},
+		"invalid model name": {
+			mutate:        func(spec *kaitov1alpha1.ModelPresetSpec) { spec.ModelName = "-custom" },
+			expectedError: "invalid model reference",
+		},
+		"public preset without tag": {
+			mutate:        func(spec *kaitov1alpha1.ModelPresetSpec) { spec.Tag = "" },
+			expectedError: "tag is required",
+		},
+		"private preset without tag": {
+			mutate: func(spec *kaitov1alpha1.ModelPresetSpec) {
+				spec.Tag = ""
+				spec.ImageAccessMode = kaitov1alpha1.ModelImageAccessModePrivate
+			},
+		},
+		"unknown image access mode": {
+			mutate:        func(spec *kaitov1alpha1.ModelPresetSpec) { spec.ImageAccessMode = "shared" },
+			expectedError: "imageAccessMode",
+		},
+		"fractional GPU count": {
+			mutate:        func(spec *kaitov1alpha1.ModelPresetSpec) { spec.GPUCountRequirement = "0.5" },
+			expectedError: "gpuCountRequirement",
+		},
+		"invalid memory quantity": {
+			mutate:        func(spec *kaitov1alpha1.ModelPresetSpec) { spec.TotalGPUMemoryRequirement = "12 GB" },
+			expectedError: "totalGPUMemoryRequirement",
+		},
+		"missing disk storage": {
+			mutate:        func(spec *kaitov1alpha1.ModelPresetSpec) { spec.DiskStorageRequirement = "" },
+			expectedError: "diskStorageRequirement is required",
+		},
+		"distributed without world size": {
+			mutate:        func(spec *kaitov1alpha1.ModelPresetSpec) { spec.SupportDistributedInference = true },
+			expectedError: "worldSize is required",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			preset := newPreset()
+			tc.mutate(&preset.Spec)
+			errs := Validate(preset)
+			if tc.expectedError == "" {
+				if len(errs) > 0 {
+					t.Fatalf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			for _, err := range errs {
+				if strings.Contains(err.Error(), tc.expectedError) {
+					return
+				}
+			}
+			t.Errorf("expected error containing %q, got %v", tc.expectedError, errs)
+		})
+	}
+}
+
+func TestRenderCommand(t *testing.T) {
+	command, err := RenderCommand(newPreset())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rendered := strings.Join(command, " ")
+	for _, expected := range []string{"accelerate launch", "--num_processes=1", "--torch_dtype=float16"} {
+		if !strings.Contains(rendered, expected) {
+			t.Errorf("expected %q in command %q", expected, rendered)
+		}
+	}
+
+	invalid := newPreset()
+	invalid.Spec.GPUCountRequirement = "one"
+	if _, err := RenderCommand(invalid); err == nil {
+		t.Errorf("expected an error for an invalid preset")
+	}
+}
+
+func TestFittingSKUs(t *testing.T) {
+	gpuConfigs := map[string]sku.GPUConfig{
+		"small": {SKU: "small", GPUCount: 1, GPUMem: 16},
+		"large": {SKU: "large", GPUCount: 2, GPUMem: 32},
+		"arm":   {SKU: "arm", GPUCount: 1, GPUMem: 16, Arch: "arm64"},
+	}
+	testcases := map[string]struct {
+		mutate        func(spec *kaitov1alpha1.ModelPresetSpec)
+		expectedSKUs  []string
+		expectedError string
+	}{
+		"fits all": {
+			mutate:       func(spec *kaitov1alpha1.ModelPresetSpec) {},
+			expectedSKUs: []string{"large", "small"},
+		},
+		"multi-arch image": {
+			mutate:       func(spec *kaitov1alpha1.ModelPresetSpec) { spec.Architectures = []string{"amd64", "arm64"} },
+			expectedSKUs: []string{"arm", "large", "small"},
+		},
+		"requires two GPUs": {
+			mutate: func(spec *kaitov1alpha1.ModelPresetSpec) {
+				spec.GPUCountRequirement = "2"
+				spec.TotalGPUMemoryRequirement = "30Gi"
+			},
+			expectedSKUs: []string{"large"},
+		},
+		"too large": {
+			mutate:        func(spec *kaitov1alpha1.ModelPresetSpec) { spec.TotalGPUMemoryRequirement = "64Gi" },
+			expectedError: "no SKU",
+		},
+		"inconsistent memory per GPU": {
+			mutate:        func(spec *kaitov1alpha1.ModelPresetSpec) { spec.PerGPUMemoryRequirement = "8Gi" },
+			expectedError: "cannot hold",
+		},
+		"zero total memory": {
+			mutate:        func(spec *kaitov1alpha1.ModelPresetSpec) { spec.TotalGPUMemoryRequirement = "0Gi" },
+			expectedError: "must be positive",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			preset := newPreset()
+			tc.mutate(&preset.Spec)
+			skus, err := FittingSKUs(preset, gpuConfigs)
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(skus, ",") != strings.Join(tc.expectedSKUs, ",") {
+				t.Errorf("expected SKUs %v, got %v", tc.expectedSKUs, skus)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package modelpreset
+
+import (
+	"context"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/utils/plugin"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Resolver resolves presets declared by ModelPreset custom resources, so that platform
+// teams can curate models without rebuilding the operator.
+type Resolver struct {
+	Client client.Reader
+}
+
+func (r *Resolver) Name() string {
+	return "modelpreset"
+}
+
+// Resolve returns one registration per ModelPreset declaring the model. A reference with
+// a version only matches the ModelPreset declaring that version.
+func (r *Resolver) Resolve(ctx context.Context, ref string) ([]*plugin.Registration, error) {
+	name, version := plugin.ParseModelReference(ref)
+	presetList := &kaitov1alpha1.ModelPresetList{}
+	if err := r.Client.List(ctx, presetList); err != nil {
+		return nil, err
+	}
+
+	var registrations []*plugin.Registration
+	for i := range presetList.Items {
+		preset := &presetList.Items[i]
+		if string(preset.Spec.ModelName) != name || (version != "" && preset.Spec.Version != version) {
+			continue
+		}
+		registrations = append(registrations, Registration(preset))
+	}
+	return registrations, nil
+}
+
+// Registration returns the transient registration of the model declared by the ModelPreset.
+func Registration(preset *kaitov1alpha1.ModelPreset) *plugin.Registration {
+	declaration := &plugin.PresetDeclaration{
+		ModelFamilyName:             preset.Spec.ModelFamilyName,
+		ImageAccessMode:             string(preset.Spec.ImageAccessMode),
+		DiskStorageRequirement:      preset.Spec.DiskStorageRequirement,
+		GPUCountRequirement:         preset.Spec.GPUCountRequirement,
+		TotalGPUMemoryRequirement:   preset.Spec.TotalGPUMemoryRequirement,
+		PerGPUMemoryRequirement:     preset.Spec.PerGPUMemoryRequirement,
+		MinDriverVersion:            preset.Spec.MinDriverVersion,
+		TorchRunParams:              preset.Spec.TorchRunParams,
+		TorchRunRdzvParams:          preset.Spec.TorchRunRdzvParams,
+		BaseCommand:                 preset.Spec.BaseCommand,
+		ModelRunParams:              preset.Spec.ModelRunParams,
+		Architectures:               preset.Spec.Architectures,
+		ArchModelRunParams:          preset.Spec.ArchModelRunParams,
+		WorldSize:                   preset.Spec.WorldSize,
+		Tag:                         preset.Spec.Tag,
+		SupportDistributedInference: preset.Spec.SupportDistributedInference,
+	}
+	if preset.Spec.ReadinessTimeout != nil {
+		declaration.ReadinessTimeout = *preset.Spec.ReadinessTimeout
+	}
+	if deprecation := preset.Spec.Deprecation; deprecation != nil {
+		declaration.Deprecation = &plugin.DeprecationDeclaration{Replacement: deprecation.Replacement}
+		if deprecation.EndOfLife != nil {
+			declaration.Deprecation.EndOfLife = *deprecation.EndOfLife
+		}
+	}
+	return &plugin.Registration{
+		Name:      string(preset.Spec.ModelName),
+		Version:   preset.Spec.Version,
+		Instance:  declaration.Model(),
+		Transient: true,
+	}
+}
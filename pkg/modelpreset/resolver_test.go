@@ -0,0 +1,97 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package modelpreset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResolve(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = kaitov1alpha1.AddToScheme(scheme)
+	newPreset := func(name, version, tag string) *kaitov1alpha1.ModelPreset {
+		return &kaitov1alpha1.ModelPreset{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-" + version},
+			Spec: kaitov1alpha1.ModelPresetSpec{
+				ModelName:        kaitov1alpha1.ModelName(name),
+				Version:          version,
+				Tag:              tag,
+				ReadinessTimeout: &metav1.Duration{Duration: 10 * time.Minute},
+			},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newPreset("custom", "1", "0.0.1"),
+		newPreset("custom", "2", "0.0.2"),
+		newPreset("other", "1", "0.0.1"),
+	).Build()
+	resolver := &Resolver{Client: c}
+
+	testcases := map[string]struct {
+		ref          string
+		expectedTags []string
+	}{
+		"all versions of the model": {
+			ref:          "custom",
+			expectedTags: []string{"0.0.1", "0.0.2"},
+		},
+		"pinned version": {
+			ref:          "custom@2",
+			expectedTags: []string{"0.0.2"},
+		},
+		"unknown model": {
+			ref: "unknown",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			registrations, err := resolver.Resolve(context.Background(), tc.ref)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(registrations) != len(tc.expectedTags) {
+				t.Fatalf("expected %d registrations, got %d", len(tc.expectedTags), len(registrations))
+			}
+			for i, r := range registrations {
+				param := r.Instance.GetInferenceParameters()
+				if param.Tag != tc.expectedTags[i] {
+					t.Errorf("expected tag %s, got %s", tc.expectedTags[i], param.Tag)
+				}
+				if param.ReadinessTimeout != 10*time.Minute {
+					t.Errorf("expected readiness timeout 10m, got %s", param.ReadinessTimeout)
+				}
+				if !r.Transient {
+					t.Errorf("expected transient registration")
+				}
+			}
+		})
+	}
+}
+
+func TestRegistrationDeprecation(t *testing.T) {
+	endOfLife := metav1.NewTime(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	preset := &kaitov1alpha1.ModelPreset{
+		Spec: kaitov1alpha1.ModelPresetSpec{
+			ModelName:   "custom",
+			Deprecation: &kaitov1alpha1.PresetDeprecation{Replacement: "custom-v2", EndOfLife: &endOfLife},
+		},
+	}
+
+	deprecation := Registration(preset).Instance.GetInferenceParameters().Deprecation
+	if deprecation == nil || deprecation.Replacement != "custom-v2" || !deprecation.EndOfLife.Equal(endOfLife.Time) {
+		t.Errorf("unexpected deprecation %v", deprecation)
+	}
+
+	preset.Spec.Deprecation = nil
+	if deprecation := Registration(preset).Instance.GetInferenceParameters().Deprecation; deprecation != nil {
+		t.Errorf("expected no deprecation, got %v", deprecation)
+	}
+}
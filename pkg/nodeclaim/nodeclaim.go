@@ -80,7 +80,7 @@ func GenerateNodeClaimManifest(ctx context.Context, storageRequirement string, w
 					NodeSelectorRequirement: v1.NodeSelectorRequirement{
 						Key:      v1.LabelArchStable,
 						Operator: v1.NodeSelectorOpIn,
-						Values:   []string{"amd64"},
+						Values:   []string{kaitov1alpha1.InstanceTypeArchitecture(workspaceObj.Resource.InstanceType)},
 					},
 					MinValues: lo.ToPtr(1),
 				},
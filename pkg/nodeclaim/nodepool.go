@@ -0,0 +1,119 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package nodeclaim
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+)
+
+const capacityNvidiaGPU = v1.ResourceName("nvidia.com/gpu")
+
+// NodePoolTarget selects an existing Karpenter NodePool, by name or by labels, that the nodeClaims
+// created by Kaito belong to. The zero value creates standalone nodeClaims in the fake "kaito" pool.
+type NodePoolTarget struct {
+	Name     string
+	Selector labels.Selector
+}
+
+// IsSet returns whether a NodePool is targeted.
+func (t NodePoolTarget) IsSet() bool {
+	return t.Name != "" || (t.Selector != nil && !t.Selector.Empty())
+}
+
+// TargetNodePool is the NodePool targeted by the nodeClaims created by Kaito, set from the operator flags.
+var TargetNodePool NodePoolTarget
+
+// FindNodePool returns the NodePool selected by target. If several NodePools match the selector,
+// the one with the highest weight is returned, then the first by name.
+func FindNodePool(ctx context.Context, target NodePoolTarget, kubeClient client.Client) (*v1beta1.NodePool, error) {
+	if target.Name != "" {
+		nodePool := &v1beta1.NodePool{}
+		if err := kubeClient.Get(ctx, client.ObjectKey{Name: target.Name}, nodePool); err != nil {
+			return nil, fmt.Errorf("failed to get NodePool %s: %w", target.Name, err)
+		}
+		return nodePool, nil
+	}
+
+	nodePools := &v1beta1.NodePoolList{}
+	if err := kubeClient.List(ctx, nodePools, client.MatchingLabelsSelector{Selector: target.Selector}); err != nil {
+		return nil, fmt.Errorf("failed to list NodePools: %w", err)
+	}
+	if len(nodePools.Items) == 0 {
+		return nil, fmt.Errorf("no NodePool matches the selector %s", target.Selector)
+	}
+	sort.Slice(nodePools.Items, func(i, j int) bool {
+		wi := lo.FromPtr(nodePools.Items[i].Spec.Weight)
+		wj := lo.FromPtr(nodePools.Items[j].Spec.Weight)
+		if wi != wj {
+			return wi > wj
+		}
+		return nodePools.Items[i].Name < nodePools.Items[j].Name
+	})
+	return &nodePools.Items[0], nil
+}
+
+// CheckNodePoolLimits returns an error if a node of the instance type would exceed the GPU limit of the NodePool.
+func CheckNodePoolLimits(nodePool *v1beta1.NodePool, instanceType string) error {
+	limit, ok := nodePool.Spec.Limits[capacityNvidiaGPU]
+	if !ok {
+		return nil
+	}
+	gpuCount := 1
+	if gpuConfig, ok := kaitov1alpha1.SupportedGPUConfigs[instanceType]; ok {
+		gpuCount = gpuConfig.GPUCount
+	}
+	required := nodePool.Status.Resources[capacityNvidiaGPU]
+	required.Add(*resource.NewQuantity(int64(gpuCount), resource.DecimalSI))
+	if required.Cmp(limit) > 0 {
+		return fmt.Errorf("NodePool %s GPU limit %s would be exceeded by instance type %s, %s GPUs are provisioned",
+			nodePool.Name, limit.String(), instanceType, nodePool.Status.Resources.Name(capacityNvidiaGPU, resource.DecimalSI).String())
+	}
+	return nil
+}
+
+// ApplyNodePool makes the nodeClaim a member of the NodePool: the nodeClaim inherits the node class,
+// labels, taints, requirements and kubelet configuration of the NodePool template, and is subject to
+// the disruption budgets of the NodePool instead of being excluded from disruption.
+func ApplyNodePool(nodeClaim *v1beta1.NodeClaim, nodePool *v1beta1.NodePool) {
+	template := nodePool.Spec.Template
+
+	nodeClaim.Labels = lo.Assign(template.Labels, nodeClaim.Labels)
+	nodeClaim.Labels[LabelNodePool] = nodePool.Name
+	delete(nodeClaim.Labels, v1beta1.DoNotDisruptAnnotationKey)
+	if len(template.Annotations) != 0 {
+		nodeClaim.Annotations = lo.Assign(template.Annotations, nodeClaim.Annotations)
+	}
+
+	for i := range nodeClaim.Spec.Requirements {
+		if nodeClaim.Spec.Requirements[i].Key == LabelNodePool {
+			nodeClaim.Spec.Requirements[i].Values = []string{nodePool.Name}
+		}
+	}
+	for _, requirement := range template.Spec.Requirements {
+		if _, found := lo.Find(nodeClaim.Spec.Requirements, func(r v1beta1.NodeSelectorRequirementWithMinValues) bool {
+			return r.Key == requirement.Key
+		}); !found {
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, requirement)
+		}
+	}
+
+	for _, taint := range template.Spec.Taints {
+		if !lo.ContainsBy(nodeClaim.Spec.Taints, func(t v1.Taint) bool { return t.MatchTaint(&taint) }) {
+			nodeClaim.Spec.Taints = append(nodeClaim.Spec.Taints, taint)
+		}
+	}
+	nodeClaim.Spec.StartupTaints = append(nodeClaim.Spec.StartupTaints, template.Spec.StartupTaints...)
+	nodeClaim.Spec.NodeClassRef = template.Spec.NodeClassRef
+	nodeClaim.Spec.Kubelet = template.Spec.Kubelet
+}
@@ -0,0 +1,146 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package nodeclaim
+
+import (
+	"context"
+	"testing"
+
+	"github.com/azure/kaito/pkg/utils/test"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/mock"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+)
+
+func TestFindNodePool(t *testing.T) {
+	testcases := map[string]struct {
+		target           NodePoolTarget
+		nodePools        []*v1beta1.NodePool
+		callMocks        func(c *test.MockClient)
+		expectedNodePool string
+		expectedError    bool
+	}{
+		"NodePool by name": {
+			target: NodePoolTarget{Name: "gpu"},
+			callMocks: func(c *test.MockClient) {
+				c.CreateOrUpdateObjectInMap(&v1beta1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "gpu"}})
+				c.On("Get", mock.IsType(context.Background()), mock.Anything, mock.IsType(&v1beta1.NodePool{}), mock.Anything).Return(nil)
+			},
+			expectedNodePool: "gpu",
+		},
+		"NodePool with the highest weight by selector": {
+			target: NodePoolTarget{Selector: labels.SelectorFromSet(labels.Set{"team": "ml"})},
+			callMocks: func(c *test.MockClient) {
+				relevantMap := c.CreateMapWithType(&v1beta1.NodePoolList{})
+				for _, np := range []*v1beta1.NodePool{
+					{ObjectMeta: metav1.ObjectMeta{Name: "a"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "b"}, Spec: v1beta1.NodePoolSpec{Weight: lo.ToPtr(int32(10))}},
+				} {
+					relevantMap[client.ObjectKeyFromObject(np)] = np
+				}
+				c.On("List", mock.IsType(context.Background()), mock.IsType(&v1beta1.NodePoolList{}), mock.Anything).Return(nil)
+			},
+			expectedNodePool: "b",
+		},
+		"No NodePool matches the selector": {
+			target: NodePoolTarget{Selector: labels.SelectorFromSet(labels.Set{"team": "ml"})},
+			callMocks: func(c *test.MockClient) {
+				c.CreateMapWithType(&v1beta1.NodePoolList{})
+				c.On("List", mock.IsType(context.Background()), mock.IsType(&v1beta1.NodePoolList{}), mock.Anything).Return(nil)
+			},
+			expectedError: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			mockClient := test.NewClient()
+			tc.callMocks(mockClient)
+
+			nodePool, err := FindNodePool(context.Background(), tc.target, mockClient)
+			if tc.expectedError {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, nodePool.Name, tc.expectedNodePool)
+		})
+	}
+}
+
+func TestCheckNodePoolLimits(t *testing.T) {
+	testcases := map[string]struct {
+		limits        v1beta1.Limits
+		provisioned   corev1.ResourceList
+		expectedError bool
+	}{
+		"No GPU limit": {
+			provisioned: corev1.ResourceList{capacityNvidiaGPU: resource.MustParse("100")},
+		},
+		"Within the GPU limit": {
+			limits:      v1beta1.Limits{capacityNvidiaGPU: resource.MustParse("4")},
+			provisioned: corev1.ResourceList{capacityNvidiaGPU: resource.MustParse("2")},
+		},
+		"GPU limit exceeded": {
+			limits:        v1beta1.Limits{capacityNvidiaGPU: resource.MustParse("4")},
+			provisioned:   corev1.ResourceList{capacityNvidiaGPU: resource.MustParse("3")},
+			expectedError: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			nodePool := &v1beta1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{Name: "gpu"},
+				Spec:       v1beta1.NodePoolSpec{Limits: tc.limits},
+				Status:     v1beta1.NodePoolStatus{Resources: tc.provisioned},
+			}
+			// Standard_NC12s_v3 has 2 GPUs.
+			err := CheckNodePoolLimits(nodePool, "Standard_NC12s_v3")
+			assert.Equal(t, err != nil, tc.expectedError)
+		})
+	}
+}
+
+func TestApplyNodePool(t *testing.T) {
+	nodeClaim := GenerateNodeClaimManifest(context.Background(), "0", test.MockWorkspaceWithPreset)
+	nodePool := &v1beta1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpu"},
+		Spec: v1beta1.NodePoolSpec{
+			Template: v1beta1.NodeClaimTemplate{
+				ObjectMeta: v1beta1.ObjectMeta{Labels: map[string]string{"team": "ml"}},
+				Spec: v1beta1.NodeClaimSpec{
+					Requirements: []v1beta1.NodeSelectorRequirementWithMinValues{
+						{NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+							Key: "karpenter.sh/capacity-type", Operator: corev1.NodeSelectorOpIn, Values: []string{"on-demand"}}},
+						{NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+							Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"Standard_D4s_v3"}}},
+					},
+					Taints:       []corev1.Taint{{Key: "sku", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}},
+					NodeClassRef: &v1beta1.NodeClassReference{Name: "default"},
+				},
+			},
+		},
+	}
+
+	ApplyNodePool(nodeClaim, nodePool)
+
+	assert.Equal(t, nodeClaim.Labels[LabelNodePool], "gpu")
+	assert.Equal(t, nodeClaim.Labels["team"], "ml")
+	_, doNotDisrupt := nodeClaim.Labels[v1beta1.DoNotDisruptAnnotationKey]
+	assert.Assert(t, !doNotDisrupt)
+	requirements := lo.SliceToMap(nodeClaim.Spec.Requirements, func(r v1beta1.NodeSelectorRequirementWithMinValues) (string, []string) {
+		return r.Key, r.Values
+	})
+	assert.DeepEqual(t, requirements[LabelNodePool], []string{"gpu"})
+	assert.DeepEqual(t, requirements["karpenter.sh/capacity-type"], []string{"on-demand"})
+	assert.DeepEqual(t, requirements[corev1.LabelInstanceTypeStable], []string{test.MockWorkspaceWithPreset.Resource.InstanceType})
+	assert.Equal(t, len(nodeClaim.Spec.Taints), 1)
+	assert.Equal(t, nodeClaim.Spec.NodeClassRef.Name, "default")
+}
@@ -0,0 +1,47 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+// Package operatorconfig holds the operator settings changed at runtime by the KaitoConfig object.
+package operatorconfig
+
+import (
+	"sync/atomic"
+)
+
+// Overrides are the operator settings set by the KaitoConfig object. They take precedence over the
+// command line flags of the operator. Unset fields fall back to the flags.
+type Overrides struct {
+	// ModelRunParams, if not nil, replace the --model-run-params flag.
+	ModelRunParams map[string]string
+	// SchedulerName, if not nil, replaces the --scheduler-name flag.
+	SchedulerName *string
+	// GPUScoringStrategy, if not nil, replaces the --gpu-scoring-strategy flag.
+	GPUScoringStrategy *string
+	// PresetImageMirrors, if not nil, replace the --preset-image-mirrors flag.
+	PresetImageMirrors map[string]string
+	// PresetImagePullSecrets, if not nil, replace the --preset-image-pull-secrets flag.
+	PresetImagePullSecrets []string
+}
+
+var current atomic.Pointer[Overrides]
+
+// Get returns the current overrides. The returned value must not be modified.
+func Get() *Overrides {
+	if overrides := current.Load(); overrides != nil {
+		return overrides
+	}
+	return &Overrides{}
+}
+
+// Set replaces the current overrides. Nil clears them.
+func Set(overrides *Overrides) {
+	current.Store(overrides)
+}
+
+// String returns the override if set, the flag value otherwise.
+func String(override *string, flagValue string) string {
+	if override != nil {
+		return *override
+	}
+	return flagValue
+}
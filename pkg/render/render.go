@@ -0,0 +1,224 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+// Package render generates the resources the workspace controller creates for a workspace without
+// applying them, e.g., to review them in a GitOps pipeline.
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/featuregates"
+	"github.com/azure/kaito/pkg/inference"
+	"github.com/azure/kaito/pkg/machine"
+	"github.com/azure/kaito/pkg/nodeclaim"
+	"github.com/azure/kaito/pkg/resources"
+	"github.com/azure/kaito/pkg/utils/consts"
+	"github.com/azure/kaito/pkg/utils/plugin"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/yaml"
+)
+
+// defaultInstanceType is the instance type defaulted by the Workspace CRD.
+const defaultInstanceType = "Standard_NC12s_v3"
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(kaitov1alpha1.AddToScheme(scheme))
+	utilruntime.Must(v1alpha5.SchemeBuilder.AddToScheme(scheme))
+	utilruntime.Must(v1beta1.SchemeBuilder.AddToScheme(scheme))
+}
+
+// Workspace returns the resources the workspace controller creates for a new workspace: its nodes,
+// services and inference workload. The workspace is defaulted like by the API server. The class of the
+// workspace, if it references one, must be passed. Only the builtin presets are rendered, and the
+// tuning workspaces are not supported. The names of the nodes are generated like by the controller,
+// so they differ between renders.
+func Workspace(ctx context.Context, wObj *kaitov1alpha1.Workspace, class *kaitov1alpha1.WorkspaceClass) ([]client.Object, error) {
+	wObj = wObj.DeepCopy()
+	setDefaults(wObj)
+	if wObj.WorkspaceClassName != "" {
+		if class == nil || class.Name != wObj.WorkspaceClassName {
+			return nil, fmt.Errorf("workspace class %s of workspace %s/%s not found", wObj.WorkspaceClassName, wObj.Namespace, wObj.Name)
+		}
+		wObj.ApplyWorkspaceClass(class)
+	}
+	if wObj.Tuning != nil {
+		return nil, fmt.Errorf("workspace %s/%s: rendering tuning workspaces is not supported", wObj.Namespace, wObj.Name)
+	}
+	if wObj.Inference == nil || wObj.Inference.Template == nil && wObj.Inference.Preset == nil {
+		return nil, fmt.Errorf("workspace %s/%s has neither an inference preset nor a template", wObj.Namespace, wObj.Name)
+	}
+
+	var objs []client.Object
+	if wObj.Inference.Template != nil {
+		objs = append(objs, nodes(ctx, wObj, "")...)
+		objs = append(objs, inference.GenerateTemplateInference(ctx, wObj))
+		return withKinds(objs)
+	}
+
+	presetName := wObj.Inference.Preset.ModelReference()
+	model, err := plugin.KaitoModelRegister.Get(presetName)
+	if err != nil {
+		return nil, err
+	}
+	inferenceParam, _, err := inference.ResolveRunParams(wObj, model.GetInferenceParameters())
+	if err != nil {
+		return nil, err
+	}
+	objs = append(objs, nodes(ctx, wObj, inferenceParam.DiskStorageRequirement)...)
+
+	services := []client.Object{resources.GenerateServiceManifest(ctx, wObj, resources.ServiceType(wObj), model.SupportDistributedInference())}
+	if model.SupportDistributedInference() {
+		services = append(services, resources.GenerateHeadlessServiceManifest(ctx, wObj))
+	}
+	objs = append(objs, services...)
+
+	if inference.UsesModelCachePVC(wObj, model.SupportDistributedInference()) {
+		pvc, err := inference.GenerateModelCachePVCManifest(wObj, inferenceParam)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, pvc)
+	}
+	// The generation of the distributed workloads reads the services of the workspace.
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(lo.Map(services, func(obj client.Object, _ int) client.Object {
+		return obj.DeepCopyObject().(client.Object)
+	})...).Build()
+	workloadObj, err := inference.GeneratePresetInference(ctx, wObj, inferenceParam, model.SupportDistributedInference(), kubeClient)
+	if err != nil {
+		return nil, err
+	}
+	objs = append(objs, workloadObj)
+	return withKinds(objs)
+}
+
+// setDefaults sets the defaults of the Workspace CRD.
+func setDefaults(wObj *kaitov1alpha1.Workspace) {
+	if wObj.Resource.Count == nil {
+		wObj.Resource.Count = lo.ToPtr(1)
+	}
+	if wObj.Resource.InstanceType == "" {
+		wObj.Resource.InstanceType = defaultInstanceType
+	}
+	if wObj.Inference == nil {
+		return
+	}
+	if wObj.Inference.Preset != nil && wObj.Inference.Preset.AccessMode == "" {
+		wObj.Inference.Preset.AccessMode = kaitov1alpha1.ModelImageAccessModePublic
+	}
+	if wObj.Inference.Storage != nil && wObj.Inference.Storage.Policy == "" {
+		wObj.Inference.Storage.Policy = kaitov1alpha1.ModelStoragePolicyNodeLocal
+	}
+}
+
+// nodes returns the machines, or the nodeClaims if the Karpenter feature gate is enabled, created for
+// the workspace when no existing node qualifies.
+func nodes(ctx context.Context, wObj *kaitov1alpha1.Workspace, nodeOSDiskSize string) []client.Object {
+	if nodeOSDiskSize == "" {
+		nodeOSDiskSize = "0" // The default OS size is used
+	}
+	var objs []client.Object
+	for i := 0; i < *wObj.Resource.Count; i++ {
+		if featuregates.FeatureGates[consts.FeatureFlagKarpenter] {
+			objs = append(objs, nodeclaim.GenerateNodeClaimManifest(ctx, nodeOSDiskSize, wObj))
+		} else {
+			objs = append(objs, machine.GenerateMachineManifest(ctx, nodeOSDiskSize, wObj))
+		}
+	}
+	return objs
+}
+
+// withKinds sets the API version and kind of the objects, which are left empty by the generation.
+func withKinds(objs []client.Object) ([]client.Object, error) {
+	for _, obj := range objs {
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+		if err != nil {
+			return nil, err
+		}
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+	}
+	return objs, nil
+}
+
+// Decode reads the workspace of a multi-document manifest, and the workspace class it references if the
+// manifest contains it. The other objects are ignored.
+func Decode(r io.Reader) (*kaitov1alpha1.Workspace, *kaitov1alpha1.WorkspaceClass, error) {
+	var workspace *kaitov1alpha1.Workspace
+	var classes []*kaitov1alpha1.WorkspaceClass
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		raw := runtime.RawExtension{}
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, nil, err
+		}
+		raw.Raw = bytes.TrimSpace(raw.Raw)
+		if len(raw.Raw) == 0 || bytes.Equal(raw.Raw, []byte("null")) {
+			// Empty document.
+			continue
+		}
+		typeMeta := runtime.TypeMeta{}
+		if err := yaml.Unmarshal(raw.Raw, &typeMeta); err != nil {
+			return nil, nil, err
+		}
+		if typeMeta.APIVersion != kaitov1alpha1.GroupVersion.String() {
+			continue
+		}
+		switch typeMeta.Kind {
+		case "Workspace":
+			if workspace != nil {
+				return nil, nil, fmt.Errorf("the manifest contains more than one workspace")
+			}
+			workspace = &kaitov1alpha1.Workspace{}
+			if err := yaml.UnmarshalStrict(raw.Raw, workspace); err != nil {
+				return nil, nil, err
+			}
+		case "WorkspaceClass":
+			class := &kaitov1alpha1.WorkspaceClass{}
+			if err := yaml.UnmarshalStrict(raw.Raw, class); err != nil {
+				return nil, nil, err
+			}
+			classes = append(classes, class)
+		}
+	}
+	if workspace == nil {
+		return nil, nil, fmt.Errorf("the manifest contains no workspace")
+	}
+	class, _ := lo.Find(classes, func(class *kaitov1alpha1.WorkspaceClass) bool {
+		return class.Name == workspace.WorkspaceClassName
+	})
+	return workspace, class, nil
+}
+
+// Encode writes the objects as a multi-document YAML manifest.
+func Encode(w io.Writer, objs []client.Object) error {
+	for _, obj := range objs {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
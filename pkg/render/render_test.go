@@ -0,0 +1,145 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package render
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/utils/test"
+	"gotest.tools/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func kinds(objs []client.Object) []string {
+	var kinds []string
+	for _, obj := range objs {
+		kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+	}
+	return kinds
+}
+
+func TestWorkspace(t *testing.T) {
+	test.RegisterTestModel()
+	class := &kaitov1alpha1.WorkspaceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "team"},
+		Spec:       kaitov1alpha1.WorkspaceClassSpec{SchedulerName: "gpu-scheduler"},
+	}
+	testcases := map[string]struct {
+		workspace     func() *kaitov1alpha1.Workspace
+		class         *kaitov1alpha1.WorkspaceClass
+		expectedKinds []string
+		expectedError string
+	}{
+		"Preset": {
+			workspace:     test.MockWorkspaceWithPreset.DeepCopy,
+			expectedKinds: []string{"Machine", "Service", "Deployment"},
+		},
+		"Distributed preset": {
+			workspace:     test.MockWorkspaceDistributedModel.DeepCopy,
+			expectedKinds: []string{"Machine", "Service", "Service", "StatefulSet"},
+		},
+		"Preset with class": {
+			workspace: func() *kaitov1alpha1.Workspace {
+				workspace := test.MockWorkspaceWithPreset.DeepCopy()
+				workspace.WorkspaceClassName = "team"
+				return workspace
+			},
+			class:         class,
+			expectedKinds: []string{"Machine", "Service", "Deployment"},
+		},
+		"Class not found": {
+			workspace: func() *kaitov1alpha1.Workspace {
+				workspace := test.MockWorkspaceWithPreset.DeepCopy()
+				workspace.WorkspaceClassName = "missing"
+				return workspace
+			},
+			class:         class,
+			expectedError: "workspace class missing",
+		},
+		"Inference template": {
+			workspace:     test.MockWorkspaceWithInferenceTemplate.DeepCopy,
+			expectedKinds: []string{"Machine", "Deployment"},
+		},
+		"Tuning": {
+			workspace: func() *kaitov1alpha1.Workspace {
+				workspace := test.MockWorkspaceWithPreset.DeepCopy()
+				workspace.Inference = nil
+				workspace.Tuning = &kaitov1alpha1.TuningSpec{Preset: &kaitov1alpha1.PresetSpec{PresetMeta: kaitov1alpha1.PresetMeta{Name: "test-model"}}}
+				return workspace
+			},
+			expectedError: "not supported",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			workspace := tc.workspace()
+			objs, err := Workspace(context.Background(), workspace, tc.class)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, kinds(objs), tc.expectedKinds)
+			if tc.class != nil {
+				deployment := objs[len(objs)-1].(*appsv1.Deployment)
+				assert.Equal(t, deployment.Spec.Template.Spec.SchedulerName, tc.class.Spec.SchedulerName)
+			}
+			// The workspace itself is left unchanged.
+			assert.DeepEqual(t, workspace, tc.workspace())
+		})
+	}
+}
+
+func TestDecodeEncode(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: Namespace
+metadata:
+  name: team
+---
+apiVersion: kaito.sh/v1alpha1
+kind: WorkspaceClass
+metadata:
+  name: other
+---
+apiVersion: kaito.sh/v1alpha1
+kind: WorkspaceClass
+metadata:
+  name: team
+spec:
+  schedulerName: gpu-scheduler
+---
+apiVersion: kaito.sh/v1alpha1
+kind: Workspace
+metadata:
+  name: workspace-phi-2
+workspaceClassName: team
+resource:
+  instanceType: Standard_NC6s_v3
+inference:
+  preset:
+    name: phi-2
+`
+	workspace, class, err := Decode(strings.NewReader(manifest))
+	assert.NilError(t, err)
+	assert.Equal(t, workspace.Name, "workspace-phi-2")
+	assert.Equal(t, class.Name, "team")
+	assert.Equal(t, class.Spec.SchedulerName, "gpu-scheduler")
+
+	_, _, err = Decode(strings.NewReader("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: team\n"))
+	assert.ErrorContains(t, err, "no workspace")
+
+	_, _, err = Decode(strings.NewReader("apiVersion: kaito.sh/v1alpha1\nkind: Workspace\nresources: {}\n"))
+	assert.ErrorContains(t, err, "unknown field")
+
+	var out bytes.Buffer
+	assert.NilError(t, Encode(&out, []client.Object{workspace, class}))
+	assert.Equal(t, strings.Count(out.String(), "---\n"), 2)
+}
@@ -0,0 +1,146 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	"context"
+	"fmt"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The labels set by the GPU feature discovery of the NVIDIA GPU operator on the nodes whose GPUs are shared.
+const (
+	LabelGPUSharingStrategy = "nvidia.com/gpu.sharing-strategy"
+	LabelMIGStrategy        = "nvidia.com/mig.strategy"
+)
+
+// DefaultTuningColocation is the tuning colocation policy of the workspaces without the
+// kaito.sh/tuning-colocation annotation.
+var DefaultTuningColocation = kaitov1alpha1.TuningColocationDeny
+
+// ValidateTuningColocation checks that the policy is a supported tuning colocation policy.
+func ValidateTuningColocation(policy string) error {
+	if !utils.Contains(kaitov1alpha1.TuningColocationPolicies, policy) {
+		return fmt.Errorf("unsupported tuning colocation policy %s, must be %s, %s or %s", policy,
+			kaitov1alpha1.TuningColocationDeny, kaitov1alpha1.TuningColocationPartitioned, kaitov1alpha1.TuningColocationAllow)
+	}
+	return nil
+}
+
+// TuningColocation returns the tuning colocation policy of the workspace.
+func TuningColocation(workspaceObj *kaitov1alpha1.Workspace) string {
+	if policy, ok := workspaceObj.Annotations[kaitov1alpha1.AnnotationTuningColocation]; ok {
+		return policy
+	}
+	return DefaultTuningColocation
+}
+
+// tuningNodeSelectorTerms returns the node selector terms of the nodes the tuning pods of the workspace
+// can be scheduled on, or nil if they can be scheduled on any node.
+func tuningNodeSelectorTerms(workspaceObj *kaitov1alpha1.Workspace) []corev1.NodeSelectorTerm {
+	policy := TuningColocation(workspaceObj)
+	if policy == kaitov1alpha1.TuningColocationAllow {
+		return nil
+	}
+	terms := []corev1.NodeSelectorTerm{{
+		MatchExpressions: []corev1.NodeSelectorRequirement{{
+			Key:      kaitov1alpha1.LabelInferenceNode,
+			Operator: corev1.NodeSelectorOpDoesNotExist,
+		}},
+	}}
+	if policy == kaitov1alpha1.TuningColocationPartitioned {
+		terms = append(terms, corev1.NodeSelectorTerm{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{
+				Key:      LabelGPUSharingStrategy,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{"mps"},
+			}},
+		}, corev1.NodeSelectorTerm{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{
+				Key:      LabelMIGStrategy,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{"single", "mixed"},
+			}},
+		})
+	}
+	return terms
+}
+
+// ConfigureTuningColocation keeps the tuning pods of the workspace off the nodes serving inference
+// workspaces, unless its tuning colocation policy allows them there. The requirements are added to
+// every required node affinity term of the pod template.
+func ConfigureTuningColocation(template *corev1.PodTemplateSpec, workspaceObj *kaitov1alpha1.Workspace) {
+	terms := tuningNodeSelectorTerms(workspaceObj)
+	if terms == nil {
+		return
+	}
+	if template.Spec.Affinity == nil {
+		template.Spec.Affinity = &corev1.Affinity{}
+	}
+	if template.Spec.Affinity.NodeAffinity == nil {
+		template.Spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	nodeAffinity := template.Spec.Affinity.NodeAffinity
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+	selector := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	existing := selector.NodeSelectorTerms
+	if len(existing) == 0 {
+		existing = []corev1.NodeSelectorTerm{{}}
+	}
+	// The terms are ORed, so every existing term is combined with every colocation term.
+	combined := make([]corev1.NodeSelectorTerm, 0, len(existing)*len(terms))
+	for _, e := range existing {
+		for _, t := range terms {
+			term := *e.DeepCopy()
+			term.MatchExpressions = append(term.MatchExpressions, t.MatchExpressions...)
+			combined = append(combined, term)
+		}
+	}
+	selector.NodeSelectorTerms = combined
+}
+
+// TuningAllowedOnNode returns whether the tuning pods of the workspace can be scheduled on the node,
+// according to its tuning colocation policy.
+func TuningAllowedOnNode(workspaceObj *kaitov1alpha1.Workspace, nodeObj *corev1.Node) bool {
+	if _, serving := nodeObj.Labels[kaitov1alpha1.LabelInferenceNode]; !serving {
+		return true
+	}
+	switch TuningColocation(workspaceObj) {
+	case kaitov1alpha1.TuningColocationAllow:
+		return true
+	case kaitov1alpha1.TuningColocationPartitioned:
+		return nodeObj.Labels[LabelGPUSharingStrategy] == "mps" || utils.Contains([]string{"single", "mixed"}, nodeObj.Labels[LabelMIGStrategy])
+	}
+	return false
+}
+
+// EnsureInferenceNodeLabel labels the node as serving an inference workspace.
+func EnsureInferenceNodeLabel(ctx context.Context, nodeObj *corev1.Node, kubeClient client.Client) error {
+	if _, ok := nodeObj.Labels[kaitov1alpha1.LabelInferenceNode]; ok {
+		return nil
+	}
+	return UpdateNodeWithLabel(ctx, nodeObj.Name, kaitov1alpha1.LabelInferenceNode, "true", kubeClient)
+}
+
+// RemoveInferenceNodeLabel removes the inference label from the node, e.g., once its inference
+// workspace is deleted. A node that is gone is ignored.
+func RemoveInferenceNodeLabel(ctx context.Context, nodeName string, kubeClient client.Client) error {
+	nodeObj, err := GetNode(ctx, nodeName, kubeClient)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if _, ok := nodeObj.Labels[kaitov1alpha1.LabelInferenceNode]; !ok {
+		return nil
+	}
+	klog.InfoS("RemoveInferenceNodeLabel", "nodeName", nodeName)
+	delete(nodeObj.Labels, kaitov1alpha1.LabelInferenceNode)
+	return client.IgnoreNotFound(kubeClient.Update(ctx, nodeObj))
+}
@@ -0,0 +1,153 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/utils/test"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestConfigureTuningColocation(t *testing.T) {
+	notServing := corev1.NodeSelectorRequirement{Key: kaitov1alpha1.LabelInferenceNode, Operator: corev1.NodeSelectorOpDoesNotExist}
+	mps := corev1.NodeSelectorRequirement{Key: LabelGPUSharingStrategy, Operator: corev1.NodeSelectorOpIn, Values: []string{"mps"}}
+	mig := corev1.NodeSelectorRequirement{Key: LabelMIGStrategy, Operator: corev1.NodeSelectorOpIn, Values: []string{"single", "mixed"}}
+	zone := corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"eastus-1"}}
+
+	testcases := map[string]struct {
+		annotation string
+		existing   []corev1.NodeSelectorTerm
+		expected   []corev1.NodeSelectorTerm
+	}{
+		"Denied by default": {
+			expected: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{notServing}}},
+		},
+		"Allowed on partitioned GPUs": {
+			annotation: kaitov1alpha1.TuningColocationPartitioned,
+			expected: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{notServing}},
+				{MatchExpressions: []corev1.NodeSelectorRequirement{mps}},
+				{MatchExpressions: []corev1.NodeSelectorRequirement{mig}},
+			},
+		},
+		"Allowed": {
+			annotation: kaitov1alpha1.TuningColocationAllow,
+		},
+		"Combined with the existing terms": {
+			annotation: kaitov1alpha1.TuningColocationPartitioned,
+			existing:   []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{zone}}},
+			expected: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{zone, notServing}},
+				{MatchExpressions: []corev1.NodeSelectorRequirement{zone, mps}},
+				{MatchExpressions: []corev1.NodeSelectorRequirement{zone, mig}},
+			},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			workspace := test.MockWorkspaceWithPreset.DeepCopy()
+			if tc.annotation != "" {
+				workspace.Annotations = map[string]string{kaitov1alpha1.AnnotationTuningColocation: tc.annotation}
+			}
+			template := &corev1.PodTemplateSpec{}
+			if tc.existing != nil {
+				template.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: tc.existing},
+				}}
+			}
+
+			ConfigureTuningColocation(template, workspace)
+
+			if tc.expected == nil {
+				assert.Assert(t, template.Spec.Affinity == nil)
+				return
+			}
+			assert.DeepEqual(t, template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms, tc.expected)
+		})
+	}
+}
+
+func TestTuningAllowedOnNode(t *testing.T) {
+	serving := map[string]string{kaitov1alpha1.LabelInferenceNode: "true"}
+	testcases := map[string]struct {
+		annotation string
+		labels     map[string]string
+		expected   bool
+	}{
+		"Node not serving inference": {
+			expected: true,
+		},
+		"Node serving inference": {
+			labels: serving,
+		},
+		"Node serving inference with MPS, denied": {
+			labels: map[string]string{kaitov1alpha1.LabelInferenceNode: "true", LabelGPUSharingStrategy: "mps"},
+		},
+		"Node serving inference with MPS": {
+			annotation: kaitov1alpha1.TuningColocationPartitioned,
+			labels:     map[string]string{kaitov1alpha1.LabelInferenceNode: "true", LabelGPUSharingStrategy: "mps"},
+			expected:   true,
+		},
+		"Node serving inference with MIG": {
+			annotation: kaitov1alpha1.TuningColocationPartitioned,
+			labels:     map[string]string{kaitov1alpha1.LabelInferenceNode: "true", LabelMIGStrategy: "single"},
+			expected:   true,
+		},
+		"Node serving inference without partitioning": {
+			annotation: kaitov1alpha1.TuningColocationPartitioned,
+			labels:     map[string]string{kaitov1alpha1.LabelInferenceNode: "true", LabelMIGStrategy: "none"},
+		},
+		"Node serving inference, allowed": {
+			annotation: kaitov1alpha1.TuningColocationAllow,
+			labels:     serving,
+			expected:   true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			workspace := test.MockWorkspaceWithPreset.DeepCopy()
+			if tc.annotation != "" {
+				workspace.Annotations = map[string]string{kaitov1alpha1.AnnotationTuningColocation: tc.annotation}
+			}
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: tc.labels}}
+			assert.Equal(t, TuningAllowedOnNode(workspace, node), tc.expected)
+		})
+	}
+}
+
+func TestValidateTuningColocation(t *testing.T) {
+	assert.NilError(t, ValidateTuningColocation(kaitov1alpha1.TuningColocationDeny))
+	assert.NilError(t, ValidateTuningColocation(kaitov1alpha1.TuningColocationPartitioned))
+	assert.Assert(t, ValidateTuningColocation("") != nil)
+	assert.Assert(t, ValidateTuningColocation("mps") != nil)
+}
+
+func TestInferenceNodeLabel(t *testing.T) {
+	ctx := context.Background()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"accelerator": "nvidia"}}}
+	kubeClient := fake.NewClientBuilder().WithObjects(node).Build()
+
+	assert.NilError(t, EnsureInferenceNodeLabel(ctx, node, kubeClient))
+	updated, err := GetNode(ctx, "node1", kubeClient)
+	assert.NilError(t, err)
+	assert.Equal(t, updated.Labels[kaitov1alpha1.LabelInferenceNode], "true")
+
+	assert.NilError(t, RemoveInferenceNodeLabel(ctx, "node1", kubeClient))
+	updated, err = GetNode(ctx, "node1", kubeClient)
+	assert.NilError(t, err)
+	_, ok := updated.Labels[kaitov1alpha1.LabelInferenceNode]
+	assert.Assert(t, !ok)
+	assert.Equal(t, updated.Labels["accelerator"], "nvidia")
+
+	// A deleted node is ignored.
+	assert.NilError(t, RemoveInferenceNodeLabel(ctx, "node2", kubeClient))
+}
@@ -0,0 +1,138 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DetectDrift returns the fields of the existing workload that differ from the desired one. The
+// container of the desired workload is compared with the existing container of the same name.
+// Fields defaulted by the API server are not compared, e.g., the resource requests not set by Kaito.
+// Only the replicas and the container fields listed by kaitov1alpha1.DriftFields are compared; the
+// rest of the pod template and the objects other than the workload, e.g., the Service, are not.
+func DetectDrift(existing, desired client.Object) []string {
+	var drifted []string
+	if !equality.Semantic.DeepEqual(replicasOf(existing), replicasOf(desired)) {
+		drifted = append(drifted, kaitov1alpha1.DriftFieldReplicas)
+	}
+	existingContainer, desiredContainer := driftContainers(existing, desired)
+	if existingContainer == nil || desiredContainer == nil {
+		return drifted
+	}
+	if existingContainer.Image != desiredContainer.Image {
+		drifted = append(drifted, kaitov1alpha1.DriftFieldImage)
+	}
+	if !equality.Semantic.DeepEqual(existingContainer.Command, desiredContainer.Command) ||
+		!equality.Semantic.DeepEqual(existingContainer.Args, desiredContainer.Args) {
+		drifted = append(drifted, kaitov1alpha1.DriftFieldCommand)
+	}
+	if !equality.Semantic.DeepEqual(plainEnv(existingContainer.Env), plainEnv(desiredContainer.Env)) {
+		drifted = append(drifted, kaitov1alpha1.DriftFieldEnv)
+	}
+	if resourcesDrifted(existingContainer.Resources, desiredContainer.Resources) {
+		drifted = append(drifted, kaitov1alpha1.DriftFieldResources)
+	}
+	return drifted
+}
+
+// RestoreDrift sets the fields of the existing workload to the ones of the desired workload.
+func RestoreDrift(existing, desired client.Object, fields []string) {
+	existingContainer, desiredContainer := driftContainers(existing, desired)
+	for _, field := range fields {
+		switch field {
+		case kaitov1alpha1.DriftFieldReplicas:
+			setReplicas(existing, replicasOf(desired))
+		case kaitov1alpha1.DriftFieldImage:
+			if existingContainer != nil && desiredContainer != nil {
+				existingContainer.Image = desiredContainer.Image
+			}
+		case kaitov1alpha1.DriftFieldCommand:
+			if existingContainer != nil && desiredContainer != nil {
+				existingContainer.Command = desiredContainer.Command
+				existingContainer.Args = desiredContainer.Args
+			}
+		case kaitov1alpha1.DriftFieldEnv:
+			if existingContainer != nil && desiredContainer != nil {
+				existingContainer.Env = desiredContainer.Env
+			}
+		case kaitov1alpha1.DriftFieldResources:
+			if existingContainer != nil && desiredContainer != nil {
+				existingContainer.Resources = desiredContainer.Resources
+			}
+		}
+	}
+}
+
+// driftContainers returns the first container of the desired workload and the existing container
+// of the same name.
+func driftContainers(existing, desired client.Object) (*corev1.Container, *corev1.Container) {
+	existingTemplate, desiredTemplate := PodTemplateOf(existing), PodTemplateOf(desired)
+	if existingTemplate == nil || desiredTemplate == nil || len(desiredTemplate.Spec.Containers) == 0 {
+		return nil, nil
+	}
+	desiredContainer := &desiredTemplate.Spec.Containers[0]
+	for i := range existingTemplate.Spec.Containers {
+		if existingTemplate.Spec.Containers[i].Name == desiredContainer.Name {
+			return &existingTemplate.Spec.Containers[i], desiredContainer
+		}
+	}
+	return nil, desiredContainer
+}
+
+func replicasOf(obj client.Object) *int32 {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		return o.Spec.Replicas
+	case *appsv1.StatefulSet:
+		return o.Spec.Replicas
+	}
+	return nil
+}
+
+func setReplicas(obj client.Object, replicas *int32) {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		o.Spec.Replicas = replicas
+	case *appsv1.StatefulSet:
+		o.Spec.Replicas = replicas
+	}
+}
+
+// plainEnv returns the env vars with a plain value. The sources of the others are defaulted by the
+// API server.
+func plainEnv(env []corev1.EnvVar) map[string]string {
+	values := map[string]string{}
+	for _, e := range env {
+		if e.ValueFrom == nil {
+			values[e.Name] = e.Value
+		}
+	}
+	return values
+}
+
+// resourcesDrifted compares the limits and the requests set in the desired resources. The API
+// server defaults the requests of the resources with a limit only.
+func resourcesDrifted(existing, desired corev1.ResourceRequirements) bool {
+	if !equality.Semantic.DeepEqual(nonEmpty(existing.Limits), nonEmpty(desired.Limits)) {
+		return true
+	}
+	for name, quantity := range desired.Requests {
+		if existingQuantity, ok := existing.Requests[name]; !ok || existingQuantity.Cmp(quantity) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func nonEmpty(list corev1.ResourceList) corev1.ResourceList {
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
@@ -0,0 +1,85 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	"testing"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"gotest.tools/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func driftTestDeployment(replicas int32, image string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:    "testWorkspace",
+						Image:   image,
+						Command: []string{"/bin/sh", "-c", "python3 inference_api.py"},
+						Env: []corev1.EnvVar{
+							{Name: "PORT", Value: "5000"},
+						},
+						Resources: corev1.ResourceRequirements{
+							Limits: corev1.ResourceList{
+								"nvidia.com/gpu": resource.MustParse("1"),
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func TestDetectDrift(t *testing.T) {
+	testcases := map[string]struct {
+		mutate   func(existing *appsv1.Deployment)
+		expected []string
+	}{
+		"No drift": {
+			mutate: func(existing *appsv1.Deployment) {},
+		},
+		"Fields defaulted by the API server": {
+			mutate: func(existing *appsv1.Deployment) {
+				container := &existing.Spec.Template.Spec.Containers[0]
+				container.Resources.Requests = corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}
+				container.Env = append(container.Env, corev1.EnvVar{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "metadata.name"},
+				}})
+				container.TerminationMessagePath = corev1.TerminationMessagePathDefault
+			},
+		},
+		"Edited by hand": {
+			mutate: func(existing *appsv1.Deployment) {
+				replicas := int32(3)
+				existing.Spec.Replicas = &replicas
+				container := &existing.Spec.Template.Spec.Containers[0]
+				container.Image = "custom/image:latest"
+				container.Env[0].Value = "8000"
+				container.Resources.Limits["nvidia.com/gpu"] = resource.MustParse("2")
+			},
+			expected: []string{kaitov1alpha1.DriftFieldReplicas, kaitov1alpha1.DriftFieldImage, kaitov1alpha1.DriftFieldEnv, kaitov1alpha1.DriftFieldResources},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			desired := driftTestDeployment(1, "mcr.microsoft.com/aks/kaito/kaito-falcon-7b:0.0.4")
+			existing := desired.DeepCopy()
+			tc.mutate(existing)
+
+			drifted := DetectDrift(existing, desired)
+			assert.DeepEqual(t, drifted, tc.expected)
+
+			RestoreDrift(existing, desired, drifted)
+			assert.Equal(t, len(DetectDrift(existing, desired)), 0)
+		})
+	}
+}
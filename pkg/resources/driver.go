@@ -0,0 +1,81 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The labels of the NVIDIA driver version set on the GPU nodes by GPU feature discovery.
+const (
+	LabelCUDADriverMajor = "nvidia.com/cuda.driver.major"
+	LabelCUDADriverMinor = "nvidia.com/cuda.driver.minor"
+	LabelCUDADriverRev   = "nvidia.com/cuda.driver.rev"
+)
+
+// NodeDriverVersion returns the NVIDIA driver version of the node, e.g., "535.104.05", from the
+// labels of GPU feature discovery. It returns false if the node is not labeled.
+func NodeDriverVersion(nodeObj *corev1.Node) (string, bool) {
+	major, ok := nodeObj.Labels[LabelCUDADriverMajor]
+	if !ok {
+		return "", false
+	}
+	version := major
+	for _, label := range []string{LabelCUDADriverMinor, LabelCUDADriverRev} {
+		part, ok := nodeObj.Labels[label]
+		if !ok || part == "" {
+			break
+		}
+		version += "." + part
+	}
+	return version, true
+}
+
+// CheckDriverVersion returns an error if the NVIDIA driver of the node is older than minVersion.
+// Nodes whose driver version is unknown pass the check.
+func CheckDriverVersion(nodeObj *corev1.Node, minVersion string) error {
+	if minVersion == "" {
+		return nil
+	}
+	version, ok := NodeDriverVersion(nodeObj)
+	if !ok {
+		return nil
+	}
+	older, err := versionLess(version, minVersion)
+	if err != nil {
+		return fmt.Errorf("invalid NVIDIA driver version of node %s: %w", nodeObj.Name, err)
+	}
+	if older {
+		return fmt.Errorf("node %s has NVIDIA driver %s, the preset image requires at least %s", nodeObj.Name, version, minVersion)
+	}
+	return nil
+}
+
+// versionLess compares dotted numeric versions, missing parts being zero. The revisions of the
+// NVIDIA drivers are compared as numbers, e.g., "05" equals "5".
+func versionLess(a, b string) (bool, error) {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var x, y int
+		var err error
+		if i < len(aParts) {
+			if x, err = strconv.Atoi(aParts[i]); err != nil {
+				return false, fmt.Errorf("%q is not a numeric version", a)
+			}
+		}
+		if i < len(bParts) {
+			if y, err = strconv.Atoi(bParts[i]); err != nil {
+				return false, fmt.Errorf("%q is not a numeric version", b)
+			}
+		}
+		if x != y {
+			return x < y, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,59 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckDriverVersion(t *testing.T) {
+	testcases := map[string]struct {
+		labels     map[string]string
+		minVersion string
+		expectErr  bool
+	}{
+		"No minimum version": {
+			labels: map[string]string{LabelCUDADriverMajor: "470"},
+		},
+		"Unknown driver version": {
+			minVersion: "525.60.13",
+		},
+		"Newer driver": {
+			labels:     map[string]string{LabelCUDADriverMajor: "535", LabelCUDADriverMinor: "104", LabelCUDADriverRev: "05"},
+			minVersion: "525.60.13",
+		},
+		"Same driver": {
+			labels:     map[string]string{LabelCUDADriverMajor: "525", LabelCUDADriverMinor: "60", LabelCUDADriverRev: "13"},
+			minVersion: "525.60.13",
+		},
+		"Older revision": {
+			labels:     map[string]string{LabelCUDADriverMajor: "525", LabelCUDADriverMinor: "60", LabelCUDADriverRev: "05"},
+			minVersion: "525.60.13",
+			expectErr:  true,
+		},
+		"Older major version": {
+			labels:     map[string]string{LabelCUDADriverMajor: "470", LabelCUDADriverMinor: "82"},
+			minVersion: "525",
+			expectErr:  true,
+		},
+		"Invalid driver version": {
+			labels:     map[string]string{LabelCUDADriverMajor: "latest"},
+			minVersion: "525",
+			expectErr:  true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			nodeObj := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", Labels: tc.labels}}
+			err := CheckDriverVersion(nodeObj, tc.minVersion)
+			if (err != nil) != tc.expectErr {
+				t.Errorf("CheckDriverVersion() error = %v, expected error %t", err, tc.expectErr)
+			}
+		})
+	}
+}
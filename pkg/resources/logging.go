@@ -0,0 +1,35 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// loggingEnv maps the logging annotations of the workspace to the env vars read by the preset
+// inference service.
+var loggingEnv = []struct {
+	annotation string
+	env        string
+}{
+	{kaitov1alpha1.AnnotationInferenceLogLevel, "LOG_LEVEL"},
+	{kaitov1alpha1.AnnotationInferenceLogFormat, "LOG_FORMAT"},
+	{kaitov1alpha1.AnnotationRequestLogging, "REQUEST_LOGGING"},
+}
+
+// ConfigureLogging sets the logging env of the preset inference service on the containers of the pod
+// template from the annotations of the workspace. Env vars already set on a container are kept.
+func ConfigureLogging(template *corev1.PodTemplateSpec, workspaceObj *kaitov1alpha1.Workspace) {
+	for i := range template.Spec.Containers {
+		container := &template.Spec.Containers[i]
+		for _, l := range loggingEnv {
+			value, ok := workspaceObj.Annotations[l.annotation]
+			if !ok || hasEnv(container.Env, l.env) {
+				continue
+			}
+			container.Env = append(container.Env, corev1.EnvVar{Name: l.env, Value: value})
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	"testing"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/utils/test"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestConfigureLogging(t *testing.T) {
+	testcases := map[string]struct {
+		annotations map[string]string
+		env         []corev1.EnvVar
+		expectedEnv []corev1.EnvVar
+	}{
+		"No logging annotations": {
+			annotations: map[string]string{kaitov1alpha1.AnnotationRDMA: kaitov1alpha1.RDMAEnabled},
+		},
+		"All logging annotations": {
+			annotations: map[string]string{
+				kaitov1alpha1.AnnotationInferenceLogLevel:  "debug",
+				kaitov1alpha1.AnnotationInferenceLogFormat: "json",
+				kaitov1alpha1.AnnotationRequestLogging:     "none",
+			},
+			expectedEnv: []corev1.EnvVar{
+				{Name: "LOG_LEVEL", Value: "debug"},
+				{Name: "LOG_FORMAT", Value: "json"},
+				{Name: "REQUEST_LOGGING", Value: "none"},
+			},
+		},
+		"Env set on the container is kept": {
+			annotations: map[string]string{kaitov1alpha1.AnnotationInferenceLogLevel: "debug"},
+			env:         []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "error"}},
+			expectedEnv: []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "error"}},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			workspace := test.MockWorkspaceWithPreset.DeepCopy()
+			workspace.Annotations = tc.annotations
+			template := &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "inference", Env: tc.env}},
+				},
+			}
+			ConfigureLogging(template, workspace)
+			assert.DeepEqual(t, template.Spec.Containers[0].Env, tc.expectedEnv)
+		})
+	}
+}
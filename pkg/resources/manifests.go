@@ -6,6 +6,7 @@ package resources
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/utils/pointer"
@@ -57,6 +58,15 @@ func GenerateHeadlessServiceManifest(ctx context.Context, workspaceObj *kaitov1a
 	}
 }
 
+// ServiceType returns the type of the service of the workspace: LoadBalancer if the workspace is annotated
+// with kaito.sh/enablelb=True, ClusterIP otherwise.
+func ServiceType(workspaceObj *kaitov1alpha1.Workspace) corev1.ServiceType {
+	if workspaceObj.Annotations[kaitov1alpha1.AnnotationEnableLB] == "True" {
+		return corev1.ServiceTypeLoadBalancer
+	}
+	return corev1.ServiceTypeClusterIP
+}
+
 func GenerateServiceManifest(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, serviceType corev1.ServiceType, isStatefulSet bool) *corev1.Service {
 	selector := map[string]string{
 		kaitov1alpha1.LabelWorkspaceName: workspaceObj.Name,
@@ -107,19 +117,38 @@ func GenerateServiceManifest(ctx context.Context, workspaceObj *kaitov1alpha1.Wo
 	}
 }
 
-func GenerateStatefulSetManifest(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, imageName string,
-	imagePullSecretRefs []corev1.LocalObjectReference, replicas int, commands []string, containerPorts []corev1.ContainerPort,
-	livenessProbe, readinessProbe *corev1.Probe, resourceRequirements corev1.ResourceRequirements,
-	tolerations []corev1.Toleration, volumes []corev1.Volume, volumeMount []corev1.VolumeMount) *appsv1.StatefulSet {
-
-	nodeRequirements := make([]corev1.NodeSelectorRequirement, 0, len(workspaceObj.Resource.LabelSelector.MatchLabels))
-	for key, value := range workspaceObj.Resource.LabelSelector.MatchLabels {
+// labelSelectorRequirements returns the node affinity requirements matching the label selector of the
+// workspace: its match labels, ordered by label so that the generated workloads are stable, followed by
+// its match expressions. The operators of label selectors are also valid node selector operators.
+func labelSelectorRequirements(workspaceObj *kaitov1alpha1.Workspace) []corev1.NodeSelectorRequirement {
+	labelSelector := workspaceObj.Resource.LabelSelector
+	matchLabels := labelSelector.MatchLabels
+	keys := lo.Keys(matchLabels)
+	sort.Strings(keys)
+	nodeRequirements := make([]corev1.NodeSelectorRequirement, 0, len(keys)+len(labelSelector.MatchExpressions))
+	for _, key := range keys {
 		nodeRequirements = append(nodeRequirements, corev1.NodeSelectorRequirement{
 			Key:      key,
 			Operator: corev1.NodeSelectorOpIn,
-			Values:   []string{value},
+			Values:   []string{matchLabels[key]},
+		})
+	}
+	for _, expression := range labelSelector.MatchExpressions {
+		nodeRequirements = append(nodeRequirements, corev1.NodeSelectorRequirement{
+			Key:      expression.Key,
+			Operator: corev1.NodeSelectorOperator(expression.Operator),
+			Values:   append([]string(nil), expression.Values...),
 		})
 	}
+	return nodeRequirements
+}
+
+func GenerateStatefulSetManifest(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, imageName string,
+	imagePullSecretRefs []corev1.LocalObjectReference, replicas int, commands []string, containerPorts []corev1.ContainerPort,
+	livenessProbe, readinessProbe *corev1.Probe, resourceRequirements corev1.ResourceRequirements,
+	tolerations []corev1.Toleration, volumes []corev1.Volume, volumeMount []corev1.VolumeMount) *appsv1.StatefulSet {
+
+	nodeRequirements := labelSelectorRequirements(workspaceObj)
 
 	selector := map[string]string{
 		kaitov1alpha1.LabelWorkspaceName: workspaceObj.Name,
@@ -255,14 +284,7 @@ func GenerateDeploymentManifest(ctx context.Context, workspaceObj *kaitov1alpha1
 	livenessProbe, readinessProbe *corev1.Probe, resourceRequirements corev1.ResourceRequirements,
 	tolerations []corev1.Toleration, volumes []corev1.Volume, volumeMount []corev1.VolumeMount) *appsv1.Deployment {
 
-	nodeRequirements := make([]corev1.NodeSelectorRequirement, 0, len(workspaceObj.Resource.LabelSelector.MatchLabels))
-	for key, value := range workspaceObj.Resource.LabelSelector.MatchLabels {
-		nodeRequirements = append(nodeRequirements, corev1.NodeSelectorRequirement{
-			Key:      key,
-			Operator: corev1.NodeSelectorOpIn,
-			Values:   []string{value},
-		})
-	}
+	nodeRequirements := labelSelectorRequirements(workspaceObj)
 
 	selector := map[string]string{
 		kaitov1alpha1.LabelWorkspaceName: workspaceObj.Name,
@@ -348,14 +370,7 @@ func GenerateDeploymentManifest(ctx context.Context, workspaceObj *kaitov1alpha1
 }
 
 func GenerateDeploymentManifestWithPodTemplate(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, tolerations []corev1.Toleration) *appsv1.Deployment {
-	nodeRequirements := make([]corev1.NodeSelectorRequirement, 0, len(workspaceObj.Resource.LabelSelector.MatchLabels))
-	for key, value := range workspaceObj.Resource.LabelSelector.MatchLabels {
-		nodeRequirements = append(nodeRequirements, corev1.NodeSelectorRequirement{
-			Key:      key,
-			Operator: corev1.NodeSelectorOpIn,
-			Values:   []string{value},
-		})
-	}
+	nodeRequirements := labelSelectorRequirements(workspaceObj)
 
 	templateCopy := workspaceObj.Inference.Template.DeepCopy()
 
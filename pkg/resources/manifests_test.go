@@ -13,6 +13,7 @@ import (
 
 	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestGenerateStatefulSetManifest(t *testing.T) {
@@ -178,3 +179,75 @@ func TestGenerateHeadlessServiceManifest(t *testing.T) {
 		}
 	})
 }
+
+func TestGenerateLocalNVMeSetupManifest(t *testing.T) {
+	workspace := test.MockWorkspaceWithPreset
+
+	obj := GenerateLocalNVMeSetupManifest(context.TODO(), workspace)
+
+	if obj.Name != fmt.Sprintf("%s-nvme-setup", workspace.Name) {
+		t.Errorf("daemonset name is wrong")
+	}
+	if !reflect.DeepEqual(obj.Spec.Selector.MatchLabels, obj.Spec.Template.ObjectMeta.Labels) {
+		t.Errorf("template label is wrong")
+	}
+	if len(obj.Spec.Template.Spec.InitContainers) != 1 || !*obj.Spec.Template.Spec.InitContainers[0].SecurityContext.Privileged {
+		t.Errorf("setup container is wrong")
+	}
+
+	nodeReq := obj.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions
+	for key, value := range workspace.Resource.LabelSelector.MatchLabels {
+		if !kvInNodeRequirement(key, value, nodeReq) {
+			t.Errorf("nodel affinity is wrong")
+		}
+	}
+
+	// The setup only runs on the nodes matching the match expressions of the workspace too.
+	workspace = workspace.DeepCopy()
+	workspace.Resource.LabelSelector.MatchExpressions = []metav1.LabelSelectorRequirement{
+		{Key: "kubernetes.azure.com/agentpool", Operator: metav1.LabelSelectorOpIn, Values: []string{"gpu"}},
+	}
+	obj = GenerateLocalNVMeSetupManifest(context.TODO(), workspace)
+	nodeReq = obj.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions
+	if !kvInNodeRequirement("kubernetes.azure.com/agentpool", "gpu", nodeReq) {
+		t.Errorf("node affinity %v does not match the expressions of the label selector", nodeReq)
+	}
+}
+
+func TestLabelSelectorRequirements(t *testing.T) {
+	workspace := test.MockWorkspaceWithPreset.DeepCopy()
+	workspace.Resource.LabelSelector.MatchLabels = map[string]string{"c": "3", "a": "1", "b": "2", "d": "4"}
+
+	expected := []v1.NodeSelectorRequirement{
+		{Key: "a", Operator: v1.NodeSelectorOpIn, Values: []string{"1"}},
+		{Key: "b", Operator: v1.NodeSelectorOpIn, Values: []string{"2"}},
+		{Key: "c", Operator: v1.NodeSelectorOpIn, Values: []string{"3"}},
+		{Key: "d", Operator: v1.NodeSelectorOpIn, Values: []string{"4"}},
+	}
+	// The iteration order of maps is random, the requirements must not depend on it.
+	for i := 0; i < 10; i++ {
+		if requirements := labelSelectorRequirements(workspace); !reflect.DeepEqual(requirements, expected) {
+			t.Fatalf("expected requirements %v, got %v", expected, requirements)
+		}
+	}
+}
+
+func TestLabelSelectorRequirementsWithExpressions(t *testing.T) {
+	workspace := test.MockWorkspaceWithPreset.DeepCopy()
+	workspace.Resource.LabelSelector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{"apps": "test"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "zone", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"eastus-1"}},
+			{Key: "gpu", Operator: metav1.LabelSelectorOpExists},
+		},
+	}
+
+	expected := []v1.NodeSelectorRequirement{
+		{Key: "apps", Operator: v1.NodeSelectorOpIn, Values: []string{"test"}},
+		{Key: "zone", Operator: v1.NodeSelectorOpNotIn, Values: []string{"eastus-1"}},
+		{Key: "gpu", Operator: v1.NodeSelectorOpExists},
+	}
+	if requirements := labelSelectorRequirements(workspace); !reflect.DeepEqual(requirements, expected) {
+		t.Fatalf("expected requirements %v, got %v", expected, requirements)
+	}
+}
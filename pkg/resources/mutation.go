@@ -0,0 +1,144 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// WorkloadMutation is the mutation applied to the pods of all the workloads generated by Kaito, e.g.,
+// to tolerate the taints of the cluster or to pull the images from an internal mirror. It is set by
+// the cluster admin in the operator configuration.
+type WorkloadMutation struct {
+	// Labels are added to the pods. Labels set by Kaito are kept.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations are added to the pods. Annotations set by Kaito are kept.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Tolerations are added to the pods.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// Env is added to the containers. Env vars set by Kaito are kept.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// ImageRewrites rewrite the image references of the containers. The rewrite with the longest
+	// matching prefix applies, see RewriteImage.
+	ImageRewrites []ImageRewrite `json:"imageRewrites,omitempty"`
+}
+
+// ImageRewrite replaces the From prefix of an image reference with To, e.g., "mcr.microsoft.com/"
+// with "mirror.example.com/mcr/".
+type ImageRewrite struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// GlobalWorkloadMutation is the mutation applied to all the workloads, if set.
+var GlobalWorkloadMutation *WorkloadMutation
+
+// LoadWorkloadMutation reads a workload mutation from a YAML or JSON file.
+func LoadWorkloadMutation(path string) (*WorkloadMutation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	mutation := &WorkloadMutation{}
+	if err := yaml.UnmarshalStrict(data, mutation); err != nil {
+		return nil, fmt.Errorf("invalid workload mutation %s: %w", path, err)
+	}
+	for _, rewrite := range mutation.ImageRewrites {
+		if rewrite.From == "" {
+			return nil, fmt.Errorf("invalid workload mutation %s: image rewrite with an empty prefix", path)
+		}
+	}
+	return mutation, nil
+}
+
+// RewriteImage returns the image reference rewritten by the image rewrite with the longest matching
+// prefix. Of the rewrites with the same prefix, the first one applies.
+func (m *WorkloadMutation) RewriteImage(image string) string {
+	if m == nil {
+		return image
+	}
+	var match *ImageRewrite
+	for i, rewrite := range m.ImageRewrites {
+		if strings.HasPrefix(image, rewrite.From) && (match == nil || len(rewrite.From) > len(match.From)) {
+			match = &m.ImageRewrites[i]
+		}
+	}
+	if match == nil {
+		return image
+	}
+	return match.To + strings.TrimPrefix(image, match.From)
+}
+
+// Apply mutates the pod template.
+func (m *WorkloadMutation) Apply(template *corev1.PodTemplateSpec) {
+	if m == nil || template == nil {
+		return
+	}
+	template.Labels = mergeMissing(template.Labels, m.Labels)
+	template.Annotations = mergeMissing(template.Annotations, m.Annotations)
+	template.Spec.Tolerations = append(template.Spec.Tolerations, m.Tolerations...)
+	mutateContainers := func(containers []corev1.Container) {
+		for i := range containers {
+			containers[i].Image = m.RewriteImage(containers[i].Image)
+			for _, env := range m.Env {
+				if !hasEnv(containers[i].Env, env.Name) {
+					containers[i].Env = append(containers[i].Env, env)
+				}
+			}
+		}
+	}
+	mutateContainers(template.Spec.InitContainers)
+	mutateContainers(template.Spec.Containers)
+}
+
+// ApplyWorkloadMutation applies the global workload mutation to the pod template. Its images are
+// rewritten by the preset image mirrors and the image rewrites of the global workload mutation, see
+// imageRewrites.
+func ApplyWorkloadMutation(template *corev1.PodTemplateSpec) {
+	mutation := WorkloadMutation{}
+	if GlobalWorkloadMutation != nil {
+		mutation = *GlobalWorkloadMutation
+	}
+	mutation.ImageRewrites = imageRewrites()
+	mutation.Apply(template)
+}
+
+// RewriteImage returns the image reference rewritten as in the workloads, see ApplyWorkloadMutation.
+func RewriteImage(image string) string {
+	return (&WorkloadMutation{ImageRewrites: imageRewrites()}).RewriteImage(image)
+}
+
+// imageRewrites returns the image rewrites applied to the workloads: the preset image mirrors, followed
+// by the image rewrites of the global workload mutation. The longest matching prefix applies, so the
+// preset image mirrors only win over a workload mutation rewrite of the very same prefix.
+func imageRewrites() []ImageRewrite {
+	var rewrites []ImageRewrite
+	for from, to := range presetImageMirrors() {
+		rewrites = append(rewrites, ImageRewrite{From: from, To: to})
+	}
+	if GlobalWorkloadMutation != nil {
+		rewrites = append(rewrites, GlobalWorkloadMutation.ImageRewrites...)
+	}
+	return rewrites
+}
+
+func mergeMissing(dst, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = map[string]string{}
+	}
+	for k, v := range src {
+		if _, ok := dst[k]; !ok {
+			dst[k] = v
+		}
+	}
+	return dst
+}
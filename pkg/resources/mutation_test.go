@@ -0,0 +1,81 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLoadWorkloadMutation(t *testing.T) {
+	testcases := map[string]struct {
+		content     string
+		expectedErr bool
+	}{
+		"Valid mutation": {
+			content: "labels:\n  team: ml\nimageRewrites:\n- from: mcr.microsoft.com/\n  to: mirror.example.com/mcr/\n",
+		},
+		"Unknown field": {
+			content:     "label:\n  team: ml\n",
+			expectedErr: true,
+		},
+		"Image rewrite with an empty prefix": {
+			content:     "imageRewrites:\n- to: mirror.example.com/\n",
+			expectedErr: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "mutation.yaml")
+			assert.NilError(t, os.WriteFile(path, []byte(tc.content), 0600))
+
+			_, err := LoadWorkloadMutation(path)
+			assert.Equal(t, tc.expectedErr, err != nil)
+		})
+	}
+}
+
+func TestWorkloadMutationApply(t *testing.T) {
+	mutation := &WorkloadMutation{
+		Labels:      map[string]string{"team": "ml", "app": "other"},
+		Tolerations: []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+		Env:         []corev1.EnvVar{{Name: "HTTPS_PROXY", Value: "http://proxy:3128"}, {Name: "PORT", Value: "0"}},
+		ImageRewrites: []ImageRewrite{
+			{From: "mcr.microsoft.com/", To: "mirror.example.com/mcr/"},
+			{From: "mcr.microsoft.com/aks/", To: "mirror.example.com/aks/"},
+		},
+	}
+	template := &corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "testWorkspace"}},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init", Image: "mcr.microsoft.com/cbl-mariner/busybox:2.0"}},
+			Containers: []corev1.Container{{
+				Name:  "main",
+				Image: "mcr.microsoft.com/aks/kaito/kaito-falcon-7b:0.0.4",
+				Env:   []corev1.EnvVar{{Name: "PORT", Value: "5000"}},
+			}},
+		},
+	}
+
+	mutation.Apply(template)
+
+	assert.DeepEqual(t, template.Labels, map[string]string{"app": "testWorkspace", "team": "ml"})
+	assert.DeepEqual(t, template.Spec.Tolerations, mutation.Tolerations)
+	assert.Equal(t, template.Spec.InitContainers[0].Image, "mirror.example.com/mcr/cbl-mariner/busybox:2.0")
+	assert.Equal(t, template.Spec.Containers[0].Image, "mirror.example.com/aks/kaito/kaito-falcon-7b:0.0.4")
+	assert.DeepEqual(t, template.Spec.Containers[0].Env, []corev1.EnvVar{
+		{Name: "PORT", Value: "5000"},
+		{Name: "HTTPS_PROXY", Value: "http://proxy:3128"},
+	})
+
+	var unset *WorkloadMutation
+	unset.Apply(template)
+	assert.Equal(t, unset.RewriteImage("mcr.microsoft.com/a:b"), "mcr.microsoft.com/a:b")
+}
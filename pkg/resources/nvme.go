@@ -0,0 +1,152 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	"context"
+	"fmt"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/featuregates"
+	"github.com/azure/kaito/pkg/utils"
+	"github.com/azure/kaito/pkg/utils/consts"
+	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// UtilityImage is the image of the helper containers created by Kaito, e.g., the local NVMe setup
+	// whose script runs in the host mount namespace with the host mdadm and mkfs.
+	UtilityImage = "mcr.microsoft.com/cbl-mariner/busybox:2.0"
+
+	// localNVMeSetupScript stripes the local NVMe disks of the node into a RAID 0 array, formats it and
+	// mounts it at the model cache path. A single disk is formatted and mounted as is. Network attached
+	// NVMe disks, e.g., EBS volumes, are skipped. The script is idempotent.
+	localNVMeSetupScript = `set -eu
+MOUNT_PATH=%s
+if mountpoint -q "$MOUNT_PATH"; then
+  echo "$MOUNT_PATH is already mounted"
+  exit 0
+fi
+DISKS=""
+for dev in /sys/block/nvme*n1; do
+  [ -e "$dev" ] || continue
+  if grep -q "Elastic Block Store" "$dev/device/model" 2>/dev/null; then
+    continue
+  fi
+  DISKS="$DISKS /dev/$(basename "$dev")"
+done
+if [ -z "$DISKS" ]; then
+  echo "no local NVMe disks found"
+  exit 1
+fi
+set -- $DISKS
+if [ $# -gt 1 ]; then
+  DEVICE=/dev/md/kaito
+  if [ ! -e "$DEVICE" ]; then
+    mdadm --create "$DEVICE" --level=0 --raid-devices=$# --run "$@"
+  fi
+else
+  DEVICE=$1
+fi
+blkid "$DEVICE" >/dev/null 2>&1 || mkfs.ext4 -F "$DEVICE"
+mkdir -p "$MOUNT_PATH"
+mount "$DEVICE" "$MOUNT_PATH"
+echo "mounted $DEVICE at $MOUNT_PATH"
+`
+)
+
+// LocalNVMeEnabled returns whether the local NVMe disks of the workspace nodes are set up as the
+// model cache.
+func LocalNVMeEnabled(workspaceObj *kaitov1alpha1.Workspace) bool {
+	if !featuregates.FeatureGates[consts.FeatureFlagLocalNVMe] {
+		return false
+	}
+	gpuConfig, ok := kaitov1alpha1.SupportedGPUConfigs[workspaceObj.Resource.InstanceType]
+	return ok && gpuConfig.NVMeDiskCount > 0
+}
+
+// LocalNVMeSetupName returns the name of the local NVMe setup DaemonSet of the workspace.
+func LocalNVMeSetupName(workspaceObj *kaitov1alpha1.Workspace) string {
+	return fmt.Sprintf("%s-nvme-setup", workspaceObj.Name)
+}
+
+// GenerateLocalNVMeSetupManifest returns the DaemonSet that sets up the local NVMe disks of the
+// workspace nodes. The setup runs in a privileged init container so that the DaemonSet pod is
+// ready once the model cache path is mounted. It tolerates all taints, like the GPU taints of the nodes.
+func GenerateLocalNVMeSetupManifest(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace) *appsv1.DaemonSet {
+	nodeRequirements := labelSelectorRequirements(workspaceObj)
+
+	selector := map[string]string{
+		kaitov1alpha1.LabelWorkspaceName: workspaceObj.Name,
+		"app":                            LocalNVMeSetupName(workspaceObj),
+	}
+
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      LocalNVMeSetupName(workspaceObj),
+			Namespace: workspaceObj.Namespace,
+			OwnerReferences: []v1.OwnerReference{
+				{
+					APIVersion: kaitov1alpha1.GroupVersion.String(),
+					Kind:       "Workspace",
+					UID:        workspaceObj.UID,
+					Name:       workspaceObj.Name,
+					Controller: &controller,
+				},
+			},
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &v1.LabelSelector{
+				MatchLabels: selector,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: v1.ObjectMeta{
+					Labels: selector,
+				},
+				Spec: corev1.PodSpec{
+					HostPID: true,
+					Affinity: &corev1.Affinity{
+						NodeAffinity: &corev1.NodeAffinity{
+							RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+								NodeSelectorTerms: []corev1.NodeSelectorTerm{
+									{
+										MatchExpressions: nodeRequirements,
+									},
+								},
+							},
+						},
+					},
+					InitContainers: []corev1.Container{
+						{
+							Name:  "nvme-setup",
+							Image: UtilityImage,
+							Command: []string{"nsenter", "--target", "1", "--mount", "--", "/bin/sh", "-c",
+								fmt.Sprintf(localNVMeSetupScript, utils.DefaultModelCacheHostPath)},
+							SecurityContext: &corev1.SecurityContext{
+								Privileged: lo.ToPtr(true),
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    "pause",
+							Image:   UtilityImage,
+							Command: []string{"sleep", "infinity"},
+						},
+					},
+					Tolerations: []corev1.Toleration{
+						{
+							Operator: corev1.TolerationOpExists,
+						},
+					},
+				},
+			},
+		},
+	}
+	ApplyWorkloadMutation(&ds.Spec.Template)
+	return ds
+}
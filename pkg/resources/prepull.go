@@ -0,0 +1,72 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ImagePrePullName is the name of the DaemonSet pre-pulling the preset images on the GPU nodes.
+const ImagePrePullName = "kaito-image-prepull"
+
+// GenerateImagePrePullManifest returns the DaemonSet that pulls the images on the nvidia GPU nodes. Each
+// image is pulled by an init container that exits right away, so that the DaemonSet pod of a node is
+// ready once all the images are pulled on the node.
+func GenerateImagePrePullManifest(namespace string, images []string) *appsv1.DaemonSet {
+	selector := map[string]string{
+		"app": ImagePrePullName,
+	}
+
+	initContainers := make([]corev1.Container, 0, len(images))
+	for i, image := range images {
+		initContainers = append(initContainers, corev1.Container{
+			Name:            fmt.Sprintf("prepull-%d", i),
+			Image:           image,
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command:         []string{"/bin/sh", "-c", "exit 0"},
+		})
+	}
+
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      ImagePrePullName,
+			Namespace: namespace,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &v1.LabelSelector{
+				MatchLabels: selector,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: v1.ObjectMeta{
+					Labels: selector,
+				},
+				Spec: corev1.PodSpec{
+					NodeSelector: map[string]string{
+						LabelKeyNvidia: LabelValueNvidia,
+					},
+					ImagePullSecrets: PresetImagePullSecretRefs(),
+					InitContainers:   initContainers,
+					Containers: []corev1.Container{
+						{
+							Name:    "pause",
+							Image:   UtilityImage,
+							Command: []string{"sleep", "infinity"},
+						},
+					},
+					Tolerations: []corev1.Toleration{
+						{
+							Operator: corev1.TolerationOpExists,
+						},
+					},
+				},
+			},
+		},
+	}
+	ApplyWorkloadMutation(&ds.Spec.Template)
+	return ds
+}
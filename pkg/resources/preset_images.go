@@ -0,0 +1,114 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/azure/kaito/pkg/operatorconfig"
+	"github.com/azure/kaito/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PublicPresetRegistry is the public registry the preset images are published to.
+const PublicPresetRegistry = "mcr.microsoft.com/aks/kaito"
+
+// PresetImageMirrors maps registry prefixes of the public preset images, e.g.,
+// "mcr.microsoft.com/aks/kaito", to the prefixes of their private mirrors. They are image rewrites
+// of all the workloads, like those of the workload mutation, see imageRewrites.
+var PresetImageMirrors = map[string]string{}
+
+// PresetImagePullSecrets are the secrets in the release namespace used to pull the public preset
+// images, e.g., from a private mirror. They are copied to the namespaces of the workspaces.
+var PresetImagePullSecrets []string
+
+// PresetImageInternal reports whether the public image of the preset is pulled from an internal
+// registry: the preset registry is not the public one, or the image is pulled from a mirror.
+func PresetImageInternal(registryName, presetName string) bool {
+	image := RewriteImage(fmt.Sprintf("%s/kaito-%s", registryName, presetName))
+	return !strings.HasPrefix(image, PublicPresetRegistry+"/")
+}
+
+// PresetImagePullSecretRefs returns the references to the preset image pull secrets.
+func PresetImagePullSecretRefs() []corev1.LocalObjectReference {
+	secrets := presetImagePullSecrets()
+	refs := make([]corev1.LocalObjectReference, 0, len(secrets))
+	for _, name := range secrets {
+		refs = append(refs, corev1.LocalObjectReference{Name: name})
+	}
+	return refs
+}
+
+// EnsurePresetImagePullSecrets copies the preset image pull secrets from the release namespace to
+// the namespace, and refreshes the copies whose data or labels differ, e.g., after the secrets are
+// rotated. The secrets are read with uncached reads, see the secret cache options in cmd/main.go.
+func EnsurePresetImagePullSecrets(ctx context.Context, namespace string, kubeClient client.Client) error {
+	secrets := presetImagePullSecrets()
+	if len(secrets) == 0 {
+		return nil
+	}
+	releaseNamespace, err := utils.GetReleaseNamespace(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get release namespace: %v", err)
+	}
+	if namespace == releaseNamespace {
+		return nil
+	}
+	for _, name := range secrets {
+		secret := &corev1.Secret{}
+		if err := GetResource(ctx, name, releaseNamespace, kubeClient, secret); err != nil {
+			return fmt.Errorf("failed to get preset image pull secret %s from the release namespace: %v", name, err)
+		}
+
+		copied := &corev1.Secret{}
+		err := kubeClient.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, copied)
+		if err == nil {
+			if equality.Semantic.DeepEqual(copied.Data, secret.Data) && equality.Semantic.DeepEqual(copied.Labels, secret.Labels) {
+				continue
+			}
+			copied.Labels = secret.Labels
+			copied.Data = secret.Data
+			klog.InfoS("RefreshPresetImagePullSecret", "secret", klog.KObj(copied))
+			if err := kubeClient.Update(ctx, copied); err != nil {
+				return fmt.Errorf("failed to update preset image pull secret %s: %v", name, err)
+			}
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return err
+		}
+
+		copied = &corev1.Secret{}
+		copied.Name = name
+		copied.Namespace = namespace
+		copied.Labels = secret.Labels
+		copied.Type = secret.Type
+		copied.Data = secret.Data
+		klog.InfoS("CopyPresetImagePullSecret", "secret", klog.KObj(copied))
+		if err := kubeClient.Create(ctx, copied, &client.CreateOptions{}); client.IgnoreAlreadyExists(err) != nil {
+			return fmt.Errorf("failed to create preset image pull secret %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func presetImageMirrors() map[string]string {
+	if overrides := operatorconfig.Get().PresetImageMirrors; overrides != nil {
+		return overrides
+	}
+	return PresetImageMirrors
+}
+
+func presetImagePullSecrets() []string {
+	if overrides := operatorconfig.Get().PresetImagePullSecrets; overrides != nil {
+		return overrides
+	}
+	return PresetImagePullSecrets
+}
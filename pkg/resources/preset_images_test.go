@@ -0,0 +1,92 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/azure/kaito/pkg/utils"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRewriteImage(t *testing.T) {
+	PresetImageMirrors = map[string]string{
+		"mcr.microsoft.com/":           "mirror.example.com/mcr/",
+		"mcr.microsoft.com/aks/kaito/": "mirror.example.com/kaito/",
+	}
+	GlobalWorkloadMutation = &WorkloadMutation{ImageRewrites: []ImageRewrite{
+		{From: "mcr.microsoft.com/aks/", To: "other.example.com/aks/"},
+		{From: "mcr.microsoft.com/", To: "other.example.com/mcr/"},
+		{From: "docker.io/", To: "other.example.com/docker/"},
+	}}
+	defer func() {
+		PresetImageMirrors = map[string]string{}
+		GlobalWorkloadMutation = nil
+	}()
+
+	// The longest matching prefix applies, whether a preset image mirror or an image rewrite.
+	assert.Equal(t, RewriteImage("mcr.microsoft.com/aks/kaito/kaito-falcon-7b:0.0.4"), "mirror.example.com/kaito/kaito-falcon-7b:0.0.4")
+	assert.Equal(t, RewriteImage("mcr.microsoft.com/aks/other:1.0"), "other.example.com/aks/other:1.0")
+	// The preset image mirror wins over an image rewrite of the same prefix.
+	assert.Equal(t, RewriteImage("mcr.microsoft.com/oss/busybox:1.0"), "mirror.example.com/mcr/oss/busybox:1.0")
+	assert.Equal(t, RewriteImage("docker.io/library/busybox:1.0"), "other.example.com/docker/library/busybox:1.0")
+	assert.Equal(t, RewriteImage("ghcr.io/library/busybox:1.0"), "ghcr.io/library/busybox:1.0")
+
+	template := &corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "main", Image: "mcr.microsoft.com/aks/kaito/kaito-falcon-7b:0.0.4"}},
+	}}
+	ApplyWorkloadMutation(template)
+	assert.Equal(t, template.Spec.Containers[0].Image, "mirror.example.com/kaito/kaito-falcon-7b:0.0.4")
+}
+
+func TestPresetImageInternal(t *testing.T) {
+	assert.Equal(t, PresetImageInternal(PublicPresetRegistry, "falcon-7b"), false)
+	assert.Equal(t, PresetImageInternal("registry.internal/kaito", "falcon-7b"), true)
+
+	PresetImageMirrors = map[string]string{"mcr.microsoft.com/aks/kaito/": "mirror.example.com/kaito/"}
+	defer func() { PresetImageMirrors = map[string]string{} }()
+	assert.Equal(t, PresetImageInternal(PublicPresetRegistry, "falcon-7b"), true)
+}
+
+func TestEnsurePresetImagePullSecrets(t *testing.T) {
+	PresetImagePullSecrets = []string{"mirror-creds"}
+	utils.ReleaseNamespaceResolver.Override = "kaito"
+	defer func() {
+		PresetImagePullSecrets = nil
+		utils.ReleaseNamespaceResolver.Override = ""
+	}()
+
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mirror-creds", Namespace: "kaito"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte("{}")},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	ctx := context.Background()
+
+	assert.NilError(t, EnsurePresetImagePullSecrets(ctx, "default", c))
+	copied := &corev1.Secret{}
+	assert.NilError(t, c.Get(ctx, client.ObjectKey{Name: "mirror-creds", Namespace: "default"}, copied))
+	assert.Equal(t, copied.Type, secret.Type)
+	assert.DeepEqual(t, copied.Data, secret.Data)
+
+	// The secret already exists in the namespace.
+	assert.NilError(t, EnsurePresetImagePullSecrets(ctx, "default", c))
+
+	// The copy is refreshed once the secret is rotated.
+	secret.Data = map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{}}`)}
+	assert.NilError(t, c.Update(ctx, secret))
+	assert.NilError(t, EnsurePresetImagePullSecrets(ctx, "default", c))
+	assert.NilError(t, c.Get(ctx, client.ObjectKey{Name: "mirror-creds", Namespace: "default"}, copied))
+	assert.DeepEqual(t, copied.Data, secret.Data)
+	assert.DeepEqual(t, PresetImagePullSecretRefs(), []corev1.LocalObjectReference{{Name: "mirror-creds"}})
+}
@@ -0,0 +1,94 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// RDMADeviceResource is the extended resource of the RDMA devices, e.g., "rdma/ib", requested by the
+// pods on RDMA capable instance types. It depends on the RDMA device plugin of the cluster and is
+// not requested if empty.
+var RDMADeviceResource string
+
+// ncclRDMAEnv configures NCCL to communicate over InfiniBand between nodes.
+var ncclRDMAEnv = []corev1.EnvVar{
+	{Name: "NCCL_IB_DISABLE", Value: "0"},
+	{Name: "NCCL_IB_PCI_RELAXED_ORDERING", Value: "1"},
+	{Name: "NCCL_SOCKET_IFNAME", Value: "eth0"},
+	{Name: "UCX_IB_PCI_RELAXED_ORDERING", Value: "on"},
+	{Name: "CUDA_DEVICE_ORDER", Value: "PCI_BUS_ID"},
+}
+
+// RDMAEnabled returns whether the workload pods of the workspace are configured for RDMA. It is enabled
+// on RDMA capable instance types, unless overridden by the kaito.sh/rdma annotation.
+func RDMAEnabled(workspaceObj *kaitov1alpha1.Workspace) bool {
+	switch workspaceObj.Annotations[kaitov1alpha1.AnnotationRDMA] {
+	case kaitov1alpha1.RDMAEnabled:
+		return true
+	case kaitov1alpha1.RDMADisabled:
+		return false
+	}
+	gpuConfig, ok := kaitov1alpha1.SupportedGPUConfigs[workspaceObj.Resource.InstanceType]
+	return ok && gpuConfig.RDMA
+}
+
+// ConfigureRDMA sets the NCCL env, the IPC_LOCK capability required to register memory with the
+// InfiniBand devices and the RDMA device resource on the workload container of the pod template, the
+// one named after the workspace. Sidecars, e.g., the one pushing the tuning output, are left alone.
+// Env vars already set on the container are kept.
+func ConfigureRDMA(template *corev1.PodTemplateSpec, workspaceObj *kaitov1alpha1.Workspace) {
+	for i := range template.Spec.Containers {
+		container := &template.Spec.Containers[i]
+		if container.Name != workspaceObj.Name {
+			continue
+		}
+		for _, env := range ncclRDMAEnv {
+			if !hasEnv(container.Env, env.Name) {
+				container.Env = append(container.Env, env)
+			}
+		}
+
+		if container.SecurityContext == nil {
+			container.SecurityContext = &corev1.SecurityContext{}
+		}
+		if container.SecurityContext.Capabilities == nil {
+			container.SecurityContext.Capabilities = &corev1.Capabilities{}
+		}
+		if !hasCapability(container.SecurityContext.Capabilities.Add, "IPC_LOCK") {
+			container.SecurityContext.Capabilities.Add = append(container.SecurityContext.Capabilities.Add, "IPC_LOCK")
+		}
+
+		if RDMADeviceResource != "" {
+			if container.Resources.Requests == nil {
+				container.Resources.Requests = corev1.ResourceList{}
+			}
+			if container.Resources.Limits == nil {
+				container.Resources.Limits = corev1.ResourceList{}
+			}
+			container.Resources.Requests[corev1.ResourceName(RDMADeviceResource)] = resource.MustParse("1")
+			container.Resources.Limits[corev1.ResourceName(RDMADeviceResource)] = resource.MustParse("1")
+		}
+	}
+}
+
+func hasEnv(env []corev1.EnvVar, name string) bool {
+	for _, e := range env {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasCapability(capabilities []corev1.Capability, capability corev1.Capability) bool {
+	for _, c := range capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
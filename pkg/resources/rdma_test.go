@@ -0,0 +1,83 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	"testing"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/utils/test"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRDMAEnabled(t *testing.T) {
+	testcases := map[string]struct {
+		instanceType string
+		annotation   string
+		expected     bool
+	}{
+		"RDMA capable instance type": {
+			instanceType: "Standard_ND96asr_v4",
+			expected:     true,
+		},
+		"Instance type without RDMA": {
+			instanceType: "Standard_NC12s_v3",
+		},
+		"Disabled by annotation": {
+			instanceType: "Standard_ND96asr_v4",
+			annotation:   kaitov1alpha1.RDMADisabled,
+		},
+		"Enabled by annotation": {
+			instanceType: "Standard_NC12s_v3",
+			annotation:   kaitov1alpha1.RDMAEnabled,
+			expected:     true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			workspace := test.MockWorkspaceWithPreset.DeepCopy()
+			workspace.Resource.InstanceType = tc.instanceType
+			if tc.annotation != "" {
+				workspace.Annotations = map[string]string{kaitov1alpha1.AnnotationRDMA: tc.annotation}
+			}
+			assert.Equal(t, RDMAEnabled(workspace), tc.expected)
+		})
+	}
+}
+
+func TestConfigureRDMA(t *testing.T) {
+	RDMADeviceResource = "rdma/ib"
+	defer func() { RDMADeviceResource = "" }()
+
+	workspace := test.MockWorkspaceWithPreset.DeepCopy()
+	template := &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: workspace.Name,
+					Env:  []corev1.EnvVar{{Name: "NCCL_SOCKET_IFNAME", Value: "ib0"}},
+				},
+				{
+					Name: "docker-sidecar",
+				},
+			},
+		},
+	}
+	ConfigureRDMA(template, workspace)
+	ConfigureRDMA(template, workspace)
+
+	container := template.Spec.Containers[0]
+	assert.Equal(t, len(container.Env), len(ncclRDMAEnv))
+	assert.Equal(t, container.Env[0].Value, "ib0")
+	assert.DeepEqual(t, container.SecurityContext.Capabilities.Add, []corev1.Capability{"IPC_LOCK"})
+	assert.Equal(t, container.Resources.Limits.Name("rdma/ib", "").String(), "1")
+
+	// The sidecar neither gets the capability nor requests an RDMA device.
+	sidecar := template.Spec.Containers[1]
+	assert.Assert(t, sidecar.SecurityContext == nil)
+	assert.Assert(t, sidecar.Resources.Limits == nil)
+	assert.Assert(t, sidecar.Env == nil)
+}
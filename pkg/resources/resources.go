@@ -4,12 +4,17 @@ package resources
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -21,10 +26,14 @@ func CreateResource(ctx context.Context, resource client.Object, kubeClient clie
 		klog.InfoS("CreateDeployment", "deployment", klog.KObj(r))
 	case *appsv1.StatefulSet:
 		klog.InfoS("CreateStatefulSet", "statefulset", klog.KObj(r))
+	case *appsv1.DaemonSet:
+		klog.InfoS("CreateDaemonSet", "daemonset", klog.KObj(r))
 	case *corev1.Service:
 		klog.InfoS("CreateService", "service", klog.KObj(r))
 	case *corev1.ConfigMap:
 		klog.InfoS("CreateConfigMap", "configmap", klog.KObj(r))
+	case *corev1.PersistentVolumeClaim:
+		klog.InfoS("CreatePersistentVolumeClaim", "persistentvolumeclaim", klog.KObj(r))
 	}
 
 	// Create the resource.
@@ -90,3 +99,93 @@ func CheckResourceStatus(obj client.Object, kubeClient client.Client, timeoutDur
 		}
 	}
 }
+
+// PodTemplateOf returns the pod template of a Deployment or StatefulSet, or nil for other objects.
+func PodTemplateOf(obj client.Object) *corev1.PodTemplateSpec {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		return &o.Spec.Template
+	case *appsv1.StatefulSet:
+		return &o.Spec.Template
+	case *batchv1.Job:
+		return &o.Spec.Template
+	}
+	return nil
+}
+
+// SpecHash returns a digest of the spec of a Deployment, StatefulSet or Job, or "" for other objects.
+// The workloads are generated deterministically, so the digest only changes with the generated spec.
+func SpecHash(obj client.Object) string {
+	var spec interface{}
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		spec = o.Spec
+	case *appsv1.StatefulSet:
+		spec = o.Spec
+	case *batchv1.Job:
+		spec = o.Spec
+	default:
+		return ""
+	}
+	// encoding/json sorts map keys, so the digest is stable.
+	b, _ := json.Marshal(spec)
+	return fmt.Sprintf("%x", sha256.Sum256(b))[:16]
+}
+
+// SetSpecHash records the digest of the spec of a generated workload on its metadata, not on its pod
+// template, so that recording it does not roll the pods out.
+func SetSpecHash(obj client.Object) {
+	hash := SpecHash(obj)
+	if hash == "" {
+		return
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[kaitov1alpha1.AnnotationSpecHash] = hash
+	obj.SetAnnotations(annotations)
+}
+
+// ImageDigest returns the digest of the image the pods of the workload run in the container, e.g.,
+// "sha256:...", as resolved by the container runtime. It is empty until the pods run the image or
+// while they run different digests, e.g., during a rollout.
+func ImageDigest(ctx context.Context, workloadObj client.Object, containerName string, kubeClient client.Client) (string, error) {
+	var selector *metav1.LabelSelector
+	switch o := workloadObj.(type) {
+	case *appsv1.Deployment:
+		selector = o.Spec.Selector
+	case *appsv1.StatefulSet:
+		selector = o.Spec.Selector
+	}
+	if selector == nil {
+		return "", nil
+	}
+	podSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return "", err
+	}
+	podList := &corev1.PodList{}
+	if err := kubeClient.List(ctx, podList, client.InNamespace(workloadObj.GetNamespace()),
+		client.MatchingLabelsSelector{Selector: podSelector}); err != nil {
+		return "", err
+	}
+
+	var digest string
+	for _, pod := range podList.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name != containerName || status.ImageID == "" {
+				continue
+			}
+			_, podDigest, found := strings.Cut(status.ImageID, "@")
+			if !found {
+				continue
+			}
+			if digest != "" && digest != podDigest {
+				return "", nil
+			}
+			digest = podDigest
+		}
+	}
+	return digest, nil
+}
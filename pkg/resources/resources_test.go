@@ -5,6 +5,7 @@ package resources
 import (
 	"context"
 	"errors"
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
 	"github.com/azure/kaito/pkg/utils/test"
 	"testing"
 	"time"
@@ -12,6 +13,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/stretchr/testify/assert"
@@ -193,3 +195,84 @@ func TestGetResource(t *testing.T) {
 		})
 	}
 }
+
+func TestImageDigest(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "ws", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "ws"}},
+		},
+	}
+	newPod := func(name, imageID string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "ws"}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "ws", ImageID: imageID},
+			}},
+		}
+	}
+
+	testcases := map[string]struct {
+		pods           []runtime.Object
+		expectedDigest string
+	}{
+		"No pods": {},
+		"Image not pulled": {
+			pods: []runtime.Object{newPod("ws-1", "")},
+		},
+		"Same digest": {
+			pods: []runtime.Object{
+				newPod("ws-1", "mcr.microsoft.com/aks/kaito/kaito-phi-2@sha256:abc"),
+				newPod("ws-2", "mcr.microsoft.com/aks/kaito/kaito-phi-2@sha256:abc"),
+			},
+			expectedDigest: "sha256:abc",
+		},
+		"Different digests during a rollout": {
+			pods: []runtime.Object{
+				newPod("ws-1", "mcr.microsoft.com/aks/kaito/kaito-phi-2@sha256:abc"),
+				newPod("ws-2", "mcr.microsoft.com/aks/kaito/kaito-phi-2@sha256:def"),
+			},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(tc.pods...).Build()
+			digest, err := ImageDigest(context.Background(), dep, "ws", cl)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedDigest, digest)
+		})
+	}
+}
+
+func TestSpecHash(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "testWorkspace", Namespace: "kaito"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: int32Ptr(1),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"b": "2", "a": "1"}},
+			},
+		},
+	}
+	hash := SpecHash(deployment)
+	goassert.Assert(t, hash != "")
+
+	copied := deployment.DeepCopy()
+	SetSpecHash(copied)
+	goassert.Equal(t, copied.Annotations[kaitov1alpha1.AnnotationSpecHash], hash)
+	// Recording the hash does not change it.
+	goassert.Equal(t, SpecHash(copied), hash)
+
+	copied.Spec.Replicas = int32Ptr(2)
+	goassert.Assert(t, SpecHash(copied) != hash)
+
+	goassert.Equal(t, SpecHash(&corev1.Service{}), "")
+	service := &corev1.Service{}
+	SetSpecHash(service)
+	goassert.Assert(t, service.Annotations == nil)
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	"fmt"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/operatorconfig"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The GPU scoring strategies, named after the scoring strategies of the NodeResourcesFit plugin of
+// kube-scheduler.
+const (
+	GPUScoringMostAllocated  = "MostAllocated"
+	GPUScoringLeastAllocated = "LeastAllocated"
+)
+
+var (
+	// DefaultSchedulerName is the scheduler of the workload pods of the workspaces that do not specify
+	// one. The default scheduler of the cluster is used if empty.
+	DefaultSchedulerName string
+	// GPUScoringStrategy, if set, is recorded on the workload pods as a bin-packing hint for GPU-aware
+	// scheduler plugins. MostAllocated packs the pods on the fewest GPU nodes.
+	GPUScoringStrategy string
+)
+
+// ValidateGPUScoringStrategy checks that the strategy is empty or a supported GPU scoring strategy.
+func ValidateGPUScoringStrategy(strategy string) error {
+	switch strategy {
+	case "", GPUScoringMostAllocated, GPUScoringLeastAllocated:
+		return nil
+	}
+	return fmt.Errorf("unsupported GPU scoring strategy %s, must be %s or %s", strategy, GPUScoringMostAllocated, GPUScoringLeastAllocated)
+}
+
+// ConfigureScheduling sets the scheduler of the workspace and the GPU scoring hint on the pod template.
+// A scheduler already set on the pod template, e.g., by a custom inference template, is kept.
+func ConfigureScheduling(template *corev1.PodTemplateSpec, workspaceObj *kaitov1alpha1.Workspace) {
+	if template.Spec.SchedulerName == "" {
+		if workspaceObj.Resource.SchedulerName != "" {
+			template.Spec.SchedulerName = workspaceObj.Resource.SchedulerName
+		} else {
+			template.Spec.SchedulerName = operatorconfig.String(operatorconfig.Get().SchedulerName, DefaultSchedulerName)
+		}
+	}
+	if strategy := operatorconfig.String(operatorconfig.Get().GPUScoringStrategy, GPUScoringStrategy); strategy != "" {
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		template.Annotations[kaitov1alpha1.AnnotationGPUScoringStrategy] = strategy
+	}
+}
+
+// ConfigureArchitectures restricts the pods to the nodes of the CPU architectures the image is built
+// for, by adding a requirement on the kubernetes.io/arch label to every required node affinity term.
+func ConfigureArchitectures(template *corev1.PodTemplateSpec, architectures []string) {
+	requirement := corev1.NodeSelectorRequirement{
+		Key:      corev1.LabelArchStable,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   architectures,
+	}
+	if template.Spec.Affinity == nil {
+		template.Spec.Affinity = &corev1.Affinity{}
+	}
+	if template.Spec.Affinity.NodeAffinity == nil {
+		template.Spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	nodeAffinity := template.Spec.Affinity.NodeAffinity
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+	selector := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(selector.NodeSelectorTerms) == 0 {
+		selector.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+	}
+	for i := range selector.NodeSelectorTerms {
+		selector.NodeSelectorTerms[i].MatchExpressions = append(selector.NodeSelectorTerms[i].MatchExpressions, requirement)
+	}
+}
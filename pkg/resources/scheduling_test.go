@@ -0,0 +1,88 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package resources
+
+import (
+	"testing"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/utils/test"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestConfigureScheduling(t *testing.T) {
+	DefaultSchedulerName = "default-gpu-scheduler"
+	GPUScoringStrategy = GPUScoringMostAllocated
+	defer func() {
+		DefaultSchedulerName = ""
+		GPUScoringStrategy = ""
+	}()
+
+	testcases := map[string]struct {
+		workspaceScheduler string
+		templateScheduler  string
+		expected           string
+	}{
+		"Default scheduler": {
+			expected: "default-gpu-scheduler",
+		},
+		"Workspace scheduler": {
+			workspaceScheduler: "gpu-scheduler",
+			expected:           "gpu-scheduler",
+		},
+		"Template scheduler is kept": {
+			workspaceScheduler: "gpu-scheduler",
+			templateScheduler:  "custom-scheduler",
+			expected:           "custom-scheduler",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			workspace := test.MockWorkspaceWithPreset.DeepCopy()
+			workspace.Resource.SchedulerName = tc.workspaceScheduler
+			template := &corev1.PodTemplateSpec{Spec: corev1.PodSpec{SchedulerName: tc.templateScheduler}}
+
+			ConfigureScheduling(template, workspace)
+
+			assert.Equal(t, template.Spec.SchedulerName, tc.expected)
+			assert.Equal(t, template.Annotations[kaitov1alpha1.AnnotationGPUScoringStrategy], GPUScoringMostAllocated)
+		})
+	}
+}
+
+func TestValidateGPUScoringStrategy(t *testing.T) {
+	assert.NilError(t, ValidateGPUScoringStrategy(""))
+	assert.NilError(t, ValidateGPUScoringStrategy(GPUScoringMostAllocated))
+	assert.Assert(t, ValidateGPUScoringStrategy("Spread") != nil)
+}
+
+func TestConfigureArchitectures(t *testing.T) {
+	instanceType := corev1.NodeSelectorRequirement{
+		Key:      corev1.LabelInstanceTypeStable,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{"Standard_NC12s_v3"},
+	}
+	arch := corev1.NodeSelectorRequirement{
+		Key:      corev1.LabelArchStable,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{"amd64", "arm64"},
+	}
+
+	template := &corev1.PodTemplateSpec{Spec: corev1.PodSpec{Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{instanceType}}},
+		},
+	}}}}
+	ConfigureArchitectures(template, []string{"amd64", "arm64"})
+	assert.DeepEqual(t, template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms,
+		[]corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{instanceType, arch}}})
+
+	// Pod templates without node affinity, e.g., custom inference templates.
+	template = &corev1.PodTemplateSpec{}
+	ConfigureArchitectures(template, []string{"amd64", "arm64"})
+	assert.DeepEqual(t, template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms,
+		[]corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{arch}}})
+}
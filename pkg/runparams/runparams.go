@@ -0,0 +1,118 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+// Package runparams merges the model run parameters of a workload from the sources that can set
+// them, in an explicit order of precedence.
+package runparams
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/azure/kaito/pkg/operatorconfig"
+)
+
+// Source identifies where a set of run parameters comes from.
+type Source string
+
+// The sources of run parameters, by increasing precedence.
+const (
+	SourcePreset     Source = "preset"
+	SourceOperator   Source = "operator"
+	SourceWorkspace  Source = "workspace"
+	SourceAnnotation Source = "annotation"
+)
+
+var precedence = map[Source]int{
+	SourcePreset:     0,
+	SourceOperator:   1,
+	SourceWorkspace:  2,
+	SourceAnnotation: 3,
+}
+
+// OperatorDefaults holds the run parameters set by the operator configuration for all workloads.
+var OperatorDefaults = map[string]string{}
+
+// OperatorParams returns the run parameters of the operator configuration, set by the KaitoConfig
+// object or else by OperatorDefaults.
+func OperatorParams() map[string]string {
+	if params := operatorconfig.Get().ModelRunParams; params != nil {
+		return params
+	}
+	return OperatorDefaults
+}
+
+// Layer is a set of run parameters from a source.
+type Layer struct {
+	Source Source
+	Params map[string]string
+}
+
+// Override records a run parameter set by a source and overridden with another value by a source
+// of higher precedence.
+type Override struct {
+	Key              string
+	Value            string
+	Source           Source
+	OverriddenValue  string
+	OverriddenSource Source
+}
+
+func (o Override) String() string {
+	return fmt.Sprintf("%s=%q from %s overrides %q from %s", o.Key, o.Value, o.Source, o.OverriddenValue, o.OverriddenSource)
+}
+
+// Merge merges the layers according to the precedence of their sources, whatever the order they
+// are passed in. It returns the merged parameters and the overrides between sources, sorted by key.
+// Layers of an unknown source have the lowest precedence.
+func Merge(layers ...Layer) (map[string]string, []Override) {
+	sorted := make([]Layer, len(layers))
+	copy(sorted, layers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return precedence[sorted[i].Source] < precedence[sorted[j].Source]
+	})
+
+	merged := map[string]string{}
+	sources := map[string]Source{}
+	var overrides []Override
+	for _, layer := range sorted {
+		for key, value := range layer.Params {
+			if previous, ok := merged[key]; ok && previous != value {
+				overrides = append(overrides, Override{
+					Key:              key,
+					Value:            value,
+					Source:           layer.Source,
+					OverriddenValue:  previous,
+					OverriddenSource: sources[key],
+				})
+			}
+			merged[key] = value
+			sources[key] = layer.Source
+		}
+	}
+	sort.SliceStable(overrides, func(i, j int) bool {
+		return overrides[i].Key < overrides[j].Key
+	})
+	return merged, overrides
+}
+
+// ParseAnnotation parses run parameters set by an annotation as a JSON object of strings,
+// e.g. {"max_length": "200"}.
+func ParseAnnotation(value string) (map[string]string, error) {
+	params := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &params); err != nil {
+		return nil, fmt.Errorf("run parameters must be a JSON object of strings: %w", err)
+	}
+	return params, nil
+}
+
+// Describe returns a human readable summary of the overrides.
+func Describe(overrides []Override) string {
+	descriptions := make([]string, 0, len(overrides))
+	for _, o := range overrides {
+		descriptions = append(descriptions, o.String())
+	}
+	return strings.Join(descriptions, "; ")
+}
@@ -0,0 +1,61 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package runparams
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	testcases := map[string]struct {
+		layers            []Layer
+		expectedParams    map[string]string
+		expectedOverrides []Override
+	}{
+		"no layers": {
+			expectedParams: map[string]string{},
+		},
+		"higher precedence wins whatever the order": {
+			layers: []Layer{
+				{Source: SourceAnnotation, Params: map[string]string{"max_length": "300"}},
+				{Source: SourcePreset, Params: map[string]string{"max_length": "100", "torch_dtype": "bfloat16"}},
+				{Source: SourceOperator, Params: map[string]string{"max_length": "200"}},
+			},
+			expectedParams: map[string]string{"max_length": "300", "torch_dtype": "bfloat16"},
+			expectedOverrides: []Override{
+				{Key: "max_length", Value: "200", Source: SourceOperator, OverriddenValue: "100", OverriddenSource: SourcePreset},
+				{Key: "max_length", Value: "300", Source: SourceAnnotation, OverriddenValue: "200", OverriddenSource: SourceOperator},
+			},
+		},
+		"same value is not an override": {
+			layers: []Layer{
+				{Source: SourcePreset, Params: map[string]string{"trust_remote_code": ""}},
+				{Source: SourceWorkspace, Params: map[string]string{"trust_remote_code": "", "pipeline": "text-generation"}},
+			},
+			expectedParams: map[string]string{"trust_remote_code": "", "pipeline": "text-generation"},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			params, overrides := Merge(tc.layers...)
+			if !reflect.DeepEqual(params, tc.expectedParams) {
+				t.Errorf("unexpected params %v, expect %v", params, tc.expectedParams)
+			}
+			if !reflect.DeepEqual(overrides, tc.expectedOverrides) {
+				t.Errorf("unexpected overrides %v, expect %v", overrides, tc.expectedOverrides)
+			}
+		})
+	}
+}
+
+func TestParseAnnotation(t *testing.T) {
+	if params, err := ParseAnnotation(`{"max_length": "200"}`); err != nil || params["max_length"] != "200" {
+		t.Errorf("unexpected result %v, %v", params, err)
+	}
+	if _, err := ParseAnnotation(`{"max_length": 200}`); err == nil {
+		t.Errorf("expected error for non-string value")
+	}
+}
@@ -13,4 +13,14 @@ type GPUConfig struct {
 	GPUCount int
 	GPUMem   int
 	GPUModel string
+	// Arch is the CPU architecture of the instance type, e.g., "arm64". Empty means amd64.
+	Arch string
+}
+
+// Architecture returns the CPU architecture of the instance type, as in the kubernetes.io/arch label.
+func (c GPUConfig) Architecture() string {
+	if c.Arch == "" {
+		return "amd64"
+	}
+	return c.Arch
 }
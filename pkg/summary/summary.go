@@ -0,0 +1,129 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+// Package summary serves an overview of the workspaces of the cluster for dashboards and CLIs,
+// so that they do not have to list and join workspaces, services and SKU data themselves.
+package summary
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WorkspacesPath is the path the workspace summary handler is served on by the operator.
+const WorkspacesPath = "/summary/workspaces"
+
+// The phases of a workspace in the summary.
+const (
+	PhasePending  = "Pending"
+	PhaseReady    = "Ready"
+	PhaseNotReady = "NotReady"
+	PhaseDeleting = "Deleting"
+)
+
+// WorkspaceSummary summarizes the state of a workspace.
+type WorkspaceSummary struct {
+	Name         string `json:"name"`
+	Namespace    string `json:"namespace"`
+	Phase        string `json:"phase"`
+	Message      string `json:"message,omitempty"`
+	Mode         string `json:"mode"`
+	Model        string `json:"model,omitempty"`
+	InstanceType string `json:"instanceType"`
+	NodeCount    int    `json:"nodeCount"`
+	ReadyNodes   int    `json:"readyNodes"`
+	GPUCount     int    `json:"gpuCount,omitempty"`
+	Endpoint     string `json:"endpoint,omitempty"`
+}
+
+// WorkspaceSummaryList is the response body of the workspace summary handler.
+type WorkspaceSummaryList struct {
+	Workspaces []WorkspaceSummary `json:"workspaces"`
+}
+
+// Summarize returns the summary of the workspace.
+func Summarize(w *kaitov1alpha1.Workspace) WorkspaceSummary {
+	s := WorkspaceSummary{
+		Name:         w.Name,
+		Namespace:    w.Namespace,
+		InstanceType: w.Resource.InstanceType,
+		ReadyNodes:   len(w.Status.WorkerNodes),
+	}
+	if w.Resource.Count != nil {
+		s.NodeCount = *w.Resource.Count
+	}
+	if gpuConfig, ok := kaitov1alpha1.SupportedGPUConfigs[w.Resource.InstanceType]; ok {
+		s.GPUCount = gpuConfig.GPUCount * s.NodeCount
+	}
+
+	switch {
+	case w.Inference != nil:
+		s.Mode = "inference"
+		if w.Inference.Preset != nil {
+			s.Model = w.Inference.Preset.ModelReference()
+		}
+		s.Endpoint = fmt.Sprintf("http://%s.%s.svc.cluster.local:80", w.Name, w.Namespace)
+	case w.Tuning != nil:
+		s.Mode = "tuning"
+		if w.Tuning.Preset != nil {
+			s.Model = w.Tuning.Preset.ModelReference()
+		}
+	}
+
+	ready := meta.FindStatusCondition(w.Status.Conditions, string(kaitov1alpha1.WorkspaceConditionTypeReady))
+	switch {
+	case !w.DeletionTimestamp.IsZero():
+		s.Phase = PhaseDeleting
+	case ready == nil:
+		s.Phase = PhasePending
+	case ready.Status == metav1.ConditionTrue:
+		s.Phase = PhaseReady
+	default:
+		s.Phase = PhaseNotReady
+		s.Message = ready.Message
+	}
+	return s
+}
+
+// WorkspacesHandler serves the summary of the workspaces read from reader as JSON, sorted by
+// namespace and name. The workspaces
+// of a single namespace can be selected with the "namespace" query parameter.
+func WorkspacesHandler(reader client.Reader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var opts []client.ListOption
+		if namespace := req.URL.Query().Get("namespace"); namespace != "" {
+			opts = append(opts, client.InNamespace(namespace))
+		}
+		workspaces := &kaitov1alpha1.WorkspaceList{}
+		if err := reader.List(req.Context(), workspaces, opts...); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		list := WorkspaceSummaryList{Workspaces: make([]WorkspaceSummary, 0, len(workspaces.Items))}
+		for i := range workspaces.Items {
+			list.Workspaces = append(list.Workspaces, Summarize(&workspaces.Items[i]))
+		}
+		sort.Slice(list.Workspaces, func(i, j int) bool {
+			a, b := list.Workspaces[i], list.Workspaces[j]
+			return a.Namespace < b.Namespace || (a.Namespace == b.Namespace && a.Name < b.Name)
+		})
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(list); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package summary
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestWorkspacesHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := kaitov1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	ready := &kaitov1alpha1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "falcon", Namespace: "default"},
+		Resource:   kaitov1alpha1.ResourceSpec{InstanceType: "Standard_NC12s_v3", Count: pointer.Int(2)},
+		Inference: &kaitov1alpha1.InferenceSpec{
+			Preset: &kaitov1alpha1.PresetSpec{PresetMeta: kaitov1alpha1.PresetMeta{Name: "falcon-7b"}},
+		},
+		Status: kaitov1alpha1.WorkspaceStatus{
+			WorkerNodes: []string{"node1", "node2"},
+			Conditions: []metav1.Condition{{
+				Type:   string(kaitov1alpha1.WorkspaceConditionTypeReady),
+				Status: metav1.ConditionTrue,
+			}},
+		},
+	}
+	pending := &kaitov1alpha1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "tuning", Namespace: "team"},
+		Resource:   kaitov1alpha1.ResourceSpec{InstanceType: "Standard_NC6s_v3", Count: pointer.Int(1)},
+		Tuning: &kaitov1alpha1.TuningSpec{
+			Preset: &kaitov1alpha1.PresetSpec{PresetMeta: kaitov1alpha1.PresetMeta{Name: "phi-3-mini-4k-instruct"}},
+		},
+	}
+	reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pending, ready).Build()
+	handler := WorkspacesHandler(reader)
+
+	testcases := map[string]struct {
+		method             string
+		target             string
+		expectedStatus     int
+		expectedWorkspaces []WorkspaceSummary
+	}{
+		"all workspaces sorted by namespace": {
+			method:         http.MethodGet,
+			target:         WorkspacesPath,
+			expectedStatus: http.StatusOK,
+			expectedWorkspaces: []WorkspaceSummary{
+				{Name: "falcon", Namespace: "default", Phase: PhaseReady, Mode: "inference", Model: "falcon-7b",
+					InstanceType: "Standard_NC12s_v3", NodeCount: 2, ReadyNodes: 2, GPUCount: 4,
+					Endpoint: "http://falcon.default.svc.cluster.local:80"},
+				{Name: "tuning", Namespace: "team", Phase: PhasePending, Mode: "tuning", Model: "phi-3-mini-4k-instruct",
+					InstanceType: "Standard_NC6s_v3", NodeCount: 1, GPUCount: 1},
+			},
+		},
+		"single namespace": {
+			method:             http.MethodGet,
+			target:             WorkspacesPath + "?namespace=empty",
+			expectedStatus:     http.StatusOK,
+			expectedWorkspaces: []WorkspaceSummary{},
+		},
+		"method not allowed": {
+			method:         http.MethodPost,
+			target:         WorkspacesPath,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.target, nil))
+			if rec.Code != tc.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tc.expectedStatus, rec.Code)
+			}
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			var list WorkspaceSummaryList
+			if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(list.Workspaces, tc.expectedWorkspaces) {
+				t.Errorf("unexpected workspaces %+v, expect %+v", list.Workspaces, tc.expectedWorkspaces)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package tuning
+
+import (
+	"math"
+	"strconv"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/model"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// PerDeviceTrainBatchSizeEnv and GradientAccumulationStepsEnv set the derived training arguments of the
+	// tuning script, see presets/tuning/text-generation/fine_tuning.py.
+	PerDeviceTrainBatchSizeEnv   = "PER_DEVICE_TRAIN_BATCH_SIZE"
+	GradientAccumulationStepsEnv = "GRADIENT_ACCUMULATION_STEPS"
+
+	// maxPerDeviceBatchSize caps the derived per-device batch size.
+	maxPerDeviceBatchSize = 16
+	// effectiveBatchSize is the per-device batch size times the gradient accumulation steps the derivation
+	// aims for, so that the training converges alike on every instance type.
+	effectiveBatchSize = 16
+	// gpuMemoryHeadroom is the fraction of the GPU memory left for the CUDA context and fragmentation.
+	gpuMemoryHeadroom = 0.1
+	// sampleMemoryRatio estimates the memory of the activations of a sample from the memory required to
+	// tune with a batch size of 1, which grows with the size of the model.
+	sampleMemoryRatio = 0.125
+)
+
+// DeriveBatchSize returns the per-device batch size and the gradient accumulation steps of the tuning job
+// of the workspace. The batch size is the largest power of two whose activations fit in the GPU memory of
+// the instance type, beside the memory required by the preset to tune with a batch size of 1, and the
+// gradient accumulation makes up the effective batch size. It returns false if the GPU memory of the
+// instance type or the memory requirement of the preset is unknown.
+func DeriveBatchSize(workspaceObj *kaitov1alpha1.Workspace, tuningObj *model.PresetParam) (int, int, bool) {
+	gpuConfig, ok := kaitov1alpha1.SupportedGPUConfigs[workspaceObj.Resource.InstanceType]
+	if !ok || gpuConfig.GPUCount == 0 || gpuConfig.GPUMem == 0 {
+		return 0, 0, false
+	}
+	required := tuningMemoryRequirement(workspaceObj, tuningObj, gpuConfig.GPUCount)
+	if required <= 0 {
+		return 0, 0, false
+	}
+
+	available := float64(gpuConfig.GPUMem) / float64(gpuConfig.GPUCount) * (1 - gpuMemoryHeadroom)
+	perSample := math.Max(1, required*sampleMemoryRatio)
+	batchSize := 1
+	if available > required {
+		batchSize += int((available - required) / perSample)
+	}
+	batchSize = min(batchSize, maxPerDeviceBatchSize)
+	// Round down to a power of two.
+	batchSize = 1 << int(math.Log2(float64(batchSize)))
+	return batchSize, max(1, effectiveBatchSize/batchSize), true
+}
+
+// tuningMemoryRequirement returns the GPU memory, in GiB, required per GPU to tune the preset with a
+// batch size of 1: the requirement of the tuning method if the preset has one, else its per GPU memory
+// requirement, else its total GPU memory requirement spread over the GPUs of the instance type.
+func tuningMemoryRequirement(workspaceObj *kaitov1alpha1.Workspace, tuningObj *model.PresetParam, gpuCount int) float64 {
+	if required, ok := tuningObj.TuningPerGPUMemoryRequirement[string(workspaceObj.Tuning.Method)]; ok {
+		return float64(required)
+	}
+	gibibytes := func(value string) float64 {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return 0
+		}
+		return float64(quantity.Value()) / (1 << 30)
+	}
+	if required := gibibytes(tuningObj.PerGPUMemoryRequirement); required > 0 {
+		return required
+	}
+	return gibibytes(tuningObj.TotalGPUMemoryRequirement) / float64(gpuCount)
+}
+
+// configureBatchSize sets the derived batch size and gradient accumulation steps of the tuning container,
+// unless the training config of the workspace sets either of them, or lets the trainer find the batch size.
+func configureBatchSize(jobObj *batchv1.Job, workspaceObj *kaitov1alpha1.Workspace, tuningObj *model.PresetParam, configMap *corev1.ConfigMap) {
+	config, fieldErr := kaitov1alpha1.UnmarshalTrainingConfig(configMap)
+	if fieldErr != nil {
+		return
+	}
+	args, fieldErr := config.TrainingConfig.GetTrainingArguments()
+	if fieldErr != nil {
+		return
+	}
+	if args != nil && (args.PerDeviceTrainBatchSize != nil || args.GradientAccumulationSteps != nil ||
+		(args.AutoFindBatchSize != nil && *args.AutoFindBatchSize)) {
+		return
+	}
+	batchSize, steps, ok := DeriveBatchSize(workspaceObj, tuningObj)
+	if !ok {
+		return
+	}
+	klog.InfoS("Derived the batch size of the tuning job", "workspace", klog.KObj(workspaceObj),
+		"perDeviceTrainBatchSize", batchSize, "gradientAccumulationSteps", steps)
+	for i := range jobObj.Spec.Template.Spec.Containers {
+		container := &jobObj.Spec.Template.Spec.Containers[i]
+		if container.Name != workspaceObj.Name {
+			continue
+		}
+		container.Env = append(container.Env,
+			corev1.EnvVar{Name: PerDeviceTrainBatchSizeEnv, Value: strconv.Itoa(batchSize)},
+			corev1.EnvVar{Name: GradientAccumulationStepsEnv, Value: strconv.Itoa(steps)})
+	}
+}
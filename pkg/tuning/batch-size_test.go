@@ -0,0 +1,134 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package tuning
+
+import (
+	"testing"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/model"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func batchSizeWorkspace(instanceType string, method kaitov1alpha1.TuningMethod) *kaitov1alpha1.Workspace {
+	return &kaitov1alpha1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-workspace", Namespace: "default"},
+		Resource:   kaitov1alpha1.ResourceSpec{InstanceType: instanceType},
+		Tuning:     &kaitov1alpha1.TuningSpec{Method: method},
+	}
+}
+
+func TestDeriveBatchSize(t *testing.T) {
+	testcases := map[string]struct {
+		instanceType      string
+		method            kaitov1alpha1.TuningMethod
+		tuningObj         *model.PresetParam
+		expectedBatchSize int
+		expectedSteps     int
+		expectedOK        bool
+	}{
+		"Small model on a large GPU": {
+			instanceType:      "Standard_NC24ads_A100_v4",
+			tuningObj:         &model.PresetParam{PerGPUMemoryRequirement: "16Gi"},
+			expectedBatchSize: 16,
+			expectedSteps:     1,
+			expectedOK:        true,
+		},
+		"Large model on a large GPU": {
+			instanceType:      "Standard_NC24ads_A100_v4",
+			tuningObj:         &model.PresetParam{PerGPUMemoryRequirement: "40Gi"},
+			expectedBatchSize: 4,
+			expectedSteps:     4,
+			expectedOK:        true,
+		},
+		"Model barely fitting the GPU": {
+			instanceType:      "Standard_NC12s_v3",
+			tuningObj:         &model.PresetParam{PerGPUMemoryRequirement: "16Gi"},
+			expectedBatchSize: 1,
+			expectedSteps:     16,
+			expectedOK:        true,
+		},
+		"Requirement of the tuning method": {
+			instanceType: "Standard_NC24ads_A100_v4",
+			method:       kaitov1alpha1.TuningMethodQLora,
+			tuningObj: &model.PresetParam{
+				PerGPUMemoryRequirement:       "70Gi",
+				TuningPerGPUMemoryRequirement: map[string]int{"qlora": 48},
+			},
+			expectedBatchSize: 4,
+			expectedSteps:     4,
+			expectedOK:        true,
+		},
+		"Total requirement spread over the GPUs": {
+			instanceType:      "Standard_NC96ads_A100_v4",
+			tuningObj:         &model.PresetParam{PerGPUMemoryRequirement: "0Gi", TotalGPUMemoryRequirement: "160Gi"},
+			expectedBatchSize: 4,
+			expectedSteps:     4,
+			expectedOK:        true,
+		},
+		"Unknown instance type": {
+			instanceType: "Standard_Custom",
+			tuningObj:    &model.PresetParam{PerGPUMemoryRequirement: "16Gi"},
+		},
+		"Unknown requirement": {
+			instanceType: "Standard_NC24ads_A100_v4",
+			tuningObj:    &model.PresetParam{},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = kaitov1alpha1.TuningMethodLora
+			}
+			batchSize, steps, ok := DeriveBatchSize(batchSizeWorkspace(tc.instanceType, method), tc.tuningObj)
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedBatchSize, batchSize)
+			assert.Equal(t, tc.expectedSteps, steps)
+		})
+	}
+}
+
+func TestConfigureBatchSize(t *testing.T) {
+	testcases := map[string]struct {
+		trainingArguments string
+		expectedEnv       []corev1.EnvVar
+	}{
+		"Derived": {
+			trainingArguments: `output_dir: "/mnt/results"`,
+			expectedEnv: []corev1.EnvVar{
+				{Name: PerDeviceTrainBatchSizeEnv, Value: "16"},
+				{Name: GradientAccumulationStepsEnv, Value: "1"},
+			},
+		},
+		"Batch size set": {
+			trainingArguments: `per_device_train_batch_size: 2`,
+		},
+		"Gradient accumulation steps set": {
+			trainingArguments: `gradient_accumulation_steps: 8`,
+		},
+		"Batch size found by the trainer": {
+			trainingArguments: `auto_find_batch_size: true`,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			workspaceObj := batchSizeWorkspace("Standard_NC24ads_A100_v4", kaitov1alpha1.TuningMethodLora)
+			configMap := &corev1.ConfigMap{Data: map[string]string{
+				"training_config.yaml": "training_config:\n  TrainingArguments:\n    " + tc.trainingArguments + "\n",
+			}}
+			jobObj := &batchv1.Job{}
+			jobObj.Spec.Template.Spec.Containers = []corev1.Container{{Name: "test-workspace"}, {Name: "docker-sidecar"}}
+
+			configureBatchSize(jobObj, workspaceObj, &model.PresetParam{PerGPUMemoryRequirement: "16Gi"}, configMap)
+			assert.Equal(t, tc.expectedEnv, jobObj.Spec.Template.Spec.Containers[0].Env)
+			assert.Empty(t, jobObj.Spec.Template.Spec.Containers[1].Env)
+		})
+	}
+}
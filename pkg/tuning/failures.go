@@ -0,0 +1,214 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package tuning
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/samber/lo"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// failureMarker prefixes the failure the tuning script writes to the termination log of the tuning
+	// container, e.g., "kaito-tuning-failure: DatasetError: Unable to load the dataset.".
+	failureMarker = "kaito-tuning-failure: "
+
+	// The exit codes of the tuning script, see presets/tuning/text-generation/fine_tuning.py.
+	exitCodeDatasetError = 3
+	exitCodeOutOfMemory  = 4
+	exitCodeNaNLoss      = 5
+
+	// BatchSizeDivisorEnv is the environment variable the batch size of the tuning script is divided by.
+	// The gradient accumulation steps are multiplied by it, keeping the effective batch size.
+	BatchSizeDivisorEnv = "BATCH_SIZE_DIVISOR"
+
+	maxFailureMessageLength = 512
+)
+
+// RetryPolicy decides which failed tuning jobs are recreated by the controller. The failures that
+// would fail again, NaN losses, dataset errors and host out of memory kills, are never retried.
+type RetryPolicy struct {
+	// MaxPreemptionRetries is how many times a job is retried after its pod is preempted or evicted.
+	MaxPreemptionRetries int
+	// MaxOutOfMemoryRetries is how many times a job is retried after running out of GPU memory, each
+	// time with half the batch size of the previous attempt.
+	MaxOutOfMemoryRetries int
+	// MaxUnknownRetries is how many times a job is retried after any other failure.
+	MaxUnknownRetries int
+}
+
+// GlobalRetryPolicy is the retry policy of the operator.
+var GlobalRetryPolicy = RetryPolicy{
+	MaxPreemptionRetries:  3,
+	MaxOutOfMemoryRetries: 1,
+}
+
+// ShouldRetry returns whether a job failing for the reason is retried, given the previous attempts.
+func (p RetryPolicy) ShouldRetry(reason kaitov1alpha1.TuningFailureReason, attempts []kaitov1alpha1.TuningAttempt) bool {
+	var limit int
+	switch reason {
+	case kaitov1alpha1.TuningFailurePreempted:
+		limit = p.MaxPreemptionRetries
+	case kaitov1alpha1.TuningFailureOutOfMemory:
+		limit = p.MaxOutOfMemoryRetries
+	case kaitov1alpha1.TuningFailureUnknown:
+		limit = p.MaxUnknownRetries
+	default:
+		return false
+	}
+	retries := lo.CountBy(attempts, func(attempt kaitov1alpha1.TuningAttempt) bool {
+		return attempt.Retried && attempt.FailureReason == reason
+	})
+	return retries < limit
+}
+
+// BatchSizeDivisor returns the divisor of the batch size of the next tuning job of the workspace,
+// halved for every attempt retried after running out of memory.
+func BatchSizeDivisor(workspaceObj *kaitov1alpha1.Workspace) int {
+	divisor := 1
+	if workspaceObj.Status.Tuning == nil {
+		return divisor
+	}
+	for _, attempt := range workspaceObj.Status.Tuning.Attempts {
+		if attempt.Retried && attempt.FailureReason == kaitov1alpha1.TuningFailureOutOfMemory {
+			divisor *= 2
+		}
+	}
+	return divisor
+}
+
+// configureRetries leaves the retries of the tuning job to the controller, which classifies the
+// failures, and reduces the batch size of the tuning container after it ran out of memory.
+func configureRetries(jobObj *batchv1.Job, workspaceObj *kaitov1alpha1.Workspace) {
+	jobObj.Spec.BackoffLimit = lo.ToPtr[int32](0)
+	for i := range jobObj.Spec.Template.Spec.Containers {
+		container := &jobObj.Spec.Template.Spec.Containers[i]
+		if container.Name != workspaceObj.Name {
+			continue
+		}
+		// The logs explain the failures the tuning script could not write to the termination log.
+		container.TerminationMessagePolicy = corev1.TerminationMessageFallbackToLogsOnError
+		if divisor := BatchSizeDivisor(workspaceObj); divisor > 1 {
+			container.Env = append(container.Env, corev1.EnvVar{Name: BatchSizeDivisorEnv, Value: strconv.Itoa(divisor)})
+		}
+	}
+}
+
+// JobFailed returns whether the job has failed.
+func JobFailed(jobObj *batchv1.Job) bool {
+	return lo.ContainsBy(jobObj.Status.Conditions, func(condition batchv1.JobCondition) bool {
+		return condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue
+	})
+}
+
+// ClassifyJobFailure returns the reason and a description of the failure of the tuning job of the
+// workspace, from its failed pods.
+func ClassifyJobFailure(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, jobObj *batchv1.Job, kubeClient client.Client) (kaitov1alpha1.TuningFailureReason, string, error) {
+	pods := &corev1.PodList{}
+	if err := kubeClient.List(ctx, pods, client.InNamespace(jobObj.Namespace),
+		client.MatchingLabels{batchv1.JobNameLabel: jobObj.Name}); err != nil {
+		return "", "", err
+	}
+	reason, message := ClassifyFailure(workspaceObj.Name, pods.Items)
+	if message == "" {
+		if condition, found := lo.Find(jobObj.Status.Conditions, func(condition batchv1.JobCondition) bool {
+			return condition.Type == batchv1.JobFailed
+		}); found {
+			message = condition.Message
+		}
+	}
+	return reason, message, nil
+}
+
+// ClassifyFailure returns the reason and a description of the failure of the latest failed pod, whose
+// tuning container is named containerName. A pod disrupted by the cluster, e.g., preempted, evicted
+// or whose node was lost, is preempted. A failed init container cannot have downloaded the dataset.
+// Otherwise, the failure is read from the termination log of the tuning container, its exit code or
+// the reason it was killed for.
+func ClassifyFailure(containerName string, pods []corev1.Pod) (kaitov1alpha1.TuningFailureReason, string) {
+	failed := lo.Filter(pods, func(pod corev1.Pod, _ int) bool {
+		return pod.Status.Phase == corev1.PodFailed
+	})
+	if len(failed) == 0 {
+		return kaitov1alpha1.TuningFailureUnknown, ""
+	}
+	sort.Slice(failed, func(i, j int) bool {
+		return failed[j].CreationTimestamp.Before(&failed[i].CreationTimestamp)
+	})
+	pod := failed[0]
+
+	if condition, found := lo.Find(pod.Status.Conditions, func(condition corev1.PodCondition) bool {
+		return condition.Type == corev1.DisruptionTarget && condition.Status == corev1.ConditionTrue
+	}); found {
+		return kaitov1alpha1.TuningFailurePreempted, truncate(condition.Message)
+	}
+	if lo.Contains([]string{"Evicted", "Preempting", "NodeLost", "Shutdown", "Terminated"}, pod.Status.Reason) {
+		return kaitov1alpha1.TuningFailurePreempted, truncate(pod.Status.Message)
+	}
+
+	for _, status := range pod.Status.InitContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			return kaitov1alpha1.TuningFailureDatasetError, truncate(fmt.Sprintf("init container %s exited with code %d: %s",
+				status.Name, terminated.ExitCode, strings.TrimSpace(terminated.Message)))
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != containerName || status.State.Terminated == nil {
+			continue
+		}
+		return classifyTermination(status.State.Terminated)
+	}
+	return kaitov1alpha1.TuningFailureUnknown, truncate(pod.Status.Message)
+}
+
+func classifyTermination(terminated *corev1.ContainerStateTerminated) (kaitov1alpha1.TuningFailureReason, string) {
+	log := strings.TrimSpace(terminated.Message)
+	for _, line := range strings.Split(log, "\n") {
+		marked, found := strings.CutPrefix(strings.TrimSpace(line), failureMarker)
+		if !found {
+			continue
+		}
+		reason, message, _ := strings.Cut(marked, ": ")
+		switch kaitov1alpha1.TuningFailureReason(reason) {
+		case kaitov1alpha1.TuningFailureOutOfMemory, kaitov1alpha1.TuningFailureNaNLoss, kaitov1alpha1.TuningFailureDatasetError:
+			return kaitov1alpha1.TuningFailureReason(reason), truncate(message)
+		}
+	}
+
+	message := fmt.Sprintf("exited with code %d", terminated.ExitCode)
+	if terminated.Reason != "" {
+		message = fmt.Sprintf("%s, reason %s", message, terminated.Reason)
+	}
+	if log != "" {
+		message = fmt.Sprintf("%s: %s", message, log)
+	}
+	switch {
+	case terminated.ExitCode == exitCodeOutOfMemory, strings.Contains(log, "CUDA out of memory"):
+		return kaitov1alpha1.TuningFailureOutOfMemory, truncate(message)
+	case terminated.Reason == "OOMKilled":
+		// The kernel killed the container for exceeding its memory limit, not CUDA.
+		return kaitov1alpha1.TuningFailureHostOutOfMemory, truncate(message)
+	case terminated.ExitCode == exitCodeNaNLoss:
+		return kaitov1alpha1.TuningFailureNaNLoss, truncate(message)
+	case terminated.ExitCode == exitCodeDatasetError:
+		return kaitov1alpha1.TuningFailureDatasetError, truncate(message)
+	}
+	return kaitov1alpha1.TuningFailureUnknown, truncate(message)
+}
+
+// truncate keeps the end of long messages, e.g., the last lines of the logs.
+func truncate(message string) string {
+	if len(message) <= maxFailureMessageLength {
+		return message
+	}
+	return "..." + message[len(message)-maxFailureMessageLength:]
+}
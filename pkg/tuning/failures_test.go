@@ -0,0 +1,210 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package tuning
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func failedPod(name string, created time.Time, terminated *corev1.ContainerStateTerminated) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(created),
+			Labels:            map[string]string{batchv1.JobNameLabel: "test-workspace"},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodFailed},
+	}
+	if terminated != nil {
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+			{Name: "docker-sidecar", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}},
+			{Name: "test-workspace", State: corev1.ContainerState{Terminated: terminated}},
+		}
+	}
+	return pod
+}
+
+func TestClassifyFailure(t *testing.T) {
+	now := time.Now()
+	testcases := map[string]struct {
+		pods            func() []corev1.Pod
+		expectedReason  kaitov1alpha1.TuningFailureReason
+		expectedMessage string
+	}{
+		"No failed pod": {
+			pods:           func() []corev1.Pod { return nil },
+			expectedReason: kaitov1alpha1.TuningFailureUnknown,
+		},
+		"Preempted": {
+			pods: func() []corev1.Pod {
+				pod := failedPod("pod", now, &corev1.ContainerStateTerminated{ExitCode: 137})
+				pod.Status.Conditions = []corev1.PodCondition{{
+					Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue, Message: "Preempted by a higher priority pod",
+				}}
+				return []corev1.Pod{pod}
+			},
+			expectedReason:  kaitov1alpha1.TuningFailurePreempted,
+			expectedMessage: "Preempted by a higher priority pod",
+		},
+		"Evicted": {
+			pods: func() []corev1.Pod {
+				pod := failedPod("pod", now, nil)
+				pod.Status.Reason = "Evicted"
+				pod.Status.Message = "The node was low on resource: memory."
+				return []corev1.Pod{pod}
+			},
+			expectedReason:  kaitov1alpha1.TuningFailurePreempted,
+			expectedMessage: "The node was low on resource: memory.",
+		},
+		"Dataset download failed": {
+			pods: func() []corev1.Pod {
+				pod := failedPod("pod", now, nil)
+				pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+					Name:  "data-downloader",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: "404 Not Found"}},
+				}}
+				return []corev1.Pod{pod}
+			},
+			expectedReason:  kaitov1alpha1.TuningFailureDatasetError,
+			expectedMessage: "init container data-downloader exited with code 1: 404 Not Found",
+		},
+		"Failure marker": {
+			pods: func() []corev1.Pod {
+				return []corev1.Pod{failedPod("pod", now, &corev1.ContainerStateTerminated{
+					ExitCode: 1,
+					Message:  "kaito-tuning-failure: DatasetError: Unable to load the dataset.\n",
+				})}
+			},
+			expectedReason:  kaitov1alpha1.TuningFailureDatasetError,
+			expectedMessage: "Unable to load the dataset.",
+		},
+		"NaN loss exit code": {
+			pods: func() []corev1.Pod {
+				return []corev1.Pod{failedPod("pod", now, &corev1.ContainerStateTerminated{ExitCode: exitCodeNaNLoss, Reason: "Error"})}
+			},
+			expectedReason:  kaitov1alpha1.TuningFailureNaNLoss,
+			expectedMessage: "exited with code 5, reason Error",
+		},
+		"OOM killed": {
+			pods: func() []corev1.Pod {
+				return []corev1.Pod{failedPod("pod", now, &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"})}
+			},
+			expectedReason:  kaitov1alpha1.TuningFailureHostOutOfMemory,
+			expectedMessage: "exited with code 137, reason OOMKilled",
+		},
+		"CUDA out of memory in the logs": {
+			pods: func() []corev1.Pod {
+				return []corev1.Pod{failedPod("pod", now, &corev1.ContainerStateTerminated{
+					ExitCode: 1,
+					Message:  "torch.cuda.OutOfMemoryError: CUDA out of memory. Tried to allocate 2.00 GiB",
+				})}
+			},
+			expectedReason:  kaitov1alpha1.TuningFailureOutOfMemory,
+			expectedMessage: "exited with code 1: torch.cuda.OutOfMemoryError: CUDA out of memory. Tried to allocate 2.00 GiB",
+		},
+		"Latest pod": {
+			pods: func() []corev1.Pod {
+				return []corev1.Pod{
+					failedPod("old", now.Add(-time.Hour), &corev1.ContainerStateTerminated{ExitCode: exitCodeNaNLoss}),
+					failedPod("new", now, &corev1.ContainerStateTerminated{ExitCode: 1, Message: "Segmentation fault"}),
+				}
+			},
+			expectedReason:  kaitov1alpha1.TuningFailureUnknown,
+			expectedMessage: "exited with code 1: Segmentation fault",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			reason, message := ClassifyFailure("test-workspace", tc.pods())
+			assert.Equal(t, tc.expectedReason, reason)
+			assert.Equal(t, tc.expectedMessage, message)
+		})
+	}
+}
+
+func TestClassifyFailureTruncatesLogs(t *testing.T) {
+	logs := strings.Repeat("x", 2*maxFailureMessageLength) + "RuntimeError: boom"
+	_, message := ClassifyFailure("test-workspace", []corev1.Pod{
+		failedPod("pod", time.Now(), &corev1.ContainerStateTerminated{ExitCode: 1, Message: logs}),
+	})
+	assert.Len(t, message, maxFailureMessageLength+3)
+	assert.True(t, strings.HasSuffix(message, "RuntimeError: boom"))
+}
+
+func TestClassifyJobFailure(t *testing.T) {
+	pod := failedPod("pod", time.Now(), &corev1.ContainerStateTerminated{ExitCode: exitCodeOutOfMemory})
+	other := failedPod("other", time.Now(), &corev1.ContainerStateTerminated{ExitCode: exitCodeDatasetError})
+	other.Labels = map[string]string{batchv1.JobNameLabel: "other-workspace"}
+	kubeClient := fake.NewClientBuilder().WithObjects(&pod, &other).Build()
+	workspaceObj := &kaitov1alpha1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "test-workspace", Namespace: "default"}}
+	jobObj := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "test-workspace", Namespace: "default"}}
+
+	reason, message, err := ClassifyJobFailure(context.Background(), workspaceObj, jobObj, kubeClient)
+	assert.NoError(t, err)
+	assert.Equal(t, kaitov1alpha1.TuningFailureOutOfMemory, reason)
+	assert.Equal(t, "exited with code 4", message)
+
+	// Without pods, the message of the job condition is reported.
+	jobObj.Name = "gone-workspace"
+	jobObj.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "Job has reached the specified backoff limit"}}
+	reason, message, err = ClassifyJobFailure(context.Background(), workspaceObj, jobObj, kubeClient)
+	assert.NoError(t, err)
+	assert.Equal(t, kaitov1alpha1.TuningFailureUnknown, reason)
+	assert.Equal(t, "Job has reached the specified backoff limit", message)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{MaxPreemptionRetries: 2, MaxOutOfMemoryRetries: 1}
+	preempted := kaitov1alpha1.TuningAttempt{FailureReason: kaitov1alpha1.TuningFailurePreempted, Retried: true}
+	oom := kaitov1alpha1.TuningAttempt{FailureReason: kaitov1alpha1.TuningFailureOutOfMemory, Retried: true}
+
+	assert.True(t, policy.ShouldRetry(kaitov1alpha1.TuningFailurePreempted, nil))
+	assert.True(t, policy.ShouldRetry(kaitov1alpha1.TuningFailurePreempted, []kaitov1alpha1.TuningAttempt{preempted, oom}))
+	assert.False(t, policy.ShouldRetry(kaitov1alpha1.TuningFailurePreempted, []kaitov1alpha1.TuningAttempt{preempted, preempted}))
+	assert.True(t, policy.ShouldRetry(kaitov1alpha1.TuningFailureOutOfMemory, []kaitov1alpha1.TuningAttempt{preempted}))
+	assert.False(t, policy.ShouldRetry(kaitov1alpha1.TuningFailureOutOfMemory, []kaitov1alpha1.TuningAttempt{oom}))
+	assert.False(t, policy.ShouldRetry(kaitov1alpha1.TuningFailureHostOutOfMemory, nil))
+	assert.False(t, policy.ShouldRetry(kaitov1alpha1.TuningFailureUnknown, nil))
+	assert.False(t, policy.ShouldRetry(kaitov1alpha1.TuningFailureNaNLoss, nil))
+	assert.False(t, policy.ShouldRetry(kaitov1alpha1.TuningFailureDatasetError, nil))
+}
+
+func TestConfigureRetries(t *testing.T) {
+	workspaceObj := &kaitov1alpha1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "test-workspace"}}
+	newJob := func() *batchv1.Job {
+		jobObj := &batchv1.Job{}
+		jobObj.Spec.Template.Spec.Containers = []corev1.Container{{Name: "test-workspace"}, {Name: "docker-sidecar"}}
+		return jobObj
+	}
+
+	jobObj := newJob()
+	configureRetries(jobObj, workspaceObj)
+	assert.Equal(t, int32(0), *jobObj.Spec.BackoffLimit)
+	assert.Equal(t, corev1.TerminationMessageFallbackToLogsOnError, jobObj.Spec.Template.Spec.Containers[0].TerminationMessagePolicy)
+	assert.Empty(t, jobObj.Spec.Template.Spec.Containers[0].Env)
+	assert.Empty(t, jobObj.Spec.Template.Spec.Containers[1].TerminationMessagePolicy)
+
+	// The batch size is halved for every retry after running out of memory.
+	workspaceObj.Status.Tuning = &kaitov1alpha1.TuningStatus{Attempts: []kaitov1alpha1.TuningAttempt{
+		{FailureReason: kaitov1alpha1.TuningFailureOutOfMemory, Retried: true},
+		{FailureReason: kaitov1alpha1.TuningFailurePreempted, Retried: true},
+		{FailureReason: kaitov1alpha1.TuningFailureOutOfMemory, Retried: true},
+		{FailureReason: kaitov1alpha1.TuningFailureOutOfMemory},
+	}}
+	jobObj = newJob()
+	configureRetries(jobObj, workspaceObj)
+	assert.Equal(t, []corev1.EnvVar{{Name: BatchSizeDivisorEnv, Value: "4"}}, jobObj.Spec.Template.Spec.Containers[0].Env)
+}
@@ -0,0 +1,96 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package tuning
+
+import (
+	"context"
+	"fmt"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/samber/lo"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// mountVolumeName returns the name of the volume of a mount of the tuning spec, which cannot collide
+// with the volumes of Kaito.
+func mountVolumeName(mount kaitov1alpha1.TuningMount) string {
+	return "mount-" + mount.Name
+}
+
+// configureEnvAndMounts adds the environment variables and the mounts of the tuning spec of the
+// workspace to the tuning container.
+func configureEnvAndMounts(jobObj *batchv1.Job, workspaceObj *kaitov1alpha1.Workspace) {
+	podSpec := &jobObj.Spec.Template.Spec
+	for _, mount := range workspaceObj.Tuning.Mounts {
+		volume := corev1.Volume{Name: mountVolumeName(mount)}
+		if mount.Secret != "" {
+			volume.Secret = &corev1.SecretVolumeSource{SecretName: mount.Secret}
+		} else {
+			volume.ConfigMap = &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: mount.ConfigMap}}
+		}
+		podSpec.Volumes = append(podSpec.Volumes, volume)
+	}
+	for i := range podSpec.Containers {
+		container := &podSpec.Containers[i]
+		if container.Name != workspaceObj.Name {
+			continue
+		}
+		for _, env := range workspaceObj.Tuning.Env {
+			container.Env = append(container.Env, *env.DeepCopy())
+		}
+		for _, mount := range workspaceObj.Tuning.Mounts {
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      mountVolumeName(mount),
+				MountPath: mount.MountPath,
+				ReadOnly:  true,
+			})
+		}
+	}
+}
+
+// checkEnvAndMountReferences checks that the secrets and configmaps referenced by the environment
+// variables and the mounts of the tuning spec exist, rather than leaving the tuning pod stuck.
+func checkEnvAndMountReferences(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, kubeClient client.Client) error {
+	check := func(name string, obj client.Object) error {
+		err := kubeClient.Get(ctx, client.ObjectKey{Name: name, Namespace: workspaceObj.Namespace}, obj)
+		if apierrors.IsNotFound(err) {
+			kind := "secret"
+			if _, ok := obj.(*corev1.ConfigMap); ok {
+				kind = "configmap"
+			}
+			return fmt.Errorf("%s %s referenced by the tuning spec is not found", kind, name)
+		}
+		return err
+	}
+	for _, env := range workspaceObj.Tuning.Env {
+		if env.ValueFrom == nil {
+			continue
+		}
+		if ref := env.ValueFrom.SecretKeyRef; ref != nil && !lo.FromPtr(ref.Optional) {
+			if err := check(ref.Name, &corev1.Secret{}); err != nil {
+				return err
+			}
+		}
+		if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil && !lo.FromPtr(ref.Optional) {
+			if err := check(ref.Name, &corev1.ConfigMap{}); err != nil {
+				return err
+			}
+		}
+	}
+	for _, mount := range workspaceObj.Tuning.Mounts {
+		var err error
+		if mount.Secret != "" {
+			err = check(mount.Secret, &corev1.Secret{})
+		} else {
+			err = check(mount.ConfigMap, &corev1.ConfigMap{})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
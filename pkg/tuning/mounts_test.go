@@ -0,0 +1,87 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package tuning
+
+import (
+	"context"
+	"testing"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func envAndMountsWorkspace() *kaitov1alpha1.Workspace {
+	return &kaitov1alpha1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-workspace", Namespace: "default"},
+		Tuning: &kaitov1alpha1.TuningSpec{
+			Env: []corev1.EnvVar{
+				{Name: "WANDB_PROJECT", Value: "kaito"},
+				{Name: "WANDB_API_KEY", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "wandb"}, Key: "api-key",
+				}}},
+			},
+			Mounts: []kaitov1alpha1.TuningMount{
+				{Name: "pip", MountPath: "/etc/pip", ConfigMap: "pip-conf"},
+				{Name: "netrc", MountPath: "/root/.config/netrc", Secret: "netrc"},
+			},
+		},
+	}
+}
+
+func TestConfigureEnvAndMounts(t *testing.T) {
+	workspaceObj := envAndMountsWorkspace()
+	jobObj := &batchv1.Job{}
+	jobObj.Spec.Template.Spec.Volumes = []corev1.Volume{{Name: "config-volume"}}
+	jobObj.Spec.Template.Spec.Containers = []corev1.Container{{Name: "test-workspace"}, {Name: "docker-sidecar"}}
+
+	configureEnvAndMounts(jobObj, workspaceObj)
+
+	podSpec := jobObj.Spec.Template.Spec
+	assert.Equal(t, []corev1.Volume{
+		{Name: "config-volume"},
+		{Name: "mount-pip", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "pip-conf"},
+		}}},
+		{Name: "mount-netrc", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "netrc"}}},
+	}, podSpec.Volumes)
+	assert.Equal(t, workspaceObj.Tuning.Env, podSpec.Containers[0].Env)
+	assert.Equal(t, []corev1.VolumeMount{
+		{Name: "mount-pip", MountPath: "/etc/pip", ReadOnly: true},
+		{Name: "mount-netrc", MountPath: "/root/.config/netrc", ReadOnly: true},
+	}, podSpec.Containers[0].VolumeMounts)
+	assert.Empty(t, podSpec.Containers[1].Env)
+	assert.Empty(t, podSpec.Containers[1].VolumeMounts)
+}
+
+func TestCheckEnvAndMountReferences(t *testing.T) {
+	ctx := context.Background()
+	workspaceObj := envAndMountsWorkspace()
+	secret := func(name string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+	}
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "pip-conf", Namespace: "default"}}
+
+	kubeClient := fake.NewClientBuilder().WithObjects(secret("netrc"), configMap).Build()
+	err := checkEnvAndMountReferences(ctx, workspaceObj, kubeClient)
+	assert.EqualError(t, err, "secret wandb referenced by the tuning spec is not found")
+
+	// Optional references may be missing.
+	workspaceObj.Tuning.Env[1].ValueFrom.SecretKeyRef.Optional = pointer.Bool(true)
+	assert.NoError(t, checkEnvAndMountReferences(ctx, workspaceObj, kubeClient))
+
+	kubeClient = fake.NewClientBuilder().WithObjects(secret("wandb"), secret("netrc")).Build()
+	err = checkEnvAndMountReferences(ctx, workspaceObj, kubeClient)
+	assert.EqualError(t, err, "configmap pip-conf referenced by the tuning spec is not found")
+}
+
+func TestReservedTuningEnv(t *testing.T) {
+	for _, name := range []string{BatchSizeDivisorEnv, PerDeviceTrainBatchSizeEnv, GradientAccumulationStepsEnv} {
+		assert.Contains(t, kaitov1alpha1.ReservedTuningEnv, name)
+	}
+}
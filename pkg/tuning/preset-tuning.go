@@ -3,7 +3,6 @@ package tuning
 import (
 	"context"
 	"fmt"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/pointer"
 	"knative.dev/pkg/apis"
 	"os"
@@ -72,8 +71,9 @@ func GetTuningImageInfo(ctx context.Context, workspaceObj *kaitov1alpha1.Workspa
 		imageName := string(workspaceObj.Tuning.Preset.Name)
 		imageTag := presetObj.Tag
 		registryName := os.Getenv("PRESET_REGISTRY_NAME")
+		// ApplyWorkloadMutation rewrites the image to its mirror, if any.
 		imageName = fmt.Sprintf("%s/kaito-%s:%s", registryName, imageName, imageTag)
-		return imageName, imagePullSecretRefs
+		return imageName, resources.PresetImagePullSecretRefs()
 	}
 }
 
@@ -117,7 +117,7 @@ func EnsureTuningConfigMap(ctx context.Context, workspaceObj *kaitov1alpha1.Work
 		return existingCM, nil
 	}
 
-	releaseNamespace, err := utils.GetReleaseNamespace()
+	releaseNamespace, err := utils.GetReleaseNamespace(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get release namespace: %v", err)
 	}
@@ -202,19 +202,13 @@ func PrepareOutputDir(outputDir string) (string, error) {
 }
 
 // GetOutputDirFromTrainingArgs retrieves the output directory from training arguments if specified.
-func GetOutputDirFromTrainingArgs(trainingArgs map[string]runtime.RawExtension) (string, *apis.FieldError) {
-	if trainingArgsRaw, exists := trainingArgs["TrainingArguments"]; exists {
-		outputDirValue, found, err := utils.SearchRawExtension(trainingArgsRaw, "output_dir")
-		if err != nil {
-			return "", apis.ErrGeneric(fmt.Sprintf("Failed to parse 'output_dir': %v", err), "output_dir")
-		}
-		if found {
-			outputDir, ok := outputDirValue.(string)
-			if !ok {
-				return "", apis.ErrInvalidValue("output_dir is not a string", "output_dir")
-			}
-			return outputDir, nil
-		}
+func GetOutputDirFromTrainingArgs(trainingConfig *kaitov1alpha1.TrainingConfig) (string, *apis.FieldError) {
+	trainingArgs, err := trainingConfig.GetTrainingArguments()
+	if err != nil {
+		return "", err
+	}
+	if trainingArgs != nil && trainingArgs.OutputDir != nil {
+		return *trainingArgs.OutputDir, nil
 	}
 	return "", nil
 }
@@ -226,12 +220,9 @@ func GetTrainingOutputDir(ctx context.Context, configMap *corev1.ConfigMap) (str
 		return "", err
 	}
 
-	outputDir := ""
-	if trainingArgs := config.TrainingConfig.TrainingArguments; trainingArgs != nil {
-		outputDir, err = GetOutputDirFromTrainingArgs(trainingArgs)
-		if err != nil {
-			return "", err
-		}
+	outputDir, err := GetOutputDirFromTrainingArgs(&config.TrainingConfig)
+	if err != nil {
+		return "", err
 	}
 
 	return PrepareOutputDir(outputDir)
@@ -271,12 +262,27 @@ func CreatePresetTuning(ctx context.Context, workspaceObj *kaitov1alpha1.Workspa
 	if err != nil {
 		return nil, err
 	}
+	if tuningObj.ImageAccessMode != string(kaitov1alpha1.ModelImageAccessModePrivate) {
+		if err := resources.EnsurePresetImagePullSecrets(ctx, workspaceObj.Namespace, kubeClient); err != nil {
+			return nil, err
+		}
+	}
 
 	var initContainers, sidecarContainers []corev1.Container
 	volumes, volumeMounts := setupDefaultSharedVolumes(workspaceObj, cm.Name)
 
+	if err := checkEnvAndMountReferences(ctx, workspaceObj, kubeClient); err != nil {
+		return nil, err
+	}
+	if err := ensureVolumeClaims(ctx, workspaceObj, kubeClient); err != nil {
+		return nil, err
+	}
+
 	// Add shared volume for training output
 	trainingOutputVolume, trainingOutputVolumeMount, outputDir := SetupTrainingOutputVolume(ctx, cm)
+	if source := outputVolumeSource(workspaceObj); source != nil {
+		trainingOutputVolume.VolumeSource = *source
+	}
 	volumes = append(volumes, trainingOutputVolume)
 	volumeMounts = append(volumeMounts, trainingOutputVolumeMount)
 
@@ -303,11 +309,14 @@ func CreatePresetTuning(ctx context.Context, workspaceObj *kaitov1alpha1.Workspa
 		imagePullSecrets = append(imagePullSecrets, *imagePushSecret)
 	}
 
-	modelCommand, err := prepareModelRunParameters(ctx, tuningObj)
+	modelArgs, err := prepareModelRunParameters(ctx, tuningObj)
+	if err != nil {
+		return nil, err
+	}
+	commands, resourceReq, err := prepareTuningParameters(ctx, workspaceObj, modelArgs, tuningObj)
 	if err != nil {
 		return nil, err
 	}
-	commands, resourceReq := prepareTuningParameters(ctx, workspaceObj, modelCommand, tuningObj)
 	tuningImage, tuningImagePullSecrets := GetTuningImageInfo(ctx, workspaceObj, tuningObj)
 	if tuningImagePullSecrets != nil {
 		imagePullSecrets = append(imagePullSecrets, tuningImagePullSecrets...)
@@ -315,6 +324,16 @@ func CreatePresetTuning(ctx context.Context, workspaceObj *kaitov1alpha1.Workspa
 
 	jobObj := resources.GenerateTuningJobManifest(ctx, workspaceObj, tuningImage, imagePullSecrets, *workspaceObj.Resource.Count, commands,
 		containerPorts, nil, nil, resourceReq, tolerations, initContainers, sidecarContainers, volumes, volumeMounts)
+	if resources.RDMAEnabled(workspaceObj) {
+		resources.ConfigureRDMA(resources.PodTemplateOf(jobObj), workspaceObj)
+	}
+	resources.ConfigureScheduling(resources.PodTemplateOf(jobObj), workspaceObj)
+	resources.ConfigureTuningColocation(resources.PodTemplateOf(jobObj), workspaceObj)
+	configureEnvAndMounts(jobObj, workspaceObj)
+	configureBatchSize(jobObj, workspaceObj, tuningObj, cm)
+	configureRetries(jobObj, workspaceObj)
+	resources.ApplyWorkloadMutation(resources.PodTemplateOf(jobObj))
+	resources.SetSpecHash(jobObj)
 
 	err = resources.CreateResource(ctx, jobObj, kubeClient)
 	if client.IgnoreAlreadyExists(err) != nil {
@@ -323,7 +342,26 @@ func CreatePresetTuning(ctx context.Context, workspaceObj *kaitov1alpha1.Workspa
 	return jobObj, nil
 }
 
+// outputVolumeSource returns the volume the training output is saved to, the volume or the PVC of the
+// data destination, or nil for an emptyDir volume.
+func outputVolumeSource(workspaceObj *kaitov1alpha1.Workspace) *corev1.VolumeSource {
+	switch {
+	case workspaceObj.Tuning.Output.VolumeClaim != nil:
+		return volumeClaimSource(OutputVolumeClaimName(workspaceObj))
+	case workspaceObj.Tuning.Output.Volume != nil:
+		return workspaceObj.Tuning.Output.Volume.DeepCopy()
+	}
+	return nil
+}
+
+func volumeClaimSource(claimName string) *corev1.VolumeSource {
+	return &corev1.VolumeSource{
+		PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+	}
+}
+
 // Now there are two options for data destination 1. HostPath - 2. Image
+// The output volume of the data destination is mounted as the training output volume.
 func prepareDataDestination(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, outputDir string) (*corev1.Container, *corev1.LocalObjectReference, corev1.Volume, corev1.VolumeMount, error) {
 	var sidecarContainer *corev1.Container
 	var volume corev1.Volume
@@ -334,8 +372,6 @@ func prepareDataDestination(ctx context.Context, workspaceObj *kaitov1alpha1.Wor
 		image, secret := workspaceObj.Tuning.Output.Image, workspaceObj.Tuning.Output.ImagePushSecret
 		imagePushSecret = &corev1.LocalObjectReference{Name: secret}
 		sidecarContainer, volume, volumeMount = handleImageDataDestination(ctx, outputDir, image, secret)
-		// TODO: Future PR include
-		//case workspaceObj.Tuning.Output.Volume != nil:
 	}
 	return sidecarContainer, imagePushSecret, volume, volumeMount, nil
 }
@@ -368,8 +404,12 @@ func prepareDataSource(ctx context.Context, workspaceObj *kaitov1alpha1.Workspac
 		initContainer, volume, volumeMount = handleImageDataSource(ctx, image)
 	case len(workspaceObj.Tuning.Input.URLs) > 0:
 		initContainer, volume, volumeMount = handleURLDataSource(ctx, workspaceObj)
-		// TODO: Future PR include
-		// case workspaceObj.Tuning.Input.Volume != nil:
+	case workspaceObj.Tuning.Input.Volume != nil:
+		volume, volumeMount = utils.ConfigDataVolume(nil)
+		volume.VolumeSource = *workspaceObj.Tuning.Input.Volume.DeepCopy()
+	}
+	if workspaceObj.Tuning.Input.VolumeClaim != nil {
+		volume.VolumeSource = *volumeClaimSource(InputVolumeClaimName(workspaceObj))
 	}
 	return initContainer, imagePullSecrets, volume, volumeMount, nil
 }
@@ -424,25 +464,41 @@ func handleURLDataSource(ctx context.Context, workspaceObj *kaitov1alpha1.Worksp
 	return initContainer, volume, volumeMount
 }
 
-func prepareModelRunParameters(ctx context.Context, tuningObj *model.PresetParam) (string, error) {
-	modelCommand := utils.BuildCmdStr(TuningFile, tuningObj.ModelRunParams)
-	return modelCommand, nil
+// prepareModelRunParameters returns the tuning script and its arguments.
+func prepareModelRunParameters(ctx context.Context, tuningObj *model.PresetParam) ([]string, error) {
+	// The tuning script is the base of the command so that its arguments are built in exec form:
+	// TuningFile followed by the run parameters ordered by key.
+	modelCommand := utils.NewCommand(TuningFile)
+	if err := modelCommand.AddParams(tuningObj.ModelRunParams); err != nil {
+		return nil, err
+	}
+	argv, ok := modelCommand.Exec()
+	if !ok {
+		return nil, fmt.Errorf("invalid tuning command %q", modelCommand.String())
+	}
+	return argv, nil
 }
 
 // prepareTuningParameters builds a PyTorch command:
 // accelerate launch <TORCH_PARAMS> baseCommand <MODEL_PARAMS>
 // and sets the GPU resources required for tuning.
 // Returns the command and resource configuration.
-func prepareTuningParameters(ctx context.Context, wObj *kaitov1alpha1.Workspace, modelCommand string, tuningObj *model.PresetParam) ([]string, corev1.ResourceRequirements) {
+func prepareTuningParameters(ctx context.Context, wObj *kaitov1alpha1.Workspace, modelArgs []string, tuningObj *model.PresetParam) ([]string, corev1.ResourceRequirements, error) {
 	if tuningObj.TorchRunParams == nil {
 		tuningObj.TorchRunParams = make(map[string]string)
 	}
 	// Set # of processes to GPU Count
 	numProcesses := getInstanceGPUCount(wObj.Resource.InstanceType)
 	tuningObj.TorchRunParams["num_processes"] = fmt.Sprintf("%d", numProcesses)
-	torchCommand := utils.BuildCmdStr(tuningObj.BaseCommand, tuningObj.TorchRunParams)
-	torchCommand = utils.BuildCmdStr(torchCommand, tuningObj.TorchRunRdzvParams)
-	commands := utils.ShellCmd(torchCommand + " " + modelCommand)
+	command := utils.NewCommand(tuningObj.BaseCommand)
+	if err := command.AddParams(tuningObj.TorchRunParams); err != nil {
+		return nil, corev1.ResourceRequirements{}, err
+	}
+	if err := command.AddParams(tuningObj.TorchRunRdzvParams); err != nil {
+		return nil, corev1.ResourceRequirements{}, err
+	}
+	command.AddArgs(modelArgs...)
+	commands := command.ContainerCommand()
 
 	resourceRequirements := corev1.ResourceRequirements{
 		Requests: corev1.ResourceList{
@@ -453,5 +509,5 @@ func prepareTuningParameters(ctx context.Context, wObj *kaitov1alpha1.Workspace,
 		},
 	}
 
-	return commands, resourceRequirements
+	return commands, resourceRequirements, nil
 }
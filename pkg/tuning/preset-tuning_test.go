@@ -17,11 +17,14 @@ import (
 	"github.com/azure/kaito/pkg/utils/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 // Mocking the SupportedGPUConfigs to be used in test scenarios.
@@ -409,7 +412,7 @@ func TestPrepareTuningParameters(t *testing.T) {
 	testcases := map[string]struct {
 		name                 string
 		workspaceObj         *kaitov1alpha1.Workspace
-		modelCommand         string
+		modelArgs            []string
 		tuningObj            *model.PresetParam
 		expectedCommands     []string
 		expectedRequirements corev1.ResourceRequirements
@@ -420,14 +423,14 @@ func TestPrepareTuningParameters(t *testing.T) {
 					InstanceType: "gpu-instance-type",
 				},
 			},
-			modelCommand: "model-command",
+			modelArgs: []string{"model-command"},
 			tuningObj: &model.PresetParam{
 				BaseCommand:         "python train.py",
 				TorchRunParams:      map[string]string{},
 				TorchRunRdzvParams:  map[string]string{},
 				GPUCountRequirement: "2",
 			},
-			expectedCommands: []string{"/bin/sh", "-c", "python train.py --num_processes=1 model-command"},
+			expectedCommands: []string{"python", "train.py", "--num_processes=1", "model-command"},
 			expectedRequirements: corev1.ResourceRequirements{
 				Requests: corev1.ResourceList{
 					corev1.ResourceName("nvidia.com/gpu"): resource.MustParse("2"),
@@ -441,7 +444,8 @@ func TestPrepareTuningParameters(t *testing.T) {
 
 	for name, tc := range testcases {
 		t.Run(name, func(t *testing.T) {
-			commands, resources := prepareTuningParameters(ctx, tc.workspaceObj, tc.modelCommand, tc.tuningObj)
+			commands, resources, err := prepareTuningParameters(ctx, tc.workspaceObj, tc.modelArgs, tc.tuningObj)
+			assert.NoError(t, err)
 			assert.Equal(t, tc.expectedCommands, commands)
 			assert.Equal(t, tc.expectedRequirements.Requests, resources.Requests)
 			assert.Equal(t, tc.expectedRequirements.Limits, resources.Limits)
@@ -486,3 +490,103 @@ func TestPrepareDataSource_ImageSource(t *testing.T) {
 	assert.Equal(t, expectedVolumeMount, volumeMount)
 	assert.Equal(t, expectedImagePullSecrets, imagePullSecrets)
 }
+
+func TestPrepareDataSource_VolumeSource(t *testing.T) {
+	hostPath := &corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/data/dataset"}}
+	workspaceObj := &kaitov1alpha1.Workspace{
+		Tuning: &kaitov1alpha1.TuningSpec{
+			Input: &kaitov1alpha1.DataSource{Volume: hostPath},
+		},
+	}
+
+	initContainer, imagePullSecrets, volume, volumeMount, err := prepareDataSource(context.TODO(), workspaceObj)
+
+	assert.NoError(t, err)
+	assert.Nil(t, initContainer)
+	assert.Nil(t, imagePullSecrets)
+	assert.Equal(t, corev1.Volume{Name: "data-volume", VolumeSource: *hostPath}, volume)
+	assert.Equal(t, corev1.VolumeMount{Name: "data-volume", MountPath: "/mnt/data"}, volumeMount)
+}
+
+func TestPrepareDataSource_VolumeClaim(t *testing.T) {
+	workspaceObj := &kaitov1alpha1.Workspace{
+		Tuning: &kaitov1alpha1.TuningSpec{
+			Input: &kaitov1alpha1.DataSource{
+				URLs:        []string{"http://example.com/data"},
+				VolumeClaim: &kaitov1alpha1.VolumeClaimSpec{Size: resource.MustParse("100Gi")},
+			},
+		},
+	}
+	workspaceObj.Name = "test-workspace"
+
+	initContainer, _, volume, volumeMount, err := prepareDataSource(context.TODO(), workspaceObj)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "data-downloader", initContainer.Name)
+	assert.Equal(t, corev1.Volume{
+		Name: "data-volume",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "test-workspace-tuning-input"},
+		},
+	}, volume)
+	assert.Equal(t, corev1.VolumeMount{Name: "data-volume", MountPath: "/mnt/data"}, volumeMount)
+}
+
+func TestOutputVolumeSource(t *testing.T) {
+	hostPath := &corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/data/output"}}
+	testcases := map[string]struct {
+		output   *kaitov1alpha1.DataDestination
+		expected *corev1.VolumeSource
+	}{
+		"Image destination": {
+			output: &kaitov1alpha1.DataDestination{Image: "registry/output:0.0.1", ImagePushSecret: "secret"},
+		},
+		"Volume destination": {
+			output:   &kaitov1alpha1.DataDestination{Volume: hostPath},
+			expected: hostPath,
+		},
+		"Volume claim destination": {
+			output: &kaitov1alpha1.DataDestination{VolumeClaim: &kaitov1alpha1.VolumeClaimSpec{Size: resource.MustParse("10Gi")}},
+			expected: &corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "test-workspace-tuning-output"},
+			},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			workspaceObj := &kaitov1alpha1.Workspace{Tuning: &kaitov1alpha1.TuningSpec{Output: tc.output}}
+			workspaceObj.Name = "test-workspace"
+			assert.Equal(t, tc.expected, outputVolumeSource(workspaceObj))
+		})
+	}
+}
+
+func TestCreatePresetTuningCommand(t *testing.T) {
+	kaitov1alpha1.SupportedGPUConfigs = mockSupportedGPUConfigs
+	workspaceObj := &kaitov1alpha1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-workspace", Namespace: "default"},
+		Resource:   kaitov1alpha1.ResourceSpec{Count: pointer.Int(1), InstanceType: "sku2"},
+		Tuning: &kaitov1alpha1.TuningSpec{
+			Preset: &kaitov1alpha1.PresetSpec{PresetMeta: kaitov1alpha1.PresetMeta{Name: "falcon-7b"}},
+			Method: kaitov1alpha1.TuningMethodLora,
+			Input:  &kaitov1alpha1.DataSource{URLs: []string{"https://example.com/data.parquet"}},
+			Output: &kaitov1alpha1.DataDestination{Image: "registry/adapter:0.0.1", ImagePushSecret: "push-secret"},
+		},
+	}
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: kaitov1alpha1.DefaultLoraConfigMapTemplate, Namespace: "default"}}
+	kubeClient := fake.NewClientBuilder().WithObjects(configMap).Build()
+	tuningObj := &model.PresetParam{
+		BaseCommand:         "accelerate launch",
+		TorchRunParams:      map[string]string{},
+		ModelRunParams:      map[string]string{"pipeline": "text-generation", "trust_remote_code": ""},
+		GPUCountRequirement: "2",
+	}
+
+	obj, err := CreatePresetTuning(context.Background(), workspaceObj, tuningObj, kubeClient)
+	assert.NoError(t, err)
+	jobObj := obj.(*batchv1.Job)
+	assert.Equal(t, "test-workspace", jobObj.Spec.Template.Spec.Containers[0].Name)
+	assert.Equal(t, []string{"accelerate", "launch", "--num_processes=4", TuningFile, "--pipeline=text-generation", "--trust_remote_code"},
+		jobObj.Spec.Template.Spec.Containers[0].Command)
+}
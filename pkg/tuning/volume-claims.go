@@ -0,0 +1,108 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package tuning
+
+import (
+	"context"
+	"fmt"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/resources"
+	"github.com/samber/lo"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// InputVolumeClaimName returns the name of the PVC the tuning input of a workspace is downloaded to.
+func InputVolumeClaimName(workspaceObj *kaitov1alpha1.Workspace) string {
+	return fmt.Sprintf("%s-tuning-input", workspaceObj.Name)
+}
+
+// OutputVolumeClaimName returns the name of the PVC the tuning output of a workspace is saved to.
+func OutputVolumeClaimName(workspaceObj *kaitov1alpha1.Workspace) string {
+	return fmt.Sprintf("%s-tuning-output", workspaceObj.Name)
+}
+
+// volumeClaims returns the PVCs of the tuning input and output of the workspace created by Kaito, by name.
+func volumeClaims(workspaceObj *kaitov1alpha1.Workspace) map[string]*kaitov1alpha1.VolumeClaimSpec {
+	claims := map[string]*kaitov1alpha1.VolumeClaimSpec{}
+	if workspaceObj.Tuning.Input != nil && workspaceObj.Tuning.Input.VolumeClaim != nil {
+		claims[InputVolumeClaimName(workspaceObj)] = workspaceObj.Tuning.Input.VolumeClaim
+	}
+	if workspaceObj.Tuning.Output != nil && workspaceObj.Tuning.Output.VolumeClaim != nil {
+		claims[OutputVolumeClaimName(workspaceObj)] = workspaceObj.Tuning.Output.VolumeClaim
+	}
+	return claims
+}
+
+// GenerateVolumeClaimManifest returns a PVC of the tuning job of a workspace. The PVC is owned by
+// the workspace, it is deleted with the workspace unless deleted earlier per its retention policy.
+func GenerateVolumeClaimManifest(workspaceObj *kaitov1alpha1.Workspace, name string, claim *kaitov1alpha1.VolumeClaimSpec) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: workspaceObj.Namespace,
+			Labels: map[string]string{
+				kaitov1alpha1.LabelWorkspaceName: workspaceObj.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: kaitov1alpha1.GroupVersion.String(),
+					Kind:       "Workspace",
+					UID:        workspaceObj.UID,
+					Name:       workspaceObj.Name,
+					Controller: lo.ToPtr(true),
+				},
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: claim.StorageClassName,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: claim.Size,
+				},
+			},
+		},
+	}
+}
+
+// ensureVolumeClaims creates the PVCs of the tuning input and output of the workspace.
+func ensureVolumeClaims(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, kubeClient client.Client) error {
+	for name, claim := range volumeClaims(workspaceObj) {
+		pvc := GenerateVolumeClaimManifest(workspaceObj, name, claim)
+		if err := resources.CreateResource(ctx, pvc, kubeClient); client.IgnoreAlreadyExists(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleaseVolumeClaims deletes the PVCs of the tuning job with the Delete retention policy once the
+// job has completed. The volumes of a failed job are kept for troubleshooting.
+func ReleaseVolumeClaims(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, jobObj *batchv1.Job, kubeClient client.Client) error {
+	_, completed := lo.Find(jobObj.Status.Conditions, func(condition batchv1.JobCondition) bool {
+		return condition.Type == batchv1.JobComplete && condition.Status == corev1.ConditionTrue
+	})
+	if !completed {
+		return nil
+	}
+	for name, claim := range volumeClaims(workspaceObj) {
+		if claim.RetentionPolicy != kaitov1alpha1.VolumeClaimRetentionPolicyDelete {
+			continue
+		}
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: workspaceObj.Namespace},
+		}
+		if err := kubeClient.Delete(ctx, pvc); client.IgnoreNotFound(err) != nil {
+			return err
+		} else if err == nil {
+			klog.InfoS("Deleted the volume claim of the completed tuning job", "pvc", klog.KObj(pvc))
+		}
+	}
+	return nil
+}
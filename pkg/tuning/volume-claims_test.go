@@ -0,0 +1,81 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package tuning
+
+import (
+	"context"
+	"testing"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func volumeClaimWorkspace() *kaitov1alpha1.Workspace {
+	return &kaitov1alpha1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-workspace", Namespace: "default", UID: "uid"},
+		Tuning: &kaitov1alpha1.TuningSpec{
+			Input: &kaitov1alpha1.DataSource{
+				URLs: []string{"http://example.com/data"},
+				VolumeClaim: &kaitov1alpha1.VolumeClaimSpec{
+					Size:            resource.MustParse("100Gi"),
+					RetentionPolicy: kaitov1alpha1.VolumeClaimRetentionPolicyDelete,
+				},
+			},
+			Output: &kaitov1alpha1.DataDestination{
+				VolumeClaim: &kaitov1alpha1.VolumeClaimSpec{
+					Size:             resource.MustParse("10Gi"),
+					StorageClassName: pointer.String("premium"),
+					RetentionPolicy:  kaitov1alpha1.VolumeClaimRetentionPolicyRetain,
+				},
+			},
+		},
+	}
+}
+
+func TestGenerateVolumeClaimManifest(t *testing.T) {
+	workspaceObj := volumeClaimWorkspace()
+	pvc := GenerateVolumeClaimManifest(workspaceObj, OutputVolumeClaimName(workspaceObj), workspaceObj.Tuning.Output.VolumeClaim)
+
+	assert.Equal(t, "test-workspace-tuning-output", pvc.Name)
+	assert.Equal(t, "default", pvc.Namespace)
+	assert.Equal(t, "test-workspace", pvc.Labels[kaitov1alpha1.LabelWorkspaceName])
+	assert.Equal(t, "test-workspace", pvc.OwnerReferences[0].Name)
+	assert.Equal(t, pointer.String("premium"), pvc.Spec.StorageClassName)
+	assert.Equal(t, resource.MustParse("10Gi"), pvc.Spec.Resources.Requests[corev1.ResourceStorage])
+}
+
+func TestVolumeClaimLifecycle(t *testing.T) {
+	ctx := context.Background()
+	workspaceObj := volumeClaimWorkspace()
+	kubeClient := fake.NewClientBuilder().Build()
+
+	assert.NoError(t, ensureVolumeClaims(ctx, workspaceObj, kubeClient))
+	// The claims are created once.
+	assert.NoError(t, ensureVolumeClaims(ctx, workspaceObj, kubeClient))
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	assert.NoError(t, kubeClient.List(ctx, pvcs, client.InNamespace("default")))
+	assert.Len(t, pvcs.Items, 2)
+
+	jobObj := &batchv1.Job{}
+	assert.NoError(t, ReleaseVolumeClaims(ctx, workspaceObj, jobObj, kubeClient))
+	assert.NoError(t, kubeClient.List(ctx, pvcs, client.InNamespace("default")))
+	assert.Len(t, pvcs.Items, 2, "the claims are kept while the job runs")
+
+	jobObj.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	assert.NoError(t, ReleaseVolumeClaims(ctx, workspaceObj, jobObj, kubeClient))
+	err := kubeClient.Get(ctx, client.ObjectKey{Name: "test-workspace-tuning-input", Namespace: "default"}, &corev1.PersistentVolumeClaim{})
+	assert.True(t, apierrors.IsNotFound(err))
+	assert.NoError(t, kubeClient.Get(ctx, client.ObjectKey{Name: "test-workspace-tuning-output", Namespace: "default"}, &corev1.PersistentVolumeClaim{}))
+
+	// Releasing the claims again is a no-op.
+	assert.NoError(t, ReleaseVolumeClaims(ctx, workspaceObj, jobObj, kubeClient))
+}
@@ -0,0 +1,336 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+// Package usage reports the usage of the workspaces for chargeback: the GPU time of their nodes and the
+// requests and tokens served by their preset inference services. A UsageReport is written per workspace
+// and period, and optionally posted to a webhook, so that FinOps tools do not have to scrape the clusters.
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// MetricsPath is the path of the Prometheus metrics of the preset inference services.
+	MetricsPath = "/metrics/prometheus"
+
+	inferencePort = 5000
+
+	metricRequests     = "inference_generation_requests_total"
+	metricInputTokens  = "inference_input_tokens_total"
+	metricOutputTokens = "inference_output_tokens_total"
+)
+
+// Reporter writes the usage report of every workspace each interval. It runs on the leader only.
+type Reporter struct {
+	Client   client.Client
+	Interval time.Duration
+	// Retention is the number of reports kept per workspace, the older ones are deleted. Zero keeps them all.
+	Retention int
+	// WebhookURL, if set, receives every report as a JSON POST request. The reports that fail to be posted
+	// are posted again on the next interval.
+	WebhookURL string
+	HTTPClient *http.Client
+	// Scrape returns the counters of an inference pod. It defaults to scraping the metrics of its inference service.
+	Scrape func(ctx context.Context, pod *corev1.Pod) (kaitov1alpha1.PodUsage, error)
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that a single replica writes the reports.
+func (r *Reporter) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable.
+func (r *Reporter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.Report(ctx); err != nil {
+				klog.ErrorS(err, "failed to report the usage of the workspaces")
+			}
+		}
+	}
+}
+
+// Report writes the usage reports of all the workspaces for the period since their last report.
+func (r *Reporter) Report(ctx context.Context) error {
+	workspaces := &kaitov1alpha1.WorkspaceList{}
+	if err := r.Client.List(ctx, workspaces); err != nil {
+		return err
+	}
+	var errs []error
+	for i := range workspaces.Items {
+		if err := r.reportWorkspace(ctx, &workspaces.Items[i]); err != nil {
+			errs = append(errs, fmt.Errorf("workspace %s/%s: %w", workspaces.Items[i].Namespace, workspaces.Items[i].Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Reporter) reportWorkspace(ctx context.Context, wObj *kaitov1alpha1.Workspace) error {
+	if !wObj.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	reports := &kaitov1alpha1.UsageReportList{}
+	if err := r.Client.List(ctx, reports, client.InNamespace(wObj.Namespace),
+		client.MatchingLabels{kaitov1alpha1.LabelWorkspaceName: wObj.Name}); err != nil {
+		return err
+	}
+	sort.Slice(reports.Items, func(i, j int) bool {
+		return reports.Items[i].Spec.End.Before(&reports.Items[j].Spec.End)
+	})
+
+	end := r.now().UTC().Truncate(time.Second)
+	start := wObj.CreationTimestamp.Time
+	var previous *kaitov1alpha1.UsageReport
+	if len(reports.Items) > 0 {
+		previous = &reports.Items[len(reports.Items)-1]
+		start = previous.Spec.End.Time
+	}
+	if end.After(start) {
+		report := &kaitov1alpha1.UsageReport{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%d", wObj.Name, end.Unix()),
+				Namespace: wObj.Namespace,
+				Labels:    map[string]string{kaitov1alpha1.LabelWorkspaceName: wObj.Name},
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(wObj, kaitov1alpha1.GroupVersion.WithKind("Workspace")),
+				},
+			},
+			Spec: kaitov1alpha1.UsageReportSpec{
+				Workspace:    wObj.Name,
+				Start:        metav1.NewTime(start),
+				End:          metav1.NewTime(end),
+				InstanceType: wObj.Resource.InstanceType,
+				GPUHours:     fmt.Sprintf("%.3f", GPUHours(wObj, end.Sub(start))),
+			},
+		}
+		if wObj.Inference != nil && wObj.Inference.Preset != nil {
+			if err := r.countRequests(ctx, wObj, previous, &report.Spec); err != nil {
+				return err
+			}
+		}
+		if err := r.Client.Create(ctx, report); err != nil {
+			return err
+		}
+		reports.Items = append(reports.Items, *report)
+	}
+
+	if r.WebhookURL != "" {
+		if err := r.postReports(ctx, reports.Items); err != nil {
+			return err
+		}
+	}
+	return r.prune(ctx, reports.Items)
+}
+
+// postReports posts the reports not posted yet to the webhook, oldest first, and records the outcome in
+// their Posted condition. It stops at the first failure, so that the webhook receives the reports in order
+// and the failed report and the following ones are posted again on the next interval.
+func (r *Reporter) postReports(ctx context.Context, reports []kaitov1alpha1.UsageReport) error {
+	for i := range reports {
+		report := &reports[i]
+		if isPosted(report) {
+			continue
+		}
+		postErr := r.post(ctx, report)
+		condition := metav1.Condition{
+			Type:               string(kaitov1alpha1.UsageReportConditionTypePosted),
+			Status:             metav1.ConditionTrue,
+			Reason:             "Posted",
+			Message:            "The report was posted to the usage report webhook",
+			ObservedGeneration: report.Generation,
+			LastTransitionTime: metav1.NewTime(r.now()),
+		}
+		if postErr != nil {
+			condition.Status, condition.Reason, condition.Message = metav1.ConditionFalse, "PostFailed", postErr.Error()
+		}
+		meta.SetStatusCondition(&report.Status.Conditions, condition)
+		if err := r.Client.Status().Update(ctx, report); err != nil {
+			if postErr != nil {
+				return fmt.Errorf("failed to post usage report %s: %w", report.Name, errors.Join(postErr, err))
+			}
+			// The report is posted again on the next interval, the webhook must tolerate duplicates.
+			return fmt.Errorf("failed to record the posting of usage report %s: %w", report.Name, err)
+		}
+		if postErr != nil {
+			return fmt.Errorf("failed to post usage report %s: %w", report.Name, postErr)
+		}
+	}
+	return nil
+}
+
+func isPosted(report *kaitov1alpha1.UsageReport) bool {
+	return meta.IsStatusConditionTrue(report.Status.Conditions, string(kaitov1alpha1.UsageReportConditionTypePosted))
+}
+
+// GPUHours returns the GPU time of the ready nodes of the workspace over the duration. The nodes are the
+// ready nodes at the time of the report, the nodes added or removed during the duration are not prorated.
+func GPUHours(wObj *kaitov1alpha1.Workspace, duration time.Duration) float64 {
+	gpuConfig, ok := kaitov1alpha1.SupportedGPUConfigs[wObj.Resource.InstanceType]
+	if !ok {
+		return 0
+	}
+	return float64(gpuConfig.GPUCount*len(wObj.Status.WorkerNodes)) * duration.Hours()
+}
+
+// countRequests sets the counts of the report from the counters of the inference pods. The counts of
+// a pod are the difference with its counters in the previous report, or its counters if they were reset.
+// The pods that cannot be scraped keep their previous counters.
+func (r *Reporter) countRequests(ctx context.Context, wObj *kaitov1alpha1.Workspace, previous *kaitov1alpha1.UsageReport, spec *kaitov1alpha1.UsageReportSpec) error {
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods, client.InNamespace(wObj.Namespace),
+		client.MatchingLabels{kaitov1alpha1.LabelWorkspaceName: wObj.Name}); err != nil {
+		return err
+	}
+	previousPods := map[string]kaitov1alpha1.PodUsage{}
+	if previous != nil {
+		for _, pod := range previous.Spec.Pods {
+			previousPods[pod.Name] = pod
+		}
+	}
+
+	sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+		last, seen := previousPods[pod.Name]
+		current, err := r.scrape(ctx, pod)
+		if err != nil {
+			klog.V(2).InfoS("Unable to read the usage counters of the inference pod", "pod", klog.KObj(pod), "err", err)
+			if seen {
+				spec.Pods = append(spec.Pods, last)
+			}
+			continue
+		}
+		current.Name = pod.Name
+		if current.Requests < last.Requests || current.InputTokens < last.InputTokens || current.OutputTokens < last.OutputTokens {
+			// The inference service restarted.
+			last = kaitov1alpha1.PodUsage{}
+		}
+		spec.Requests += current.Requests - last.Requests
+		spec.InputTokens += current.InputTokens - last.InputTokens
+		spec.OutputTokens += current.OutputTokens - last.OutputTokens
+		spec.Pods = append(spec.Pods, current)
+	}
+	return nil
+}
+
+func (r *Reporter) scrape(ctx context.Context, pod *corev1.Pod) (kaitov1alpha1.PodUsage, error) {
+	if r.Scrape != nil {
+		return r.Scrape(ctx, pod)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s:%d%s", pod.Status.PodIP, inferencePort, MetricsPath), nil)
+	if err != nil {
+		return kaitov1alpha1.PodUsage{}, err
+	}
+	resp, err := r.httpClient().Do(req)
+	if err != nil {
+		return kaitov1alpha1.PodUsage{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return kaitov1alpha1.PodUsage{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	families, err := (&expfmt.TextParser{}).TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return kaitov1alpha1.PodUsage{}, err
+	}
+	return ParseCounters(families), nil
+}
+
+// ParseCounters returns the usage counters of the metrics of a preset inference service.
+func ParseCounters(families map[string]*dto.MetricFamily) kaitov1alpha1.PodUsage {
+	counter := func(name string) int64 {
+		family, ok := families[name]
+		if !ok {
+			return 0
+		}
+		var total float64
+		for _, metric := range family.GetMetric() {
+			total += metric.GetCounter().GetValue()
+		}
+		return int64(total)
+	}
+	return kaitov1alpha1.PodUsage{
+		Requests:     counter(metricRequests),
+		InputTokens:  counter(metricInputTokens),
+		OutputTokens: counter(metricOutputTokens),
+	}
+}
+
+func (r *Reporter) post(ctx context.Context, report *kaitov1alpha1.UsageReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// prune deletes the oldest reports beyond the retention. The reports are sorted by period. The reports
+// not posted yet to the webhook are kept until they are posted.
+func (r *Reporter) prune(ctx context.Context, reports []kaitov1alpha1.UsageReport) error {
+	if r.Retention <= 0 || len(reports) <= r.Retention {
+		return nil
+	}
+	for i := range reports[:len(reports)-r.Retention] {
+		if r.WebhookURL != "" && !isPosted(&reports[i]) {
+			continue
+		}
+		if err := r.Client.Delete(ctx, &reports[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reporter) httpClient() *http.Client {
+	if r.HTTPClient != nil {
+		return r.HTTPClient
+	}
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+func (r *Reporter) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
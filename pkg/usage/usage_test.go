@@ -0,0 +1,314 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/prometheus/common/expfmt"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	assert.NilError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NilError(t, kaitov1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+func newWorkspace() *kaitov1alpha1.Workspace {
+	return &kaitov1alpha1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "falcon",
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(now.Add(-2 * time.Hour)),
+		},
+		Resource: kaitov1alpha1.ResourceSpec{InstanceType: "Standard_NC12s_v3", Count: pointer.Int(1)},
+		Inference: &kaitov1alpha1.InferenceSpec{
+			Preset: &kaitov1alpha1.PresetSpec{PresetMeta: kaitov1alpha1.PresetMeta{Name: "falcon-7b"}},
+		},
+		Status: kaitov1alpha1.WorkspaceStatus{WorkerNodes: []string{"node1"}},
+	}
+}
+
+func newPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{kaitov1alpha1.LabelWorkspaceName: "falcon"},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.1"},
+	}
+}
+
+func listReports(t *testing.T, c client.Client) []kaitov1alpha1.UsageReport {
+	reports := &kaitov1alpha1.UsageReportList{}
+	assert.NilError(t, c.List(context.Background(), reports))
+	sort.Slice(reports.Items, func(i, j int) bool {
+		return reports.Items[i].Spec.End.Before(&reports.Items[j].Spec.End)
+	})
+	return reports.Items
+}
+
+func TestReport(t *testing.T) {
+	previous := &kaitov1alpha1.UsageReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "falcon-previous",
+			Namespace: "default",
+			Labels:    map[string]string{kaitov1alpha1.LabelWorkspaceName: "falcon"},
+		},
+		Spec: kaitov1alpha1.UsageReportSpec{
+			Workspace: "falcon",
+			Start:     metav1.NewTime(now.Add(-2 * time.Hour)),
+			End:       metav1.NewTime(now.Add(-30 * time.Minute)),
+			GPUHours:  "3.000",
+			Pods: []kaitov1alpha1.PodUsage{
+				{Name: "falcon-a", Requests: 10, InputTokens: 100, OutputTokens: 1000},
+				{Name: "falcon-b", Requests: 5, InputTokens: 50, OutputTokens: 500},
+				{Name: "falcon-c", Requests: 1, InputTokens: 10, OutputTokens: 100},
+			},
+		},
+	}
+	counters := map[string]kaitov1alpha1.PodUsage{
+		// Counts since the previous report.
+		"falcon-a": {Name: "falcon-a", Requests: 12, InputTokens: 120, OutputTokens: 1200},
+		// Restarted since the previous report.
+		"falcon-b": {Name: "falcon-b", Requests: 1, InputTokens: 10, OutputTokens: 100},
+		// New since the previous report.
+		"falcon-d": {Name: "falcon-d", Requests: 3, InputTokens: 30, OutputTokens: 300},
+	}
+
+	testcases := map[string]struct {
+		workspace       *kaitov1alpha1.Workspace
+		objects         []client.Object
+		expectedReports int
+		expectedSpec    kaitov1alpha1.UsageReportSpec
+	}{
+		"First report of a workspace": {
+			workspace:       newWorkspace(),
+			objects:         []client.Object{newPod("falcon-a")},
+			expectedReports: 1,
+			expectedSpec: kaitov1alpha1.UsageReportSpec{
+				Workspace:    "falcon",
+				Start:        metav1.NewTime(now.Add(-2 * time.Hour)),
+				End:          metav1.NewTime(now),
+				InstanceType: "Standard_NC12s_v3",
+				GPUHours:     "4.000",
+				Requests:     12,
+				InputTokens:  120,
+				OutputTokens: 1200,
+				Pods:         []kaitov1alpha1.PodUsage{counters["falcon-a"]},
+			},
+		},
+		"Report since the previous report": {
+			workspace:       newWorkspace(),
+			objects:         []client.Object{previous.DeepCopy(), newPod("falcon-a"), newPod("falcon-b"), newPod("falcon-c"), newPod("falcon-d")},
+			expectedReports: 2,
+			expectedSpec: kaitov1alpha1.UsageReportSpec{
+				Workspace:    "falcon",
+				Start:        metav1.NewTime(now.Add(-30 * time.Minute)),
+				End:          metav1.NewTime(now),
+				InstanceType: "Standard_NC12s_v3",
+				GPUHours:     "1.000",
+				Requests:     2 + 1 + 3,
+				InputTokens:  20 + 10 + 30,
+				OutputTokens: 200 + 100 + 300,
+				Pods: []kaitov1alpha1.PodUsage{
+					counters["falcon-a"],
+					counters["falcon-b"],
+					// The counters of the pods that cannot be scraped are kept.
+					{Name: "falcon-c", Requests: 1, InputTokens: 10, OutputTokens: 100},
+					counters["falcon-d"],
+				},
+			},
+		},
+		"Workspace without ready nodes": {
+			workspace: func() *kaitov1alpha1.Workspace {
+				workspace := newWorkspace()
+				workspace.Inference = nil
+				workspace.Tuning = &kaitov1alpha1.TuningSpec{}
+				workspace.Status.WorkerNodes = nil
+				return workspace
+			}(),
+			objects:         []client.Object{newPod("falcon-a")},
+			expectedReports: 1,
+			expectedSpec: kaitov1alpha1.UsageReportSpec{
+				Workspace:    "falcon",
+				Start:        metav1.NewTime(now.Add(-2 * time.Hour)),
+				End:          metav1.NewTime(now),
+				InstanceType: "Standard_NC12s_v3",
+				GPUHours:     "0.000",
+			},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			kubeClient := fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(tc.workspace).WithObjects(tc.objects...).Build()
+			reporter := &Reporter{
+				Client: kubeClient,
+				Now:    func() time.Time { return now },
+				Scrape: func(_ context.Context, pod *corev1.Pod) (kaitov1alpha1.PodUsage, error) {
+					usage, ok := counters[pod.Name]
+					if !ok {
+						return kaitov1alpha1.PodUsage{}, errors.New("connection refused")
+					}
+					return usage, nil
+				},
+			}
+
+			assert.NilError(t, reporter.Report(context.Background()))
+			reports := listReports(t, kubeClient)
+			assert.Equal(t, len(reports), tc.expectedReports)
+			report := reports[len(reports)-1]
+			assert.Equal(t, report.Name, "falcon-1767268800")
+			assert.Equal(t, report.Labels[kaitov1alpha1.LabelWorkspaceName], "falcon")
+			assert.Equal(t, report.OwnerReferences[0].Name, "falcon")
+			assert.Equal(t, report.Spec.Start.Unix(), tc.expectedSpec.Start.Unix())
+			assert.Equal(t, report.Spec.End.Unix(), tc.expectedSpec.End.Unix())
+			report.Spec.Start, report.Spec.End = tc.expectedSpec.Start, tc.expectedSpec.End
+			assert.DeepEqual(t, report.Spec, tc.expectedSpec)
+
+			// Nothing is reported again for the same period.
+			assert.NilError(t, reporter.Report(context.Background()))
+			assert.Equal(t, len(listReports(t, kubeClient)), tc.expectedReports)
+		})
+	}
+}
+
+func TestReportRetentionAndWebhook(t *testing.T) {
+	var posted []kaitov1alpha1.UsageReport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, req.Method, http.MethodPost)
+		assert.Equal(t, req.Header.Get("Content-Type"), "application/json")
+		report := kaitov1alpha1.UsageReport{}
+		assert.NilError(t, json.NewDecoder(req.Body).Decode(&report))
+		posted = append(posted, report)
+	}))
+	defer server.Close()
+
+	workspace := newWorkspace()
+	workspace.Inference.Preset = nil
+	kubeClient := fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(workspace).
+		WithStatusSubresource(&kaitov1alpha1.UsageReport{}).Build()
+	current := now
+	reporter := &Reporter{
+		Client:     kubeClient,
+		Retention:  2,
+		WebhookURL: server.URL,
+		Now:        func() time.Time { return current },
+	}
+	for i := 0; i < 3; i++ {
+		assert.NilError(t, reporter.Report(context.Background()))
+		current = current.Add(time.Hour)
+	}
+
+	reports := listReports(t, kubeClient)
+	assert.Equal(t, len(reports), 2)
+	assert.Equal(t, reports[0].Spec.End.Unix(), now.Add(time.Hour).Unix())
+	assert.Equal(t, reports[1].Spec.End.Unix(), now.Add(2*time.Hour).Unix())
+	assert.Equal(t, len(posted), 3)
+	assert.Equal(t, posted[2].Name, reports[1].Name)
+	assert.Equal(t, posted[2].Spec.GPUHours, "2.000")
+
+	for _, report := range reports {
+		assert.Assert(t, isPosted(&report))
+	}
+
+	server.Close()
+	current = current.Add(time.Hour)
+	assert.ErrorContains(t, reporter.Report(context.Background()), "failed to post usage report")
+	reports = listReports(t, kubeClient)
+	condition := meta.FindStatusCondition(reports[len(reports)-1].Status.Conditions, string(kaitov1alpha1.UsageReportConditionTypePosted))
+	assert.Assert(t, condition != nil)
+	assert.Equal(t, condition.Status, metav1.ConditionFalse)
+	assert.Equal(t, condition.Reason, "PostFailed")
+}
+
+func TestReportWebhookRetry(t *testing.T) {
+	failing := true
+	var attempts, posted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := kaitov1alpha1.UsageReport{}
+		assert.NilError(t, json.NewDecoder(req.Body).Decode(&report))
+		attempts = append(attempts, report.Name)
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		posted = append(posted, report.Name)
+	}))
+	defer server.Close()
+
+	workspace := newWorkspace()
+	workspace.Inference.Preset = nil
+	kubeClient := fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(workspace).
+		WithStatusSubresource(&kaitov1alpha1.UsageReport{}).Build()
+	current := now
+	reporter := &Reporter{
+		Client:     kubeClient,
+		Retention:  1,
+		WebhookURL: server.URL,
+		Now:        func() time.Time { return current },
+	}
+
+	for i := 0; i < 2; i++ {
+		assert.ErrorContains(t, reporter.Report(context.Background()), "failed to post usage report")
+		current = current.Add(time.Hour)
+	}
+	// The reports not posted are kept beyond the retention, and the posting stops at the oldest one.
+	reports := listReports(t, kubeClient)
+	assert.Equal(t, len(reports), 2)
+	assert.DeepEqual(t, attempts, []string{reports[0].Name, reports[0].Name})
+	for _, report := range reports {
+		assert.Assert(t, !isPosted(&report))
+	}
+	unposted := []string{reports[0].Name, reports[1].Name}
+
+	failing = false
+	assert.NilError(t, reporter.Report(context.Background()))
+	reports = listReports(t, kubeClient)
+	assert.Equal(t, len(reports), 1)
+	assert.Assert(t, isPosted(&reports[0]))
+	assert.DeepEqual(t, posted, append(unposted, reports[0].Name))
+}
+
+func TestParseCounters(t *testing.T) {
+	metrics := `# HELP inference_generation_requests_total Number of generation requests served.
+# TYPE inference_generation_requests_total counter
+inference_generation_requests_total 3.0
+# HELP inference_generation_requests_created Number of generation requests served.
+# TYPE inference_generation_requests_created gauge
+inference_generation_requests_created 1.7e+09
+# HELP inference_input_tokens_total Number of tokens of the prompts of the generation requests.
+# TYPE inference_input_tokens_total counter
+inference_input_tokens_total 42.0
+# HELP inference_output_tokens_total Number of tokens generated for the generation requests.
+# TYPE inference_output_tokens_total counter
+inference_output_tokens_total 420.0
+`
+	families, err := (&expfmt.TextParser{}).TextToMetricFamilies(strings.NewReader(metrics))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, ParseCounters(families), kaitov1alpha1.PodUsage{Requests: 3, InputTokens: 42, OutputTokens: 420})
+}
@@ -0,0 +1,127 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// paramKeyRegex matches the accepted run parameter names, e.g. "nproc_per_node" or "torch-dtype".
+	paramKeyRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+	// shellSafeRegex matches the arguments that do not need to be quoted in a shell command.
+	shellSafeRegex = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+)
+
+// deniedParamChars are the characters not allowed in run parameter values whatever the command form.
+const deniedParamChars = "\x00\n\r"
+
+// shellSyntaxChars are the characters that make a base command require a shell, e.g. "cd dir && torchrun".
+const shellSyntaxChars = "&|;<>()$`\\\"'*?[]#~={}"
+
+type commandPart struct {
+	arg string
+	// shell is true if arg is a trusted expression to be expanded by the shell.
+	shell bool
+}
+
+// Command builds a container command from the base command of a preset, e.g. "accelerate launch",
+// and run parameters. The base command is trusted, whereas arguments and parameter values are
+// passed to the program as is: they are never interpreted by a shell.
+type Command struct {
+	base  string
+	parts []commandPart
+}
+
+// NewCommand returns a command starting with the base command.
+func NewCommand(base string) *Command {
+	return &Command{base: strings.TrimSpace(base)}
+}
+
+// AddArgs appends literal arguments to the command.
+func (c *Command) AddArgs(args ...string) {
+	for _, arg := range args {
+		c.parts = append(c.parts, commandPart{arg: arg})
+	}
+}
+
+// AddParams appends the run parameters to the command as "--key=value", or "--key" if the value
+// is empty, ordered by key.
+func (c *Command) AddParams(params map[string]string) error {
+	keys := make([]string, 0, len(params))
+	for key, value := range params {
+		if !paramKeyRegex.MatchString(key) {
+			return fmt.Errorf("invalid run parameter name %q", key)
+		}
+		if strings.ContainsAny(value, deniedParamChars) {
+			return fmt.Errorf("invalid value of run parameter %s: control characters are not allowed", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if params[key] == "" {
+			c.AddArgs("--" + key)
+		} else {
+			c.AddArgs(fmt.Sprintf("--%s=%s", key, params[key]))
+		}
+	}
+	return nil
+}
+
+// AddShellParam appends "--key=expr" to the command, where expr is expanded by the shell, e.g. a
+// command substitution. It must only be used with expressions generated by Kaito.
+func (c *Command) AddShellParam(key, expr string) {
+	c.parts = append(c.parts, commandPart{arg: fmt.Sprintf("--%s=%s", key, expr), shell: true})
+}
+
+// Exec returns the command in exec form, or false if the command requires a shell.
+func (c *Command) Exec() ([]string, bool) {
+	if c.base == "" || strings.ContainsAny(c.base, shellSyntaxChars) {
+		return nil, false
+	}
+	argv := strings.Fields(c.base)
+	for _, part := range c.parts {
+		if part.shell {
+			return nil, false
+		}
+		argv = append(argv, part.arg)
+	}
+	return argv, true
+}
+
+// String returns the command in shell form, with the arguments quoted as needed.
+func (c *Command) String() string {
+	var words []string
+	if c.base != "" {
+		words = append(words, c.base)
+	}
+	for _, part := range c.parts {
+		if part.shell {
+			words = append(words, part.arg)
+		} else {
+			words = append(words, ShellQuote(part.arg))
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// ContainerCommand returns the command for a container, in exec form if possible.
+func (c *Command) ContainerCommand() []string {
+	if argv, ok := c.Exec(); ok {
+		return argv
+	}
+	return ShellCmd(c.String())
+}
+
+// ShellQuote quotes s so that a POSIX shell passes it as a single literal argument.
+func ShellQuote(s string) string {
+	if shellSafeRegex.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,69 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	testcases := map[string]struct {
+		base         string
+		params       map[string]string
+		shellParam   string
+		expectedExec []string
+		expectedStr  string
+		expectErr    bool
+	}{
+		"exec form": {
+			base:         "accelerate launch",
+			params:       map[string]string{"num_processes": "2", "trust_remote_code": ""},
+			expectedExec: []string{"accelerate", "launch", "--num_processes=2", "--trust_remote_code"},
+			expectedStr:  "accelerate launch --num_processes=2 --trust_remote_code",
+		},
+		"values are quoted in shell form": {
+			base:        "cd /workspace && torchrun",
+			params:      map[string]string{"prompt": "it's $(id)"},
+			expectedStr: `cd /workspace && torchrun '--prompt=it'\''s $(id)'`,
+		},
+		"shell parameter requires shell form": {
+			base:        "torchrun",
+			shellParam:  "$(hostname)",
+			expectedStr: "torchrun --rank=$(hostname)",
+		},
+		"invalid parameter name": {
+			base:      "torchrun",
+			params:    map[string]string{"--nnodes": "1"},
+			expectErr: true,
+		},
+		"denied character in value": {
+			base:      "torchrun",
+			params:    map[string]string{"nnodes": "1\nid"},
+			expectErr: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			cmd := NewCommand(tc.base)
+			err := cmd.AddParams(tc.params)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if tc.expectErr {
+				return
+			}
+			if tc.shellParam != "" {
+				cmd.AddShellParam("rank", tc.shellParam)
+			}
+			if exec, _ := cmd.Exec(); !reflect.DeepEqual(exec, tc.expectedExec) {
+				t.Errorf("unexpected exec form %q, expect %q", exec, tc.expectedExec)
+			}
+			if s := cmd.String(); s != tc.expectedStr {
+				t.Errorf("unexpected shell form %q, expect %q", s, tc.expectedStr)
+			}
+		})
+	}
+}
@@ -11,6 +11,12 @@ const (
 	DefaultConfigMapMountPath = "/mnt/config"
 	DefaultDataVolumePath     = "/mnt/data"
 	DefaultAdapterVolumePath  = "/mnt/adapter"
+
+	// DefaultModelCacheHostPath is where the local NVMe disks of a node are mounted.
+	DefaultModelCacheHostPath  = "/mnt/kaito-model-cache"
+	DefaultModelCacheMountPath = "/mnt/model-cache"
+
+	DefaultChatTemplatesMountPath = "/workspace/chat_templates"
 )
 
 func ConfigResultsVolume(outputPath string) (corev1.Volume, corev1.VolumeMount) {
@@ -142,3 +148,50 @@ func ConfigAdapterVolume() (corev1.Volume, corev1.VolumeMount) {
 	}
 	return volume, volumeMount
 }
+
+func ConfigModelCacheVolume() (corev1.Volume, corev1.VolumeMount) {
+	// The directory is created by the local NVMe setup, the pod does not start before.
+	hostPathType := corev1.HostPathDirectory
+	volume := corev1.Volume{
+		Name: "model-cache-volume",
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{
+				Path: DefaultModelCacheHostPath,
+				Type: &hostPathType,
+			},
+		},
+	}
+
+	volumeMount := corev1.VolumeMount{
+		Name:      volume.Name,
+		MountPath: DefaultModelCacheMountPath,
+	}
+	return volume, volumeMount
+}
+
+// ConfigChatTemplatesVolume projects the keys of the chat template ConfigMaps into a single directory.
+func ConfigChatTemplatesVolume(configMaps []string) (corev1.Volume, corev1.VolumeMount) {
+	sources := make([]corev1.VolumeProjection, 0, len(configMaps))
+	for _, name := range configMaps {
+		sources = append(sources, corev1.VolumeProjection{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: corev1.LocalObjectReference{Name: name},
+			},
+		})
+	}
+	volume := corev1.Volume{
+		Name: "chat-templates-volume",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: sources,
+			},
+		},
+	}
+
+	volumeMount := corev1.VolumeMount{
+		Name:      volume.Name,
+		MountPath: DefaultChatTemplatesMountPath,
+		ReadOnly:  true,
+	}
+	return volume, volumeMount
+}
@@ -4,13 +4,16 @@
 package utils
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
-	"gopkg.in/yaml.v2"
-	"io/ioutil"
-	"k8s.io/apimachinery/pkg/runtime"
+	"io/fs"
 	"os"
+	"strings"
 
 	"github.com/azure/kaito/pkg/utils/consts"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func Contains(s []string, e string) bool {
@@ -22,76 +25,83 @@ func Contains(s []string, e string) bool {
 	return false
 }
 
-// SearchMap performs a search for a key in a map[string]interface{}.
-func SearchMap(m map[string]interface{}, key string) (value interface{}, exists bool) {
-	if val, ok := m[key]; ok {
-		return val, true
+func ShellCmd(command string) []string {
+	return []string{
+		"/bin/sh",
+		"-c",
+		command,
 	}
-	return nil, false
 }
 
-// SearchRawExtension performs a search for a key within a runtime.RawExtension.
-func SearchRawExtension(raw runtime.RawExtension, key string) (interface{}, bool, error) {
-	var data map[string]interface{}
-	if err := yaml.Unmarshal(raw.Raw, &data); err != nil {
-		return nil, false, fmt.Errorf("failed to unmarshal runtime.RawExtension: %w", err)
-	}
-
-	result, found := data[key]
-	if !found {
-		return nil, false, nil
-	}
+// serviceAccountNamespaceFile is the path of the namespace file inside a Kubernetes pod.
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// NamespaceResolver determines the namespace Kaito is released in, from the first of:
+// the override, the service account namespace file and the release namespace env var.
+type NamespaceResolver struct {
+	// Override, if set, is the release namespace.
+	Override string
+	// FS is the file system the service account namespace file is read from.
+	FS fs.FS
+	// LookupEnv looks up the release namespace env var.
+	LookupEnv func(key string) (string, bool)
+}
 
-	return result, true, nil
+// ReleaseNamespaceResolver is the resolver used by GetReleaseNamespace.
+var ReleaseNamespaceResolver = &NamespaceResolver{
+	FS:        os.DirFS("/"),
+	LookupEnv: os.LookupEnv,
 }
 
-func MergeConfigMaps(baseMap, overrideMap map[string]string) map[string]string {
-	merged := make(map[string]string)
-	for k, v := range baseMap {
-		merged[k] = v
+// ReleaseNamespace returns the release namespace.
+func (r *NamespaceResolver) ReleaseNamespace(ctx context.Context) (string, error) {
+	if r.Override != "" {
+		return r.Override, nil
 	}
 
-	// Override with values from overrideMap
-	for k, v := range overrideMap {
-		merged[k] = v
+	if r.FS != nil {
+		content, err := ReadFile(ctx, r.FS, serviceAccountNamespaceFile)
+		if err == nil && len(bytes.TrimSpace(content)) > 0 {
+			return string(bytes.TrimSpace(content)), nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
 	}
 
-	return merged
-}
-
-func BuildCmdStr(baseCommand string, runParams map[string]string) string {
-	updatedBaseCommand := baseCommand
-	for key, value := range runParams {
-		if value == "" {
-			updatedBaseCommand = fmt.Sprintf("%s --%s", updatedBaseCommand, key)
-		} else {
-			updatedBaseCommand = fmt.Sprintf("%s --%s=%s", updatedBaseCommand, key, value)
+	// Fallback: Read the namespace from an environment variable
+	if r.LookupEnv != nil {
+		if namespace, exists := r.LookupEnv(consts.DefaultReleaseNamespaceEnvVar); exists {
+			return namespace, nil
 		}
 	}
+	return "", fmt.Errorf("failed to determine release namespace from file %s and env var %s", serviceAccountNamespaceFile, consts.DefaultReleaseNamespaceEnvVar)
+}
 
-	return updatedBaseCommand
+// GetReleaseNamespace returns the release namespace determined by ReleaseNamespaceResolver.
+func GetReleaseNamespace(ctx context.Context) (string, error) {
+	return ReleaseNamespaceResolver.ReleaseNamespace(ctx)
 }
 
-func ShellCmd(command string) []string {
-	return []string{
-		"/bin/sh",
-		"-c",
-		command,
+// ReadFile reads the named file from fsys unless ctx is done. Absolute names are read relative
+// to the root of fsys, e.g., os.DirFS("/").
+func ReadFile(ctx context.Context, fsys fs.FS, name string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
+	return fs.ReadFile(fsys, strings.TrimPrefix(name, "/"))
 }
 
-func GetReleaseNamespace() (string, error) {
-	// Path to the namespace file inside a Kubernetes pod
-	namespaceFilePath := "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
-
-	// Attempt to read the namespace from the file
-	if content, err := ioutil.ReadFile(namespaceFilePath); err == nil {
-		return string(content), nil
+// ParseResourceRequests parses a JSON object of CPU and memory requests, e.g., {"cpu": "8", "memory": "64Gi"}.
+func ParseResourceRequests(value string) (corev1.ResourceList, error) {
+	requests := corev1.ResourceList{}
+	if err := json.Unmarshal([]byte(value), &requests); err != nil {
+		return nil, fmt.Errorf("expected a JSON object of quantities: %w", err)
 	}
-
-	// Fallback: Read the namespace from an environment variable
-	if namespace, exists := os.LookupEnv(consts.DefaultReleaseNamespaceEnvVar); exists {
-		return namespace, nil
+	for name := range requests {
+		if name != corev1.ResourceCPU && name != corev1.ResourceMemory {
+			return nil, fmt.Errorf("unsupported resource %s, only %s and %s can be set", name, corev1.ResourceCPU, corev1.ResourceMemory)
+		}
 	}
-	return "", fmt.Errorf("failed to determine release namespace from file %s and env var %s", namespaceFilePath, consts.DefaultReleaseNamespaceEnvVar)
+	return requests, nil
 }
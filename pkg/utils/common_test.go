@@ -0,0 +1,71 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package utils
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/azure/kaito/pkg/utils/consts"
+)
+
+func TestReleaseNamespace(t *testing.T) {
+	namespaceFS := fstest.MapFS{
+		"var/run/secrets/kubernetes.io/serviceaccount/namespace": {Data: []byte("kaito-system\n")},
+	}
+	env := func(key string) (string, bool) {
+		if key == consts.DefaultReleaseNamespaceEnvVar {
+			return "env-namespace", true
+		}
+		return "", false
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testcases := map[string]struct {
+		ctx               context.Context
+		resolver          NamespaceResolver
+		expectedNamespace string
+		expectErr         bool
+	}{
+		"override takes precedence": {
+			resolver:          NamespaceResolver{Override: "override", FS: namespaceFS, LookupEnv: env},
+			expectedNamespace: "override",
+		},
+		"service account namespace file": {
+			resolver:          NamespaceResolver{FS: namespaceFS, LookupEnv: env},
+			expectedNamespace: "kaito-system",
+		},
+		"env var fallback": {
+			resolver:          NamespaceResolver{FS: fstest.MapFS{}, LookupEnv: env},
+			expectedNamespace: "env-namespace",
+		},
+		"not found": {
+			resolver:  NamespaceResolver{FS: fstest.MapFS{}},
+			expectErr: true,
+		},
+		"canceled context": {
+			ctx:       canceled,
+			resolver:  NamespaceResolver{FS: namespaceFS, LookupEnv: env},
+			expectErr: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			ctx := tc.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			namespace, err := tc.resolver.ReleaseNamespace(ctx)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if namespace != tc.expectedNamespace {
+				t.Errorf("expected namespace %q, got %q", tc.expectedNamespace, namespace)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/azure/kaito/pkg/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// PresetNameLabel is the label selecting the ConfigMaps that declare a preset model.
+	PresetNameLabel = "kaito.sh/preset-name"
+	// PresetVersionLabel is the optional label holding the preset version declared by a ConfigMap.
+	PresetVersionLabel = "kaito.sh/preset-version"
+	// PresetConfigMapKey is the ConfigMap data key holding the PresetDeclaration in YAML.
+	PresetConfigMapKey = "preset.yaml"
+)
+
+// PresetDeclaration describes a preset model declared outside of the operator binary.
+type PresetDeclaration struct {
+	ModelFamilyName             string                       `json:"modelFamilyName,omitempty"`
+	ImageAccessMode             string                       `json:"imageAccessMode,omitempty"`
+	DiskStorageRequirement      string                       `json:"diskStorageRequirement,omitempty"`
+	GPUCountRequirement         string                       `json:"gpuCountRequirement,omitempty"`
+	TotalGPUMemoryRequirement   string                       `json:"totalGPUMemoryRequirement,omitempty"`
+	PerGPUMemoryRequirement     string                       `json:"perGPUMemoryRequirement,omitempty"`
+	MinDriverVersion            string                       `json:"minDriverVersion,omitempty"`
+	TorchRunParams              map[string]string            `json:"torchRunParams,omitempty"`
+	TorchRunRdzvParams          map[string]string            `json:"torchRunRdzvParams,omitempty"`
+	BaseCommand                 string                       `json:"baseCommand,omitempty"`
+	ModelRunParams              map[string]string            `json:"modelRunParams,omitempty"`
+	Architectures               []string                     `json:"architectures,omitempty"`
+	ArchModelRunParams          map[string]map[string]string `json:"archModelRunParams,omitempty"`
+	ReadinessTimeout            metav1.Duration              `json:"readinessTimeout,omitempty"`
+	WorldSize                   int                          `json:"worldSize,omitempty"`
+	Tag                         string                       `json:"tag,omitempty"`
+	SupportDistributedInference bool                         `json:"supportDistributedInference,omitempty"`
+	Deprecation                 *DeprecationDeclaration      `json:"deprecation,omitempty"`
+}
+
+// DeprecationDeclaration describes the lifecycle of a deprecated declared preset.
+type DeprecationDeclaration struct {
+	Replacement string      `json:"replacement,omitempty"`
+	EndOfLife   metav1.Time `json:"endOfLife,omitempty"`
+}
+
+// defaultReadinessTimeout is used when a declaration does not specify a readiness timeout.
+const defaultReadinessTimeout = 30 * time.Minute
+
+// Model returns the inference-only model described by the declaration.
+func (d *PresetDeclaration) Model() model.Model {
+	readinessTimeout := d.ReadinessTimeout.Duration
+	if readinessTimeout == 0 {
+		readinessTimeout = defaultReadinessTimeout
+	}
+	var deprecation *model.Deprecation
+	if d.Deprecation != nil {
+		deprecation = &model.Deprecation{Replacement: d.Deprecation.Replacement, EndOfLife: d.Deprecation.EndOfLife.Time}
+	}
+	return &model.StaticModel{
+		InferenceParam: &model.PresetParam{
+			ModelFamilyName:           d.ModelFamilyName,
+			ImageAccessMode:           d.ImageAccessMode,
+			DiskStorageRequirement:    d.DiskStorageRequirement,
+			GPUCountRequirement:       d.GPUCountRequirement,
+			TotalGPUMemoryRequirement: d.TotalGPUMemoryRequirement,
+			PerGPUMemoryRequirement:   d.PerGPUMemoryRequirement,
+			MinDriverVersion:          d.MinDriverVersion,
+			TorchRunParams:            d.TorchRunParams,
+			TorchRunRdzvParams:        d.TorchRunRdzvParams,
+			BaseCommand:               d.BaseCommand,
+			ModelRunParams:            d.ModelRunParams,
+			Architectures:             d.Architectures,
+			ArchModelRunParams:        d.ArchModelRunParams,
+			ReadinessTimeout:          readinessTimeout,
+			WorldSize:                 d.WorldSize,
+			Tag:                       d.Tag,
+			Deprecation:               deprecation,
+		},
+		DistributedInference: d.SupportDistributedInference,
+	}
+}
+
+// ConfigMapResolver resolves presets declared by ConfigMaps labeled with PresetNameLabel
+// in the operator namespace.
+type ConfigMapResolver struct {
+	Client    client.Reader
+	Namespace string
+}
+
+func (r *ConfigMapResolver) Name() string {
+	return "configmap"
+}
+
+// Resolve returns one registration per ConfigMap declaring the model. A reference with
+// a version only matches the ConfigMaps labeled with that version.
+func (r *ConfigMapResolver) Resolve(ctx context.Context, ref string) ([]*Registration, error) {
+	name, version := ParseModelReference(ref)
+	selector := client.MatchingLabels{PresetNameLabel: name}
+	if version != "" {
+		selector[PresetVersionLabel] = version
+	}
+	cmList := &corev1.ConfigMapList{}
+	if err := r.Client.List(ctx, cmList, client.InNamespace(r.Namespace), selector); err != nil {
+		return nil, err
+	}
+
+	var registrations []*Registration
+	for i := range cmList.Items {
+		cm := &cmList.Items[i]
+		declaration := &PresetDeclaration{}
+		if err := yaml.Unmarshal([]byte(cm.Data[PresetConfigMapKey]), declaration); err != nil {
+			return nil, fmt.Errorf("failed to parse preset declared by ConfigMap %s/%s: %w", cm.Namespace, cm.Name, err)
+		}
+		registrations = append(registrations, &Registration{
+			Name:     name,
+			Version:  cm.Labels[PresetVersionLabel],
+			Instance: declaration.Model(),
+		})
+	}
+	return registrations, nil
+}
@@ -0,0 +1,111 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package plugin
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// ModelsPath is the path the models handler is served on by the operator.
+const ModelsPath = "/models"
+
+// ModelInfo describes a registered preset model.
+type ModelInfo struct {
+	Name                        string   `json:"name"`
+	Version                     string   `json:"version,omitempty"`
+	ModelFamily                 string   `json:"modelFamily,omitempty"`
+	Tag                         string   `json:"tag,omitempty"`
+	ImageAccessMode             string   `json:"imageAccessMode,omitempty"`
+	DiskStorageRequirement      string   `json:"diskStorageRequirement,omitempty"`
+	GPUCountRequirement         string   `json:"gpuCountRequirement,omitempty"`
+	TotalGPUMemoryRequirement   string   `json:"totalGPUMemoryRequirement,omitempty"`
+	PerGPUMemoryRequirement     string   `json:"perGPUMemoryRequirement,omitempty"`
+	SupportDistributedInference bool     `json:"supportDistributedInference"`
+	SupportTuning               bool     `json:"supportTuning"`
+	TuningMethods               []string `json:"tuningMethods,omitempty"`
+	Transient                   bool     `json:"transient,omitempty"`
+	Deprecated                  bool     `json:"deprecated,omitempty"`
+	Replacement                 string   `json:"replacement,omitempty"`
+	EndOfLife                   string   `json:"endOfLife,omitempty"`
+}
+
+// ModelList is the response body of the models handler.
+type ModelList struct {
+	Models []ModelInfo `json:"models"`
+}
+
+// ListModels returns the metadata of all models registered in reg, sorted by name and version.
+func (reg *ModelRegister) ListModels() []ModelInfo {
+	registrations := reg.List()
+	models := make([]ModelInfo, 0, len(registrations))
+	for _, r := range registrations {
+		info := ModelInfo{
+			Name:                        r.Name,
+			Version:                     r.Version,
+			SupportDistributedInference: r.Instance.SupportDistributedInference(),
+			SupportTuning:               r.Instance.SupportTuning(),
+			Transient:                   r.Transient,
+		}
+		if param := r.Instance.GetInferenceParameters(); param != nil {
+			info.ModelFamily = param.ModelFamilyName
+			info.Tag = param.Tag
+			info.ImageAccessMode = param.ImageAccessMode
+			info.DiskStorageRequirement = param.DiskStorageRequirement
+			info.GPUCountRequirement = param.GPUCountRequirement
+			info.TotalGPUMemoryRequirement = param.TotalGPUMemoryRequirement
+			info.PerGPUMemoryRequirement = param.PerGPUMemoryRequirement
+			if param.Deprecation != nil {
+				info.Deprecated = true
+				info.Replacement = param.Deprecation.Replacement
+				if !param.Deprecation.EndOfLife.IsZero() {
+					info.EndOfLife = param.Deprecation.EndOfLife.Format(time.DateOnly)
+				}
+			}
+		}
+		if info.SupportTuning {
+			if param := r.Instance.GetTuningParameters(); param != nil {
+				for method := range param.TuningPerGPUMemoryRequirement {
+					info.TuningMethods = append(info.TuningMethods, method)
+				}
+				sort.Strings(info.TuningMethods)
+			}
+		}
+		models = append(models, info)
+	}
+	return models
+}
+
+// ModelsHandler serves the registered preset models of reg as JSON, so users can discover
+// what they can deploy. A single model can be selected with the "name" query parameter.
+func ModelsHandler(reg *ModelRegister) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		models := reg.ListModels()
+		if name := req.URL.Query().Get("name"); name != "" {
+			var selected []ModelInfo
+			for _, m := range models {
+				if m.Name == name {
+					selected = append(selected, m)
+				}
+			}
+			if len(selected) == 0 {
+				http.Error(w, "model "+name+" is not registered", http.StatusNotFound)
+				return
+			}
+			models = selected
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(ModelList{Models: models}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package plugin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestModelsHandler(t *testing.T) {
+	var reg ModelRegister
+	reg.Register(&Registration{Name: "b", Instance: &fakeModel{tag: "2"}})
+	reg.Register(&Registration{Name: "a", Instance: &fakeModel{tag: "1"}, Transient: true})
+	handler := ModelsHandler(&reg)
+
+	testcases := map[string]struct {
+		method         string
+		target         string
+		expectedStatus int
+		expectedNames  []string
+	}{
+		"list all models sorted by name": {
+			method:         http.MethodGet,
+			target:         ModelsPath,
+			expectedStatus: http.StatusOK,
+			expectedNames:  []string{"a", "b"},
+		},
+		"select a single model": {
+			method:         http.MethodGet,
+			target:         ModelsPath + "?name=b",
+			expectedStatus: http.StatusOK,
+			expectedNames:  []string{"b"},
+		},
+		"unknown model": {
+			method:         http.MethodGet,
+			target:         ModelsPath + "?name=c",
+			expectedStatus: http.StatusNotFound,
+		},
+		"unsupported method": {
+			method:         http.MethodPost,
+			target:         ModelsPath,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.target, nil))
+			if rec.Code != tc.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tc.expectedStatus, rec.Code)
+			}
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var list ModelList
+			if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(list.Models) != len(tc.expectedNames) {
+				t.Fatalf("expected %d models, got %d", len(tc.expectedNames), len(list.Models))
+			}
+			for i, name := range tc.expectedNames {
+				if list.Models[i].Name != name {
+					t.Errorf("expected model %s at index %d, got %s", name, i, list.Models[i].Name)
+				}
+			}
+		})
+	}
+}
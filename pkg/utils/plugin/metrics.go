@@ -0,0 +1,42 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package plugin
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	lookupResultHit  = "hit"
+	lookupResultMiss = "miss"
+
+	evictionReasonTTL      = "ttl"
+	evictionReasonCapacity = "capacity"
+)
+
+var (
+	lookupsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kaito_model_register_lookups_total",
+			Help: "Number of model register lookups, partitioned by result (hit or miss).",
+		},
+		[]string{"result"},
+	)
+
+	evictionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kaito_model_register_evictions_total",
+			Help: "Number of transient model registrations evicted, partitioned by reason (ttl or capacity).",
+		},
+		[]string{"reason"},
+	)
+
+	// The lookup counters are resolved once since lookups are on the admission hot path.
+	lookupHits   = lookupsTotal.WithLabelValues(lookupResultHit)
+	lookupMisses = lookupsTotal.WithLabelValues(lookupResultMiss)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(lookupsTotal, evictionsTotal)
+}
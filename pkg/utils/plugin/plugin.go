@@ -3,19 +3,84 @@
 package plugin
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/azure/kaito/pkg/model"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/utils/clock"
 )
 
+// versionSeparator separates the model name and the pinned version in a model reference, e.g., "falcon-7b@0.0.4".
+const versionSeparator = "@"
+
+// ModelReference returns the reference of a model version. An empty version refers to the
+// default version of the model, see ModelRegister.PinVersion.
+func ModelReference(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + versionSeparator + version
+}
+
+// ParseModelReference splits a model reference into the model name and the pinned version.
+func ParseModelReference(ref string) (name, version string) {
+	name, version, _ = strings.Cut(ref, versionSeparator)
+	return name, version
+}
+
 type Registration struct {
-	Name     string
+	Name string
+	// Version is the optional preset revision of the model. Multiple versions of the same
+	// model name can be registered side by side.
+	Version  string
 	Instance model.Model
+	// Transient marks a registration that is created at runtime rather than by a builtin preset package.
+	// A transient registration is removed from the register once no workspace references it anymore.
+	Transient bool
+
+	// lastUsed is the last time a transient registration was looked up.
+	lastUsed time.Time
+}
+
+func (r *Registration) key() string {
+	return ModelReference(r.Name, r.Version)
+}
+
+// TransientCachePolicy bounds the transient registrations kept in memory.
+// Builtin registrations are never evicted, and neither are transient
+// registrations that are still referenced by a workspace.
+type TransientCachePolicy struct {
+	// MaxEntries is the maximum number of transient registrations. Zero means unbounded.
+	MaxEntries int
+	// TTL is how long an unused transient registration is kept. Zero means forever.
+	TTL time.Duration
 }
 
+// ModelRegister holds the registered models keyed by model reference. A reference without
+// a version resolves to the pinned version of the model if any, otherwise to the unversioned
+// registration, and otherwise to the latest registered version.
 type ModelRegister struct {
 	sync.RWMutex
 	models map[string]*Registration
+	// versions indexes the registered versions of each model name.
+	versions map[string]map[string]struct{}
+	// pinned holds the version a model name resolves to, overriding the latest version.
+	pinned map[string]string
+	// refs tracks the owners (e.g., workspace namespace/name) that currently reference each model version.
+	refs map[string]map[string]struct{}
+
+	policy TransientCachePolicy
+	clock  clock.PassiveClock
+
+	resolvers       []ModelResolver
+	namePolicy      NamePolicy
+	lifecyclePolicy LifecyclePolicy
+	imagePolicy     ImagePolicy
 }
 
 var KaitoModelRegister ModelRegister
@@ -27,36 +92,411 @@ func (reg *ModelRegister) Register(r *Registration) {
 	if r.Name == "" {
 		panic("model name is not specified")
 	}
+	if strings.Contains(r.Name, versionSeparator) || strings.Contains(r.Version, versionSeparator) {
+		panic(fmt.Sprintf("model name and version must not contain %q", versionSeparator))
+	}
 
 	if reg.models == nil {
 		reg.models = make(map[string]*Registration)
 	}
+	if reg.versions == nil {
+		reg.versions = make(map[string]map[string]struct{})
+	}
+	if reg.versions[r.Name] == nil {
+		reg.versions[r.Name] = make(map[string]struct{})
+	}
 
-	reg.models[r.Name] = r
+	if r.Transient {
+		r.lastUsed = reg.now()
+	}
+	reg.models[r.key()] = r
+	reg.versions[r.Name][r.Version] = struct{}{}
+	reg.evictLocked()
 }
 
-func (reg *ModelRegister) MustGet(name string) model.Model {
+// SetTransientCachePolicy configures the eviction policy of transient registrations
+// and applies it to the current content of the register.
+func (reg *ModelRegister) SetTransientCachePolicy(policy TransientCachePolicy) {
 	reg.Lock()
 	defer reg.Unlock()
-	if _, ok := reg.models[name]; ok {
-		return reg.models[name].Instance
+	reg.policy = policy
+	reg.evictLocked()
+}
+
+// PinVersion makes references without a version resolve to the given version of the model
+// instead of the latest one. An empty version restores the latest resolution policy.
+func (reg *ModelRegister) PinVersion(name, version string) {
+	reg.Lock()
+	defer reg.Unlock()
+	if version == "" {
+		delete(reg.pinned, name)
+		return
 	}
-	panic("model is not registered")
+	if reg.pinned == nil {
+		reg.pinned = make(map[string]string)
+	}
+	reg.pinned[name] = version
 }
 
-func (reg *ModelRegister) ListModelNames() []string {
+// Replace swaps an existing registration with r, keeping the references recorded for the model. A
+// builtin registration cannot be replaced by a transient one, which would be removed later on.
+func (reg *ModelRegister) Replace(r *Registration) error {
 	reg.Lock()
 	defer reg.Unlock()
+	if r.Name == "" {
+		return fmt.Errorf("model name is not specified")
+	}
+	existing, ok := reg.models[r.key()]
+	if !ok {
+		return fmt.Errorf("model %s is not registered", r.key())
+	}
+	if r.Transient && !existing.Transient {
+		return fmt.Errorf("builtin model %s cannot be replaced by a transient registration", r.key())
+	}
+	if r.Transient {
+		r.lastUsed = reg.now()
+	}
+	reg.models[r.key()] = r
+	return nil
+}
+
+// Unregister removes the model and all its references. A reference without a version removes
+// all versions of the model. It is a no-op if the model is not registered.
+func (reg *ModelRegister) Unregister(ref string) {
+	reg.Lock()
+	defer reg.Unlock()
+	for _, key := range reg.keysLocked(ref) {
+		reg.deleteLocked(key)
+	}
+}
+
+// UnregisterTransient removes the transient registration of the model version, e.g., once the object
+// declaring it is deleted. Unlike Unregister, an empty version only matches the unversioned
+// registration. Builtin registrations are kept.
+func (reg *ModelRegister) UnregisterTransient(name, version string) {
+	reg.Lock()
+	defer reg.Unlock()
+	key := ModelReference(name, version)
+	if r, ok := reg.models[key]; ok && r.Transient {
+		reg.deleteLocked(key)
+	}
+}
+
+// NotFoundError is returned when a model is not registered.
+type NotFoundError struct {
+	Ref string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("model %s is not registered", e.Ref)
+}
+
+// IsNotFound reports whether err, or an error it wraps, is a NotFoundError.
+func IsNotFound(err error) bool {
+	var notFound *NotFoundError
+	return errors.As(err, &notFound)
+}
+
+// Get returns the registered model, or a NotFoundError if it is not registered.
+func (reg *ModelRegister) Get(ref string) (model.Model, error) {
+	if r := reg.lookup(ref); r != nil {
+		return r.Instance, nil
+	}
+	return nil, &NotFoundError{Ref: ref}
+}
+
+// MustGet returns the registered model and panics if it is not registered. It must only be
+// used for models known to be registered, e.g., builtin presets; use Get otherwise.
+func (reg *ModelRegister) MustGet(ref string) model.Model {
+	m, err := reg.Get(ref)
+	if err != nil {
+		panic(err.Error())
+	}
+	return m
+}
+
+// ListModelNames returns the distinct names of the registered models.
+func (reg *ModelRegister) ListModelNames() []string {
+	reg.RLock()
+	defer reg.RUnlock()
 	n := []string{}
-	for k := range reg.models {
+	for k := range reg.versions {
 		n = append(n, k)
 	}
 	return n
 }
 
-func (reg *ModelRegister) Has(name string) bool {
+// ListVersions returns the registered versions of the model sorted from oldest to latest.
+// The unversioned registration, if any, is reported as an empty version.
+func (reg *ModelRegister) ListVersions(name string) []string {
+	reg.RLock()
+	defer reg.RUnlock()
+	v := []string{}
+	for version := range reg.versions[name] {
+		v = append(v, version)
+	}
+	sort.Slice(v, func(i, j int) bool {
+		return compareVersions(v[i], v[j]) < 0
+	})
+	return v
+}
+
+// List returns a snapshot of all registrations sorted by model reference.
+func (reg *ModelRegister) List() []Registration {
+	reg.RLock()
+	defer reg.RUnlock()
+	l := make([]Registration, 0, len(reg.models))
+	for _, r := range reg.models {
+		l = append(l, *r)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].Name != l[j].Name {
+			return l[i].Name < l[j].Name
+		}
+		return compareVersions(l[i].Version, l[j].Version) < 0
+	})
+	return l
+}
+
+// IsBuiltin reports whether a version of the model is registered by a builtin preset package.
+func (reg *ModelRegister) IsBuiltin(name string) bool {
+	reg.RLock()
+	defer reg.RUnlock()
+	for version := range reg.versions[name] {
+		if r, ok := reg.models[ModelReference(name, version)]; ok && !r.Transient {
+			return true
+		}
+	}
+	return false
+}
+
+func (reg *ModelRegister) Has(ref string) bool {
+	return reg.lookup(ref) != nil
+}
+
+// ResolveReference returns the reference of the model version ref currently resolves to.
+func (reg *ModelRegister) ResolveReference(ref string) (string, bool) {
+	reg.RLock()
+	defer reg.RUnlock()
+	key := reg.resolveLocked(ref)
+	_, ok := reg.models[key]
+	return key, ok
+}
+
+// Acquire records that owner references the model version ref resolves to. Acquiring the same
+// model for the same owner multiple times is idempotent. It returns false if the model is not registered.
+func (reg *ModelRegister) Acquire(ref, owner string) bool {
+	// Workspaces acquire their models on every reconciliation, check for an existing reference first.
+	reg.RLock()
+	_, held := reg.refs[reg.resolveLocked(ref)][owner]
+	reg.RUnlock()
+	if held {
+		return true
+	}
+
+	reg.Lock()
+	defer reg.Unlock()
+	key := reg.resolveLocked(ref)
+	if _, ok := reg.models[key]; !ok {
+		return false
+	}
+	if reg.refs == nil {
+		reg.refs = make(map[string]map[string]struct{})
+	}
+	if reg.refs[key] == nil {
+		reg.refs[key] = make(map[string]struct{})
+	}
+	reg.refs[key][owner] = struct{}{}
+	return true
+}
+
+// Release drops the reference owner holds on the model. A reference without a version releases
+// every version of the model held by owner, since the version it resolves to may have changed
+// since it was acquired. Transient models without any remaining reference are unregistered.
+// Builtin models are never removed by Release.
+func (reg *ModelRegister) Release(ref, owner string) {
 	reg.Lock()
 	defer reg.Unlock()
-	_, ok := reg.models[name]
-	return ok
+	for _, key := range reg.keysLocked(ref) {
+		owners := reg.refs[key]
+		if _, ok := owners[owner]; !ok {
+			continue
+		}
+		delete(owners, owner)
+		if len(owners) != 0 {
+			continue
+		}
+		delete(reg.refs, key)
+		if r, ok := reg.models[key]; ok && r.Transient {
+			reg.deleteLocked(key)
+		}
+	}
+}
+
+// RefCount returns the number of owners currently referencing the model version ref resolves to.
+func (reg *ModelRegister) RefCount(ref string) int {
+	reg.RLock()
+	defer reg.RUnlock()
+	return len(reg.refs[reg.resolveLocked(ref)])
+}
+
+// resolveLocked returns the key of the registration ref resolves to, which may not be registered.
+func (reg *ModelRegister) resolveLocked(ref string) string {
+	name, version := ParseModelReference(ref)
+	if version != "" {
+		return ref
+	}
+	if pinned, ok := reg.pinned[name]; ok {
+		return ModelReference(name, pinned)
+	}
+	if _, ok := reg.models[name]; ok {
+		return name
+	}
+	latest := ""
+	for v := range reg.versions[name] {
+		if compareVersions(v, latest) > 0 {
+			latest = v
+		}
+	}
+	return ModelReference(name, latest)
+}
+
+// keysLocked returns the registered keys matched by ref: the pinned version, or all versions of the model.
+func (reg *ModelRegister) keysLocked(ref string) []string {
+	name, version := ParseModelReference(ref)
+	if version != "" {
+		return []string{ref}
+	}
+	keys := []string{}
+	for v := range reg.versions[name] {
+		keys = append(keys, ModelReference(name, v))
+	}
+	return keys
+}
+
+// lookup returns the registration ref resolves to, or nil if it is not registered or has expired.
+// Builtin registrations are served under the read lock. Transient registrations take the write
+// lock, since looking them up refreshes their last used time or expires them.
+func (reg *ModelRegister) lookup(ref string) *Registration {
+	reg.RLock()
+	r, ok := reg.models[reg.resolveLocked(ref)]
+	reg.RUnlock()
+	if ok && !r.Transient {
+		lookupHits.Inc()
+		return r
+	}
+
+	reg.Lock()
+	defer reg.Unlock()
+	return reg.lookupLocked(ref)
+}
+
+// lookupLocked returns the registration ref resolves to, or nil if it is not registered or has expired.
+// It refreshes the last used time of transient registrations and records cache metrics.
+func (reg *ModelRegister) lookupLocked(ref string) *Registration {
+	key := reg.resolveLocked(ref)
+	r, ok := reg.models[key]
+	if ok && r.Transient {
+		if reg.expiredLocked(r) {
+			reg.removeLocked(key, evictionReasonTTL)
+			ok = false
+		} else {
+			r.lastUsed = reg.now()
+		}
+	}
+	if !ok {
+		lookupMisses.Inc()
+		return nil
+	}
+	lookupHits.Inc()
+	return r
+}
+
+// expiredLocked reports whether an unreferenced transient registration outlived the TTL.
+func (reg *ModelRegister) expiredLocked(r *Registration) bool {
+	return reg.policy.TTL > 0 && len(reg.refs[r.key()]) == 0 && reg.now().Sub(r.lastUsed) > reg.policy.TTL
+}
+
+// evictLocked removes expired transient registrations first, then the least recently used
+// unreferenced ones until the register fits in MaxEntries.
+func (reg *ModelRegister) evictLocked() {
+	var candidates []*Registration
+	transientCount := 0
+	for key, r := range reg.models {
+		if !r.Transient {
+			continue
+		}
+		if reg.expiredLocked(r) {
+			reg.removeLocked(key, evictionReasonTTL)
+			continue
+		}
+		transientCount++
+		if len(reg.refs[key]) == 0 {
+			candidates = append(candidates, r)
+		}
+	}
+
+	if reg.policy.MaxEntries <= 0 || transientCount <= reg.policy.MaxEntries {
+		return
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].lastUsed.Before(candidates[j].lastUsed)
+	})
+	for _, r := range candidates {
+		if transientCount <= reg.policy.MaxEntries {
+			return
+		}
+		reg.removeLocked(r.key(), evictionReasonCapacity)
+		transientCount--
+	}
+}
+
+func (reg *ModelRegister) removeLocked(key, reason string) {
+	reg.deleteLocked(key)
+	evictionsTotal.WithLabelValues(reason).Inc()
+}
+
+// deleteLocked removes the registration stored under key together with its references.
+func (reg *ModelRegister) deleteLocked(key string) {
+	delete(reg.models, key)
+	delete(reg.refs, key)
+	name, version := ParseModelReference(key)
+	if versions, ok := reg.versions[name]; ok {
+		delete(versions, version)
+		if len(versions) == 0 {
+			delete(reg.versions, name)
+		}
+	}
+}
+
+func (reg *ModelRegister) now() time.Time {
+	if reg.clock == nil {
+		return time.Now()
+	}
+	return reg.clock.Now()
+}
+
+// compareVersions orders preset versions, comparing them semantically when both parse as
+// versions and lexically otherwise. The empty version sorts first.
+func compareVersions(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return -1
+	}
+	if b == "" {
+		return 1
+	}
+	va, errA := utilversion.ParseGeneric(a)
+	vb, errB := utilversion.ParseGeneric(b)
+	if errA == nil && errB == nil {
+		if va.LessThan(vb) {
+			return -1
+		}
+		if vb.LessThan(va) {
+			return 1
+		}
+	}
+	return strings.Compare(a, b)
 }
@@ -0,0 +1,299 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package plugin
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/azure/kaito/pkg/model"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+type fakeModel struct {
+	tag string
+}
+
+func (m *fakeModel) GetInferenceParameters() *model.PresetParam {
+	return &model.PresetParam{Tag: m.tag}
+}
+func (m *fakeModel) GetTuningParameters() *model.PresetParam {
+	return nil
+}
+func (m *fakeModel) SupportDistributedInference() bool {
+	return false
+}
+func (m *fakeModel) SupportTuning() bool {
+	return false
+}
+
+func TestGetAndUnregister(t *testing.T) {
+	var reg ModelRegister
+	reg.Register(&Registration{Name: "a", Instance: &fakeModel{tag: "1"}})
+
+	if m, err := reg.Get("a"); err != nil || m.GetInferenceParameters().Tag != "1" {
+		t.Fatalf("expected model a to be registered")
+	}
+	if _, err := reg.Get("b"); !IsNotFound(err) {
+		t.Fatalf("expected not found error for model b, got %v", err)
+	}
+
+	reg.Unregister("a")
+	if reg.Has("a") {
+		t.Fatalf("expected model a to be unregistered")
+	}
+	// Unregistering an unknown model is a no-op.
+	reg.Unregister("a")
+}
+
+func TestUnregisterTransient(t *testing.T) {
+	var reg ModelRegister
+	reg.Register(&Registration{Name: "a", Instance: &fakeModel{tag: "builtin"}})
+	reg.Register(&Registration{Name: "b", Instance: &fakeModel{tag: "1"}, Transient: true})
+	reg.Register(&Registration{Name: "b", Version: "2", Instance: &fakeModel{tag: "2"}, Transient: true})
+
+	reg.UnregisterTransient("a", "")
+	if !reg.Has("a") {
+		t.Fatalf("expected builtin model a to be kept")
+	}
+	reg.UnregisterTransient("b", "")
+	// The unversioned reference now resolves to the latest version.
+	if m, err := reg.Get("b"); err != nil || m.GetInferenceParameters().Tag != "2" {
+		t.Fatalf("expected only the unversioned registration of model b to be removed")
+	}
+	reg.UnregisterTransient("b", "2")
+	if reg.Has("b") {
+		t.Fatalf("expected model b to be unregistered")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	var reg ModelRegister
+	if err := reg.Replace(&Registration{Name: "a", Instance: &fakeModel{tag: "1"}}); err == nil {
+		t.Fatalf("expected error when replacing an unregistered model")
+	}
+
+	reg.Register(&Registration{Name: "a", Instance: &fakeModel{tag: "1"}})
+	reg.Acquire("a", "default/ws")
+	if err := reg.Replace(&Registration{Name: "a", Instance: &fakeModel{tag: "2"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag := reg.MustGet("a").GetInferenceParameters().Tag; tag != "2" {
+		t.Errorf("expected replaced model tag 2, got %s", tag)
+	}
+	if n := reg.RefCount("a"); n != 1 {
+		t.Errorf("expected references to survive replacement, got %d", n)
+	}
+
+	// A builtin model is not shadowed by a transient registration.
+	if err := reg.Replace(&Registration{Name: "a", Instance: &fakeModel{tag: "3"}, Transient: true}); err == nil {
+		t.Fatalf("expected error when replacing a builtin model with a transient registration")
+	}
+	if tag := reg.MustGet("a").GetInferenceParameters().Tag; tag != "2" {
+		t.Errorf("expected builtin model tag 2, got %s", tag)
+	}
+}
+
+func TestIsBuiltin(t *testing.T) {
+	var reg ModelRegister
+	reg.Register(&Registration{Name: "a", Instance: &fakeModel{tag: "builtin"}})
+	reg.Register(&Registration{Name: "b", Version: "1", Instance: &fakeModel{tag: "1"}, Transient: true})
+
+	if !reg.IsBuiltin("a") {
+		t.Errorf("expected model a to be builtin")
+	}
+	if reg.IsBuiltin("b") || reg.IsBuiltin("c") {
+		t.Errorf("expected models b and c not to be builtin")
+	}
+}
+
+func TestAcquireRelease(t *testing.T) {
+	testcases := map[string]struct {
+		transient      bool
+		expectedExists bool
+	}{
+		"builtin model is kept after last release": {
+			transient:      false,
+			expectedExists: true,
+		},
+		"transient model is removed after last release": {
+			transient:      true,
+			expectedExists: false,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			var reg ModelRegister
+			reg.Register(&Registration{Name: "a", Instance: &fakeModel{}, Transient: tc.transient})
+
+			if !reg.Acquire("a", "default/ws1") || !reg.Acquire("a", "default/ws1") || !reg.Acquire("a", "default/ws2") {
+				t.Fatalf("expected acquire to succeed")
+			}
+			if n := reg.RefCount("a"); n != 2 {
+				t.Fatalf("expected 2 references, got %d", n)
+			}
+
+			reg.Release("a", "default/unknown")
+			reg.Release("a", "default/ws1")
+			if !reg.Has("a") {
+				t.Fatalf("expected model to be kept while still referenced")
+			}
+
+			reg.Release("a", "default/ws2")
+			if reg.Has("a") != tc.expectedExists {
+				t.Errorf("expected model existence %v, got %v", tc.expectedExists, reg.Has("a"))
+			}
+		})
+	}
+
+	var reg ModelRegister
+	if reg.Acquire("missing", "default/ws") {
+		t.Errorf("expected acquire of unregistered model to fail")
+	}
+}
+
+func TestTransientCachePolicy(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	reg := ModelRegister{clock: fakeClock}
+	reg.Register(&Registration{Name: "builtin", Instance: &fakeModel{}})
+	reg.SetTransientCachePolicy(TransientCachePolicy{MaxEntries: 2, TTL: time.Hour})
+
+	reg.Register(&Registration{Name: "t1", Instance: &fakeModel{}, Transient: true})
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+	reg.Register(&Registration{Name: "t2", Instance: &fakeModel{}, Transient: true})
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+
+	// Touch t1 so that t2 becomes the least recently used entry.
+	if !reg.Has("t1") {
+		t.Fatalf("expected t1 to be registered")
+	}
+	reg.Register(&Registration{Name: "t3", Instance: &fakeModel{}, Transient: true})
+	if reg.Has("t2") {
+		t.Errorf("expected least recently used t2 to be evicted")
+	}
+	if !reg.Has("t1") || !reg.Has("t3") || !reg.Has("builtin") {
+		t.Errorf("expected t1, t3 and builtin to be kept")
+	}
+
+	// Referenced transient entries and builtin presets survive the TTL, unreferenced transient entries expire.
+	reg.Acquire("t1", "default/ws")
+	fakeClock.SetTime(fakeClock.Now().Add(2 * time.Hour))
+	if _, err := reg.Get("t3"); err == nil {
+		t.Errorf("expected t3 to expire")
+	}
+	if _, err := reg.Get("t1"); err != nil {
+		t.Errorf("expected referenced t1 to be kept")
+	}
+	if _, err := reg.Get("builtin"); err != nil {
+		t.Errorf("expected builtin model to be kept")
+	}
+}
+
+func TestVersionResolution(t *testing.T) {
+	var reg ModelRegister
+	reg.Register(&Registration{Name: "a", Version: "0.0.9", Instance: &fakeModel{tag: "0.0.9"}})
+	reg.Register(&Registration{Name: "a", Version: "0.0.10", Instance: &fakeModel{tag: "0.0.10"}})
+	reg.Register(&Registration{Name: "b", Instance: &fakeModel{tag: "b"}})
+
+	testcases := map[string]struct {
+		ref         string
+		pin         string
+		expectedTag string
+		expectedOK  bool
+	}{
+		"unversioned reference resolves to latest version": {
+			ref:         "a",
+			expectedTag: "0.0.10",
+			expectedOK:  true,
+		},
+		"pinned reference": {
+			ref:         "a@0.0.9",
+			expectedTag: "0.0.9",
+			expectedOK:  true,
+		},
+		"unversioned reference follows pinned default version": {
+			ref:         "a",
+			pin:         "0.0.9",
+			expectedTag: "0.0.9",
+			expectedOK:  true,
+		},
+		"unknown version": {
+			ref: "a@0.1.0",
+		},
+		"unversioned model": {
+			ref:         "b",
+			expectedTag: "b",
+			expectedOK:  true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			reg.PinVersion("a", tc.pin)
+			m, err := reg.Get(tc.ref)
+			if ok := err == nil; ok != tc.expectedOK {
+				t.Fatalf("expected found %v, got %v", tc.expectedOK, ok)
+			}
+			if err == nil && m.GetInferenceParameters().Tag != tc.expectedTag {
+				t.Errorf("expected tag %s, got %s", tc.expectedTag, m.GetInferenceParameters().Tag)
+			}
+		})
+	}
+
+	reg.PinVersion("a", "")
+	if versions := reg.ListVersions("a"); len(versions) != 2 || versions[1] != "0.0.10" {
+		t.Errorf("unexpected versions %v", versions)
+	}
+}
+
+func TestReleaseVersions(t *testing.T) {
+	var reg ModelRegister
+	reg.Register(&Registration{Name: "a", Version: "1", Instance: &fakeModel{}, Transient: true})
+	reg.Acquire("a", "default/ws")
+	// A newer version is rolled out while the workspace still references the previous one.
+	reg.Register(&Registration{Name: "a", Version: "2", Instance: &fakeModel{}, Transient: true})
+	if n := reg.RefCount("a@1"); n != 1 {
+		t.Fatalf("expected 1 reference on a@1, got %d", n)
+	}
+
+	reg.Release("a", "default/ws")
+	if reg.Has("a@1") {
+		t.Errorf("expected unreferenced transient a@1 to be removed")
+	}
+	if !reg.Has("a@2") {
+		t.Errorf("expected a@2 to be kept")
+	}
+
+	reg.Unregister("a")
+	if len(reg.ListModelNames()) != 0 {
+		t.Errorf("expected all versions to be unregistered")
+	}
+}
+
+func BenchmarkHas(b *testing.B) {
+	benchmarks := map[string]struct {
+		transient bool
+	}{
+		"builtin":   {transient: false},
+		"transient": {transient: true},
+	}
+
+	for k, bm := range benchmarks {
+		b.Run(k, func(b *testing.B) {
+			var reg ModelRegister
+			for i := 0; i < 50; i++ {
+				reg.Register(&Registration{Name: fmt.Sprintf("model-%d", i), Instance: &fakeModel{}, Transient: bm.transient})
+			}
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					reg.Has(fmt.Sprintf("model-%d", i%50))
+					i++
+				}
+			})
+		})
+	}
+}
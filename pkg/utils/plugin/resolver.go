@@ -0,0 +1,63 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/azure/kaito/pkg/model"
+)
+
+// ModelResolver resolves models that are not registered in the register, e.g., presets declared
+// outside of the operator binary. Builtin presets register themselves when their package is
+// imported, so they are always found before any resolver is consulted.
+type ModelResolver interface {
+	// Name identifies the resolver in errors and logs.
+	Name() string
+	// Resolve returns the registrations that serve ref. It returns no registration, and no error,
+	// if the resolver does not know the model.
+	Resolve(ctx context.Context, ref string) ([]*Registration, error)
+}
+
+// SetResolvers configures the chain of resolvers consulted in order by GetOrResolve.
+func (reg *ModelRegister) SetResolvers(resolvers ...ModelResolver) {
+	reg.Lock()
+	defer reg.Unlock()
+	reg.resolvers = resolvers
+}
+
+// GetOrResolve returns the registered model ref resolves to. If it is not registered, the
+// resolvers are consulted in order and the registrations of the first one that serves ref
+// are added to the register as transient registrations. It returns a NotFoundError if no
+// resolver serves ref, and an error without consulting the resolvers if ref is not valid.
+func (reg *ModelRegister) GetOrResolve(ctx context.Context, ref string) (model.Model, error) {
+	if m, err := reg.Get(ref); err == nil {
+		return m, nil
+	}
+	// Do not query external sources for malformed or disallowed references.
+	if err := reg.ValidateReference(ref); err != nil {
+		return nil, err
+	}
+
+	reg.RLock()
+	resolvers := reg.resolvers
+	reg.RUnlock()
+	for _, resolver := range resolvers {
+		registrations, err := resolver.Resolve(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve model %s with resolver %s: %w", ref, resolver.Name(), err)
+		}
+		if len(registrations) == 0 {
+			continue
+		}
+		for _, r := range registrations {
+			r.Transient = true
+			reg.Register(r)
+		}
+		if m, err := reg.Get(ref); err == nil {
+			return m, nil
+		}
+	}
+	return nil, &NotFoundError{Ref: ref}
+}
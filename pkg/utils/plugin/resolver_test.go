@@ -0,0 +1,145 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package plugin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeResolver struct {
+	name          string
+	registrations []*Registration
+	err           error
+	calls         int
+}
+
+func (r *fakeResolver) Name() string {
+	return r.name
+}
+
+func (r *fakeResolver) Resolve(_ context.Context, _ string) ([]*Registration, error) {
+	r.calls++
+	return r.registrations, r.err
+}
+
+func TestGetOrResolve(t *testing.T) {
+	testcases := map[string]struct {
+		resolvers     []*fakeResolver
+		expectedTag   string
+		expectedErr   string
+		expectedCalls []int
+	}{
+		"first resolver serving the model wins": {
+			resolvers: []*fakeResolver{
+				{name: "empty"},
+				{name: "second", registrations: []*Registration{{Name: "a", Instance: &fakeModel{tag: "second"}}}},
+				{name: "third", registrations: []*Registration{{Name: "a", Instance: &fakeModel{tag: "third"}}}},
+			},
+			expectedTag:   "second",
+			expectedCalls: []int{1, 1, 0},
+		},
+		"resolver error stops the chain": {
+			resolvers: []*fakeResolver{
+				{name: "broken", err: errors.New("boom")},
+				{name: "second", registrations: []*Registration{{Name: "a", Instance: &fakeModel{}}}},
+			},
+			expectedErr:   "resolver broken: boom",
+			expectedCalls: []int{1, 0},
+		},
+		"no resolver serves the model": {
+			resolvers:     []*fakeResolver{{name: "empty"}},
+			expectedErr:   "model a is not registered",
+			expectedCalls: []int{1},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			var reg ModelRegister
+			var resolvers []ModelResolver
+			for _, r := range tc.resolvers {
+				resolvers = append(resolvers, r)
+			}
+			reg.SetResolvers(resolvers...)
+
+			m, err := reg.GetOrResolve(context.Background(), "a")
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if m.GetInferenceParameters().Tag != tc.expectedTag {
+					t.Errorf("expected tag %s, got %s", tc.expectedTag, m.GetInferenceParameters().Tag)
+				}
+				// The resolved model is served from the register afterwards.
+				if _, err := reg.GetOrResolve(context.Background(), "a"); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+			for i, r := range tc.resolvers {
+				if r.calls != tc.expectedCalls[i] {
+					t.Errorf("expected resolver %s to be called %d times, got %d", r.name, tc.expectedCalls[i], r.calls)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigMapResolver(t *testing.T) {
+	newConfigMap := func(name, version, data string) *corev1.ConfigMap {
+		labels := map[string]string{PresetNameLabel: "custom"}
+		if version != "" {
+			labels[PresetVersionLabel] = version
+		}
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kaito-system", Labels: labels},
+			Data:       map[string]string{PresetConfigMapKey: data},
+		}
+	}
+	c := fake.NewClientBuilder().WithObjects(
+		newConfigMap("custom-v1", "1", "tag: \"1\"\ngpuCountRequirement: \"1\"\nreadinessTimeout: 10m\n"),
+		newConfigMap("custom-v2", "2", "tag: \"2\"\n"),
+	).Build()
+
+	var reg ModelRegister
+	reg.SetResolvers(&ConfigMapResolver{Client: c, Namespace: "kaito-system"})
+
+	m, err := reg.GetOrResolve(context.Background(), "custom@1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if param := m.GetInferenceParameters(); param.Tag != "1" || param.GPUCountRequirement != "1" || param.ReadinessTimeout.Minutes() != 10 {
+		t.Errorf("unexpected preset parameters %+v", param)
+	}
+	if reg.Has("custom@2") {
+		t.Errorf("expected only the pinned version to be resolved")
+	}
+
+	// Resolving a reference without a version registers all declared versions.
+	reg = ModelRegister{}
+	reg.SetResolvers(&ConfigMapResolver{Client: c, Namespace: "kaito-system"})
+	m, err = reg.GetOrResolve(context.Background(), "custom")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag := m.GetInferenceParameters().Tag; tag != "2" {
+		t.Errorf("expected latest version 2, got %s", tag)
+	}
+	if !reg.Has("custom@1") {
+		t.Errorf("expected all declared versions to be registered")
+	}
+
+	if _, err := reg.GetOrResolve(context.Background(), "unknown"); err == nil {
+		t.Errorf("expected error for undeclared preset")
+	}
+}
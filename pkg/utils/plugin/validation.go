@@ -0,0 +1,148 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/azure/kaito/pkg/model"
+)
+
+const (
+	// maxModelNameLength follows the Hugging Face limit on repository names.
+	maxModelNameLength = 96
+	maxVersionLength   = 64
+)
+
+var (
+	// modelNamePartRegex matches an organization or model name as accepted by Hugging Face:
+	// alphanumeric characters, '-', '_' and '.', not starting or ending with '-' or '.'.
+	modelNamePartRegex = regexp.MustCompile(`^[A-Za-z0-9_]([A-Za-z0-9._-]*[A-Za-z0-9_])?$`)
+	versionRegex       = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]*$`)
+)
+
+// NamePolicy restricts the organizations of the models that can be referenced as "org/model".
+// References without an organization, such as the builtin presets, are not restricted.
+type NamePolicy struct {
+	// AllowedOrgs, if not empty, is the list of the only organizations allowed.
+	AllowedOrgs []string
+	// DeniedOrgs is the list of organizations not allowed. It takes precedence over AllowedOrgs.
+	DeniedOrgs []string
+}
+
+// SetNamePolicy configures the organizations allowed in model references.
+func (reg *ModelRegister) SetNamePolicy(policy NamePolicy) {
+	reg.Lock()
+	defer reg.Unlock()
+	reg.namePolicy = policy
+}
+
+// LifecyclePolicy controls the use of deprecated presets.
+type LifecyclePolicy struct {
+	// AllowEndOfLife allows new workspaces to use presets past their end of life.
+	AllowEndOfLife bool
+}
+
+// SetLifecyclePolicy configures the use of deprecated presets.
+func (reg *ModelRegister) SetLifecyclePolicy(policy LifecyclePolicy) {
+	reg.Lock()
+	defer reg.Unlock()
+	reg.lifecyclePolicy = policy
+}
+
+// ValidateLifecycle checks that new workspaces can use the named preset at now. It returns a
+// warning if the preset is deprecated, and an error if it is past its end of life, unless allowed
+// by the lifecycle policy.
+func (reg *ModelRegister) ValidateLifecycle(name string, param *model.PresetParam, now time.Time) (warning string, err error) {
+	if param == nil || param.Deprecation == nil {
+		return "", nil
+	}
+	reg.RLock()
+	policy := reg.lifecyclePolicy
+	reg.RUnlock()
+	if param.Deprecation.PastEndOfLife(now) && !policy.AllowEndOfLife {
+		return "", errors.New(param.Deprecation.Message(name))
+	}
+	return param.Deprecation.Message(name), nil
+}
+
+// ImagePolicy controls the image tags the workspaces can pin their public preset image to.
+type ImagePolicy struct {
+	// AllowedTags is the list of the only tags allowed. If empty, workspaces cannot pin the tag.
+	AllowedTags []string
+}
+
+// SetImagePolicy configures the image tags allowed in workspaces.
+func (reg *ModelRegister) SetImagePolicy(policy ImagePolicy) {
+	reg.Lock()
+	defer reg.Unlock()
+	reg.imagePolicy = policy
+}
+
+// ValidateImageTag checks that workspaces can pin the preset image to tag.
+func (reg *ModelRegister) ValidateImageTag(tag string) error {
+	reg.RLock()
+	policy := reg.imagePolicy
+	reg.RUnlock()
+	if len(policy.AllowedTags) == 0 {
+		return fmt.Errorf("image tag %s is not allowed, pinning the preset image tag is not enabled", tag)
+	}
+	for _, allowed := range policy.AllowedTags {
+		if allowed == tag {
+			return nil
+		}
+	}
+	return fmt.Errorf("image tag %s is not allowed, allowed tags: %s", tag, strings.Join(policy.AllowedTags, ", "))
+}
+
+// ValidateReference checks that ref is a well-formed "[org/]name[@version]" model reference
+// and that its organization is allowed by the name policy.
+func (reg *ModelRegister) ValidateReference(ref string) error {
+	name, version := ParseModelReference(ref)
+	if strings.Contains(ref, versionSeparator) && version == "" {
+		return fmt.Errorf("invalid model reference %q: empty version", ref)
+	}
+	if version != "" && (len(version) > maxVersionLength || !versionRegex.MatchString(version)) {
+		return fmt.Errorf("invalid model reference %q: version must be at most %d alphanumeric, '-', '_' or '.' characters", ref, maxVersionLength)
+	}
+
+	parts := strings.Split(name, "/")
+	if len(parts) > 2 {
+		return fmt.Errorf("invalid model reference %q: expected [org/]name", ref)
+	}
+	for _, part := range parts {
+		if len(part) > maxModelNameLength || !modelNamePartRegex.MatchString(part) ||
+			strings.Contains(part, "--") || strings.Contains(part, "..") {
+			return fmt.Errorf("invalid model reference %q: %q must be at most %d alphanumeric, '-', '_' or '.' characters, "+
+				"not starting or ending with '-' or '.', and without '--' or '..'", ref, part, maxModelNameLength)
+		}
+	}
+	if len(parts) == 1 {
+		return nil
+	}
+
+	org := parts[0]
+	reg.RLock()
+	policy := reg.namePolicy
+	reg.RUnlock()
+	if containsFold(policy.DeniedOrgs, org) {
+		return fmt.Errorf("models of organization %s are not allowed", org)
+	}
+	if len(policy.AllowedOrgs) > 0 && !containsFold(policy.AllowedOrgs, org) {
+		return fmt.Errorf("models of organization %s are not allowed, allowed organizations: %s", org, strings.Join(policy.AllowedOrgs, ", "))
+	}
+	return nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, e := range list {
+		if strings.EqualFold(e, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package plugin
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/azure/kaito/pkg/model"
+)
+
+func TestValidateReference(t *testing.T) {
+	testcases := map[string]struct {
+		ref         string
+		policy      NamePolicy
+		expectedErr string
+	}{
+		"builtin preset": {
+			ref: "falcon-7b-instruct",
+		},
+		"pinned version": {
+			ref: "phi-2@0.0.3",
+		},
+		"org and model": {
+			ref: "my-org/llama.3_8b",
+		},
+		"too many path segments": {
+			ref:         "org/model/version",
+			expectedErr: "expected [org/]name",
+		},
+		"invalid characters": {
+			ref:         "model;rm -rf",
+			expectedErr: "invalid model reference",
+		},
+		"leading dash": {
+			ref:         "-model",
+			expectedErr: "invalid model reference",
+		},
+		"double dots": {
+			ref:         "org/model..v1",
+			expectedErr: "without '--' or '..'",
+		},
+		"too long": {
+			ref:         strings.Repeat("a", maxModelNameLength+1),
+			expectedErr: "at most 96",
+		},
+		"empty version": {
+			ref:         "phi-2@",
+			expectedErr: "empty version",
+		},
+		"invalid version": {
+			ref:         "phi-2@v1/2",
+			expectedErr: "version must be",
+		},
+		"allowed org": {
+			ref:    "My-Org/model",
+			policy: NamePolicy{AllowedOrgs: []string{"my-org"}},
+		},
+		"org not in allow list": {
+			ref:         "other/model",
+			policy:      NamePolicy{AllowedOrgs: []string{"my-org"}},
+			expectedErr: "allowed organizations: my-org",
+		},
+		"denied org takes precedence": {
+			ref:         "my-org/model",
+			policy:      NamePolicy{AllowedOrgs: []string{"my-org"}, DeniedOrgs: []string{"my-org"}},
+			expectedErr: "models of organization my-org are not allowed",
+		},
+		"policy does not apply without org": {
+			ref:    "phi-2",
+			policy: NamePolicy{AllowedOrgs: []string{"my-org"}},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			var reg ModelRegister
+			reg.SetNamePolicy(tc.policy)
+			err := reg.ValidateReference(tc.ref)
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("expected error containing %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateLifecycle(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	testcases := map[string]struct {
+		deprecation     *model.Deprecation
+		policy          LifecyclePolicy
+		expectedWarning string
+		expectedErr     string
+	}{
+		"not deprecated": {},
+		"deprecated with replacement": {
+			deprecation:     &model.Deprecation{Replacement: "phi-3-mini-4k-instruct"},
+			expectedWarning: "Preset phi-2 is deprecated, use preset phi-3-mini-4k-instruct instead",
+		},
+		"before end of life": {
+			deprecation:     &model.Deprecation{EndOfLife: now.Add(24 * time.Hour)},
+			expectedWarning: "its end of life is 2024-06-02",
+		},
+		"past end of life": {
+			deprecation: &model.Deprecation{EndOfLife: now, Replacement: "phi-3-mini-4k-instruct"},
+			expectedErr: "use preset phi-3-mini-4k-instruct instead",
+		},
+		"past end of life allowed by policy": {
+			deprecation:     &model.Deprecation{EndOfLife: now},
+			policy:          LifecyclePolicy{AllowEndOfLife: true},
+			expectedWarning: "its end of life is 2024-06-01",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			var reg ModelRegister
+			reg.SetLifecyclePolicy(tc.policy)
+			warning, err := reg.ValidateLifecycle("phi-2", &model.PresetParam{Deprecation: tc.deprecation}, now)
+			if (err != nil) != (tc.expectedErr != "") || err != nil && !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("expected error containing %q, got %v", tc.expectedErr, err)
+			}
+			if (warning != "") != (tc.expectedWarning != "") || !strings.Contains(warning, tc.expectedWarning) {
+				t.Errorf("expected warning containing %q, got %q", tc.expectedWarning, warning)
+			}
+		})
+	}
+}
+
+func TestValidateImageTag(t *testing.T) {
+	testcases := map[string]struct {
+		policy      ImagePolicy
+		tag         string
+		expectedErr string
+	}{
+		"pinning not enabled": {
+			tag:         "0.0.4",
+			expectedErr: "not enabled",
+		},
+		"allowed tag": {
+			policy: ImagePolicy{AllowedTags: []string{"0.0.3", "0.0.4"}},
+			tag:    "0.0.4",
+		},
+		"tag not allowed": {
+			policy:      ImagePolicy{AllowedTags: []string{"0.0.3", "0.0.4"}},
+			tag:         "0.0.5",
+			expectedErr: "allowed tags: 0.0.3, 0.0.4",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			var reg ModelRegister
+			reg.SetImagePolicy(tc.policy)
+			err := reg.ValidateImageTag(tc.tag)
+			if (err != nil) != (tc.expectedErr != "") || err != nil && !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("expected error containing %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
@@ -121,6 +121,22 @@ func (m *MockClient) getObjectListFromMap(list k8sClient.ObjectList) k8sClient.O
 			}
 		}
 		return nodeClaimList
+	case *v1beta1.NodePoolList:
+		nodePoolList := &v1beta1.NodePoolList{}
+		for _, obj := range relevantMap {
+			if m, ok := obj.(*v1beta1.NodePool); ok {
+				nodePoolList.Items = append(nodePoolList.Items, *m)
+			}
+		}
+		return nodePoolList
+	case *corev1.PodList:
+		podList := &corev1.PodList{}
+		for _, obj := range relevantMap {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				podList.Items = append(podList.Items, *pod)
+			}
+		}
+		return podList
 	}
 	//add additional object lists as needed
 	return nil
@@ -4,6 +4,7 @@ package webhooks
 
 import (
 	"context"
+	"os"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"knative.dev/pkg/configmap"
@@ -14,8 +15,16 @@ import (
 	"knative.dev/pkg/webhook/resourcesemantics/validation"
 
 	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/pkg/resources"
 )
 
+// WarnOnly reports the validation errors of the workspaces as admission warnings instead of
+// rejecting them.
+var WarnOnly bool
+
+// Offline rejects the workspaces that depend on external endpoints, for air-gapped clusters.
+var Offline bool
+
 func NewWebhooks() []knativeinjection.ControllerConstructor {
 	return []knativeinjection.ControllerConstructor{
 		certificates.NewController,
@@ -28,11 +37,24 @@ func NewCRDValidationWebhook(ctx context.Context, _ configmap.Watcher) *controll
 		"validation.workspace.kaito.sh",
 		"/validate/workspace.kaito.sh",
 		Resources,
-		func(ctx context.Context) context.Context { return ctx },
+		func(ctx context.Context) context.Context {
+			if Offline {
+				ctx = kaitov1alpha1.WithOffline(ctx, presetImageInternal)
+			}
+			if WarnOnly {
+				return kaitov1alpha1.WithWarnOnly(ctx)
+			}
+			return ctx
+		},
 		true,
 	)
 }
 
+func presetImageInternal(presetName string) bool {
+	return resources.PresetImageInternal(os.Getenv("PRESET_REGISTRY_NAME"), presetName)
+}
+
 var Resources = map[schema.GroupVersionKind]resourcesemantics.GenericCRD{
-	kaitov1alpha1.GroupVersion.WithKind("Workspace"): &kaitov1alpha1.Workspace{},
+	kaitov1alpha1.GroupVersion.WithKind("Workspace"):   &kaitov1alpha1.Workspace{},
+	kaitov1alpha1.GroupVersion.WithKind("ModelPreset"): &kaitov1alpha1.ModelPreset{},
 }
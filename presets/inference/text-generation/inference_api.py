@@ -1,7 +1,12 @@
 # Copyright (c) Microsoft Corporation.
 # Licensed under the MIT license.
+import contextvars
+import json
+import logging
 import os
 import subprocess
+import time
+import uuid
 from dataclasses import asdict, dataclass, field
 from typing import Annotated, Any, Dict, List, Optional
 
@@ -10,14 +15,82 @@ import psutil
 import torch
 import transformers
 import uvicorn
-from fastapi import Body, FastAPI, HTTPException
-from fastapi.responses import Response
+from fastapi import Body, FastAPI, HTTPException, Request
+from fastapi.responses import JSONResponse, Response
 from peft import PeftModel
+from prometheus_client import (CONTENT_TYPE_LATEST, CollectorRegistry, Counter,
+                               Histogram, generate_latest)
 from pydantic import BaseModel, Extra, Field, validator
 from transformers import (AutoModelForCausalLM, AutoTokenizer,
                           GenerationConfig, HfArgumentParser)
 
 ADAPTERS_DIR = '/mnt/adapter'
+
+# Logging is configured by the operator from the annotations of the workspace.
+LOG_LEVEL = os.environ.get("LOG_LEVEL", "info").upper()
+LOG_FORMAT = os.environ.get("LOG_FORMAT", "text")
+# "none" disables the access log, "access" logs one line per request, "full" also logs the generated text.
+REQUEST_LOGGING = os.environ.get("REQUEST_LOGGING", "access")
+
+# The ID of the request being served, taken from the X-Request-Id header of the request or generated.
+REQUEST_ID_HEADER = "X-Request-Id"
+request_id_var = contextvars.ContextVar("request_id", default="-")
+
+class RequestIDFilter(logging.Filter):
+    """
+    Sets the ID of the request being served on the log records.
+    """
+    def filter(self, record):
+        record.request_id = request_id_var.get()
+        return True
+
+class JSONFormatter(logging.Formatter):
+    """
+    Formats the log records as JSON objects, one per line.
+    """
+    def format(self, record):
+        entry = {
+            "time": self.formatTime(record),
+            "level": record.levelname,
+            "logger": record.name,
+            "message": record.getMessage(),
+            "request_id": getattr(record, "request_id", "-"),
+        }
+        if record.exc_info:
+            entry["exception"] = self.formatException(record.exc_info)
+        return json.dumps(entry)
+
+def configure_logging():
+    handler = logging.StreamHandler()
+    handler.addFilter(RequestIDFilter())
+    if LOG_FORMAT == "json":
+        handler.setFormatter(JSONFormatter())
+    else:
+        handler.setFormatter(logging.Formatter("%(asctime)s %(levelname)s %(name)s [%(request_id)s]: %(message)s"))
+    logging.basicConfig(level=LOG_LEVEL, handlers=[handler], force=True)
+    transformers.logging.set_verbosity(logging.getLevelName(LOG_LEVEL))
+
+configure_logging()
+logger = logging.getLogger("inference")
+access_logger = logging.getLogger("inference.access")
+
+# A registry of the module rather than the global one, which would reject the metrics if the module is reloaded.
+METRICS_REGISTRY = CollectorRegistry()
+REQUEST_LATENCY = Histogram(
+    "inference_request_duration_seconds",
+    "Latency of the requests served, partitioned by method, path and status code.",
+    ["method", "path", "status_code"],
+    buckets=(0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300),
+    registry=METRICS_REGISTRY,
+)
+# The usage counters are read by the operator for the usage reports of the workspace.
+GENERATION_REQUESTS = Counter(
+    "inference_generation_requests", "Number of generation requests served.", registry=METRICS_REGISTRY)
+INPUT_TOKENS = Counter(
+    "inference_input_tokens", "Number of tokens of the prompts of the generation requests.", registry=METRICS_REGISTRY)
+OUTPUT_TOKENS = Counter(
+    "inference_output_tokens", "Number of tokens generated for the generation requests.", registry=METRICS_REGISTRY)
+
 @dataclass
 class ModelConfig:
     """
@@ -40,6 +113,7 @@ class ModelConfig:
     load_in_8bit: bool = field(default=False, metadata={"help": "Load model in 8-bit mode"})
     torch_dtype: Optional[str] = field(default=None, metadata={"help": "The torch dtype for the pre-trained model"})
     device_map: str = field(default="auto", metadata={"help": "The device map for the pre-trained model"})
+    chat_template: Optional[str] = field(default=None, metadata={"help": "Path of a jinja chat template overriding the one of the tokenizer"})
 
     # Method to process additional arguments
     def process_additional_args(self, addt_args: List[str]):
@@ -89,9 +163,13 @@ model_args = asdict(args)
 model_args["local_files_only"] = not model_args.pop('allow_remote_files')
 model_pipeline = model_args.pop('pipeline')
 combination_type = model_args.pop('combination_type')
+chat_template = model_args.pop('chat_template')
 
 app = FastAPI()
 tokenizer = AutoTokenizer.from_pretrained(**model_args)
+if chat_template:
+    with open(chat_template) as f:
+        tokenizer.chat_template = f.read()
 base_model = AutoModelForCausalLM.from_pretrained(**model_args)
 
 if not os.path.exists(ADAPTERS_DIR):
@@ -126,10 +204,10 @@ else:
         for adapter in adapter_names:
             model.delete_adapter(adapter)
     else:
-        print("Warning: Did not find any valid adapters mounted, using base model")
+        logger.warning("Did not find any valid adapters mounted, using base model")
         model = base_model
 
-print("Model:", model)
+logger.debug("Model: %s", model)
 
 pipeline_kwargs = {
     "trust_remote_code": args.trust_remote_code,
@@ -155,6 +233,50 @@ try:
 except Exception as e:
     default_generate_config = {}
 
+@app.middleware("http")
+async def request_context(request: Request, call_next):
+    """
+    Propagates the X-Request-Id header of the request, or generates one, to the logs and the response,
+    logs the request in the access log and records its latency.
+    """
+    request_id = request.headers.get(REQUEST_ID_HEADER) or uuid.uuid4().hex
+    token = request_id_var.set(request_id)
+    start = time.perf_counter()
+    status_code = 500
+    try:
+        response = await call_next(request)
+        status_code = response.status_code
+        response.headers[REQUEST_ID_HEADER] = request_id
+        return response
+    finally:
+        duration = time.perf_counter() - start
+        # The route template keeps the cardinality of the path label bounded.
+        route = request.scope.get("route")
+        path = route.path if route is not None else "unmatched"
+        REQUEST_LATENCY.labels(request.method, path, str(status_code)).observe(duration)
+        if REQUEST_LOGGING != "none":
+            access_logger.info('"%s %s" %d %.3fs', request.method, request.url.path, status_code, duration)
+        request_id_var.reset(token)
+
+@app.exception_handler(HTTPException)
+async def request_id_exception_handler(request: Request, exc: HTTPException):
+    """
+    Returns the ID of the request with the error, so that it can be found in the logs.
+    """
+    return JSONResponse(
+        status_code=exc.status_code,
+        content={"detail": exc.detail, "request_id": request_id_var.get()},
+        headers=getattr(exc, "headers", None),
+    )
+
+def count_tokens(text: str) -> int:
+    return len(tokenizer(text, add_special_tokens=False)["input_ids"]) if text else 0
+
+def record_usage(input_tokens: int, output_tokens: int):
+    GENERATION_REQUESTS.inc()
+    INPUT_TOKENS.inc(input_tokens)
+    OUTPUT_TOKENS.inc(max(output_tokens, 0))
+
 class HomeResponse(BaseModel):
     message: str = Field(..., example="Server is running")
 @app.get('/', response_model=HomeResponse, summary="Home Endpoint")
@@ -360,8 +482,14 @@ def generate_text(
 
         result = ""
         for seq in sequences:
-            print(f"Result: {seq['generated_text']}")
             result += seq['generated_text']
+        input_tokens = count_tokens(request_model.prompt)
+        output_tokens = count_tokens(result)
+        if request_model.return_full_text:
+            output_tokens -= input_tokens * len(sequences)
+        record_usage(input_tokens, output_tokens)
+        if REQUEST_LOGGING == "full":
+            logger.info("Result: %s", result)
 
         return {"Result": result}
 
@@ -374,6 +502,11 @@ def generate_text(
             clean_up_tokenization_spaces=request_model.clean_up_tokenization_spaces,
             **generate_kwargs
         )
+        reply = response[-1]
+        record_usage(sum(count_tokens(message.content) for message in request_model.messages),
+                     count_tokens(reply.get("content", "") if isinstance(reply, dict) else str(reply)))
+        if REQUEST_LOGGING == "full":
+            logger.info("Result: %s", response[-1])
         return {"Result": str(response[-1])}
 
     else:
@@ -473,7 +606,17 @@ def get_metrics():
     except Exception as e:
         raise HTTPException(status_code=500, detail=str(e))
 
+@app.get("/metrics/prometheus", summary="Prometheus Metrics Endpoint", include_in_schema=False)
+def get_prometheus_metrics():
+    """
+    Provides the request latency histograms in the Prometheus text format.
+    """
+    return Response(content=generate_latest(METRICS_REGISTRY), media_type=CONTENT_TYPE_LATEST)
+
 if __name__ == "__main__":
     local_rank = int(os.environ.get("LOCAL_RANK", 0)) # Default to 0 if not set
     port = 5000 + local_rank # Adjust port based on local rank
-    uvicorn.run(app=app, host='0.0.0.0', port=port)
\ No newline at end of file
+    # Without a log config, the uvicorn loggers use the handler configured above. The access log is
+    # written by the request_context middleware, which knows the ID of the request.
+    uvicorn.run(app=app, host='0.0.0.0', port=port, log_level=LOG_LEVEL.lower(),
+                log_config=None, access_log=False)
\ No newline at end of file
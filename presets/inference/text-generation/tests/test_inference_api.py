@@ -84,6 +84,10 @@ def test_text_generation(configured_app):
     assert "Result" in data
     assert len(data["Result"]) > 0  # Check if the result text is not empty
 
+    metrics = client.get("/metrics/prometheus").text
+    assert "inference_generation_requests_total 1.0" in metrics
+    assert "inference_input_tokens_total 4.0" in metrics
+
 def test_missing_prompt(configured_app):
     if configured_app.test_config['pipeline'] != 'text-generation':
         pytest.skip("Skipping non-text-generation tests")
@@ -98,6 +102,31 @@ def test_missing_prompt(configured_app):
     assert response.status_code == 400  # Expecting a Bad Request response due to missing prompt
     assert "Text generation parameter prompt required" in response.json().get("detail", "")
 
+def test_request_id(configured_app):
+    client = TestClient(configured_app)
+    response = client.get("/", headers={"X-Request-Id": "test-request"})
+    assert response.status_code == 200
+    assert response.headers["X-Request-Id"] == "test-request"
+
+    # A request ID is generated for the requests without one.
+    response = client.get("/")
+    assert len(response.headers["X-Request-Id"]) > 0
+    assert response.headers["X-Request-Id"] != "test-request"
+
+def test_request_id_in_error_response(configured_app):
+    client = TestClient(configured_app)
+    response = client.post("/chat", json={"generate_kwargs": {"max_length": 50}}, headers={"X-Request-Id": "test-request"})
+    assert response.status_code == 400
+    assert response.json()["request_id"] == "test-request"
+    assert response.headers["X-Request-Id"] == "test-request"
+
+def test_request_latency_metrics(configured_app):
+    client = TestClient(configured_app)
+    client.get("/")
+    response = client.get("/metrics/prometheus")
+    assert response.status_code == 200
+    assert 'inference_request_duration_seconds_count{method="GET",path="/",status_code="200"}' in response.text
+
 def test_read_main(configured_app):
     client = TestClient(configured_app)
     response = client.get("/")
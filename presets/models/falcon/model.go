@@ -66,6 +66,7 @@ func (*falcon7b) GetInferenceParameters() *model.PresetParam {
 		TorchRunParams:            inference.DefaultAccelerateParams,
 		ModelRunParams:            falconRunParams,
 		ReadinessTimeout:          time.Duration(30) * time.Minute,
+		MinDriverVersion:          inference.DefaultMinDriverVersion,
 		BaseCommand:               baseCommandPresetFalcon,
 		Tag:                       PresetFalconTagMap["Falcon7B"],
 	}
@@ -109,6 +110,7 @@ func (*falcon7bInst) GetInferenceParameters() *model.PresetParam {
 		TorchRunParams:            inference.DefaultAccelerateParams,
 		ModelRunParams:            falconRunParams,
 		ReadinessTimeout:          time.Duration(30) * time.Minute,
+		MinDriverVersion:          inference.DefaultMinDriverVersion,
 		BaseCommand:               baseCommandPresetFalcon,
 		Tag:                       PresetFalconTagMap["Falcon7BInstruct"],
 	}
@@ -139,6 +141,7 @@ func (*falcon40b) GetInferenceParameters() *model.PresetParam {
 		TorchRunParams:            inference.DefaultAccelerateParams,
 		ModelRunParams:            falconRunParams,
 		ReadinessTimeout:          time.Duration(30) * time.Minute,
+		MinDriverVersion:          inference.DefaultMinDriverVersion,
 		BaseCommand:               baseCommandPresetFalcon,
 		Tag:                       PresetFalconTagMap["Falcon40B"],
 	}
@@ -181,6 +184,7 @@ func (*falcon40bInst) GetInferenceParameters() *model.PresetParam {
 		TorchRunParams:            inference.DefaultAccelerateParams,
 		ModelRunParams:            falconRunParams,
 		ReadinessTimeout:          time.Duration(30) * time.Minute,
+		MinDriverVersion:          inference.DefaultMinDriverVersion,
 		BaseCommand:               baseCommandPresetFalcon,
 		Tag:                       PresetFalconTagMap["Falcon40BInstruct"],
 	}
@@ -50,6 +50,7 @@ func (*llama2Text7b) GetInferenceParameters() *model.PresetParam {
 		TorchRunRdzvParams:        inference.DefaultTorchRunRdzvParams,
 		ModelRunParams:            llamaRunParams,
 		ReadinessTimeout:          time.Duration(10) * time.Minute,
+		MinDriverVersion:          inference.DefaultMinDriverVersion,
 		BaseCommand:               baseCommandPresetLlama,
 		WorldSize:                 1,
 		// Tag:  llama has private image access mode. The image tag is determined by the user.
@@ -82,6 +83,7 @@ func (*llama2Text13b) GetInferenceParameters() *model.PresetParam {
 		TorchRunRdzvParams:        inference.DefaultTorchRunRdzvParams,
 		ModelRunParams:            llamaRunParams,
 		ReadinessTimeout:          time.Duration(20) * time.Minute,
+		MinDriverVersion:          inference.DefaultMinDriverVersion,
 		BaseCommand:               baseCommandPresetLlama,
 		WorldSize:                 2,
 		// Tag:  llama has private image access mode. The image tag is determined by the user.
@@ -113,6 +115,7 @@ func (*llama2Text70b) GetInferenceParameters() *model.PresetParam {
 		TorchRunRdzvParams:        inference.DefaultTorchRunRdzvParams,
 		ModelRunParams:            llamaRunParams,
 		ReadinessTimeout:          time.Duration(30) * time.Minute,
+		MinDriverVersion:          inference.DefaultMinDriverVersion,
 		BaseCommand:               baseCommandPresetLlama,
 		WorldSize:                 8,
 		// Tag:  llama has private image access mode. The image tag is determined by the user.
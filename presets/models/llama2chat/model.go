@@ -50,6 +50,7 @@ func (*llama2Chat7b) GetInferenceParameters() *model.PresetParam {
 		TorchRunRdzvParams:        inference.DefaultTorchRunRdzvParams,
 		ModelRunParams:            llamaRunParams,
 		ReadinessTimeout:          time.Duration(10) * time.Minute,
+		MinDriverVersion:          inference.DefaultMinDriverVersion,
 		BaseCommand:               baseCommandPresetLlama,
 		WorldSize:                 1,
 		// Tag:  llama has private image access mode. The image tag is determined by the user.
@@ -81,6 +82,7 @@ func (*llama2Chat13b) GetInferenceParameters() *model.PresetParam {
 		TorchRunRdzvParams:        inference.DefaultTorchRunRdzvParams,
 		ModelRunParams:            llamaRunParams,
 		ReadinessTimeout:          time.Duration(20) * time.Minute,
+		MinDriverVersion:          inference.DefaultMinDriverVersion,
 		BaseCommand:               baseCommandPresetLlama,
 		WorldSize:                 2,
 		// Tag:  llama has private image access mode. The image tag is determined by the user.
@@ -112,6 +114,7 @@ func (*llama2Chat70b) GetInferenceParameters() *model.PresetParam {
 		TorchRunRdzvParams:        inference.DefaultTorchRunRdzvParams,
 		ModelRunParams:            llamaRunParams,
 		ReadinessTimeout:          time.Duration(30) * time.Minute,
+		MinDriverVersion:          inference.DefaultMinDriverVersion,
 		BaseCommand:               baseCommandPresetLlama,
 		WorldSize:                 8,
 		// Tag:  llama has private image access mode. The image tag is determined by the user.
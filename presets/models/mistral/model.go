@@ -53,6 +53,7 @@ func (*mistral7b) GetInferenceParameters() *model.PresetParam {
 		TorchRunParams:            inference.DefaultAccelerateParams,
 		ModelRunParams:            mistralRunParams,
 		ReadinessTimeout:          time.Duration(30) * time.Minute,
+		MinDriverVersion:          inference.DefaultMinDriverVersion,
 		BaseCommand:               baseCommandPresetMistral,
 		Tag:                       PresetMistralTagMap["Mistral7B"],
 	}
@@ -96,6 +97,7 @@ func (*mistral7bInst) GetInferenceParameters() *model.PresetParam {
 		TorchRunParams:            inference.DefaultAccelerateParams,
 		ModelRunParams:            mistralRunParams,
 		ReadinessTimeout:          time.Duration(30) * time.Minute,
+		MinDriverVersion:          inference.DefaultMinDriverVersion,
 		BaseCommand:               baseCommandPresetMistral,
 		Tag:                       PresetMistralTagMap["Mistral7BInstruct"],
 	}
@@ -47,6 +47,7 @@ func (*phi2) GetInferenceParameters() *model.PresetParam {
 		TorchRunParams:            inference.DefaultAccelerateParams,
 		ModelRunParams:            phiRunParams,
 		ReadinessTimeout:          time.Duration(30) * time.Minute,
+		MinDriverVersion:          inference.DefaultMinDriverVersion,
 		BaseCommand:               baseCommandPresetPhi,
 		Tag:                       PresetPhiTagMap["Phi2"],
 	}
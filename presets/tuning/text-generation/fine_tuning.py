@@ -1,5 +1,6 @@
 # Copyright (c) Microsoft Corporation.
 # Licensed under the MIT license.
+import math
 import os
 import sys
 from dataclasses import asdict
@@ -13,11 +14,40 @@ from dataset import DatasetManager
 from peft import LoraConfig, get_peft_model, prepare_model_for_kbit_training
 from transformers import (AutoModelForCausalLM, AutoTokenizer,
                           BitsAndBytesConfig, HfArgumentParser, Trainer,
-                          TrainingArguments)
+                          TrainerCallback, TrainingArguments)
 from trl import SFTTrainer
 
 CONFIG_YAML = os.environ.get('YAML_FILE_PATH', '/mnt/config/training_config.yaml')
 
+# The failures are classified by the workspace controller, which decides whether the job is retried,
+# from the exit code and the termination log, see pkg/tuning/failures.go.
+EXIT_DATASET_ERROR = 3
+EXIT_OUT_OF_MEMORY = 4
+EXIT_NAN_LOSS = 5
+TERMINATION_LOG = os.environ.get('TERMINATION_LOG_PATH', '/dev/termination-log')
+
+
+def fail(exit_code, reason, message):
+    print(message, file=sys.stderr)
+    try:
+        with open(TERMINATION_LOG, 'w') as f:
+            f.write(f"kaito-tuning-failure: {reason}: {message}\n")
+    except OSError:
+        pass
+    sys.exit(exit_code)
+
+
+class NaNLossError(Exception):
+    pass
+
+
+class NaNLossCallback(TrainerCallback):
+    def on_log(self, args, state, control, logs=None, **kwargs):
+        loss = (logs or {}).get("loss")
+        if loss is not None and math.isnan(loss):
+            raise NaNLossError(f"The training loss is NaN at step {state.global_step}.")
+
+
 parsed_configs = parse_configs(CONFIG_YAML)
 
 model_config = parsed_configs.get('ModelConfig')
@@ -27,6 +57,22 @@ ta_args = parsed_configs.get('TrainingArguments')
 ds_config = parsed_configs.get('DatasetConfig')
 dc_args = parsed_configs.get('DataCollator')
 
+# Derived by the workspace controller from the GPU memory of the instance type, when the training
+# config sets neither.
+if 'PER_DEVICE_TRAIN_BATCH_SIZE' in os.environ:
+    ta_args.per_device_train_batch_size = int(os.environ['PER_DEVICE_TRAIN_BATCH_SIZE'])
+if 'GRADIENT_ACCUMULATION_STEPS' in os.environ:
+    ta_args.gradient_accumulation_steps = int(os.environ['GRADIENT_ACCUMULATION_STEPS'])
+
+# Set by the workspace controller when the job is retried after running out of memory, the
+# gradient accumulation keeps the effective batch size.
+batch_size_divisor = int(os.environ.get('BATCH_SIZE_DIVISOR', '1'))
+if batch_size_divisor > 1:
+    ta_args.per_device_train_batch_size = max(1, ta_args.per_device_train_batch_size // batch_size_divisor)
+    ta_args.gradient_accumulation_steps *= batch_size_divisor
+    print(f"Batch size reduced to {ta_args.per_device_train_batch_size}, "
+          f"gradient accumulation steps increased to {ta_args.gradient_accumulation_steps}")
+
 accelerator = Accelerator()
 
 # Load Model Args
@@ -79,17 +125,20 @@ model.config.use_cache = False
 model.print_trainable_parameters()
 
 dm = DatasetManager(ds_config)
-# Load the dataset
-dm.load_data()
-if not dm.get_dataset():
-    print("Failed to load dataset.")
-    raise ValueError("Unable to load the dataset.")
+try:
+    # Load the dataset
+    dm.load_data()
+    if not dm.get_dataset():
+        print("Failed to load dataset.")
+        raise ValueError("Unable to load the dataset.")
 
-# Shuffling the dataset (if needed)
-if ds_config.shuffle_dataset:
-    dm.shuffle_dataset()
+    # Shuffling the dataset (if needed)
+    if ds_config.shuffle_dataset:
+        dm.shuffle_dataset()
 
-train_dataset, eval_dataset = dm.split_dataset()
+    train_dataset, eval_dataset = dm.split_dataset()
+except Exception as e:
+    fail(EXIT_DATASET_ERROR, "DatasetError", str(e))
 
 # checkpoint_callback = CheckpointCallback()
 
@@ -105,9 +154,15 @@ trainer = accelerator.prepare(SFTTrainer(
     args=ta_args,
     data_collator=dc_args,
     dataset_text_field=dm.dataset_text_field,
+    callbacks=[NaNLossCallback()],
     # metrics = "tensorboard" or "wandb" # TODO
 ))
-trainer.train()
+try:
+    trainer.train()
+except torch.cuda.OutOfMemoryError as e:
+    fail(EXIT_OUT_OF_MEMORY, "OutOfMemory", str(e).splitlines()[0])
+except NaNLossError as e:
+    fail(EXIT_NAN_LOSS, "NaNLoss", str(e))
 os.makedirs(ta_args.output_dir, exist_ok=True)
 trainer.save_model(ta_args.output_dir)
 
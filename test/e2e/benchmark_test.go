@@ -0,0 +1,117 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package e2e
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/test/e2e/utils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// benchmarkInstanceTypesEnvVar is the comma-separated list of the SKUs benchmarked.
+	benchmarkInstanceTypesEnvVar = "E2E_BENCHMARK_INSTANCE_TYPES"
+	// benchmarkRegressionTolerance is the relative change from the baseline reported as a regression.
+	benchmarkRegressionTolerance = 0.1
+)
+
+// benchmarkScenario is a request sent to the workspaces of the benchmark.
+type benchmarkScenario struct {
+	name        string
+	concurrency int
+	maxLength   int
+}
+
+var benchmarkScenarios = []benchmarkScenario{
+	{name: "short", concurrency: 1, maxLength: 50},
+	{name: "long", concurrency: 1, maxLength: 500},
+	{name: "concurrent", concurrency: 4, maxLength: 200},
+}
+
+const benchmarkRequests = 20
+
+func benchmarkInstanceTypes() []string {
+	if value := os.Getenv(benchmarkInstanceTypesEnvVar); value != "" {
+		return strings.Split(value, ",")
+	}
+	return []string{"Standard_NC6s_v3", "Standard_NC12s_v3"}
+}
+
+func createPhi2WorkspaceOnInstanceType(instanceType string) *kaitov1alpha1.Workspace {
+	workspaceObj := &kaitov1alpha1.Workspace{}
+	By(fmt.Sprintf("Creating a workspace CR with Phi 2 preset on %s", instanceType), func() {
+		uniqueID := fmt.Sprint("benchmark-", rand.Intn(1000))
+		workspaceObj = utils.GenerateWorkspaceManifest(uniqueID, namespaceName, "", 1, instanceType,
+			&metav1.LabelSelector{
+				MatchLabels: map[string]string{"kaito-workspace": "benchmark-e2e-test-phi-2"},
+			}, nil, PresetPhi2Model, kaitov1alpha1.ModelImageAccessModePublic, nil, nil)
+
+		createAndValidateWorkspace(workspaceObj)
+	})
+	return workspaceObj
+}
+
+var _ = Describe("Workspace Benchmark", Ordered, Label("benchmark"), func() {
+	report := &utils.BenchmarkReport{
+		Version:   os.Getenv(utils.BenchmarkVersionEnvVar),
+		Timestamp: time.Now().UTC(),
+	}
+
+	AfterAll(func() {
+		path, err := utils.WriteBenchmarkReport(report)
+		Expect(err).NotTo(HaveOccurred(), "Failed to write the benchmark report")
+		if path != "" {
+			GinkgoWriter.Printf("Benchmark report written to %s\n", path)
+		}
+
+		baselinePath := os.Getenv(utils.BenchmarkBaselineEnvVar)
+		if baselinePath == "" {
+			return
+		}
+		baseline, err := utils.ReadBenchmarkReport(baselinePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(utils.BenchmarkRegressions(baseline, report, benchmarkRegressionTolerance)).To(BeEmpty(),
+			"Benchmark regressed from %s", baseline.Version)
+	})
+
+	for _, instanceType := range benchmarkInstanceTypes() {
+		It(fmt.Sprintf("should benchmark the phi-2 preset on %s", instanceType), func() {
+			workspaceObj := createPhi2WorkspaceOnInstanceType(instanceType)
+
+			defer cleanupResources(workspaceObj)
+
+			validateMachineCreation(workspaceObj, 1)
+			validateResourceStatus(workspaceObj)
+			validateInferenceResource(workspaceObj, 1, false)
+			validateWorkspaceReadiness(workspaceObj)
+			validateInferenceEndpoint(workspaceObj)
+
+			for _, scenario := range benchmarkScenarios {
+				By(fmt.Sprintf("Benchmarking the %s scenario", scenario.name), func() {
+					result, err := TestingCluster.InferenceClient().Benchmark(ctx, workspaceObj, utils.BenchmarkOptions{
+						Scenario:    scenario.name,
+						Requests:    benchmarkRequests,
+						Concurrency: scenario.concurrency,
+						Request: &utils.ChatRequest{
+							Prompt:         "Explain how Kubernetes schedules pods.",
+							GenerateKwargs: map[string]interface{}{"max_length": scenario.maxLength},
+						},
+					})
+					Expect(err).NotTo(HaveOccurred())
+					GinkgoWriter.Printf("%s on %s: %.2f req/s, p50 %.2fs, p90 %.2fs, %d failures\n", scenario.name,
+						instanceType, result.RequestsPerSecond, result.LatencyP50Seconds, result.LatencyP90Seconds, result.Failures)
+					report.Results = append(report.Results, *result)
+				})
+			}
+		})
+	}
+})
@@ -6,14 +6,18 @@ package e2e
 import (
 	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
 	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/azure/kaito/test/e2e/utils"
+	"github.com/onsi/ginkgo/v2"
 	"github.com/onsi/gomega"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/kubernetes/test/e2e/framework"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 )
 
 const (
@@ -29,7 +33,24 @@ var (
 type Cluster struct {
 	Scheme        *runtime.Scheme
 	KubeClient    client.Client
+	Clientset     kubernetes.Interface
 	DynamicClient dynamic.Interface
+	Provider      utils.Provider
+}
+
+// InferenceClient returns the client of the inference API of the workspaces of the cluster.
+func (c *Cluster) InferenceClient() *utils.InferenceClient {
+	return &utils.InferenceClient{Clientset: c.Clientset}
+}
+
+// Disruptor returns the disruptor of the nodes of the workspaces of the cluster.
+func (c *Cluster) Disruptor() *utils.Disruptor {
+	return &utils.Disruptor{Client: c.KubeClient, Clientset: c.Clientset}
+}
+
+// Waiter returns the waiter of the resources of the workspaces of the cluster.
+func (c *Cluster) Waiter() *utils.Waiter {
+	return &utils.Waiter{Client: c.KubeClient, Clientset: c.Clientset, Out: ginkgo.GinkgoWriter}
 }
 
 func NewCluster(scheme *runtime.Scheme) *Cluster {
@@ -43,6 +64,7 @@ func GetClusterClient(cluster *Cluster) {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(kaitov1alpha1.AddToScheme(scheme))
 	utilruntime.Must(v1alpha5.SchemeBuilder.AddToScheme(scheme))
+	utilruntime.Must(v1beta1.SchemeBuilder.AddToScheme(scheme))
 
 	restConfig := config.GetConfigOrDie()
 
@@ -52,7 +74,13 @@ func GetClusterClient(cluster *Cluster) {
 	gomega.Expect(err).Should(gomega.Succeed(), "Failed to set up Kube Client")
 	TestingCluster.KubeClient = k8sClient
 
+	cluster.Clientset, err = kubernetes.NewForConfig(restConfig)
+	gomega.Expect(err).Should(gomega.Succeed(), "Failed to set up Clientset")
+
 	cluster.DynamicClient, err = dynamic.NewForConfig(restConfig)
 	gomega.Expect(err).Should(gomega.Succeed(), "Failed to set up Dynamic Client")
 
+	cluster.Provider, err = utils.GetProvider()
+	gomega.Expect(err).Should(gomega.Succeed(), "Failed to set up the e2e provider")
+
 }
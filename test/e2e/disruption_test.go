@@ -0,0 +1,67 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package e2e
+
+import (
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// validateRecovery checks that the single-node workspace loses its workload with its node and recovers from it.
+func validateRecovery(workspaceObj *kaitov1alpha1.Workspace, disrupt func(nodeObj *corev1.Node) error) {
+	By("Disrupting the node of the workspace", func() {
+		nodeObj, err := TestingCluster.Disruptor().WorkspaceNode(ctx, workspaceObj)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(disrupt(nodeObj)).To(Succeed())
+	})
+
+	By("Checking the workload lost its ready replica", func() {
+		validateInferenceResource(workspaceObj, 0, false)
+	})
+
+	validateMachineCreation(workspaceObj, 1)
+	validateResourceStatus(workspaceObj)
+	validateInferenceResource(workspaceObj, 1, false)
+	validateWorkspaceReadiness(workspaceObj)
+	validateInferenceEndpoint(workspaceObj)
+}
+
+var _ = Describe("Workspace Disruption", Label("disruption"), func() {
+	It("should recover a workspace from the deletion of its node", func() {
+		numOfNode := 1
+		workspaceObj := createPhi2WorkspaceWithPresetPublicMode(numOfNode)
+
+		defer cleanupResources(workspaceObj)
+
+		validateMachineCreation(workspaceObj, numOfNode)
+		validateResourceStatus(workspaceObj)
+		validateInferenceResource(workspaceObj, int32(numOfNode), false)
+		validateWorkspaceReadiness(workspaceObj)
+
+		validateRecovery(workspaceObj, func(nodeObj *corev1.Node) error {
+			return TestingCluster.Disruptor().DeleteNode(ctx, nodeObj)
+		})
+	})
+
+	It("should recover a workspace from the eviction of its spot node", func() {
+		numOfNode := 1
+		workspaceObj := createPhi2WorkspaceWithPresetPublicMode(numOfNode)
+
+		defer cleanupResources(workspaceObj)
+
+		validateMachineCreation(workspaceObj, numOfNode)
+		validateResourceStatus(workspaceObj)
+		validateInferenceResource(workspaceObj, int32(numOfNode), false)
+		validateWorkspaceReadiness(workspaceObj)
+
+		validateRecovery(workspaceObj, func(nodeObj *corev1.Node) error {
+			if err := TestingCluster.Disruptor().EvictNode(ctx, nodeObj); err != nil {
+				return err
+			}
+			return TestingCluster.Disruptor().DeleteNode(ctx, nodeObj)
+		})
+	})
+})
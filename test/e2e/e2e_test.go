@@ -26,24 +26,25 @@ var (
 
 var _ = SynchronizedBeforeSuite(func() []byte {
 	GetClusterClient(TestingCluster)
-	gpuNamespace := os.Getenv("GPU_NAMESPACE")
 	kaitoNamespace := os.Getenv("KAITO_NAMESPACE")
 
-	//check gpu-provisioner deployment is up and running
-	gpuProvisionerDeployment := &v1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "gpu-provisioner",
-			Namespace: gpuNamespace,
-		},
+	//check the node provisioner deployment, e.g., gpu-provisioner, is up and running
+	if provisionerNamespace, provisionerName := TestingCluster.Provider.ProvisionerDeployment(); provisionerName != "" {
+		provisionerDeployment := &v1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      provisionerName,
+				Namespace: provisionerNamespace,
+			},
+		}
+
+		Eventually(func() error {
+			return TestingCluster.KubeClient.Get(ctx, client.ObjectKey{
+				Namespace: provisionerDeployment.Namespace,
+				Name:      provisionerDeployment.Name,
+			}, provisionerDeployment, &client.GetOptions{})
+		}, utils.PollTimeout, utils.PollInterval).Should(Succeed(), "Failed to wait for	%s deployment", provisionerName)
 	}
 
-	Eventually(func() error {
-		return TestingCluster.KubeClient.Get(ctx, client.ObjectKey{
-			Namespace: gpuProvisionerDeployment.Namespace,
-			Name:      gpuProvisionerDeployment.Name,
-		}, gpuProvisionerDeployment, &client.GetOptions{})
-	}, utils.PollTimeout, utils.PollInterval).Should(Succeed(), "Failed to wait for	gpu-provisioner deployment")
-
 	//check kaito-workspace deployment is up and running
 	kaitoWorkspaceDeployment := &v1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -10,18 +10,14 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
 	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
 	"github.com/azure/kaito/test/e2e/utils"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
-	"github.com/samber/lo"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
-	"knative.dev/pkg/apis"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -120,6 +116,11 @@ func createCustomWorkspaceWithPresetCustomMode(imageName string, numOfNode int)
 }
 
 func createAndValidateWorkspace(workspaceObj *kaitov1alpha1.Workspace) {
+	if !TestingCluster.Provider.ProvisionsNodes() {
+		By("Preparing existing nodes for the workspace", func() {
+			Expect(TestingCluster.Provider.PrepareNodes(ctx, TestingCluster.KubeClient, workspaceObj)).To(Succeed())
+		})
+	}
 	By("Creating workspace", func() {
 		Eventually(func() error {
 			return TestingCluster.KubeClient.Create(ctx, workspaceObj, &client.CreateOptions{})
@@ -136,66 +137,22 @@ func createAndValidateWorkspace(workspaceObj *kaitov1alpha1.Workspace) {
 	})
 }
 
-func getAllValidMachines(workspaceObj *kaitov1alpha1.Workspace) (*v1alpha5.MachineList, error) {
-	machineList := &v1alpha5.MachineList{}
-	ls := labels.Set{
-		kaitov1alpha1.LabelWorkspaceName:      workspaceObj.Name,
-		kaitov1alpha1.LabelWorkspaceNamespace: workspaceObj.Namespace,
-	}
-
-	err := TestingCluster.KubeClient.List(ctx, machineList, &client.MatchingLabelsSelector{Selector: ls.AsSelector()})
-	if err != nil {
-		return nil, err
-	}
-	return machineList, nil
-}
-
 // Logic to validate machine creation
 func validateMachineCreation(workspaceObj *kaitov1alpha1.Workspace, expectedCount int) {
+	if !TestingCluster.Provider.ProvisionsNodes() {
+		return
+	}
 	By("Checking machine created by the workspace CR", func() {
-		Eventually(func() bool {
-			machineList, err := getAllValidMachines(workspaceObj)
-			if err != nil {
-				fmt.Printf("Failed to get all valid machines: %v", err)
-				return false
-			}
-
-			if len(machineList.Items) != expectedCount {
-				return false
-			}
-
-			for _, machine := range machineList.Items {
-				_, conditionFound := lo.Find(machine.GetConditions(), func(condition apis.Condition) bool {
-					return condition.Type == apis.ConditionReady && condition.Status == v1.ConditionTrue
-				})
-				if !conditionFound {
-					return false
-				}
-			}
-			return true
-		}, 20*time.Minute, utils.PollInterval).Should(BeTrue(), "Failed to wait for machine to be ready")
+		Expect(TestingCluster.Waiter().WaitForMachinesReady(ctx, workspaceObj, expectedCount, 20*time.Minute)).
+			To(Succeed(), "Failed to wait for machine to be ready")
 	})
 }
 
 // Logic to validate resource status
 func validateResourceStatus(workspaceObj *kaitov1alpha1.Workspace) {
 	By("Checking the resource status", func() {
-		Eventually(func() bool {
-			err := TestingCluster.KubeClient.Get(ctx, client.ObjectKey{
-				Namespace: workspaceObj.Namespace,
-				Name:      workspaceObj.Name,
-			}, workspaceObj, &client.GetOptions{})
-
-			if err != nil {
-				return false
-			}
-
-			_, conditionFound := lo.Find(workspaceObj.Status.Conditions, func(condition metav1.Condition) bool {
-				return condition.Type == string(kaitov1alpha1.WorkspaceConditionTypeResourceStatus) &&
-					condition.Status == metav1.ConditionTrue
-			})
-			return conditionFound
-		}, 10*time.Minute, utils.PollInterval).Should(BeTrue(), "Failed to wait for resource status to be ready")
+		Expect(TestingCluster.Waiter().WaitForWorkspaceCondition(ctx, workspaceObj, kaitov1alpha1.WorkspaceConditionTypeResourceStatus, 10*time.Minute)).
+			To(Succeed(), "Failed to wait for resource status to be ready")
 	})
 }
 
@@ -279,22 +236,25 @@ func validateInferenceResource(workspaceObj *kaitov1alpha1.Workspace, expectedRe
 // Logic to validate workspace readiness
 func validateWorkspaceReadiness(workspaceObj *kaitov1alpha1.Workspace) {
 	By("Checking the workspace status is ready", func() {
-		Eventually(func() bool {
-			err := TestingCluster.KubeClient.Get(ctx, client.ObjectKey{
-				Namespace: workspaceObj.Namespace,
-				Name:      workspaceObj.Name,
-			}, workspaceObj, &client.GetOptions{})
-
-			if err != nil {
-				return false
-			}
+		Expect(TestingCluster.Waiter().WaitForWorkspaceCondition(ctx, workspaceObj, kaitov1alpha1.WorkspaceConditionTypeReady, 10*time.Minute)).
+			To(Succeed(), "Failed to wait for workspace to be ready")
+	})
+}
 
-			_, conditionFound := lo.Find(workspaceObj.Status.Conditions, func(condition metav1.Condition) bool {
-				return condition.Type == string(kaitov1alpha1.WorkspaceConditionTypeReady) &&
-					condition.Status == metav1.ConditionTrue
-			})
-			return conditionFound
-		}, 10*time.Minute, utils.PollInterval).Should(BeTrue(), "Failed to wait for workspace to be ready")
+// Logic to validate the inference endpoint of the text generation presets
+func validateInferenceEndpoint(workspaceObj *kaitov1alpha1.Workspace) {
+	By("Checking the inference endpoint serves chat requests", func() {
+		Expect(TestingCluster.Waiter().WaitForEndpointServing(ctx, workspaceObj, utils.PollTimeout)).
+			To(Succeed(), "Failed to wait for the inference endpoint")
+		inferenceClient := TestingCluster.InferenceClient()
+		Expect(inferenceClient.Healthz(ctx, workspaceObj)).To(Succeed())
+
+		response, err := inferenceClient.Chat(ctx, workspaceObj, &utils.ChatRequest{
+			Prompt:         "What is Kubernetes?",
+			GenerateKwargs: map[string]interface{}{"max_length": 50},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.Result).NotTo(BeEmpty(), "Expected generated text")
 	})
 }
 
@@ -385,6 +345,8 @@ var _ = Describe("Workspace Preset", func() {
 		validateInferenceResource(workspaceObj, int32(numOfNode), false)
 
 		validateWorkspaceReadiness(workspaceObj)
+
+		validateInferenceEndpoint(workspaceObj)
 	})
 
 	It("should create a mistral workspace with preset public mode successfully", func() {
@@ -404,6 +366,8 @@ var _ = Describe("Workspace Preset", func() {
 		validateInferenceResource(workspaceObj, int32(numOfNode), false)
 
 		validateWorkspaceReadiness(workspaceObj)
+
+		validateInferenceEndpoint(workspaceObj)
 	})
 
 	It("should create a Phi-2 workspace with preset public mode successfully", func() {
@@ -423,6 +387,8 @@ var _ = Describe("Workspace Preset", func() {
 		validateInferenceResource(workspaceObj, int32(numOfNode), false)
 
 		validateWorkspaceReadiness(workspaceObj)
+
+		validateInferenceEndpoint(workspaceObj)
 	})
 
 	It("should create a llama 7b workspace with preset private mode successfully", func() {
@@ -0,0 +1,210 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+)
+
+const (
+	// BenchmarkReportEnvVar is the path the benchmark report is written to. Defaults to
+	// benchmark-report.json in the artifacts directory.
+	BenchmarkReportEnvVar = "E2E_BENCHMARK_REPORT"
+	// BenchmarkBaselineEnvVar is the path of the report of a previous release the benchmark results
+	// are compared to.
+	BenchmarkBaselineEnvVar = "E2E_BENCHMARK_BASELINE"
+	// BenchmarkVersionEnvVar is the version of Kaito recorded in the benchmark report.
+	BenchmarkVersionEnvVar = "E2E_BENCHMARK_VERSION"
+)
+
+// BenchmarkOptions configure a load run against the inference API of a workspace.
+type BenchmarkOptions struct {
+	// Scenario names the request in the report, e.g., the generation length.
+	Scenario string
+	// Requests is the total number of requests sent.
+	Requests int
+	// Concurrency is the number of requests in flight. Defaults to 1.
+	Concurrency int
+	Request     *ChatRequest
+}
+
+// BenchmarkResult is the latency and throughput of a load run. The chat endpoint does not stream,
+// so the latencies are those of the complete responses.
+type BenchmarkResult struct {
+	Workspace          string  `json:"workspace"`
+	Preset             string  `json:"preset,omitempty"`
+	InstanceType       string  `json:"instanceType"`
+	Scenario           string  `json:"scenario"`
+	Concurrency        int     `json:"concurrency"`
+	Requests           int     `json:"requests"`
+	Failures           int     `json:"failures"`
+	DurationSeconds    float64 `json:"durationSeconds"`
+	RequestsPerSecond  float64 `json:"requestsPerSecond"`
+	LatencyMeanSeconds float64 `json:"latencyMeanSeconds"`
+	LatencyP50Seconds  float64 `json:"latencyP50Seconds"`
+	LatencyP90Seconds  float64 `json:"latencyP90Seconds"`
+	LatencyP99Seconds  float64 `json:"latencyP99Seconds"`
+}
+
+// key identifies the result across reports.
+func (r *BenchmarkResult) key() string {
+	return fmt.Sprintf("%s/%s/%s/%d", r.Preset, r.InstanceType, r.Scenario, r.Concurrency)
+}
+
+// BenchmarkReport is the structured report of a benchmark run, compared between releases.
+type BenchmarkReport struct {
+	Version   string            `json:"version,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+	Results   []BenchmarkResult `json:"results"`
+}
+
+// Benchmark sends the requests of the options to the chat endpoint of the workspace and measures
+// their latency and the throughput. Failed requests are counted but not measured.
+func (c *InferenceClient) Benchmark(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, options BenchmarkOptions) (*BenchmarkResult, error) {
+	if options.Requests <= 0 {
+		return nil, fmt.Errorf("benchmark of workspace %s/%s requires at least one request", workspaceObj.Namespace, workspaceObj.Name)
+	}
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		failures  int
+		wg        sync.WaitGroup
+	)
+	requests := make(chan struct{}, options.Requests)
+	for i := 0; i < options.Requests; i++ {
+		requests <- struct{}{}
+	}
+	close(requests)
+
+	start := time.Now()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range requests {
+				sent := time.Now()
+				_, err := c.Chat(ctx, workspaceObj, options.Request)
+				latency := time.Since(sent)
+				mu.Lock()
+				if err != nil {
+					failures++
+				} else {
+					latencies = append(latencies, latency)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	duration := time.Since(start)
+
+	result := &BenchmarkResult{
+		Workspace:       workspaceObj.Name,
+		InstanceType:    workspaceObj.Resource.InstanceType,
+		Scenario:        options.Scenario,
+		Concurrency:     concurrency,
+		Requests:        options.Requests,
+		Failures:        failures,
+		DurationSeconds: duration.Seconds(),
+	}
+	if workspaceObj.Inference != nil && workspaceObj.Inference.Preset != nil {
+		result.Preset = string(workspaceObj.Inference.Preset.Name)
+	}
+	if len(latencies) == 0 {
+		return result, fmt.Errorf("all %d requests to workspace %s/%s failed", options.Requests, workspaceObj.Namespace, workspaceObj.Name)
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	result.RequestsPerSecond = float64(len(latencies)) / duration.Seconds()
+	result.LatencyMeanSeconds = (total / time.Duration(len(latencies))).Seconds()
+	result.LatencyP50Seconds = percentile(latencies, 50).Seconds()
+	result.LatencyP90Seconds = percentile(latencies, 90).Seconds()
+	result.LatencyP99Seconds = percentile(latencies, 99).Seconds()
+	return result, nil
+}
+
+// percentile returns the nearest-rank percentile of the sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// WriteBenchmarkReport writes the report to the path of BenchmarkReportEnvVar, or to the artifacts
+// directory. It returns the path written, empty if neither is set.
+func WriteBenchmarkReport(report *BenchmarkReport) (string, error) {
+	path := os.Getenv(BenchmarkReportEnvVar)
+	if path == "" {
+		dir := os.Getenv(ArtifactsDirEnvVar)
+		if dir == "" {
+			return "", nil
+		}
+		path = filepath.Join(dir, "benchmark-report.json")
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, data, 0644)
+}
+
+// ReadBenchmarkReport reads a report written by WriteBenchmarkReport.
+func ReadBenchmarkReport(path string) (*BenchmarkReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	report := &BenchmarkReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, fmt.Errorf("invalid benchmark report %s: %w", path, err)
+	}
+	return report, nil
+}
+
+// BenchmarkRegressions compares the results of the report to those of the baseline with the same
+// preset, instance type, scenario and concurrency. It describes the results whose p90 latency grew,
+// or whose throughput dropped, by more than tolerance, e.g., 0.1 for 10%.
+func BenchmarkRegressions(baseline, report *BenchmarkReport, tolerance float64) []string {
+	previous := map[string]BenchmarkResult{}
+	for _, result := range baseline.Results {
+		previous[result.key()] = result
+	}
+
+	var regressions []string
+	for _, result := range report.Results {
+		base, ok := previous[result.key()]
+		if !ok {
+			continue
+		}
+		if base.LatencyP90Seconds > 0 && result.LatencyP90Seconds > base.LatencyP90Seconds*(1+tolerance) {
+			regressions = append(regressions, fmt.Sprintf("%s: p90 latency %.2fs, was %.2fs in %s",
+				result.key(), result.LatencyP90Seconds, base.LatencyP90Seconds, baseline.Version))
+		}
+		if base.RequestsPerSecond > 0 && result.RequestsPerSecond < base.RequestsPerSecond*(1-tolerance) {
+			regressions = append(regressions, fmt.Sprintf("%s: throughput %.2f req/s, was %.2f req/s in %s",
+				result.key(), result.RequestsPerSecond, base.RequestsPerSecond, baseline.Version))
+		}
+	}
+	return regressions
+}
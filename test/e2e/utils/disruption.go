@@ -0,0 +1,87 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Disruptor simulates the loss of the GPU nodes of the workspaces, to check that the workspaces
+// recover from it.
+type Disruptor struct {
+	Client client.Client
+	// Clientset evicts the pods through the eviction API, which honors the disruption budgets.
+	Clientset kubernetes.Interface
+}
+
+// WorkspaceNode returns a node running a pod of the workspace.
+func (d *Disruptor) WorkspaceNode(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace) (*corev1.Node, error) {
+	podList := &corev1.PodList{}
+	if err := d.Client.List(ctx, podList, client.InNamespace(workspaceObj.Namespace),
+		client.MatchingLabels{kaitov1alpha1.LabelWorkspaceName: workspaceObj.Name}); err != nil {
+		return nil, err
+	}
+	for _, pod := range podList.Items {
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		nodeObj := &corev1.Node{}
+		if err := d.Client.Get(ctx, client.ObjectKey{Name: pod.Spec.NodeName}, nodeObj); err != nil {
+			return nil, err
+		}
+		return nodeObj, nil
+	}
+	return nil, fmt.Errorf("no pod of workspace %s/%s is scheduled", workspaceObj.Namespace, workspaceObj.Name)
+}
+
+// DeleteNode simulates the abrupt loss of the node, e.g., a hardware failure: the node is deleted
+// without draining its pods.
+func (d *Disruptor) DeleteNode(ctx context.Context, nodeObj *corev1.Node) error {
+	return client.IgnoreNotFound(d.Client.Delete(ctx, nodeObj))
+}
+
+// EvictNode simulates the eviction of a spot node: the node is tainted and cordoned, and its
+// workspace pods are evicted.
+func (d *Disruptor) EvictNode(ctx context.Context, nodeObj *corev1.Node) error {
+	patch := client.MergeFrom(nodeObj.DeepCopy())
+	nodeObj.Spec.Unschedulable = true
+	nodeObj.Spec.Taints = append(nodeObj.Spec.Taints, corev1.Taint{
+		Key:    "kubernetes.azure.com/scalesetpriority",
+		Value:  "spot",
+		Effect: corev1.TaintEffectNoSchedule,
+	})
+	if err := d.Client.Patch(ctx, nodeObj, patch); err != nil {
+		return err
+	}
+
+	podList := &corev1.PodList{}
+	if err := d.Client.List(ctx, podList, client.MatchingFields{"spec.nodeName": nodeObj.Name}); err != nil {
+		return err
+	}
+	for _, pod := range podList.Items {
+		if _, ok := pod.Labels[kaitov1alpha1.LabelWorkspaceName]; !ok {
+			continue
+		}
+		if err := d.EvictPod(ctx, &pod); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EvictPod evicts the pod through the eviction API, e.g., to simulate the loss of a worker of a
+// distributed inference or of a tuning job.
+func (d *Disruptor) EvictPod(ctx context.Context, pod *corev1.Pod) error {
+	return d.Clientset.PolicyV1().Evictions(pod.Namespace).Evict(ctx, &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+	})
+}
@@ -0,0 +1,76 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// inferenceServicePort is the name of the HTTP port of the service of the workspaces.
+const inferenceServicePort = "http"
+
+// ChatMessage is a message of a conversation sent to the chat endpoint.
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest is the request body of the chat endpoint of the text generation presets. Prompt is
+// used by the text-generation pipeline, Messages by the conversational pipeline.
+type ChatRequest struct {
+	Prompt         string                 `json:"prompt,omitempty"`
+	Messages       []ChatMessage          `json:"messages,omitempty"`
+	GenerateKwargs map[string]interface{} `json:"generate_kwargs,omitempty"`
+}
+
+// ChatResponse is the response body of the chat endpoint.
+type ChatResponse struct {
+	Result string `json:"Result"`
+}
+
+// InferenceClient sends requests to the inference API of the workspaces through the service proxy
+// of the API server, so the tests need neither port forwarding nor a client pod in the cluster.
+type InferenceClient struct {
+	Clientset kubernetes.Interface
+}
+
+// Healthz checks the health endpoint of the workspace.
+func (c *InferenceClient) Healthz(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace) error {
+	_, err := c.Clientset.CoreV1().Services(workspaceObj.Namespace).
+		ProxyGet("http", workspaceObj.Name, inferenceServicePort, "healthz", nil).DoRaw(ctx)
+	if err != nil {
+		return fmt.Errorf("health check of workspace %s/%s failed: %w", workspaceObj.Namespace, workspaceObj.Name, err)
+	}
+	return nil
+}
+
+// Chat sends the request to the chat endpoint of the workspace. A non-2xx status is an error.
+func (c *InferenceClient) Chat(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, request *ChatRequest) (*ChatResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := c.Clientset.CoreV1().RESTClient().Post().
+		Namespace(workspaceObj.Namespace).
+		Resource("services").
+		Name(fmt.Sprintf("%s:%s", workspaceObj.Name, inferenceServicePort)).
+		SubResource("proxy").
+		Suffix("chat").
+		SetHeader("Content-Type", "application/json").
+		Body(body).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("chat request to workspace %s/%s failed: %w: %s", workspaceObj.Namespace, workspaceObj.Name, err, raw)
+	}
+	response := &ChatResponse{}
+	if err := json.Unmarshal(raw, response); err != nil {
+		return nil, fmt.Errorf("invalid chat response of workspace %s/%s: %w: %s", workspaceObj.Namespace, workspaceObj.Name, err, raw)
+	}
+	return response, nil
+}
@@ -0,0 +1,131 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ProviderEnvVar selects the provider of the test cluster. Defaults to ProviderAzure.
+	ProviderEnvVar = "E2E_PROVIDER"
+
+	ProviderAzure = "azure"
+	ProviderKind  = "kind"
+
+	// gpuResource is the resource advertised by the device plugin of the GPU nodes.
+	gpuResource corev1.ResourceName = "nvidia.com/gpu"
+)
+
+// Provider abstracts the cluster the e2e suite runs against.
+type Provider interface {
+	// Name is the value of ProviderEnvVar selecting the provider.
+	Name() string
+	// ProvisionsNodes returns whether Kaito provisions the nodes of the workspaces. Otherwise, the
+	// workspaces run on the existing nodes prepared by PrepareNodes.
+	ProvisionsNodes() bool
+	// Simulated returns whether the workloads run stub inference images instead of the models, so the
+	// suite checks the resources and the API of the workspaces but not the model outputs.
+	Simulated() bool
+	// ProvisionerDeployment returns the namespace and name of the deployment provisioning the nodes,
+	// waited for before the suite runs. An empty name means there is none.
+	ProvisionerDeployment() (namespace, name string)
+	// PrepareNodes makes existing nodes match the resource spec of the workspace, if the provider
+	// does not provision nodes.
+	PrepareNodes(ctx context.Context, c client.Client, workspaceObj *kaitov1alpha1.Workspace) error
+}
+
+// GetProvider returns the provider selected by ProviderEnvVar.
+func GetProvider() (Provider, error) {
+	switch name := os.Getenv(ProviderEnvVar); name {
+	case "", ProviderAzure:
+		return &azureProvider{}, nil
+	case ProviderKind:
+		return &kindProvider{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported e2e provider %q, supported providers: %s, %s", name, ProviderAzure, ProviderKind)
+	}
+}
+
+// azureProvider runs the suite on an AKS cluster with gpu-provisioner installed in GPU_NAMESPACE.
+type azureProvider struct{}
+
+func (*azureProvider) Name() string { return ProviderAzure }
+
+func (*azureProvider) ProvisionsNodes() bool { return true }
+
+func (*azureProvider) Simulated() bool { return false }
+
+func (*azureProvider) ProvisionerDeployment() (string, string) {
+	return os.Getenv("GPU_NAMESPACE"), "gpu-provisioner"
+}
+
+func (*azureProvider) PrepareNodes(context.Context, client.Client, *kaitov1alpha1.Workspace) error {
+	return nil
+}
+
+// kindProvider runs the suite without GPU quota on a kind cluster whose worker nodes advertise
+// nvidia.com/gpu through a fake device plugin. The operator is expected to rewrite the preset images
+// to stub inference images with the imageRewrites of its workload mutation.
+type kindProvider struct{}
+
+func (*kindProvider) Name() string { return ProviderKind }
+
+func (*kindProvider) ProvisionsNodes() bool { return false }
+
+func (*kindProvider) Simulated() bool { return true }
+
+func (*kindProvider) ProvisionerDeployment() (string, string) { return "", "" }
+
+// PrepareNodes labels as many nodes with GPUs as the workspace count with the label selector of
+// the workspace, so the workspace is scheduled on them instead of provisioning nodes.
+func (*kindProvider) PrepareNodes(ctx context.Context, c client.Client, workspaceObj *kaitov1alpha1.Workspace) error {
+	nodeList := &corev1.NodeList{}
+	if err := c.List(ctx, nodeList); err != nil {
+		return err
+	}
+	count := 1
+	if workspaceObj.Resource.Count != nil {
+		count = *workspaceObj.Resource.Count
+	}
+	var labels map[string]string
+	if workspaceObj.Resource.LabelSelector != nil {
+		labels = workspaceObj.Resource.LabelSelector.MatchLabels
+	}
+
+	prepared := 0
+	for i := range nodeList.Items {
+		if prepared == count {
+			break
+		}
+		nodeObj := &nodeList.Items[i]
+		if gpus, ok := nodeObj.Status.Allocatable[gpuResource]; !ok || gpus.Cmp(resource.MustParse("1")) < 0 {
+			continue
+		}
+		patch := client.MergeFrom(nodeObj.DeepCopy())
+		if nodeObj.Labels == nil {
+			nodeObj.Labels = map[string]string{}
+		}
+		for k, v := range labels {
+			nodeObj.Labels[k] = v
+		}
+		// Nodes without the instance type label qualify for any instance type.
+		delete(nodeObj.Labels, corev1.LabelInstanceTypeStable)
+		if err := c.Patch(ctx, nodeObj, patch); err != nil {
+			return err
+		}
+		prepared++
+	}
+	if prepared < count {
+		return fmt.Errorf("found %d nodes with %s, workspace %s requires %d", prepared, gpuResource, workspaceObj.Name, count)
+	}
+	return nil
+}
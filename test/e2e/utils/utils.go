@@ -5,7 +5,6 @@ package utils
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"time"
 
@@ -39,7 +38,7 @@ func GetEnv(envVar string) string {
 func GetModelConfigInfo(configFilePath string) (map[string]interface{}, error) {
 	var data map[string]interface{}
 
-	yamlData, err := ioutil.ReadFile(configFilePath)
+	yamlData, err := os.ReadFile(configFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("error reading YAML file: %w", err)
 	}
@@ -0,0 +1,237 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/karpenter-core/pkg/apis/v1alpha5"
+	kaitov1alpha1 "github.com/azure/kaito/api/v1alpha1"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/apis"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/yaml"
+)
+
+// ArtifactsDirEnvVar is the directory the diagnostics of the timed out waits are written to, e.g.,
+// to be uploaded as CI artifacts.
+const ArtifactsDirEnvVar = "E2E_ARTIFACTS_DIR"
+
+// logTailLines is the number of lines of the pod logs captured in the diagnostics.
+const logTailLines = int64(50)
+
+// Waiter polls the resources of the workspaces. When a wait times out, it dumps the diagnostics of
+// the workspace: its status, events, pods and their logs.
+type Waiter struct {
+	Client client.Client
+	// Clientset reads the logs of the pods. If nil, the logs are not captured.
+	Clientset kubernetes.Interface
+	// Out receives the diagnostics, e.g., the GinkgoWriter.
+	Out io.Writer
+}
+
+// WaitForWorkspaceCondition waits until the workspace has the condition with status True.
+func (w *Waiter) WaitForWorkspaceCondition(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace,
+	conditionType kaitov1alpha1.ConditionType, timeout time.Duration) error {
+	return w.WaitForWorkspaceConditionStatus(ctx, workspaceObj, conditionType, metav1.ConditionTrue, timeout)
+}
+
+// WaitForWorkspaceConditionStatus waits until the workspace has the condition with the status,
+// e.g., False after a disruption.
+func (w *Waiter) WaitForWorkspaceConditionStatus(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace,
+	conditionType kaitov1alpha1.ConditionType, status metav1.ConditionStatus, timeout time.Duration) error {
+	return w.poll(ctx, workspaceObj, fmt.Sprintf("condition %s %s", conditionType, status), timeout, func(ctx context.Context) (bool, error) {
+		if err := w.Client.Get(ctx, client.ObjectKeyFromObject(workspaceObj), workspaceObj); err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		return meta.IsStatusConditionPresentAndEqual(workspaceObj.Status.Conditions, string(conditionType), status), nil
+	})
+}
+
+// WaitForEndpointServing waits until the service of the workspace has a ready endpoint.
+func (w *Waiter) WaitForEndpointServing(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, timeout time.Duration) error {
+	return w.poll(ctx, workspaceObj, "serving endpoint", timeout, func(ctx context.Context) (bool, error) {
+		endpoints := &corev1.Endpoints{}
+		if err := w.Client.Get(ctx, client.ObjectKeyFromObject(workspaceObj), endpoints); err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		for _, subset := range endpoints.Subsets {
+			if len(subset.Addresses) > 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+// WaitForMachinesReady waits until the workspace has count ready machines.
+func (w *Waiter) WaitForMachinesReady(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, count int, timeout time.Duration) error {
+	return w.poll(ctx, workspaceObj, fmt.Sprintf("%d ready machines", count), timeout, func(ctx context.Context) (bool, error) {
+		machineList := &v1alpha5.MachineList{}
+		if err := w.Client.List(ctx, machineList, workspaceSelector(workspaceObj)); err != nil {
+			return false, err
+		}
+		if len(machineList.Items) != count {
+			return false, nil
+		}
+		for i := range machineList.Items {
+			if !machineList.Items[i].StatusConditions().IsHappy() {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// WaitForNodeClaimReady waits until the workspace has count ready nodeClaims.
+func (w *Waiter) WaitForNodeClaimReady(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, count int, timeout time.Duration) error {
+	return w.poll(ctx, workspaceObj, fmt.Sprintf("%d ready nodeClaims", count), timeout, func(ctx context.Context) (bool, error) {
+		nodeClaimList := &v1beta1.NodeClaimList{}
+		if err := w.Client.List(ctx, nodeClaimList, workspaceSelector(workspaceObj)); err != nil {
+			return false, err
+		}
+		if len(nodeClaimList.Items) != count {
+			return false, nil
+		}
+		for i := range nodeClaimList.Items {
+			if condition := nodeClaimList.Items[i].StatusConditions().GetCondition(apis.ConditionReady); condition == nil || !condition.IsTrue() {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+func workspaceSelector(workspaceObj *kaitov1alpha1.Workspace) client.MatchingLabels {
+	return client.MatchingLabels{
+		kaitov1alpha1.LabelWorkspaceName:      workspaceObj.Name,
+		kaitov1alpha1.LabelWorkspaceNamespace: workspaceObj.Namespace,
+	}
+}
+
+// poll polls the condition until timeout. Transient errors of the condition are retried. On
+// timeout, the diagnostics of the workspace are dumped and an error describing the wait is returned.
+func (w *Waiter) poll(ctx context.Context, workspaceObj *kaitov1alpha1.Workspace, what string, timeout time.Duration,
+	condition wait.ConditionWithContextFunc) error {
+	var lastErr error
+	err := wait.PollUntilContextTimeout(ctx, PollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		done, err := condition(ctx)
+		if err != nil {
+			lastErr = err
+			return false, nil
+		}
+		return done, nil
+	})
+	if err == nil {
+		return nil
+	}
+	err = fmt.Errorf("timed out after %s waiting for %s of workspace %s/%s: %w", timeout, what, workspaceObj.Namespace, workspaceObj.Name, err)
+	if lastErr != nil {
+		err = errors.Join(err, lastErr)
+	}
+	w.dumpDiagnostics(workspaceObj, err)
+	return err
+}
+
+// dumpDiagnostics writes the diagnostics of the workspace to Out and to the artifacts directory.
+func (w *Waiter) dumpDiagnostics(workspaceObj *kaitov1alpha1.Workspace, cause error) {
+	// The diagnostics are collected even if the context of the wait is done.
+	ctx, cancel := context.WithTimeout(context.Background(), PollTimeout)
+	defer cancel()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "=== Diagnostics of workspace %s/%s: %v\n", workspaceObj.Namespace, workspaceObj.Name, cause)
+
+	current := &kaitov1alpha1.Workspace{}
+	if err := w.Client.Get(ctx, client.ObjectKeyFromObject(workspaceObj), current); err != nil {
+		fmt.Fprintf(&b, "failed to get workspace: %v\n", err)
+	} else if status, err := yaml.Marshal(current.Status); err == nil {
+		fmt.Fprintf(&b, "--- Status\n%s", status)
+	}
+
+	eventList := &corev1.EventList{}
+	if err := w.Client.List(ctx, eventList, client.InNamespace(workspaceObj.Namespace)); err != nil {
+		fmt.Fprintf(&b, "failed to list events: %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "--- Events\n")
+		for _, event := range eventList.Items {
+			if !strings.HasPrefix(event.InvolvedObject.Name, workspaceObj.Name) {
+				continue
+			}
+			fmt.Fprintf(&b, "%s %s %s/%s %s: %s\n", event.LastTimestamp.Format(time.RFC3339), event.Type,
+				event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Reason, event.Message)
+		}
+	}
+
+	podList := &corev1.PodList{}
+	if err := w.Client.List(ctx, podList, client.InNamespace(workspaceObj.Namespace),
+		client.MatchingLabels{kaitov1alpha1.LabelWorkspaceName: workspaceObj.Name}); err != nil {
+		fmt.Fprintf(&b, "failed to list pods: %v\n", err)
+	} else {
+		for i := range podList.Items {
+			w.describePod(ctx, &b, &podList.Items[i])
+		}
+	}
+
+	diagnostics := b.String()
+	if w.Out != nil {
+		fmt.Fprint(w.Out, diagnostics)
+	}
+	if dir := os.Getenv(ArtifactsDirEnvVar); dir != "" {
+		name := fmt.Sprintf("%s-%s-%d.txt", workspaceObj.Namespace, workspaceObj.Name, time.Now().Unix())
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(diagnostics), 0644); err != nil && w.Out != nil {
+			fmt.Fprintf(w.Out, "failed to write diagnostics to %s: %v\n", dir, err)
+		}
+	}
+}
+
+func (w *Waiter) describePod(ctx context.Context, b *strings.Builder, pod *corev1.Pod) {
+	fmt.Fprintf(b, "--- Pod %s on node %q: %s\n", pod.Name, pod.Spec.NodeName, pod.Status.Phase)
+	for _, condition := range pod.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			fmt.Fprintf(b, "condition %s is %s: %s %s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		fmt.Fprintf(b, "container %s ready=%t restarts=%d", status.Name, status.Ready, status.RestartCount)
+		if status.State.Waiting != nil {
+			fmt.Fprintf(b, " waiting: %s %s", status.State.Waiting.Reason, status.State.Waiting.Message)
+		}
+		if status.LastTerminationState.Terminated != nil {
+			fmt.Fprintf(b, " last terminated: %s exit code %d", status.LastTerminationState.Terminated.Reason,
+				status.LastTerminationState.Terminated.ExitCode)
+		}
+		fmt.Fprintln(b)
+	}
+	if w.Clientset == nil {
+		return
+	}
+	for _, container := range pod.Spec.Containers {
+		logs, err := w.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+			Container: container.Name,
+			TailLines: lo.ToPtr(logTailLines),
+		}).DoRaw(ctx)
+		if err != nil {
+			if !apierrors.IsBadRequest(err) {
+				fmt.Fprintf(b, "failed to get logs of container %s: %v\n", container.Name, err)
+			}
+			continue
+		}
+		fmt.Fprintf(b, "--- Logs of container %s\n%s\n", container.Name, logs)
+	}
+}